@@ -2,8 +2,8 @@ package create
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
-	"net/http"
 
 	"github.com/codeforsanjose/open311-gateway/adapters/citysourced/cscommon"
 	"github.com/codeforsanjose/open311-gateway/common"
@@ -44,7 +44,7 @@ type Request struct {
 }
 
 // Process executes the request to create a new report.
-func (r *Request) Process(url string) (*Response, error) {
+func (r *Request) Process(ctx context.Context, url string) (*Response, error) {
 	fail := func(err error) (*Response, error) {
 		response := Response{
 			Message:  "Failed",
@@ -61,8 +61,7 @@ func (r *Request) Process(url string) (*Response, error) {
 		enc.Encode(r)
 	}
 
-	client := http.Client{Timeout: cscommon.HttpClientTimeout}
-	resp, err := client.Post(url, "application/xml", payload)
+	resp, err := cscommon.Post(ctx, url, payload)
 	if err != nil {
 		return fail(err)
 	}
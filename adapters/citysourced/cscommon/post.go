@@ -0,0 +1,19 @@
+package cscommon
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// Post sends the XML payload to the CitySourced API.  The request is abandoned when the
+// context is done.
+func Post(ctx context.Context, url string, payload io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest("POST", url, payload)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/xml")
+	client := http.Client{Timeout: HttpClientTimeout}
+	return client.Do(req.WithContext(ctx))
+}
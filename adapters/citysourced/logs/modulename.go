@@ -1,5 +0,0 @@
-package logs
-
-func init() {
-	modulename = "CitySourcedAdapter"
-}
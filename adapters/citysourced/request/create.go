@@ -6,7 +6,6 @@ import (
 	"github.com/codeforsanjose/open311-gateway/adapters/citysourced/create"
 	"github.com/codeforsanjose/open311-gateway/adapters/citysourced/data"
 	"github.com/codeforsanjose/open311-gateway/common/structs"
-	"github.com/codeforsanjose/open311-gateway/adapters/citysourced/telemetry"
 	"github.com/codeforsanjose/open311-gateway/common"
 )
 
@@ -24,7 +23,7 @@ func (r *Report) Create(rqst *structs.NCreateRequest, resp *structs.NCreateRespo
 	}
 	log.Debugf("createMgr: %#v\n", *cm)
 
-	return runRequest(processer(cm))
+	return data.Adapter().Run("Report.Create", cm)
 }
 
 // createMgr conglomerates the Normal and Native structs and supervisor logic
@@ -41,7 +40,7 @@ type createMgr struct {
 	nresp *structs.NCreateResponse
 }
 
-func (c *createMgr) convertRequest() error {
+func (c *createMgr) ConvertRequest() error {
 	provider, err := data.MIDProvider(c.nreq.MID)
 	if err != nil {
 		return err
@@ -66,18 +65,20 @@ func (c *createMgr) convertRequest() error {
 		AuthorTelephone:   c.nreq.Phone,
 		AuthorIsAnonymous: c.nreq.IsAnonymous,
 	}
-	telemetry.SendRPC(c.nreq.GetIDS(), "open", "", c.url, 0, time.Now())
+	data.Adapter().SendRPC(c.nreq.GetIDS(), "open", "", c.url, 0, time.Now())
 	return nil
 }
 
 // Process executes the request to create a new report.
-func (c *createMgr) process() error {
-	resp, err := c.req.Process(c.url)
+func (c *createMgr) Process() error {
+	ctx, cancel := c.nreq.Context()
+	defer cancel()
+	resp, err := c.req.Process(ctx, c.url)
 	c.resp = resp
 	return err
 }
 
-func (c *createMgr) convertResponse() (int, error) {
+func (c *createMgr) ConvertResponse() (int, error) {
 	route := c.nreq.GetRoute()
 	c.nresp.SetIDF(c.nreq.GetID)
 	c.nresp.SetRoute(route)
@@ -87,18 +88,18 @@ func (c *createMgr) convertResponse() (int, error) {
 	return 1, nil
 }
 
-func (c *createMgr) fail(err error) error {
+func (c *createMgr) Fail(err error) error {
 	c.nresp.Message = "Failed - " + err.Error()
 	c.nresp.RID = structs.ReportID{}
 	c.nresp.AccountID = ""
 	return err
 }
 
-func (c *createMgr) getIDS() string {
+func (c *createMgr) GetIDS() string {
 	return c.nreq.GetIDS()
 }
 
-func (c *createMgr) getRoute() string {
+func (c *createMgr) GetRoute() string {
 	return c.nreq.GetRoute().String()
 }
 
@@ -6,7 +6,6 @@ import (
 	"github.com/codeforsanjose/open311-gateway/adapters/citysourced/data"
 	"github.com/codeforsanjose/open311-gateway/adapters/citysourced/search"
 	"github.com/codeforsanjose/open311-gateway/common/structs"
-	"github.com/codeforsanjose/open311-gateway/adapters/citysourced/telemetry"
 	"github.com/codeforsanjose/open311-gateway/common"
 )
 
@@ -32,7 +31,7 @@ func (r *Report) SearchLL(rqst *structs.NSearchRequestLL, resp *structs.NSearchR
 	log.Debugf("searchLLMgr: %#v\n", *cm)
 	log.Debug(cm.nreq.String())
 
-	return runRequest(processer(cm))
+	return data.Adapter().Run("Report.SearchLL", cm)
 }
 
 // searchLLMgr conglomerates the Normal and Native structs and supervisor logic
@@ -49,7 +48,7 @@ type searchLLMgr struct {
 	nresp *structs.NSearchResponse
 }
 
-func (c *searchLLMgr) convertRequest() error {
+func (c *searchLLMgr) ConvertRequest() error {
 	provider, err := data.RouteProvider(c.nreq.Route)
 	if err != nil {
 		return err
@@ -67,18 +66,20 @@ func (c *searchLLMgr) convertRequest() error {
 		DateRangeStart:    dfltDateRangeStart,
 		DateRangeEnd:      dfltDateRangeEnd,
 	}
-	telemetry.SendRPC(c.nreq.GetIDS(), "open", "", c.url, 0, time.Now())
+	data.Adapter().SendRPC(c.nreq.GetIDS(), "open", "", c.url, 0, time.Now())
 	return nil
 }
 
 // Process executes the request to search for reports by location.
-func (c *searchLLMgr) process() error {
-	resp, err := c.req.Process(c.url)
+func (c *searchLLMgr) Process() error {
+	ctx, cancel := c.nreq.Context()
+	defer cancel()
+	resp, err := c.req.Process(ctx, c.url)
 	c.resp = resp
 	return err
 }
 
-func (c *searchLLMgr) convertResponse() (resultCount int, err error) {
+func (c *searchLLMgr) ConvertResponse() (resultCount int, err error) {
 	log.Debugf("Resp: %s", c.nresp)
 	route := c.nreq.GetRoute()
 	c.nresp.SetIDF(c.nreq.GetID)
@@ -120,16 +121,16 @@ func (c *searchLLMgr) convertResponse() (resultCount int, err error) {
 	return len(c.nresp.Reports), nil
 }
 
-func (c *searchLLMgr) fail(err error) error {
+func (c *searchLLMgr) Fail(err error) error {
 	c.nresp.Message = "Failed - " + err.Error()
 	return err
 }
 
-func (c *searchLLMgr) getIDS() string {
+func (c *searchLLMgr) GetIDS() string {
 	return c.nreq.GetIDS()
 }
 
-func (c *searchLLMgr) getRoute() string {
+func (c *searchLLMgr) GetRoute() string {
 	return c.nreq.GetRoute().String()
 }
 
@@ -157,7 +158,7 @@ func (r *Report) SearchRID(rqst *structs.NSearchRequestRID, resp *structs.NSearc
 	}
 	log.Debugf("searchRIDMgr: %#v\n", *cm)
 
-	return runRequest(processer(cm))
+	return data.Adapter().Run("Report.SearchRID", cm)
 }
 
 // searchRIDMgr conglomerates the Normal and Native structs and supervisor logic
@@ -174,7 +175,7 @@ type searchRIDMgr struct {
 	nresp *structs.NSearchResponse
 }
 
-func (c *searchRIDMgr) convertRequest() error {
+func (c *searchRIDMgr) ConvertRequest() error {
 	provider, err := data.RouteProvider(c.nreq.Route)
 	if err != nil {
 		return err
@@ -190,18 +191,20 @@ func (c *searchRIDMgr) convertRequest() error {
 		DateRangeStart:    dfltDateRangeStart,
 		DateRangeEnd:      dfltDateRangeEnd,
 	}
-	telemetry.SendRPC(c.nreq.GetIDS(), "open", "", c.url, 0, time.Now())
+	data.Adapter().SendRPC(c.nreq.GetIDS(), "open", "", c.url, 0, time.Now())
 	return nil
 }
 
 // Process executes the request to search for reports by location.
-func (c *searchRIDMgr) process() error {
-	resp, err := c.req.Process(c.url)
+func (c *searchRIDMgr) Process() error {
+	ctx, cancel := c.nreq.Context()
+	defer cancel()
+	resp, err := c.req.Process(ctx, c.url)
 	c.resp = resp
 	return err
 }
 
-func (c *searchRIDMgr) convertResponse() (resultCount int, err error) {
+func (c *searchRIDMgr) ConvertResponse() (resultCount int, err error) {
 	log.Debugf("Resp: %s", c.nresp)
 	route := c.nreq.GetRoute()
 	c.nresp.SetIDF(c.nreq.GetID)
@@ -243,16 +246,16 @@ func (c *searchRIDMgr) convertResponse() (resultCount int, err error) {
 	return len(c.nresp.Reports), nil
 }
 
-func (c *searchRIDMgr) fail(err error) error {
+func (c *searchRIDMgr) Fail(err error) error {
 	c.nresp.Message = "Failed - " + err.Error()
 	return err
 }
 
-func (c *searchRIDMgr) getIDS() string {
+func (c *searchRIDMgr) GetIDS() string {
 	return c.nreq.GetIDS()
 }
 
-func (c *searchRIDMgr) getRoute() string {
+func (c *searchRIDMgr) GetRoute() string {
 	return c.nreq.GetRoute().String()
 }
 
@@ -280,7 +283,7 @@ func (r *Report) SearchDID(rqst *structs.NSearchRequestDID, resp *structs.NSearc
 	}
 	log.Debugf("searchDIDMgr: %#v\n", *cm)
 
-	return runRequest(processer(cm))
+	return data.Adapter().Run("Report.SearchDID", cm)
 }
 
 // searchDIDMgr conglomerates the Normal and Native structs and supervisor logic
@@ -297,7 +300,7 @@ type searchDIDMgr struct {
 	nresp *structs.NSearchResponse
 }
 
-func (c *searchDIDMgr) convertRequest() error {
+func (c *searchDIDMgr) ConvertRequest() error {
 	provider, err := data.RouteProvider(c.nreq.Route)
 	if err != nil {
 		return err
@@ -314,18 +317,20 @@ func (c *searchDIDMgr) convertRequest() error {
 		DateRangeStart:    dfltDateRangeStart,
 		DateRangeEnd:      dfltDateRangeEnd,
 	}
-	telemetry.SendRPC(c.nreq.GetIDS(), "open", "", c.url, 0, time.Now())
+	data.Adapter().SendRPC(c.nreq.GetIDS(), "open", "", c.url, 0, time.Now())
 	return nil
 }
 
 // Process executes the request to search for reports by location.
-func (c *searchDIDMgr) process() error {
-	resp, err := c.req.Process(c.url)
+func (c *searchDIDMgr) Process() error {
+	ctx, cancel := c.nreq.Context()
+	defer cancel()
+	resp, err := c.req.Process(ctx, c.url)
 	c.resp = resp
 	return err
 }
 
-func (c *searchDIDMgr) convertResponse() (resultCount int, err error) {
+func (c *searchDIDMgr) ConvertResponse() (resultCount int, err error) {
 	log.Debugf("Resp: %s", c.nresp)
 	route := c.nreq.GetRoute()
 	c.nresp.SetIDF(c.nreq.GetID)
@@ -367,16 +372,16 @@ func (c *searchDIDMgr) convertResponse() (resultCount int, err error) {
 	return len(c.nresp.Reports), nil
 }
 
-func (c *searchDIDMgr) fail(err error) error {
+func (c *searchDIDMgr) Fail(err error) error {
 	c.nresp.Message = "Failed - " + err.Error()
 	return err
 }
 
-func (c *searchDIDMgr) getIDS() string {
+func (c *searchDIDMgr) GetIDS() string {
 	return c.nreq.GetIDS()
 }
 
-func (c *searchDIDMgr) getRoute() string {
+func (c *searchDIDMgr) GetRoute() string {
 	return c.nreq.GetRoute().String()
 }
 
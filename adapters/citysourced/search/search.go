@@ -2,8 +2,8 @@ package search
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
-	"net/http"
 
 	"github.com/codeforsanjose/open311-gateway/adapters/citysourced/cscommon"
 	"github.com/codeforsanjose/open311-gateway/common"
@@ -31,7 +31,7 @@ type RequestLL struct {
 }
 
 // Process executes the request to create a new report.
-func (r *RequestLL) Process(url string) (*Response, error) {
+func (r *RequestLL) Process(ctx context.Context, url string) (*Response, error) {
 	// log.Printf("%s\n", r)
 	fail := func(err error) (*Response, error) {
 		response := Response{
@@ -48,8 +48,7 @@ func (r *RequestLL) Process(url string) (*Response, error) {
 	}
 	// log.Printf("Payload:\n%v\n", payload.String())
 
-	client := http.Client{Timeout: cscommon.HttpClientTimeout}
-	resp, err := client.Post(url, "application/xml", payload)
+	resp, err := cscommon.Post(ctx, url, payload)
 	if err != nil {
 		return fail(err)
 	}
@@ -82,7 +81,7 @@ type RequestDID struct {
 }
 
 // Process executes the request to create a new report.
-func (r *RequestDID) Process(url string) (*Response, error) {
+func (r *RequestDID) Process(ctx context.Context, url string) (*Response, error) {
 	// log.Printf("%s\n", r)
 	fail := func(err error) (*Response, error) {
 		response := Response{
@@ -99,8 +98,7 @@ func (r *RequestDID) Process(url string) (*Response, error) {
 	}
 	log.Debugf("Payload:\n%v\n", payload.String())
 
-	client := http.Client{Timeout: cscommon.HttpClientTimeout}
-	resp, err := client.Post(url, "application/xml", payload)
+	resp, err := cscommon.Post(ctx, url, payload)
 	if err != nil {
 		return fail(err)
 	}
@@ -132,7 +130,7 @@ type RequestRID struct {
 }
 
 // Process executes the request to create a new report.
-func (r *RequestRID) Process(url string) (*Response, error) {
+func (r *RequestRID) Process(ctx context.Context, url string) (*Response, error) {
 	// log.Printf("%s\n", r)
 	fail := func(err error) (*Response, error) {
 		response := Response{
@@ -149,8 +147,7 @@ func (r *RequestRID) Process(url string) (*Response, error) {
 	}
 	log.Debugf("Payload:\n%v\n", payload.String())
 
-	client := http.Client{Timeout: cscommon.HttpClientTimeout}
-	resp, err := client.Post(url, "application/xml", payload)
+	resp, err := cscommon.Post(ctx, url, payload)
 	if err != nil {
 		return fail(err)
 	}
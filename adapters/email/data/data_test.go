@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"testing"
 
-	"github.com/codeforsanjose/open311-gateway/adapters/email/logs"
+	"github.com/codeforsanjose/open311-gateway/common/adaptersdk/logs"
 	"github.com/codeforsanjose/open311-gateway/common/structs"
 
 	"github.com/davecgh/go-spew/spew"
@@ -43,7 +43,7 @@ func TestServices(t *testing.T) {
 	}
 
 	for _, tt := range test1 {
-		svcs, err := ServicesArea(tt.input)
+		svcs, err := Adapter().ServicesArea(tt.input)
 
 		switch {
 		case tt.isOK && err == nil:
@@ -56,7 +56,7 @@ func TestServices(t *testing.T) {
 	}
 
 	fmt.Printf("----------------------------- [TestServicesAll] -----------------------------\n\n")
-	svcs, err := ServicesAll()
+	svcs, err := Adapter().ServicesAll()
 	if err != nil {
 		t.Errorf("ServicesArea() failed.")
 	} else {
@@ -69,14 +69,14 @@ func TestAdapter(t *testing.T) {
 	fmt.Printf("\n\n\n\n============================= [TestAdapter] =============================\n\n")
 
 	fmt.Printf("----------------------------- [Adapter] -----------------------------\n\n")
-	if name, atype, address := Adapter(); name != "EM1" || atype != "Email" {
-		t.Errorf("Adapter() failed - name: %q  atype: %q", name, atype)
+	if a := Adapter().Adapter; a.Name != "EM1" || a.Type != "Email" {
+		t.Errorf("Adapter() failed - name: %q  atype: %q", a.Name, a.Type)
 	} else {
-		fmt.Printf("OK - address: %s\n", address)
+		fmt.Printf("OK - address: %s\n", a.Address)
 	}
 
-	fmt.Printf("----------------------------- [AdapterName] -----------------------------\n\n")
-	if name := AdapterName(); name != "EM1" {
+	fmt.Printf("----------------------------- [Name] -----------------------------\n\n")
+	if name := Adapter().Name(); name != "EM1" {
 		t.Errorf("AdapterName() failed - name: %q", name)
 	} else {
 		fmt.Println("OK!")
@@ -138,9 +138,10 @@ func TestEmail(t *testing.T) {
 
 	fmt.Print(configData.Email)
 
-	for areaID, areaData := range configData.Areas {
+	for areaID, areaData := range Adapter().Areas {
 		fmt.Printf("\n\n---------- Area: %q ----------\n", areaID)
-		for _, prov := range areaData.Providers {
+		for _, p := range areaData.Providers {
+			prov := p.(*Provider)
 			fmt.Printf("\nProvider: %v - %v\n%s", prov.ID, prov.Name, prov.Email.String())
 		}
 	}
@@ -1,12 +1,11 @@
 package mail
 
 import (
-	"CitySourcedAPI/logs"
 	"fmt"
 
 	"github.com/codeforsanjose/open311-gateway/adapters/email/data"
-	"github.com/codeforsanjose/open311-gateway/common/structs"
 	"github.com/codeforsanjose/open311-gateway/common"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
 
 	log "github.com/jeffizhungry/logrus"
 	"gopkg.in/gomail.v2"
@@ -19,7 +18,6 @@ var (
 
 // Init should be called at program startup to initialize
 func Init() {
-	logs.Init(true)
 	auth = data.GetEmailAuth()
 	log.Debugf("Auth: %v", auth)
 
@@ -10,7 +10,6 @@ import (
 	"github.com/codeforsanjose/open311-gateway/adapters/email/create"
 	"github.com/codeforsanjose/open311-gateway/adapters/email/data"
 	"github.com/codeforsanjose/open311-gateway/common/structs"
-	"github.com/codeforsanjose/open311-gateway/adapters/email/telemetry"
 	"github.com/codeforsanjose/open311-gateway/common"
 
 	log "github.com/jeffizhungry/logrus"
@@ -31,13 +30,13 @@ func (r *Report) Create(rqst *structs.NCreateRequest, resp *structs.NCreateRespo
 		nresp: resp,
 	}
 
-	if cm.nsrv, err = data.ServiceFromID(cm.nreq.MID); err != nil {
+	if cm.nsrv, err = data.Adapter().Service(cm.nreq.MID); err != nil {
 		return fmt.Errorf("unable to process Create request - %s", err.Error())
 	}
 
 	log.Debugf("createMgr: %#v\n", *cm)
 
-	return runRequest(processer(cm))
+	return data.Adapter().Run("Report.Create", cm)
 }
 
 // createMgr conglomerates the Normal and Native structs and supervisor logic
@@ -56,11 +55,11 @@ type createMgr struct {
 	nresp *structs.NCreateResponse
 }
 
-func (c *createMgr) convertRequest() error {
+func (c *createMgr) ConvertRequest() error {
 	fail := func(err string) error {
 		return fmt.Errorf("Unable to create the email - %s", err)
 	}
-	telemetry.SendRPC(c.nreq.GetIDS(), "open", "", "", 0, time.Now())
+	data.Adapter().SendRPC(c.nreq.GetIDS(), "open", "", "", 0, time.Now())
 
 	// Fill in the Service Name
 	c.nreq.ServiceName = c.nsrv.Name
@@ -87,13 +86,13 @@ func (c *createMgr) convertRequest() error {
 }
 
 // Process executes the request to create a new report.
-func (c *createMgr) process() error {
+func (c *createMgr) Process() error {
 	resp, err := c.req.Process()
 	c.resp = resp
 	return err
 }
 
-func (c *createMgr) convertResponse() (int, error) {
+func (c *createMgr) ConvertResponse() (int, error) {
 	rspOK := func(msg string) bool {
 		_, ok := successMessages[strings.ToLower(msg)]
 		log.Debugf("successMessages: %#v", successMessages)
@@ -116,18 +115,18 @@ func (c *createMgr) convertResponse() (int, error) {
 	return 1, nil
 }
 
-func (c *createMgr) fail(err error) error {
+func (c *createMgr) Fail(err error) error {
 	c.nresp.Message = "Failed - " + err.Error()
 	c.nresp.RID = structs.ReportID{}
 	c.nresp.AccountID = ""
 	return err
 }
 
-func (c *createMgr) getIDS() string {
+func (c *createMgr) GetIDS() string {
 	return c.nreq.GetIDS()
 }
 
-func (c *createMgr) getRoute() string {
+func (c *createMgr) GetRoute() string {
 	return c.nreq.GetRoute().String()
 }
 
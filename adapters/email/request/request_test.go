@@ -5,7 +5,7 @@ import (
 	"testing"
 
 	"github.com/codeforsanjose/open311-gateway/adapters/email/data"
-	"github.com/codeforsanjose/open311-gateway/adapters/email/logs"
+	"github.com/codeforsanjose/open311-gateway/common/adaptersdk/logs"
 	"github.com/codeforsanjose/open311-gateway/common/structs"
 )
 
@@ -0,0 +1,216 @@
+package data
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/adapters/open311/georeport"
+	"github.com/codeforsanjose/open311-gateway/common"
+	"github.com/codeforsanjose/open311-gateway/common/adaptersdk"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+const (
+	dfltTokenPolls    = 3
+	dfltTokenInterval = 500 // milliseconds
+)
+
+var (
+	adp = adaptersdk.New(func() adaptersdk.Provider { return new(Provider) })
+)
+
+// Adapter returns the Adapter, with the config data common to all Adapters.
+func Adapter() *adaptersdk.Adapter {
+	return adp
+}
+
+// MIDProvider returns the Provider data for the specified MidAdpID.
+func MIDProvider(MID structs.ServiceID) (*Provider, error) {
+	p, err := adp.MIDProvider(MID)
+	if err != nil {
+		return nil, err
+	}
+	return p.(*Provider), nil
+}
+
+// RouteProvider returns the Provider data for the specified NRoute.
+func RouteProvider(route structs.NRoute) (*Provider, error) {
+	p, err := adp.RouteProvider(route)
+	if err != nil {
+		return nil, err
+	}
+	return p.(*Provider), nil
+}
+
+// capabilities of a GeoReport v2 endpoint.
+var capabilities = structs.NCapabilities{
+	RequestTypes: []structs.NRequestType{structs.NRTCreate, structs.NRTSearchLL, structs.NRTSearchDID, structs.NRTSearchRID},
+	Media:        true,
+	Anonymous:    true,
+}
+
+// ==============================================================================================================================
+//                                      INIT
+// ==============================================================================================================================
+
+// Init loads the config file, and the Service lists of all Providers.
+func Init(configFile string) error {
+	adp.Capabilities = capabilities
+	return adp.Init(configFile, nil)
+}
+
+// ------------------------------- Provider -------------------------------
+
+// Provider is the data for each GeoReport v2 endpoint.  If Services is specified in the
+// config file, it is used as a static Service list, and the Service ID is used as the
+// service_code.  Otherwise, the Service list and definitions are loaded from the
+// endpoint.
+type Provider struct {
+	adaptersdk.ProviderBase
+	URL            string `json:"url"`
+	JurisdictionID string `json:"jurisdictionId"`
+	Key            string `json:"key"`
+	Format         string `json:"format"`        // "json" or "xml"
+	Timeout        int    `json:"timeout"`       // seconds
+	TokenPolls     int    `json:"tokenPolls"`    // Number of times to poll for the ID of an async request
+	TokenInterval  int    `json:"tokenInterval"` // milliseconds between token polls
+
+	client      *georeport.Client
+	codes       map[int]string                          // Service ID -> service_code
+	definitions map[string]*georeport.ServiceDefinition // service_code -> definition
+	sync.RWMutex
+}
+
+// Settle creates the GeoReport v2 client, and indexes the codes of the static Service
+// list.
+func (p *Provider) Settle() error {
+	if p.URL == "" {
+		return fmt.Errorf("provider %q has no url", p.Name)
+	}
+	if p.TokenPolls == 0 {
+		p.TokenPolls = dfltTokenPolls
+	}
+	if p.TokenInterval == 0 {
+		p.TokenInterval = dfltTokenInterval
+	}
+	p.client = georeport.NewClient(p.URL, p.JurisdictionID, p.Key, p.Format, time.Duration(p.Timeout)*time.Second)
+	p.codes = make(map[int]string)
+	p.definitions = make(map[string]*georeport.ServiceDefinition)
+	for _, service := range p.Services {
+		p.codes[service.ID] = strconv.Itoa(service.ID)
+	}
+	return nil
+}
+
+// Client returns the GeoReport v2 client for the Provider.
+func (p *Provider) Client() *georeport.Client {
+	return p.client
+}
+
+// ServiceCode returns the GeoReport v2 service_code for a Service ID.
+func (p *Provider) ServiceCode(id int) (string, error) {
+	p.RLock()
+	defer p.RUnlock()
+	code, ok := p.codes[id]
+	if !ok {
+		return "", fmt.Errorf("invalid service id: %d for provider: %q", id, p.Name)
+	}
+	return code, nil
+}
+
+// ServiceID returns the Service ID for a GeoReport v2 service_code.
+func (p *Provider) ServiceID(code string) int {
+	return serviceCodeID(code)
+}
+
+// Definition returns the Service Definition for the service_code, or nil if the
+// Service has no attributes.
+func (p *Provider) Definition(code string) *georeport.ServiceDefinition {
+	p.RLock()
+	defer p.RUnlock()
+	return p.definitions[code]
+}
+
+// TokenPoll returns the number of times, and the interval, to poll for the ID of an
+// asynchronously created request.
+func (p *Provider) TokenPoll() (int, time.Duration) {
+	return p.TokenPolls, time.Duration(p.TokenInterval) * time.Millisecond
+}
+
+// LoadServices loads the Service list and definitions from the endpoint.
+func (p *Provider) LoadServices() ([]*structs.NService, error) {
+	list, err := p.client.Services()
+	if err != nil {
+		return nil, err
+	}
+	services := make([]*structs.NService, 0, len(list))
+	definitions := make(map[string]*georeport.ServiceDefinition)
+	for _, s := range list {
+		srv := &structs.NService{
+			ServiceID:    structs.ServiceID{ID: serviceCodeID(s.Code)},
+			Name:         s.Name,
+			Description:  s.Description,
+			Metadata:     s.Metadata,
+			ResponseType: s.Type,
+			Group:        s.Group,
+		}
+		for _, k := range strings.Split(s.Keywords, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				srv.Keywords = append(srv.Keywords, k)
+			}
+		}
+		if s.Metadata {
+			d, err := p.client.Definition(s.Code)
+			if err != nil {
+				log.Warningf("Unable to load the definition for service %q from %q - %s", s.Code, p.Name, err)
+			} else {
+				definitions[s.Code] = d
+			}
+		}
+		services = append(services, srv)
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	p.codes = make(map[int]string)
+	p.definitions = definitions
+	loaded := make([]*structs.NService, 0, len(services))
+	for i, srv := range services {
+		if code, dup := p.codes[srv.ID]; dup {
+			log.Warningf("Provider %q - service codes %q and %q have the same ID - %q is ignored", p.Name, code, list[i].Code, list[i].Code)
+			continue
+		}
+		p.codes[srv.ID] = list[i].Code
+		loaded = append(loaded, srv)
+	}
+	return loaded, nil
+}
+
+func (p *Provider) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddF("%s (ID: %d)\n", p.Name, p.ID)
+	ls.AddF("URL: %s  jurisdiction: %q  format: %s  Key: **********\n", p.URL, p.JurisdictionID, p.Format)
+	ls.AddS("---SERVICES:\n")
+	for _, v := range p.Services {
+		ls.AddF("   %s\n", v)
+	}
+	return ls.Box(80)
+}
+
+// serviceCodeID converts a GeoReport v2 service_code to a Service ID.  Numeric codes
+// are used as is.  Other codes are hashed, so the IDs do not change when the Service
+// list is reloaded.
+func serviceCodeID(code string) int {
+	if n, err := strconv.Atoi(code); err == nil && n > 0 {
+		return n
+	}
+	h := fnv.New32a()
+	h.Write([]byte(code))
+	return int(h.Sum32() & 0x7fffffff)
+}
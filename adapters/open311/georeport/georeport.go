@@ -0,0 +1,542 @@
+package georeport
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common"
+)
+
+// Response formats supported by GeoReport v2 servers.
+const (
+	FormatJSON = "json"
+	FormatXML  = "xml"
+)
+
+// DefaultTimeout is the HTTP timeout used if none is specified.
+const DefaultTimeout = 5 * time.Second
+
+// ================================================================================================
+//                                      CLIENT
+// ================================================================================================
+
+// Client calls a GeoReport v2 server.  URL is the base endpoint URL, e.g.
+// "https://city.example.gov/open311/v2/".  The JurisdictionID is only required if the
+// endpoint serves more than one jurisdiction, and the Key is only sent with POST
+// requests, as per the GeoReport v2 specification.
+type Client struct {
+	URL            string
+	JurisdictionID string
+	Key            string
+	Format         string
+	HTTP           *http.Client
+
+	ctx context.Context
+}
+
+// NewClient returns a Client for the endpoint.  If the format is blank, JSON is used.
+func NewClient(endpoint, jurisdictionID, key, format string, timeout time.Duration) *Client {
+	format = strings.ToLower(format)
+	if format != FormatXML {
+		format = FormatJSON
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	if !strings.HasSuffix(endpoint, "/") {
+		endpoint += "/"
+	}
+	return &Client{
+		URL:            endpoint,
+		JurisdictionID: jurisdictionID,
+		Key:            key,
+		Format:         format,
+		HTTP:           &http.Client{Timeout: timeout},
+	}
+}
+
+// WithContext returns a copy of the Client whose requests are abandoned when the context
+// is done, e.g. at the deadline of the Engine request.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	c2 := *c
+	c2.ctx = ctx
+	return &c2
+}
+
+// Services returns the list of Services.
+func (c *Client) Services() ([]Service, error) {
+	var (
+		j []Service
+		x struct {
+			Services []Service `xml:"service"`
+		}
+	)
+	if err := c.call("GET", "services", nil, &j, &x); err != nil {
+		return nil, err
+	}
+	if c.Format == FormatXML {
+		return x.Services, nil
+	}
+	return j, nil
+}
+
+// Definition returns the Service Definition (i.e. the attributes) for the Service.
+func (c *Client) Definition(code string) (*ServiceDefinition, error) {
+	var d ServiceDefinition
+	if err := c.call("GET", "services/"+url.PathEscape(code), nil, &d, &d); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Requests returns the Service Requests matching the query.
+func (c *Client) Requests(q Query) ([]ServiceRequest, error) {
+	return c.requests("requests", q.Values())
+}
+
+// Request returns the Service Request with the specified ID.
+func (c *Client) Request(id string) (*ServiceRequest, error) {
+	list, err := c.requests("requests/"+url.PathEscape(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, &Error{Status: http.StatusNotFound, Description: fmt.Sprintf("service request %q not found", id)}
+	}
+	return &list[0], nil
+}
+
+// Create submits a new Service Request.  If the server processes requests
+// asynchronously, the response will have a Token instead of an ID - use Token() to get
+// the ID once it has been assigned.
+func (c *Client) Create(p CreateParams) (*CreateResponse, error) {
+	params := p.Values()
+	if c.Key != "" {
+		params.Set("api_key", c.Key)
+	}
+	return c.createResponse("POST", "requests", params)
+}
+
+// Token returns the Service Request ID for a token returned by Create().  The ID is
+// blank if it has not been assigned yet.
+func (c *Client) Token(token string) (*CreateResponse, error) {
+	r, err := c.createResponse("GET", "tokens/"+url.PathEscape(token), nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.Token == "" {
+		r.Token = token
+	}
+	return r, nil
+}
+
+func (c *Client) requests(path string, params url.Values) ([]ServiceRequest, error) {
+	var (
+		j []ServiceRequest
+		x struct {
+			Requests []ServiceRequest `xml:"request"`
+		}
+	)
+	if err := c.call("GET", path, params, &j, &x); err != nil {
+		return nil, err
+	}
+	if c.Format == FormatXML {
+		return x.Requests, nil
+	}
+	return j, nil
+}
+
+func (c *Client) createResponse(method, path string, params url.Values) (*CreateResponse, error) {
+	var (
+		j []CreateResponse
+		x struct {
+			Requests []CreateResponse `xml:"request"`
+		}
+	)
+	if err := c.call(method, path, params, &j, &x); err != nil {
+		return nil, err
+	}
+	list := j
+	if c.Format == FormatXML {
+		list = x.Requests
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("empty response from %s", c.URL+path)
+	}
+	return &list[0], nil
+}
+
+// call sends the request, and decodes the response into jsonV or xmlV, depending on
+// the Client format.
+func (c *Client) call(method, path string, params url.Values, jsonV, xmlV interface{}) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	if c.JurisdictionID != "" {
+		params.Set("jurisdiction_id", c.JurisdictionID)
+	}
+	u := c.URL + path + "." + c.Format
+
+	var (
+		req *http.Request
+		err error
+	)
+	if method == "POST" {
+		req, err = http.NewRequest(method, u, strings.NewReader(params.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	} else {
+		if len(params) > 0 {
+			u += "?" + params.Encode()
+		}
+		req, err = http.NewRequest(method, u, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.ctx != nil {
+		req = req.WithContext(c.ctx)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return c.decodeError(resp)
+	}
+	if c.Format == FormatXML {
+		return xml.NewDecoder(resp.Body).Decode(xmlV)
+	}
+	return json.NewDecoder(resp.Body).Decode(jsonV)
+}
+
+func (c *Client) decodeError(resp *http.Response) error {
+	e := &Error{Status: resp.StatusCode}
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	var (
+		j []Error
+		x struct {
+			Errors []Error `xml:"error"`
+		}
+	)
+	switch {
+	case c.Format == FormatJSON && json.Unmarshal(body, &j) == nil && len(j) > 0:
+		e.Code, e.Description = j[0].Code, j[0].Description
+	case c.Format == FormatXML && xml.Unmarshal(body, &x) == nil && len(x.Errors) > 0:
+		e.Code, e.Description = x.Errors[0].Code, x.Errors[0].Description
+	default:
+		e.Description = strings.TrimSpace(string(body))
+	}
+	return e
+}
+
+// ================================================================================================
+//                                      TYPES
+// ================================================================================================
+
+// Service is a GeoReport v2 Service (i.e. a report category).
+type Service struct {
+	Code        string `json:"service_code" xml:"service_code"`
+	Name        string `json:"service_name" xml:"service_name"`
+	Description string `json:"description" xml:"description"`
+	Metadata    bool   `json:"metadata" xml:"metadata"`
+	Type        string `json:"type" xml:"type"`
+	Keywords    string `json:"keywords" xml:"keywords"`
+	Group       string `json:"group" xml:"group"`
+}
+
+// ServiceDefinition lists the attributes of a Service.
+type ServiceDefinition struct {
+	Code       string      `json:"service_code" xml:"service_code"`
+	Attributes []Attribute `json:"attributes" xml:"attributes>attribute"`
+}
+
+// Datatypes returns the datatype of each Attribute, indexed by the Attribute code.
+func (d *ServiceDefinition) Datatypes() map[string]string {
+	if d == nil {
+		return nil
+	}
+	m := make(map[string]string, len(d.Attributes))
+	for _, a := range d.Attributes {
+		m[a.Code] = a.Datatype
+	}
+	return m
+}
+
+// Attribute is an additional field required by a Service.
+type Attribute struct {
+	Variable            bool             `json:"variable" xml:"variable"`
+	Code                string           `json:"code" xml:"code"`
+	Datatype            string           `json:"datatype" xml:"datatype"`
+	Required            bool             `json:"required" xml:"required"`
+	DatatypeDescription string           `json:"datatype_description" xml:"datatype_description"`
+	Order               int              `json:"order" xml:"order"`
+	Description         string           `json:"description" xml:"description"`
+	Values              []AttributeValue `json:"values" xml:"values>value"`
+}
+
+// AttributeValue is one of the allowed values for a "singlevaluelist" or
+// "multivaluelist" Attribute.
+type AttributeValue struct {
+	Key  string `json:"key" xml:"key"`
+	Name string `json:"name" xml:"name"`
+}
+
+// Validate checks the value is valid for the Attribute.
+func (a Attribute) Validate(value string) error {
+	if value == "" {
+		if a.Required && a.Variable {
+			return fmt.Errorf("attribute %q is required", a.Code)
+		}
+		return nil
+	}
+	switch a.Datatype {
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("attribute %q must be a number", a.Code)
+		}
+	case "datetime":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return fmt.Errorf("attribute %q must be an ISO 8601 date and time", a.Code)
+		}
+	case "singlevaluelist", "multivaluelist":
+		for _, v := range splitValues(a.Datatype, value) {
+			if !a.hasValue(v) {
+				return fmt.Errorf("%q is not a valid value for attribute %q", v, a.Code)
+			}
+		}
+	}
+	return nil
+}
+
+// splitValues returns the values of an Attribute.  Only a "multivaluelist" has more
+// than one, separated by commas - any other value may contain a comma.
+func splitValues(datatype, value string) []string {
+	if datatype != "multivaluelist" {
+		return []string{value}
+	}
+	l := strings.Split(value, ",")
+	for i := range l {
+		l[i] = strings.TrimSpace(l[i])
+	}
+	return l
+}
+
+func (a Attribute) hasValue(key string) bool {
+	for _, v := range a.Values {
+		if v.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// ServiceRequest is a GeoReport v2 Service Request (i.e. a report).
+type ServiceRequest struct {
+	ID                ID     `json:"service_request_id" xml:"service_request_id"`
+	Status            string `json:"status" xml:"status"`
+	StatusNotes       string `json:"status_notes" xml:"status_notes"`
+	ServiceName       string `json:"service_name" xml:"service_name"`
+	ServiceCode       ID     `json:"service_code" xml:"service_code"`
+	Description       string `json:"description" xml:"description"`
+	AgencyResponsible string `json:"agency_responsible" xml:"agency_responsible"`
+	ServiceNotice     string `json:"service_notice" xml:"service_notice"`
+	RequestedAt       string `json:"requested_datetime" xml:"requested_datetime"`
+	UpdatedAt         string `json:"updated_datetime" xml:"updated_datetime"`
+	ExpectedAt        string `json:"expected_datetime" xml:"expected_datetime"`
+	Address           string `json:"address" xml:"address"`
+	AddressID         ID     `json:"address_id" xml:"address_id"`
+	Zipcode           ID     `json:"zipcode" xml:"zipcode"`
+	Lat               Coord  `json:"lat" xml:"lat"`
+	Long              Coord  `json:"long" xml:"long"`
+	MediaURL          string `json:"media_url" xml:"media_url"`
+	Token             string `json:"token" xml:"token"`
+}
+
+// CreateResponse is the response to a new Service Request, or a token query.
+type CreateResponse struct {
+	ID            ID     `json:"service_request_id" xml:"service_request_id"`
+	Token         string `json:"token" xml:"token"`
+	ServiceNotice string `json:"service_notice" xml:"service_notice"`
+	AccountID     ID     `json:"account_id" xml:"account_id"`
+}
+
+// Query is the set of filters for a Service Request search.  Lat, Long, Radius and
+// DeviceID are not part of the GeoReport v2 specification, but are widely supported.
+type Query struct {
+	IDs         []string
+	ServiceCode string
+	Status      string
+	StartDate   time.Time
+	EndDate     time.Time
+	Lat, Long   float64
+	Radius      int // meters
+	DeviceID    string
+}
+
+// Values returns the query parameters.
+func (q Query) Values() url.Values {
+	v := url.Values{}
+	if len(q.IDs) > 0 {
+		v.Set("service_request_id", strings.Join(q.IDs, ","))
+	}
+	if q.ServiceCode != "" {
+		v.Set("service_code", q.ServiceCode)
+	}
+	if q.Status != "" {
+		v.Set("status", q.Status)
+	}
+	if !q.StartDate.IsZero() {
+		v.Set("start_date", q.StartDate.Format(time.RFC3339))
+	}
+	if !q.EndDate.IsZero() {
+		v.Set("end_date", q.EndDate.Format(time.RFC3339))
+	}
+	if q.Lat != 0 || q.Long != 0 {
+		v.Set("lat", strconv.FormatFloat(q.Lat, 'f', -1, 64))
+		v.Set("long", strconv.FormatFloat(q.Long, 'f', -1, 64))
+		if q.Radius > 0 {
+			v.Set("radius", strconv.Itoa(q.Radius))
+		}
+	}
+	if q.DeviceID != "" {
+		v.Set("device_id", q.DeviceID)
+	}
+	return v
+}
+
+// CreateParams are the fields of a new Service Request.  Attributes and Datatypes are
+// indexed by the Attribute code.  The values of a "multivaluelist" Attribute are
+// separated by commas.
+type CreateParams struct {
+	ServiceCode   string
+	Lat, Long     float64
+	AddressString string
+	Email         string
+	DeviceID      string
+	AccountID     string
+	FirstName     string
+	LastName      string
+	Phone         string
+	Description   string
+	MediaURL      string
+	Attributes    map[string]string
+	Datatypes     map[string]string // From the Service Definition.
+}
+
+// Values returns the form parameters.
+func (p CreateParams) Values() url.Values {
+	v := url.Values{}
+	set := func(k, s string) {
+		if s != "" {
+			v.Set(k, s)
+		}
+	}
+	set("service_code", p.ServiceCode)
+	if p.Lat != 0 || p.Long != 0 {
+		v.Set("lat", strconv.FormatFloat(p.Lat, 'f', -1, 64))
+		v.Set("long", strconv.FormatFloat(p.Long, 'f', -1, 64))
+	}
+	set("address_string", p.AddressString)
+	set("email", p.Email)
+	set("device_id", p.DeviceID)
+	set("account_id", p.AccountID)
+	set("first_name", p.FirstName)
+	set("last_name", p.LastName)
+	set("phone", p.Phone)
+	set("description", p.Description)
+	set("media_url", p.MediaURL)
+	for code, value := range p.Attributes {
+		for _, x := range splitValues(p.Datatypes[code], value) {
+			v.Add("attribute["+code+"]", x)
+		}
+	}
+	return v
+}
+
+// Error is an error returned by the GeoReport v2 server.
+type Error struct {
+	Status      int    `json:"-" xml:"-"`
+	Code        int    `json:"code" xml:"code"`
+	Description string `json:"description" xml:"description"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("Open311 error %d (HTTP %d) - %s", e.Code, e.Status, e.Description)
+}
+
+// ID is a string value that some servers send as a JSON number.
+type ID string
+
+// UnmarshalJSON accepts a string, number or null.
+func (id *ID) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		*id = ID(s)
+		return nil
+	}
+	*id = ID(b)
+	if *id == "null" {
+		*id = ""
+	}
+	return nil
+}
+
+// Coord is a latitude or longitude that some servers send as a JSON string.
+type Coord float64
+
+// UnmarshalJSON accepts a number, string or null.
+func (c *Coord) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), "\"")
+	if s == "" || s == "null" {
+		*c = 0
+		return nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("invalid coordinate: %s", b)
+	}
+	*c = Coord(f)
+	return nil
+}
+
+// ================================================================================================
+//                                      STRINGS
+// ================================================================================================
+
+// String displays a ServiceRequest.
+func (r ServiceRequest) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddS("georeport.ServiceRequest\n")
+	ls.AddF("ID: %s  Status: %s  Notes: %q\n", r.ID, r.Status, r.StatusNotes)
+	ls.AddF("Service - code: %s  name: %q\n", r.ServiceCode, r.ServiceName)
+	ls.AddF("Requested: %s  Updated: %s\n", r.RequestedAt, r.UpdatedAt)
+	ls.AddF("Location - lat: %v  lon: %v  %s\n", r.Lat, r.Long, r.Address)
+	ls.AddF("Description: %q\n", r.Description)
+	return ls.Box(80)
+}
+
+// String displays a CreateResponse.
+func (r CreateResponse) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddS("georeport.CreateResponse\n")
+	ls.AddF("ID: %s  Token: %s  AccountID: %s\n", r.ID, r.Token, r.AccountID)
+	ls.AddF("Notice: %q\n", r.ServiceNotice)
+	return ls.Box(80)
+}
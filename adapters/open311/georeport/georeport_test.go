@@ -0,0 +1,252 @@
+package georeport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// standIn is a minimal GeoReport v2 server.  It records the last request, and returns
+// the canned response for the path.
+type standIn struct {
+	responses map[string]string // path -> body
+	status    int
+	last      *http.Request
+	form      url.Values
+}
+
+func (s *standIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	s.last, s.form = r, r.Form
+	body, ok := s.responses[strings.TrimPrefix(r.URL.Path, "/open311/v2/")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if s.status != 0 {
+		w.WriteHeader(s.status)
+	}
+	fmt.Fprint(w, body)
+}
+
+func newStandIn(format string, responses map[string]string) (*standIn, *httptest.Server, *Client) {
+	s := &standIn{responses: responses}
+	ts := httptest.NewServer(s)
+	return s, ts, NewClient(ts.URL+"/open311/v2", "city.gov", "secret", format, 0)
+}
+
+func TestServicesJSON(t *testing.T) {
+	_, ts, c := newStandIn(FormatJSON, map[string]string{
+		"services.json": `[
+			{"service_code":"001","service_name":"Cans left out 24x7","description":"Garbage cans","metadata":false,"type":"realtime","keywords":"lorem, ipsum","group":"sanitation"},
+			{"service_code":"POTHOLE","service_name":"Pothole","metadata":true,"type":"batch","group":"street"}
+		]`,
+		"services/POTHOLE.json": `{"service_code":"POTHOLE","attributes":[
+			{"variable":true,"code":"WHISHETN","datatype":"singlevaluelist","required":true,"order":1,"description":"What is the ticket/tag/DL number?",
+			 "values":[{"key":"123","name":"Ford"},{"key":"124","name":"Chrysler"}]}
+		]}`,
+	})
+	defer ts.Close()
+
+	list, err := c.Services()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Code != "001" || list[1].Name != "Pothole" || !list[1].Metadata || list[0].Keywords != "lorem, ipsum" {
+		t.Errorf("unexpected services: %+v", list)
+	}
+
+	d, err := c.Definition("POTHOLE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Attributes) != 1 || len(d.Attributes[0].Values) != 2 || !d.Attributes[0].Required {
+		t.Errorf("unexpected definition: %+v", d)
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	s, ts, c := newStandIn(FormatJSON, map[string]string{"services.json": `[]`})
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.WithContext(ctx).Services(); err == nil || s.last != nil {
+		t.Errorf("expected a cancelled request not to be sent: %v", err)
+	}
+	if _, err := c.Services(); err != nil || s.last == nil {
+		t.Errorf("the Client should not be changed: %v", err)
+	}
+}
+
+func TestServicesXML(t *testing.T) {
+	s, ts, c := newStandIn(FormatXML, map[string]string{
+		"services.xml": `<?xml version="1.0" encoding="utf-8"?>
+			<services>
+				<service><service_code>001</service_code><service_name>Cans left out 24x7</service_name><metadata>false</metadata><type>realtime</type></service>
+				<service><service_code>002</service_code><service_name>Construction plate shifted</service_name><metadata>true</metadata><type>batch</type></service>
+			</services>`,
+		"services/002.xml": `<service_definition><service_code>002</service_code><attributes>
+				<attribute><variable>true</variable><code>SIZE</code><datatype>number</datatype><required>false</required></attribute>
+			</attributes></service_definition>`,
+	})
+	defer ts.Close()
+
+	list, err := c.Services()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[1].Code != "002" || !list[1].Metadata {
+		t.Errorf("unexpected services: %+v", list)
+	}
+	if s.last.URL.Query().Get("jurisdiction_id") != "city.gov" {
+		t.Errorf("jurisdiction_id not sent: %s", s.last.URL)
+	}
+
+	d, err := c.Definition("002")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Attributes) != 1 || d.Attributes[0].Datatype != "number" {
+		t.Errorf("unexpected definition: %+v", d)
+	}
+}
+
+func TestRequests(t *testing.T) {
+	s, ts, c := newStandIn(FormatJSON, map[string]string{
+		"requests.json": `[
+			{"service_request_id":638344,"status":"closed","status_notes":"Duplicate request.","service_name":"Sidewalk","service_code":6,
+			 "requested_datetime":"2010-04-14T06:37:38-08:00","address":"8TH AVE and JUDAH ST","zipcode":94122,"lat":"37.762221815","long":-122.4651145}
+		]`,
+		"requests/638344.json": `[{"service_request_id":"638344","status":"open","lat":37.76,"long":-122.46}]`,
+	})
+	defer ts.Close()
+
+	list, err := c.Requests(Query{Lat: 37.76, Long: -122.46, Radius: 200, Status: "open"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].ID != "638344" || list[0].ServiceCode != "6" || list[0].Zipcode != "94122" || list[0].Lat != 37.762221815 {
+		t.Errorf("unexpected requests: %+v", list)
+	}
+	q := s.last.URL.Query()
+	if q.Get("lat") != "37.76" || q.Get("long") != "-122.46" || q.Get("radius") != "200" || q.Get("status") != "open" {
+		t.Errorf("unexpected query: %s", s.last.URL.RawQuery)
+	}
+	if q.Get("api_key") != "" {
+		t.Errorf("api_key should only be sent with POST requests")
+	}
+
+	sr, err := c.Request("638344")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sr.Status != "open" {
+		t.Errorf("unexpected request: %+v", sr)
+	}
+
+	if _, err := c.Request("999"); err == nil {
+		t.Errorf("expected an error for an unknown request")
+	}
+}
+
+func TestCreate(t *testing.T) {
+	s, ts, c := newStandIn(FormatJSON, map[string]string{
+		"requests.json":     `[{"token":"12345","service_notice":"Thank you"}]`,
+		"tokens/12345.json": `[{"service_request_id":"638344","token":"12345"}]`,
+	})
+	defer ts.Close()
+
+	resp, err := c.Create(CreateParams{
+		ServiceCode: "001",
+		Lat:         37.76,
+		Long:        -122.46,
+		Description: "Cans",
+		Attributes:  map[string]string{"WHISHETN": "123", "COLORS": "red, blue", "NOTE": "Near 1st St, by the bus stop"},
+		Datatypes:   map[string]string{"WHISHETN": "number", "COLORS": "multivaluelist", "NOTE": "text"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.last.Method != "POST" || s.form.Get("api_key") != "secret" || s.form.Get("service_code") != "001" ||
+		s.form.Get("attribute[WHISHETN]") != "123" || fmt.Sprint(s.form["attribute[COLORS]"]) != "[red blue]" ||
+		fmt.Sprint(s.form["attribute[NOTE]"]) != "[Near 1st St, by the bus stop]" {
+		t.Errorf("unexpected form: %v", s.form)
+	}
+	if resp.ID != "" || resp.Token != "12345" || resp.ServiceNotice != "Thank you" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	tr, err := c.Token("12345")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.ID != "638344" {
+		t.Errorf("unexpected token response: %+v", tr)
+	}
+}
+
+func TestCreateXML(t *testing.T) {
+	_, ts, c := newStandIn(FormatXML, map[string]string{
+		"requests.xml": `<service_requests><request><service_request_id>293944</service_request_id><service_notice>The City will inspect</service_notice><account_id/></request></service_requests>`,
+	})
+	defer ts.Close()
+
+	resp, err := c.Create(CreateParams{ServiceCode: "001", Lat: 37.76, Long: -122.46})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != "293944" || resp.ServiceNotice != "The City will inspect" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	s, ts, c := newStandIn(FormatJSON, map[string]string{
+		"requests.json": `[{"code":403,"description":"Invalid api_key"}]`,
+		"requests.xml":  `<errors><error><code>400</code><description>service_code was not provided</description></error></errors>`,
+	})
+	defer ts.Close()
+	s.status = http.StatusForbidden
+
+	_, err := c.Create(CreateParams{ServiceCode: "001"})
+	e, ok := err.(*Error)
+	if !ok || e.Status != 403 || e.Code != 403 || e.Description != "Invalid api_key" {
+		t.Errorf("unexpected error: %#v", err)
+	}
+
+	c.Format = FormatXML
+	s.status = http.StatusBadRequest
+	_, err = c.Create(CreateParams{})
+	e, ok = err.(*Error)
+	if !ok || e.Code != 400 || e.Description != "service_code was not provided" {
+		t.Errorf("unexpected error: %#v", err)
+	}
+}
+
+func TestAttributeValidate(t *testing.T) {
+	a := Attribute{Variable: true, Code: "COLOR", Datatype: "singlevaluelist", Required: true,
+		Values: []AttributeValue{{Key: "1", Name: "Red"}, {Key: "2", Name: "Blue"}}}
+	for _, tc := range []struct {
+		value string
+		ok    bool
+	}{{"1", true}, {"3", false}, {"", false}, {"1,2", false}} {
+		if err := a.Validate(tc.value); (err == nil) != tc.ok {
+			t.Errorf("Validate(%q) = %v", tc.value, err)
+		}
+	}
+
+	a.Datatype = "multivaluelist"
+	if a.Validate("1, 2") != nil || a.Validate("1,3") == nil {
+		t.Errorf("multivaluelist validation failed")
+	}
+
+	a = Attribute{Variable: true, Code: "SIZE", Datatype: "number"}
+	if a.Validate("") != nil || a.Validate("12.5") != nil || a.Validate("big") == nil {
+		t.Errorf("number validation failed")
+	}
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"github.com/codeforsanjose/open311-gateway/adapters/open311/data"
+	"github.com/codeforsanjose/open311-gateway/adapters/open311/request"
+	"github.com/codeforsanjose/open311-gateway/common/adaptersdk"
+)
+
+func main() {
+	adaptersdk.Main(data.Adapter(), data.Init, &request.Report{})
+}
@@ -0,0 +1,26 @@
+package request
+
+import (
+	"testing"
+
+	"github.com/codeforsanjose/open311-gateway/adapters/open311/data"
+	"github.com/codeforsanjose/open311-gateway/common/adaptersdk/conformance"
+)
+
+func TestConformance(t *testing.T) {
+	h, err := data.Adapter().Handler(&Report{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conformance.Run(t, conformance.Target{
+		Handler:   h,
+		AdpID:     "O3111",
+		Area:      "San Jose",
+		AreaID:    "SJ",
+		Latitude:  37.3375,
+		Longitude: -121.8853,
+		Service:   1,
+		// The stand-in ignores the device_id filter.
+		Skip: []string{"Report.SearchDID/empty"},
+	})
+}
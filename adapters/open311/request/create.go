@@ -0,0 +1,184 @@
+package request
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/adapters/open311/data"
+	"github.com/codeforsanjose/open311-gateway/adapters/open311/georeport"
+	"github.com/codeforsanjose/open311-gateway/common"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+// tokenPrefix marks a report ID as a GeoReport v2 token.  Servers that create requests
+// asynchronously return a token, and assign the service_request_id later.
+const tokenPrefix = "token:"
+
+// ================================================================================================
+//                                      CREATE
+// ================================================================================================
+
+// Create fully processes the Create request.
+func (r *Report) Create(rqst *structs.NCreateRequest, resp *structs.NCreateResponse) error {
+	log.Debugf("Create - request: %p  resp: %p\n", rqst, resp)
+	// Make the Create Manager
+	cm := &createMgr{
+		nreq:  rqst,
+		nresp: resp,
+	}
+	log.Debugf("createMgr: %#v\n", *cm)
+
+	return data.Adapter().Run("Report.Create", cm)
+}
+
+// createMgr conglomerates the Normal and Native structs and supervisor logic
+// for processing a request to Create a Report.
+//  1. Validates and converts the request from the Normal form to the GeoReport v2 form.
+//  2. Calls the Provider's GeoReport v2 endpoint.
+//  3. If the endpoint returns a token, polls for the request ID.
+//  4. Converts the reply back to Normal form.
+//  5. Returns the Normal Response, and any errors.
+type createMgr struct {
+	nreq     *structs.NCreateRequest
+	provider *data.Provider
+	req      *georeport.CreateParams
+	resp     *georeport.CreateResponse
+	nresp    *structs.NCreateResponse
+}
+
+func (c *createMgr) ConvertRequest() error {
+	provider, err := data.MIDProvider(c.nreq.MID)
+	if err != nil {
+		return err
+	}
+	c.provider = provider
+	code, err := provider.ServiceCode(c.nreq.MID.ID)
+	if err != nil {
+		return err
+	}
+	def := provider.Definition(code)
+	if err := validateAttributes(def, c.nreq.Attributes); err != nil {
+		return err
+	}
+	c.req = &georeport.CreateParams{
+		ServiceCode:   code,
+		Lat:           c.nreq.Latitude,
+		Long:          c.nreq.Longitude,
+		AddressString: c.nreq.FullAddress,
+		Email:         c.nreq.Email,
+		DeviceID:      c.nreq.DeviceID,
+		FirstName:     c.nreq.FirstName,
+		LastName:      c.nreq.LastName,
+		Phone:         c.nreq.Phone,
+		Description:   c.nreq.Description,
+		MediaURL:      c.nreq.MediaURL,
+		Attributes:    c.nreq.Attributes,
+		Datatypes:     def.Datatypes(),
+	}
+	if c.nreq.IsAnonymous {
+		c.req.Email, c.req.FirstName, c.req.LastName, c.req.Phone = "", "", "", ""
+	}
+	data.Adapter().SendRPC(c.nreq.GetIDS(), "open", "", provider.URL, 0, time.Now())
+	return nil
+}
+
+// validateAttributes checks the attribute values against the Service Definition.
+func validateAttributes(d *georeport.ServiceDefinition, values map[string]string) error {
+	if d == nil {
+		return nil
+	}
+	var errs []string
+	for _, a := range d.Attributes {
+		if err := a.Validate(values[a.Code]); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("invalid attributes - %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Process executes the request to create a new report.
+func (c *createMgr) Process() error {
+	ctx, cancel := c.nreq.Context()
+	defer cancel()
+	client := c.provider.Client().WithContext(ctx)
+	resp, err := client.Create(*c.req)
+	if err != nil {
+		return err
+	}
+	c.resp = resp
+
+	polls, interval := c.provider.TokenPoll()
+	for i := 0; i < polls && c.resp.ID == "" && c.resp.Token != ""; i++ {
+		select {
+		case <-ctx.Done():
+			// Out of time - the token is returned.
+			return nil
+		case <-time.After(interval):
+		}
+		t, err := client.Token(c.resp.Token)
+		if err != nil {
+			log.Warningf("Token %q lookup failed - %s", c.resp.Token, err)
+			continue
+		}
+		if t.ID != "" {
+			c.resp.ID = t.ID
+		}
+	}
+	return nil
+}
+
+func (c *createMgr) ConvertResponse() (int, error) {
+	route := c.nreq.GetRoute()
+	c.nresp.SetIDF(c.nreq.GetID)
+	c.nresp.SetRoute(route)
+	c.nresp.AccountID = string(c.resp.AccountID)
+	switch {
+	case c.resp.ID != "":
+		c.nresp.RID = structs.NewRID(route, string(c.resp.ID))
+		c.nresp.Message = "Request successfully added"
+	case c.resp.Token != "":
+		c.nresp.RID = structs.NewRID(route, tokenPrefix+c.resp.Token)
+		c.nresp.Message = "Request accepted - the request ID has not been assigned yet"
+	default:
+		return 0, fmt.Errorf("the response has no service_request_id or token")
+	}
+	if c.resp.ServiceNotice != "" {
+		c.nresp.Message = c.resp.ServiceNotice
+	}
+	return 1, nil
+}
+
+func (c *createMgr) Fail(err error) error {
+	c.nresp.Message = "Failed - " + err.Error()
+	c.nresp.RID = structs.ReportID{}
+	c.nresp.AccountID = ""
+	return err
+}
+
+func (c *createMgr) GetIDS() string {
+	return c.nreq.GetIDS()
+}
+
+func (c *createMgr) GetRoute() string {
+	return c.nreq.GetRoute().String()
+}
+
+func (c *createMgr) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddS("Create\n")
+	ls.AddS(c.nreq.String())
+	if c.req != nil {
+		ls.AddF("Params: %v\n", c.req.Values())
+	}
+	if c.resp != nil {
+		ls.AddS(c.resp.String())
+	}
+	ls.AddS(c.nresp.String())
+	return ls.Box(90)
+}
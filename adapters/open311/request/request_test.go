@@ -0,0 +1,182 @@
+package request
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codeforsanjose/open311-gateway/adapters/open311/data"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+// standIn is a GeoReport v2 stand-in for the Provider endpoint.  A create for the
+// "POTHOLE" service is accepted asynchronously, and returns a token.
+func standIn(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	path := strings.TrimPrefix(r.URL.Path, "/open311/v2/")
+	switch {
+	case path == "services.json":
+		fmt.Fprint(w, `[
+			{"service_code":"001","service_name":"Graffiti","metadata":false,"type":"realtime","group":"blight"},
+			{"service_code":"POTHOLE","service_name":"Pothole","metadata":true,"type":"batch","group":"street","keywords":"road, street"}
+		]`)
+	case path == "services/POTHOLE.json":
+		fmt.Fprint(w, `{"service_code":"POTHOLE","attributes":[
+			{"variable":true,"code":"SIZE","datatype":"singlevaluelist","required":true,"values":[{"key":"S","name":"Small"},{"key":"L","name":"Large"}]}
+		]}`)
+	case path == "requests.json" && r.Method == "POST":
+		if r.Form.Get("service_code") == "POTHOLE" {
+			fmt.Fprint(w, `[{"token":"T1"}]`)
+			return
+		}
+		fmt.Fprint(w, `[{"service_request_id":"101","service_notice":"Thank you"}]`)
+	case path == "requests.json":
+		fmt.Fprint(w, `[
+			{"service_request_id":"101","status":"open","service_code":"001","service_name":"Graffiti","address":"200 E Santa Clara St, San Jose, CA 95113","lat":37.3375,"long":-121.8853},
+			{"service_request_id":"102","status":"open","service_code":"001","service_name":"Graffiti","lat":37.3500,"long":-121.9000}
+		]`)
+	case path == "requests/101.json":
+		fmt.Fprint(w, `[{"service_request_id":"101","status":"open","service_code":"001","lat":37.3375,"long":-121.8853}]`)
+	case path == "tokens/T1.json":
+		fmt.Fprint(w, `[{"token":"T1"}]`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `[{"code":404,"description":"not found"}]`)
+	}
+}
+
+const testConfig = `{
+	"adapter": {"name": "O3111", "type": "Open311", "address": ":5004"},
+	"monitor": {"address": "127.0.0.1:5081"},
+	"serviceAreas": {
+		"SJ": {
+			"name": "San Jose",
+			"providers": [{"id": 1, "name": "Stand-in", "url": "%s/open311/v2", "jurisdictionId": "sanjose", "key": "secret", "format": "json", "tokenPolls": 1, "tokenInterval": 1}]
+		}
+	}
+}`
+
+func TestMain(m *testing.M) {
+	ts := httptest.NewServer(http.HandlerFunc(standIn))
+	dir, err := ioutil.TempDir("", "open311")
+	if err != nil {
+		panic(err)
+	}
+	configFile := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(configFile, []byte(fmt.Sprintf(testConfig, ts.URL)), 0644); err != nil {
+		panic(err)
+	}
+	if err := data.Init(configFile); err != nil {
+		panic(err)
+	}
+
+	rc := m.Run()
+	ts.Close()
+	os.RemoveAll(dir)
+	os.Exit(rc)
+}
+
+var route = structs.NRoute{AdpID: "O3111", AreaID: "SJ", ProviderID: 1}
+
+func TestServices(t *testing.T) {
+	var resp structs.NServicesResponse
+	if err := data.Adapter().Services().Area(&structs.NServiceRequest{Area: "San Jose"}, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Services) != 2 {
+		t.Fatalf("expected 2 services, got: %v", resp.Services)
+	}
+	graffiti, pothole := resp.Services[0], resp.Services[1]
+	if graffiti.ID != 1 || graffiti.AdpID != "O3111" || graffiti.AreaID != "SJ" || graffiti.ProviderID != 1 {
+		t.Errorf("unexpected service: %v", graffiti)
+	}
+	if !pothole.Metadata || pothole.Group != "street" || len(pothole.Keywords) != 2 {
+		t.Errorf("unexpected service: %v", pothole)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	mid := structs.ServiceID{AdpID: "O3111", AreaID: "SJ", ProviderID: 1, ID: 1}
+	rqst := &structs.NCreateRequest{MID: mid, Latitude: 37.3375, Longitude: -121.8853, Description: "Graffiti on wall"}
+	rqst.SetRoute(route)
+	var resp structs.NCreateResponse
+	if err := new(Report).Create(rqst, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.RID.RID() != "O3111-SJ-1-101" || resp.Message != "Thank you" {
+		t.Errorf("unexpected response: %v", resp)
+	}
+}
+
+func TestCreateToken(t *testing.T) {
+	var resp structs.NServicesResponse
+	if err := data.Adapter().Services().Area(&structs.NServiceRequest{Area: "San Jose"}, &resp); err != nil {
+		t.Fatal(err)
+	}
+	mid := resp.Services[1].ServiceID
+
+	rqst := &structs.NCreateRequest{MID: mid, Latitude: 37.3375, Longitude: -121.8853}
+	rqst.SetRoute(route)
+	var cresp structs.NCreateResponse
+	if err := new(Report).Create(rqst, &cresp); err == nil || !strings.Contains(err.Error(), "SIZE") {
+		t.Errorf("expected a missing attribute error, got: %v", err)
+	}
+
+	rqst.Attributes = map[string]string{"SIZE": "L"}
+	cresp = structs.NCreateResponse{}
+	if err := new(Report).Create(rqst, &cresp); err != nil {
+		t.Fatal(err)
+	}
+	if cresp.RID.ID != "token:T1" {
+		t.Errorf("unexpected RID: %v", cresp.RID)
+	}
+
+	// The token has not been assigned an ID yet.
+	srqst := &structs.NSearchRequestRID{RID: cresp.RID}
+	srqst.SetRoute(route)
+	var sresp structs.NSearchResponse
+	if err := new(Report).SearchRID(srqst, &sresp); err != nil {
+		t.Fatal(err)
+	}
+	if sresp.ReportCount != 0 || !strings.Contains(sresp.Message, "not been assigned") {
+		t.Errorf("unexpected response: %v", sresp)
+	}
+}
+
+func TestSearchLL(t *testing.T) {
+	rqst := &structs.NSearchRequestLL{Latitude: 37.3375, Longitude: -121.8853, Radius: 500, AreaID: "SJ"}
+	rqst.SetRoute(route)
+	var resp structs.NSearchResponse
+	if err := new(Report).SearchLL(rqst, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ReportCount != 1 {
+		t.Fatalf("expected 1 report inside the radius, got: %v", resp.Reports)
+	}
+	rpt := resp.Reports[0]
+	if rpt.RID.RID() != "O3111-SJ-1-101" || rpt.RequestTypeID != "1" || !strings.EqualFold(rpt.City, "San Jose") || rpt.State != "CA" || rpt.ZipCode != "95113" {
+		t.Errorf("unexpected report: %#v", rpt)
+	}
+}
+
+func TestSearchRID(t *testing.T) {
+	rqst := &structs.NSearchRequestRID{RID: structs.NewRID(route, "101")}
+	rqst.SetRoute(route)
+	var resp structs.NSearchResponse
+	if err := new(Report).SearchRID(rqst, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ReportCount != 1 || resp.Reports[0].Latitude != "37.3375" {
+		t.Errorf("unexpected response: %v", resp)
+	}
+
+	rqst.RID = structs.NewRID(route, "999")
+	if err := new(Report).SearchRID(rqst, &resp); err == nil {
+		t.Errorf("expected an error for an unknown report")
+	}
+}
@@ -0,0 +1,333 @@
+package request
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/adapters/open311/data"
+	"github.com/codeforsanjose/open311-gateway/adapters/open311/georeport"
+	"github.com/codeforsanjose/open311-gateway/common"
+	"github.com/codeforsanjose/open311-gateway/common/geo"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+const (
+	dfltMaxResults int = 20
+)
+
+// ================================================================================================
+//                                      SEARCH LL
+// ================================================================================================
+
+// SearchLL fully processes a "Search by Location" request.
+func (r *Report) SearchLL(rqst *structs.NSearchRequestLL, resp *structs.NSearchResponse) error {
+	log.Debugf("SearchLL - request: %p  resp: %p\n", rqst, resp)
+	// Make the Search Manager
+	cm := &searchLLMgr{
+		nreq:  rqst,
+		nresp: resp,
+	}
+	log.Debug(cm.nreq.String())
+
+	return data.Adapter().Run("Report.SearchLL", cm)
+}
+
+// searchLLMgr conglomerates the Normal and Native structs and supervisor logic
+// for processing a request to Search for reports by Location.
+//  1. Converts the request from the Normal form to a GeoReport v2 query.
+//  2. Calls the Provider's GeoReport v2 endpoint.
+//  3. Drops any reports outside the radius, in case the endpoint ignores the location.
+//  4. Converts the reply back to Normal form.
+type searchLLMgr struct {
+	nreq     *structs.NSearchRequestLL
+	provider *data.Provider
+	req      georeport.Query
+	resp     []georeport.ServiceRequest
+	nresp    *structs.NSearchResponse
+}
+
+func (c *searchLLMgr) ConvertRequest() error {
+	provider, err := data.RouteProvider(c.nreq.Route)
+	if err != nil {
+		return err
+	}
+	c.provider = provider
+	c.req = georeport.Query{
+		Lat:    c.nreq.Latitude,
+		Long:   c.nreq.Longitude,
+		Radius: c.nreq.Radius,
+	}
+	data.Adapter().SendRPC(c.nreq.GetIDS(), "open", "", provider.URL, 0, time.Now())
+	return nil
+}
+
+// Process executes the request to search for reports by location.
+func (c *searchLLMgr) Process() (err error) {
+	ctx, cancel := c.nreq.Context()
+	defer cancel()
+	c.resp, err = c.provider.Client().WithContext(ctx).Requests(c.req)
+	return err
+}
+
+func (c *searchLLMgr) ConvertResponse() (int, error) {
+	inRadius := make([]georeport.ServiceRequest, 0, len(c.resp))
+	for _, sr := range c.resp {
+		if c.nreq.Radius <= 0 || geo.Distance(c.nreq.Latitude, c.nreq.Longitude, float64(sr.Lat), float64(sr.Long)) <= float64(c.nreq.Radius) {
+			inRadius = append(inRadius, sr)
+		}
+	}
+	return convertReports(c.nresp, c.nreq.GetID, c.nreq.GetRoute(), c.provider, inRadius, c.nreq.MaxResults), nil
+}
+
+func (c *searchLLMgr) Fail(err error) error {
+	c.nresp.Message = "Failed - " + err.Error()
+	return err
+}
+
+func (c *searchLLMgr) GetIDS() string {
+	return c.nreq.GetIDS()
+}
+
+func (c *searchLLMgr) GetRoute() string {
+	return c.nreq.GetRoute().String()
+}
+
+func (c *searchLLMgr) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddS("SearchLL\n")
+	ls.AddS(c.nreq.String())
+	ls.AddF("Query: %v\n", c.req.Values())
+	ls.AddS(c.nresp.String())
+	return ls.Box(90)
+}
+
+// ================================================================================================
+//                                      SEARCH DID
+// ================================================================================================
+
+// SearchDID fully processes the Search by DeviceID request.  The device_id filter is
+// not part of the GeoReport v2 specification, so this only returns results from
+// endpoints that support it.
+func (r *Report) SearchDID(rqst *structs.NSearchRequestDID, resp *structs.NSearchResponse) error {
+	log.Debugf("SearchDID - request: %p  resp: %p\n", rqst, resp)
+	// Make the Search Manager
+	cm := &searchDIDMgr{
+		nreq:  rqst,
+		nresp: resp,
+	}
+
+	return data.Adapter().Run("Report.SearchDID", cm)
+}
+
+// searchDIDMgr conglomerates the Normal and Native structs and supervisor logic
+// for processing a request to Search for reports by Device ID.
+type searchDIDMgr struct {
+	nreq     *structs.NSearchRequestDID
+	provider *data.Provider
+	req      georeport.Query
+	resp     []georeport.ServiceRequest
+	nresp    *structs.NSearchResponse
+}
+
+func (c *searchDIDMgr) ConvertRequest() error {
+	provider, err := data.RouteProvider(c.nreq.Route)
+	if err != nil {
+		return err
+	}
+	c.provider = provider
+	c.req = georeport.Query{
+		DeviceID: c.nreq.DeviceID,
+	}
+	data.Adapter().SendRPC(c.nreq.GetIDS(), "open", "", provider.URL, 0, time.Now())
+	return nil
+}
+
+// Process executes the request to search for reports by device ID.
+func (c *searchDIDMgr) Process() (err error) {
+	ctx, cancel := c.nreq.Context()
+	defer cancel()
+	c.resp, err = c.provider.Client().WithContext(ctx).Requests(c.req)
+	return err
+}
+
+func (c *searchDIDMgr) ConvertResponse() (int, error) {
+	return convertReports(c.nresp, c.nreq.GetID, c.nreq.GetRoute(), c.provider, c.resp, c.nreq.MaxResults), nil
+}
+
+func (c *searchDIDMgr) Fail(err error) error {
+	c.nresp.Message = "Failed - " + err.Error()
+	return err
+}
+
+func (c *searchDIDMgr) GetIDS() string {
+	return c.nreq.GetIDS()
+}
+
+func (c *searchDIDMgr) GetRoute() string {
+	return c.nreq.GetRoute().String()
+}
+
+func (c *searchDIDMgr) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddS("SearchDID\n")
+	ls.AddS(c.nreq.String())
+	ls.AddF("Query: %v\n", c.req.Values())
+	ls.AddS(c.nresp.String())
+	return ls.Box(90)
+}
+
+// ================================================================================================
+//                                      SEARCH RID
+// ================================================================================================
+
+// SearchRID fully processes the Search by ReportID request.  If the ReportID is a
+// token, the token is first resolved to the service_request_id.
+func (r *Report) SearchRID(rqst *structs.NSearchRequestRID, resp *structs.NSearchResponse) error {
+	log.Debugf("SearchRID - request: %p  resp: %p\n", rqst, resp)
+	// Make the Search Manager
+	cm := &searchRIDMgr{
+		nreq:  rqst,
+		nresp: resp,
+	}
+
+	return data.Adapter().Run("Report.SearchRID", cm)
+}
+
+// searchRIDMgr conglomerates the Normal and Native structs and supervisor logic
+// for processing a request to Search for a report by ReportID.
+type searchRIDMgr struct {
+	nreq     *structs.NSearchRequestRID
+	provider *data.Provider
+	id       string
+	token    string
+	resp     []georeport.ServiceRequest
+	nresp    *structs.NSearchResponse
+}
+
+func (c *searchRIDMgr) ConvertRequest() error {
+	provider, err := data.RouteProvider(c.nreq.Route)
+	if err != nil {
+		return err
+	}
+	c.provider = provider
+	if strings.HasPrefix(c.nreq.RID.ID, tokenPrefix) {
+		c.token = strings.TrimPrefix(c.nreq.RID.ID, tokenPrefix)
+	} else {
+		c.id = c.nreq.RID.ID
+	}
+	if c.id == "" && c.token == "" {
+		return fmt.Errorf("invalid report ID: %q", c.nreq.RID.RID())
+	}
+	data.Adapter().SendRPC(c.nreq.GetIDS(), "open", "", provider.URL, 0, time.Now())
+	return nil
+}
+
+// Process executes the request to retrieve the report.
+func (c *searchRIDMgr) Process() error {
+	ctx, cancel := c.nreq.Context()
+	defer cancel()
+	client := c.provider.Client().WithContext(ctx)
+	if c.token != "" {
+		t, err := client.Token(c.token)
+		if err != nil {
+			return err
+		}
+		if t.ID == "" {
+			// Not assigned yet - there is nothing to retrieve.
+			return nil
+		}
+		c.id = string(t.ID)
+	}
+	sr, err := client.Request(c.id)
+	if err != nil {
+		return err
+	}
+	c.resp = []georeport.ServiceRequest{*sr}
+	return nil
+}
+
+func (c *searchRIDMgr) ConvertResponse() (int, error) {
+	n := convertReports(c.nresp, c.nreq.GetID, c.nreq.GetRoute(), c.provider, c.resp, 0)
+	if c.id == "" {
+		c.nresp.Message = "The request has been accepted, but the request ID has not been assigned yet"
+	}
+	return n, nil
+}
+
+func (c *searchRIDMgr) Fail(err error) error {
+	c.nresp.Message = "Failed - " + err.Error()
+	return err
+}
+
+func (c *searchRIDMgr) GetIDS() string {
+	return c.nreq.GetIDS()
+}
+
+func (c *searchRIDMgr) GetRoute() string {
+	return c.nreq.GetRoute().String()
+}
+
+func (c *searchRIDMgr) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddS("SearchRID\n")
+	ls.AddS(c.nreq.String())
+	ls.AddF("ID: %q  Token: %q\n", c.id, c.token)
+	ls.AddS(c.nresp.String())
+	return ls.Box(90)
+}
+
+// ================================================================================================
+//                                      CONVERSION
+// ================================================================================================
+
+// convertReports loads the GeoReport v2 service requests into the NSearchResponse, up
+// to maxResults (or dfltMaxResults if 0).  It returns the number of reports.
+func convertReports(nresp *structs.NSearchResponse, idf func() (int64, int64), route structs.NRoute, p *data.Provider, list []georeport.ServiceRequest, maxResults int) int {
+	if maxResults <= 0 {
+		maxResults = dfltMaxResults
+	}
+	nresp.SetIDF(idf)
+	nresp.SetRoute(route)
+	nresp.Message = "OK"
+	nresp.Reports = make([]structs.NSearchResponseReport, 0, len(list))
+	for _, sr := range list {
+		if len(nresp.Reports) >= maxResults {
+			break
+		}
+		nresp.Reports = append(nresp.Reports, convertReport(route, p, sr))
+	}
+	nresp.ReportCount = len(nresp.Reports)
+	return nresp.ReportCount
+}
+
+func convertReport(route structs.NRoute, p *data.Provider, sr georeport.ServiceRequest) structs.NSearchResponseReport {
+	rpt := structs.NSearchResponseReport{
+		RID:           structs.NewRID(route, string(sr.ID)),
+		DateCreated:   sr.RequestedAt,
+		DateUpdated:   sr.UpdatedAt,
+		RequestType:   sr.ServiceName,
+		RequestTypeID: strconv.Itoa(p.ServiceID(string(sr.ServiceCode))),
+		MediaURL:      sr.MediaURL,
+		City:          data.Adapter().AreaName(route.AreaID),
+		ZipCode:       string(sr.Zipcode),
+		Description:   sr.Description,
+		StatusType:    sr.Status,
+		TicketSLA:     sr.StatusNotes,
+	}
+	if sr.Lat != 0 || sr.Long != 0 {
+		rpt.Latitude = strconv.FormatFloat(float64(sr.Lat), 'f', -1, 64)
+		rpt.Longitude = strconv.FormatFloat(float64(sr.Long), 'f', -1, 64)
+	}
+	if n, err := geo.Normalize(sr.Address); err == nil {
+		if n.City != "" {
+			rpt.City = n.City
+		}
+		rpt.State = n.State
+		if rpt.ZipCode == "" {
+			rpt.ZipCode = n.ZipCode()
+		}
+	}
+	return rpt
+}
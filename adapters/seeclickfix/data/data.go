@@ -0,0 +1,153 @@
+package data
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/adapters/seeclickfix/scf"
+	"github.com/codeforsanjose/open311-gateway/common"
+	"github.com/codeforsanjose/open311-gateway/common/adaptersdk"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+var (
+	adp = adaptersdk.New(func() adaptersdk.Provider { return new(Provider) })
+)
+
+// Adapter returns the Adapter, with the config data common to all Adapters.
+func Adapter() *adaptersdk.Adapter {
+	return adp
+}
+
+// MIDProvider returns the Provider data for the specified MidAdpID.
+func MIDProvider(MID structs.ServiceID) (*Provider, error) {
+	p, err := adp.MIDProvider(MID)
+	if err != nil {
+		return nil, err
+	}
+	return p.(*Provider), nil
+}
+
+// RouteProvider returns the Provider data for the specified NRoute.
+func RouteProvider(route structs.NRoute) (*Provider, error) {
+	p, err := adp.RouteProvider(route)
+	if err != nil {
+		return nil, err
+	}
+	return p.(*Provider), nil
+}
+
+// capabilities of the SeeClickFix API.  SeeClickFix does not record the device
+// creating an issue, so it cannot be searched by DeviceID.
+var capabilities = structs.NCapabilities{
+	RequestTypes: []structs.NRequestType{structs.NRTCreate, structs.NRTSearchLL, structs.NRTSearchRID},
+	Media:        true,
+	Anonymous:    true,
+}
+
+// ==============================================================================================================================
+//                                      INIT
+// ==============================================================================================================================
+
+// Init loads the config file, and the Service lists of all Providers.
+func Init(configFile string) error {
+	adp.Capabilities = capabilities
+	return adp.Init(configFile, nil)
+}
+
+// ------------------------------- Provider -------------------------------
+
+// Provider is the data for each SeeClickFix API endpoint.  The Service ID is the
+// SeeClickFix request_type_id.  If Services is specified in the config file, it is used
+// as a static Service list.  Otherwise, the Request Types available at the Provider
+// location (Address, or Lat/Lng) are loaded from the API.
+type Provider struct {
+	adaptersdk.ProviderBase
+	URL      string  `json:"url"`
+	User     string  `json:"user"`     // Basic auth - issues are created as this user
+	Password string  `json:"password"` //
+	Address  string  `json:"address"`  // Location used to load the Request Types, e.g. "San Francisco, CA"
+	Lat      float64 `json:"lat"`      //
+	Lng      float64 `json:"lng"`      //
+	Timeout  int     `json:"timeout"`  // seconds
+	MaxPages int     `json:"maxPages"` // Maximum number of pages read per search
+
+	client    *scf.Client
+	questions map[int][]scf.Question // request_type_id -> non-standard questions
+	sync.RWMutex
+}
+
+// Settle creates the SeeClickFix API client.
+func (p *Provider) Settle() error {
+	if p.URL == "" {
+		return fmt.Errorf("provider %q has no url", p.Name)
+	}
+	if !p.Static() && p.Address == "" && p.Lat == 0 && p.Lng == 0 {
+		return fmt.Errorf("provider %q needs a location (address or lat/lng) to load its request types", p.Name)
+	}
+	p.client = scf.NewClient(p.URL, p.User, p.Password, p.MaxPages, time.Duration(p.Timeout)*time.Second)
+	p.questions = make(map[int][]scf.Question)
+	return nil
+}
+
+// Client returns the SeeClickFix API client for the Provider.
+func (p *Provider) Client() *scf.Client {
+	return p.client
+}
+
+// Questions returns the non-standard questions of the Request Type, or nil if it has
+// none, or they have not been loaded.
+func (p *Provider) Questions(requestTypeID int) []scf.Question {
+	p.RLock()
+	defer p.RUnlock()
+	return p.questions[requestTypeID]
+}
+
+// LoadServices loads the Request Types, and their questions, from the API.
+func (p *Provider) LoadServices() ([]*structs.NService, error) {
+	list, err := p.client.RequestTypes(p.Lat, p.Lng, p.Address)
+	if err != nil {
+		return nil, err
+	}
+	services := make([]*structs.NService, 0, len(list))
+	questions := make(map[int][]scf.Question)
+	for _, rt := range list {
+		srv := &structs.NService{
+			ServiceID: structs.ServiceID{ID: rt.ID},
+			Name:      rt.Title,
+			Group:     rt.Organization,
+		}
+		detail, err := p.client.RequestType(rt.ID)
+		if err != nil {
+			log.Warningf("Unable to load the questions for request type %d from %q - %s", rt.ID, p.Name, err)
+		} else {
+			for _, q := range detail.Questions {
+				if !q.Standard() {
+					questions[rt.ID] = append(questions[rt.ID], q)
+				}
+			}
+			srv.Metadata = len(questions[rt.ID]) > 0
+		}
+		services = append(services, srv)
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	p.questions = questions
+	return services, nil
+}
+
+func (p *Provider) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddF("%s (ID: %d)\n", p.Name, p.ID)
+	ls.AddF("URL: %s  User: %q  Password: **********\n", p.URL, p.User)
+	ls.AddF("Location: %q  lat: %v  lng: %v\n", p.Address, p.Lat, p.Lng)
+	ls.AddS("---SERVICES:\n")
+	for _, v := range p.Services {
+		ls.AddF("   %s\n", v)
+	}
+	return ls.Box(80)
+}
@@ -0,0 +1,134 @@
+// Package fixture is a stand-in for the SeeClickFix API, used for testing.  It serves
+// responses recorded from the SeeClickFix API, listed in "testdata/fixtures.json".
+package fixture
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Fixture maps a request to a recorded response.  A request matches if the method and
+// path are equal, all of the Query parameters are present, and the body contains Body.
+// The first matching Fixture is served.  "{{URL}}" in the response is replaced with
+// the stand-in server URL.
+type Fixture struct {
+	Method string            `json:"method"`
+	Path   string            `json:"path"`
+	Query  map[string]string `json:"query"`
+	Body   string            `json:"body"`
+	Status int               `json:"status"`
+	File   string            `json:"file"`
+
+	response string
+}
+
+// Recorded is a request received by the Server.
+type Recorded struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Body   string
+	User   string
+}
+
+// Server is the SeeClickFix stand-in.  The API base URL is URL + "/api/v2/".
+type Server struct {
+	*httptest.Server
+	fixtures []*Fixture
+	requests []Recorded
+	sync.Mutex
+}
+
+// NewServer loads the fixtures, and starts the Server.
+func NewServer() (*Server, error) {
+	_, src, _, _ := runtime.Caller(0)
+	dir := filepath.Join(filepath.Dir(src), "testdata")
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "fixtures.json"))
+	if err != nil {
+		return nil, err
+	}
+	s := new(Server)
+	if err := json.Unmarshal(b, &s.fixtures); err != nil {
+		return nil, err
+	}
+	for _, f := range s.fixtures {
+		b, err := ioutil.ReadFile(filepath.Join(dir, f.File))
+		if err != nil {
+			return nil, err
+		}
+		f.response = string(b)
+		if f.Status == 0 {
+			f.Status = http.StatusOK
+		}
+	}
+	s.Server = httptest.NewServer(s)
+	return s, nil
+}
+
+// APIURL returns the base API URL of the Server.
+func (s *Server) APIURL() string {
+	return s.URL + "/api/v2/"
+}
+
+// Requests returns all requests received by the Server.
+func (s *Server) Requests() []Recorded {
+	s.Lock()
+	defer s.Unlock()
+	return append([]Recorded(nil), s.requests...)
+}
+
+// Last returns the last request received by the Server.
+func (s *Server) Last() Recorded {
+	s.Lock()
+	defer s.Unlock()
+	if len(s.requests) == 0 {
+		return Recorded{}
+	}
+	return s.requests[len(s.requests)-1]
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	user, _, _ := r.BasicAuth()
+	rec := Recorded{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		Body:   string(body),
+		User:   user,
+	}
+	s.Lock()
+	s.requests = append(s.requests, rec)
+	s.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	for _, f := range s.fixtures {
+		if f.matches(rec) {
+			w.WriteHeader(f.Status)
+			w.Write([]byte(strings.Replace(f.response, "{{URL}}", s.URL, -1)))
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte(`{"error":"Not found"}`))
+}
+
+func (f *Fixture) matches(r Recorded) bool {
+	if f.Method != r.Method || f.Path != r.Path || !strings.Contains(r.Body, f.Body) {
+		return false
+	}
+	for k, v := range f.Query {
+		if len(r.Query[k]) == 0 || r.Query[k][0] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,29 @@
+package request
+
+import (
+	"testing"
+
+	"github.com/codeforsanjose/open311-gateway/adapters/seeclickfix/data"
+	"github.com/codeforsanjose/open311-gateway/common/adaptersdk/conformance"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+func TestConformance(t *testing.T) {
+	h, err := data.Adapter().Handler(&Report{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conformance.Run(t, conformance.Target{
+		Handler:   h,
+		AdpID:     "SCF1",
+		Area:      "San Francisco",
+		AreaID:    "SF",
+		Latitude:  37.7749,
+		Longitude: -122.4194,
+		Prepare: func(rqst *structs.NCreateRequest) {
+			rqst.Attributes = map[string]string{"51__nature_of_request": "Pothole"}
+		},
+		// The fixtures only serve issue 1000001.
+		Skip: []string{"Report.SearchRID"},
+	})
+}
@@ -0,0 +1,160 @@
+package request
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/adapters/seeclickfix/data"
+	"github.com/codeforsanjose/open311-gateway/adapters/seeclickfix/scf"
+	"github.com/codeforsanjose/open311-gateway/common"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+// maxSummary is the length of the summary created from the description, if the
+// request has no Service name.
+const maxSummary = 80
+
+// ================================================================================================
+//                                      CREATE
+// ================================================================================================
+
+// Create fully processes the Create request.
+func (r *Report) Create(rqst *structs.NCreateRequest, resp *structs.NCreateResponse) error {
+	log.Debugf("Create - request: %p  resp: %p\n", rqst, resp)
+	// Make the Create Manager
+	cm := &createMgr{
+		nreq:  rqst,
+		nresp: resp,
+	}
+	log.Debugf("createMgr: %#v\n", *cm)
+
+	return data.Adapter().Run("Report.Create", cm)
+}
+
+// createMgr conglomerates the Normal and Native structs and supervisor logic
+// for processing a request to Create a Report.
+//  1. Validates and converts the request from the Normal form to a SeeClickFix issue.
+//  2. Calls the Provider's SeeClickFix API.
+//  3. Converts the reply back to Normal form.
+//  4. Returns the Normal Response, and any errors.
+type createMgr struct {
+	nreq     *structs.NCreateRequest
+	provider *data.Provider
+	req      *scf.CreateParams
+	resp     *scf.Issue
+	nresp    *structs.NCreateResponse
+}
+
+func (c *createMgr) ConvertRequest() error {
+	provider, err := data.MIDProvider(c.nreq.MID)
+	if err != nil {
+		return err
+	}
+	c.provider = provider
+	if err := validateAnswers(provider.Questions(c.nreq.MID.ID), c.nreq.Attributes); err != nil {
+		return err
+	}
+	c.req = &scf.CreateParams{
+		Lat:           c.nreq.Latitude,
+		Lng:           c.nreq.Longitude,
+		Address:       c.nreq.FullAddress,
+		RequestTypeID: c.nreq.MID.ID,
+		Answers:       make(map[string]string),
+	}
+	for k, v := range c.nreq.Attributes {
+		c.req.Answers[k] = v
+	}
+	c.req.Answers[scf.QuestionSummary] = summary(c.nreq)
+	c.req.Answers[scf.QuestionDescription] = c.nreq.Description
+	if c.nreq.MediaURL != "" {
+		// SeeClickFix only accepts uploaded images, so link to the image instead.
+		c.req.Answers[scf.QuestionDescription] += "\n\nImage: " + c.nreq.MediaURL
+	}
+	data.Adapter().SendRPC(c.nreq.GetIDS(), "open", "", provider.URL, 0, time.Now())
+	return nil
+}
+
+// summary returns the issue summary - the Service name, or the start of the description.
+func summary(r *structs.NCreateRequest) string {
+	if r.ServiceName != "" {
+		return r.ServiceName
+	}
+	s := strings.TrimSpace(r.Description)
+	if i := strings.IndexAny(s, "\r\n"); i >= 0 {
+		s = s[:i]
+	}
+	if len(s) > maxSummary {
+		s = strings.TrimSpace(s[:maxSummary]) + "..."
+	}
+	return s
+}
+
+// validateAnswers checks the attribute values against the Request Type questions.
+func validateAnswers(questions []scf.Question, values map[string]string) error {
+	var errs []string
+	for _, q := range questions {
+		if err := q.Validate(values[q.PrimaryKey]); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("invalid attributes - %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Process executes the request to create a new report.
+func (c *createMgr) Process() (err error) {
+	ctx, cancel := c.nreq.Context()
+	defer cancel()
+	c.resp, err = c.provider.Client().WithContext(ctx).Create(*c.req)
+	return err
+}
+
+func (c *createMgr) ConvertResponse() (int, error) {
+	route := c.nreq.GetRoute()
+	c.nresp.SetIDF(c.nreq.GetID)
+	c.nresp.SetRoute(route)
+	if c.resp.ID == 0 {
+		return 0, fmt.Errorf("the response has no issue id")
+	}
+	c.nresp.RID = structs.NewRID(route, strconv.Itoa(c.resp.ID))
+	if c.resp.Reporter.ID != 0 {
+		c.nresp.AccountID = strconv.Itoa(c.resp.Reporter.ID)
+	}
+	c.nresp.Message = "Request successfully added"
+	return 1, nil
+}
+
+func (c *createMgr) Fail(err error) error {
+	c.nresp.Message = "Failed - " + err.Error()
+	c.nresp.RID = structs.ReportID{}
+	c.nresp.AccountID = ""
+	return err
+}
+
+func (c *createMgr) GetIDS() string {
+	return c.nreq.GetIDS()
+}
+
+func (c *createMgr) GetRoute() string {
+	return c.nreq.GetRoute().String()
+}
+
+func (c *createMgr) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddS("Create\n")
+	ls.AddS(c.nreq.String())
+	if c.req != nil {
+		ls.AddF("Params: %+v\n", *c.req)
+	}
+	if c.resp != nil {
+		ls.AddS(c.resp.String())
+	}
+	ls.AddS(c.nresp.String())
+	return ls.Box(90)
+}
@@ -0,0 +1,165 @@
+package request
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codeforsanjose/open311-gateway/adapters/seeclickfix/data"
+	"github.com/codeforsanjose/open311-gateway/adapters/seeclickfix/fixture"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+const testConfig = `{
+	"adapter": {"name": "SCF1", "type": "SeeClickFix", "address": ":5005"},
+	"monitor": {"address": "127.0.0.1:5081"},
+	"serviceAreas": {
+		"SF": {
+			"name": "San Francisco",
+			"providers": [{"id": 1, "name": "Fixture", "url": "%s", "user": "311gateway@gmail.com", "password": "secret", "address": "San Francisco, CA", "responseType": "realtime"}]
+		}
+	}
+}`
+
+var (
+	server *fixture.Server
+	route  = structs.NRoute{AdpID: "SCF1", AreaID: "SF", ProviderID: 1}
+)
+
+func TestMain(m *testing.M) {
+	var err error
+	if server, err = fixture.NewServer(); err != nil {
+		panic(err)
+	}
+	dir, err := ioutil.TempDir("", "seeclickfix")
+	if err != nil {
+		panic(err)
+	}
+	configFile := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(configFile, []byte(fmt.Sprintf(testConfig, server.APIURL())), 0644); err != nil {
+		panic(err)
+	}
+	if err := data.Init(configFile); err != nil {
+		panic(err)
+	}
+
+	rc := m.Run()
+	server.Close()
+	os.RemoveAll(dir)
+	os.Exit(rc)
+}
+
+func TestServices(t *testing.T) {
+	var resp structs.NServicesResponse
+	if err := data.Adapter().Services().Area(&structs.NServiceRequest{Area: "San Francisco"}, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Services) != 3 {
+		t.Fatalf("expected 3 services, got: %v", resp.Services)
+	}
+	pothole, vehicle, sidewalk := resp.Services[0], resp.Services[1], resp.Services[2]
+	if pothole.ID != 4052 || pothole.AdpID != "SCF1" || pothole.AreaID != "SF" || pothole.ProviderID != 1 || pothole.Name != "Street/Pothole" {
+		t.Errorf("unexpected service: %v", pothole)
+	}
+	if !pothole.Metadata || !vehicle.Metadata || sidewalk.Metadata {
+		t.Errorf("only services with non-standard questions should have metadata")
+	}
+}
+
+func TestCreate(t *testing.T) {
+	mid := structs.ServiceID{AdpID: "SCF1", AreaID: "SF", ProviderID: 1, ID: 4052}
+	rqst := &structs.NCreateRequest{MID: mid, Latitude: 37.774271, Longitude: -122.480027,
+		Description: "There's a pothole in front of my house.\nIt is deep.", MediaURL: "http://img.example.com/1.jpg"}
+	rqst.SetRoute(route)
+
+	var resp structs.NCreateResponse
+	if err := new(Report).Create(rqst, &resp); err == nil || !strings.Contains(err.Error(), "51__nature_of_request is required") {
+		t.Errorf("expected a missing answer error, got: %v", err)
+	}
+
+	rqst.Attributes = map[string]string{"51__nature_of_request": "Pothole"}
+	resp = structs.NCreateResponse{}
+	if err := new(Report).Create(rqst, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.RID.RID() != "SCF1-SF-1-1000005" || resp.AccountID != "501" {
+		t.Errorf("unexpected response: %v", resp)
+	}
+	body := server.Last().Body
+	for _, s := range []string{`"summary":"There's a pothole in front of my house."`, `"51__nature_of_request":"Pothole"`, `Image: http://img.example.com/1.jpg`, `"request_type_id":4052`} {
+		if !strings.Contains(body, s) {
+			t.Errorf("expected %s in the request: %s", s, body)
+		}
+	}
+}
+
+func TestCreateError(t *testing.T) {
+	mid := structs.ServiceID{AdpID: "SCF1", AreaID: "SF", ProviderID: 1, ID: 4043}
+	rqst := &structs.NCreateRequest{MID: mid, ServiceName: "Abandoned Vehicle", Latitude: 37.774271, Longitude: -122.480027}
+	rqst.SetRoute(route)
+	var resp structs.NCreateResponse
+	if err := new(Report).Create(rqst, &resp); err == nil || !strings.Contains(resp.Message, "duplicate") {
+		t.Errorf("expected a duplicate error, got: %v  %q", err, resp.Message)
+	}
+}
+
+func TestSearchLL(t *testing.T) {
+	rqst := &structs.NSearchRequestLL{Latitude: 37.774271, Longitude: -122.480027, Radius: 500, AreaID: "SF"}
+	rqst.SetRoute(route)
+	var resp structs.NSearchResponse
+	if err := new(Report).SearchLL(rqst, &resp); err != nil {
+		t.Fatal(err)
+	}
+	// Issue 1000004 is outside the radius.
+	if resp.ReportCount != 3 {
+		t.Fatalf("expected 3 reports inside the radius, got: %v", resp.Reports)
+	}
+	want := []struct{ rid, status string }{
+		{"SCF1-SF-1-1000001", "open"},
+		{"SCF1-SF-1-1000002", "open"},
+		{"SCF1-SF-1-1000003", "closed"},
+	}
+	for i, w := range want {
+		if rpt := resp.Reports[i]; rpt.RID.RID() != w.rid || rpt.StatusType != w.status {
+			t.Errorf("report %d: got %s/%s  want %s/%s", i, rpt.RID.RID(), rpt.StatusType, w.rid, w.status)
+		}
+	}
+	rpt := resp.Reports[0]
+	if rpt.RequestTypeID != "4052" || rpt.State != "CA" || rpt.ZipCode != "94121" || rpt.Votes != "2" || !strings.HasSuffix(rpt.URLDetail, "/issues/1000001") {
+		t.Errorf("unexpected report: %#v", rpt)
+	}
+}
+
+func TestSearchDID(t *testing.T) {
+	rqst := &structs.NSearchRequestDID{DeviceID: "12345", AreaID: "SF"}
+	rqst.SetRoute(route)
+	var resp structs.NSearchResponse
+	if err := new(Report).SearchDID(rqst, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ReportCount != 0 || !strings.Contains(resp.Message, "not support") {
+		t.Errorf("unexpected response: %v", resp)
+	}
+}
+
+func TestSearchRID(t *testing.T) {
+	rqst := &structs.NSearchRequestRID{RID: structs.NewRID(route, "1000001")}
+	rqst.SetRoute(route)
+	var resp structs.NSearchResponse
+	if err := new(Report).SearchRID(rqst, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ReportCount != 1 || resp.Reports[0].Latitude != "37.774271" || resp.Reports[0].StatusType != "open" {
+		t.Errorf("unexpected response: %v", resp)
+	}
+
+	for _, id := range []string{"42", "abc"} {
+		rqst.RID = structs.NewRID(route, id)
+		if err := new(Report).SearchRID(rqst, &resp); err == nil {
+			t.Errorf("expected an error for report %q", id)
+		}
+	}
+}
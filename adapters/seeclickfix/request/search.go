@@ -0,0 +1,308 @@
+package request
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/adapters/seeclickfix/data"
+	"github.com/codeforsanjose/open311-gateway/adapters/seeclickfix/scf"
+	"github.com/codeforsanjose/open311-gateway/common"
+	"github.com/codeforsanjose/open311-gateway/common/geo"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+const (
+	dfltMaxResults int = 20
+)
+
+// ================================================================================================
+//                                      SEARCH LL
+// ================================================================================================
+
+// SearchLL fully processes a "Search by Location" request.
+func (r *Report) SearchLL(rqst *structs.NSearchRequestLL, resp *structs.NSearchResponse) error {
+	log.Debugf("SearchLL - request: %p  resp: %p\n", rqst, resp)
+	// Make the Search Manager
+	cm := &searchLLMgr{
+		nreq:  rqst,
+		nresp: resp,
+	}
+	log.Debug(cm.nreq.String())
+
+	return data.Adapter().Run("Report.SearchLL", cm)
+}
+
+// searchLLMgr conglomerates the Normal and Native structs and supervisor logic
+// for processing a request to Search for reports by Location.
+//  1. Converts the request from the Normal form to a SeeClickFix issue query.
+//  2. Calls the Provider's SeeClickFix API, reading as many pages as needed.
+//  3. Drops any issues outside the radius - SeeClickFix only searches a bounding box.
+//  4. Converts the reply back to Normal form.
+type searchLLMgr struct {
+	nreq     *structs.NSearchRequestLL
+	provider *data.Provider
+	req      scf.Query
+	resp     []scf.Issue
+	nresp    *structs.NSearchResponse
+}
+
+func (c *searchLLMgr) ConvertRequest() error {
+	provider, err := data.RouteProvider(c.nreq.Route)
+	if err != nil {
+		return err
+	}
+	c.provider = provider
+	c.req = scf.Query{
+		Lat:    c.nreq.Latitude,
+		Lng:    c.nreq.Longitude,
+		Radius: c.nreq.Radius,
+		Status: scf.AllStatuses,
+	}
+	data.Adapter().SendRPC(c.nreq.GetIDS(), "open", "", provider.URL, 0, time.Now())
+	return nil
+}
+
+// Process executes the request to search for reports by location.
+func (c *searchLLMgr) Process() (err error) {
+	ctx, cancel := c.nreq.Context()
+	defer cancel()
+	c.resp, err = c.provider.Client().WithContext(ctx).Issues(c.req, maxResults(c.nreq.MaxResults))
+	return err
+}
+
+func (c *searchLLMgr) ConvertResponse() (int, error) {
+	inRadius := make([]scf.Issue, 0, len(c.resp))
+	for _, i := range c.resp {
+		if c.nreq.Radius <= 0 || geo.Distance(c.nreq.Latitude, c.nreq.Longitude, i.Lat, i.Lng) <= float64(c.nreq.Radius) {
+			inRadius = append(inRadius, i)
+		}
+	}
+	return convertIssues(c.nresp, c.nreq.GetID, c.nreq.GetRoute(), inRadius), nil
+}
+
+func (c *searchLLMgr) Fail(err error) error {
+	c.nresp.Message = "Failed - " + err.Error()
+	return err
+}
+
+func (c *searchLLMgr) GetIDS() string {
+	return c.nreq.GetIDS()
+}
+
+func (c *searchLLMgr) GetRoute() string {
+	return c.nreq.GetRoute().String()
+}
+
+func (c *searchLLMgr) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddS("SearchLL\n")
+	ls.AddS(c.nreq.String())
+	ls.AddF("Query: %v\n", c.req.Values())
+	ls.AddS(c.nresp.String())
+	return ls.Box(90)
+}
+
+// ================================================================================================
+//                                      SEARCH DID
+// ================================================================================================
+
+// SearchDID processes the Search by DeviceID request.  SeeClickFix does not record the
+// device that created an issue, so this always returns an empty list.
+func (r *Report) SearchDID(rqst *structs.NSearchRequestDID, resp *structs.NSearchResponse) error {
+	log.Debugf("SearchDID - request: %p  resp: %p\n", rqst, resp)
+	// Make the Search Manager
+	cm := &searchDIDMgr{
+		nreq:  rqst,
+		nresp: resp,
+	}
+
+	return data.Adapter().Run("Report.SearchDID", cm)
+}
+
+// searchDIDMgr conglomerates the Normal and Native structs and supervisor logic
+// for processing a request to Search for reports by Device ID.
+type searchDIDMgr struct {
+	nreq  *structs.NSearchRequestDID
+	nresp *structs.NSearchResponse
+}
+
+func (c *searchDIDMgr) ConvertRequest() error {
+	_, err := data.RouteProvider(c.nreq.Route)
+	return err
+}
+
+// Process does nothing - there is no device search.
+func (c *searchDIDMgr) Process() error {
+	return nil
+}
+
+func (c *searchDIDMgr) ConvertResponse() (int, error) {
+	n := convertIssues(c.nresp, c.nreq.GetID, c.nreq.GetRoute(), nil)
+	c.nresp.Message = "SeeClickFix does not support searching by DeviceID"
+	return n, nil
+}
+
+func (c *searchDIDMgr) Fail(err error) error {
+	c.nresp.Message = "Failed - " + err.Error()
+	return err
+}
+
+func (c *searchDIDMgr) GetIDS() string {
+	return c.nreq.GetIDS()
+}
+
+func (c *searchDIDMgr) GetRoute() string {
+	return c.nreq.GetRoute().String()
+}
+
+func (c *searchDIDMgr) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddS("SearchDID\n")
+	ls.AddS(c.nreq.String())
+	ls.AddS(c.nresp.String())
+	return ls.Box(90)
+}
+
+// ================================================================================================
+//                                      SEARCH RID
+// ================================================================================================
+
+// SearchRID fully processes the Search by ReportID request.
+func (r *Report) SearchRID(rqst *structs.NSearchRequestRID, resp *structs.NSearchResponse) error {
+	log.Debugf("SearchRID - request: %p  resp: %p\n", rqst, resp)
+	// Make the Search Manager
+	cm := &searchRIDMgr{
+		nreq:  rqst,
+		nresp: resp,
+	}
+
+	return data.Adapter().Run("Report.SearchRID", cm)
+}
+
+// searchRIDMgr conglomerates the Normal and Native structs and supervisor logic
+// for processing a request to Search for a report by ReportID.
+type searchRIDMgr struct {
+	nreq     *structs.NSearchRequestRID
+	provider *data.Provider
+	id       int
+	resp     *scf.Issue
+	nresp    *structs.NSearchResponse
+}
+
+func (c *searchRIDMgr) ConvertRequest() error {
+	provider, err := data.RouteProvider(c.nreq.Route)
+	if err != nil {
+		return err
+	}
+	c.provider = provider
+	c.id, err = strconv.Atoi(c.nreq.RID.ID)
+	if err != nil || c.id <= 0 {
+		return fmt.Errorf("invalid report ID: %q", c.nreq.RID.RID())
+	}
+	data.Adapter().SendRPC(c.nreq.GetIDS(), "open", "", provider.URL, 0, time.Now())
+	return nil
+}
+
+// Process executes the request to retrieve the issue.
+func (c *searchRIDMgr) Process() (err error) {
+	ctx, cancel := c.nreq.Context()
+	defer cancel()
+	c.resp, err = c.provider.Client().WithContext(ctx).Issue(c.id)
+	return err
+}
+
+func (c *searchRIDMgr) ConvertResponse() (int, error) {
+	return convertIssues(c.nresp, c.nreq.GetID, c.nreq.GetRoute(), []scf.Issue{*c.resp}), nil
+}
+
+func (c *searchRIDMgr) Fail(err error) error {
+	c.nresp.Message = "Failed - " + err.Error()
+	return err
+}
+
+func (c *searchRIDMgr) GetIDS() string {
+	return c.nreq.GetIDS()
+}
+
+func (c *searchRIDMgr) GetRoute() string {
+	return c.nreq.GetRoute().String()
+}
+
+func (c *searchRIDMgr) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddS("SearchRID\n")
+	ls.AddS(c.nreq.String())
+	ls.AddF("ID: %d\n", c.id)
+	ls.AddS(c.nresp.String())
+	return ls.Box(90)
+}
+
+// ================================================================================================
+//                                      CONVERSION
+// ================================================================================================
+
+func maxResults(n int) int {
+	if n <= 0 {
+		return dfltMaxResults
+	}
+	return n
+}
+
+// convertIssues loads the SeeClickFix issues into the NSearchResponse.  It returns the
+// number of reports.
+func convertIssues(nresp *structs.NSearchResponse, idf func() (int64, int64), route structs.NRoute, list []scf.Issue) int {
+	nresp.SetIDF(idf)
+	nresp.SetRoute(route)
+	nresp.Message = "OK"
+	nresp.Reports = make([]structs.NSearchResponseReport, 0, len(list))
+	for _, i := range list {
+		nresp.Reports = append(nresp.Reports, convertIssue(route, i))
+	}
+	nresp.ReportCount = len(nresp.Reports)
+	return nresp.ReportCount
+}
+
+func convertIssue(route structs.NRoute, i scf.Issue) structs.NSearchResponseReport {
+	rpt := structs.NSearchResponseReport{
+		RID:             structs.NewRID(route, strconv.Itoa(i.ID)),
+		DateCreated:     i.CreatedAt,
+		DateUpdated:     i.UpdatedAt,
+		RequestType:     i.RequestType.Title,
+		RequestTypeID:   strconv.Itoa(i.RequestType.ID),
+		MediaURL:        i.Media.ImageFull,
+		City:            data.Adapter().AreaName(route.AreaID),
+		Latitude:        strconv.FormatFloat(i.Lat, 'f', -1, 64),
+		Longitude:       strconv.FormatFloat(i.Lng, 'f', -1, 64),
+		Description:     i.Description,
+		AuthorNameFirst: i.Reporter.Name,
+		URLDetail:       i.HTMLURL,
+		URLShortened:    i.ShortenedURL,
+		Votes:           strconv.Itoa(i.Rating),
+		StatusType:      statusType(i.Status),
+	}
+	if rpt.Description == "" {
+		rpt.Description = i.Summary
+	}
+	if n, err := geo.Normalize(i.Address); err == nil {
+		if n.City != "" {
+			rpt.City = n.City
+		}
+		rpt.State = n.State
+		rpt.ZipCode = n.ZipCode()
+	}
+	return rpt
+}
+
+// statusType maps the SeeClickFix issue status to the Open311 status ("open" or
+// "closed").  Acknowledged issues are still open, and Archived issues are closed.
+func statusType(status string) string {
+	switch {
+	case strings.EqualFold(status, scf.StatusOpen), strings.EqualFold(status, scf.StatusAcknowledged):
+		return "open"
+	case strings.EqualFold(status, scf.StatusClosed), strings.EqualFold(status, scf.StatusArchived):
+		return "closed"
+	}
+	return strings.ToLower(status)
+}
@@ -0,0 +1,425 @@
+package scf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common"
+)
+
+// DefaultTimeout is the HTTP timeout used if none is specified.
+const DefaultTimeout = 5 * time.Second
+
+// Paging defaults.  SeeClickFix returns at most 100 issues per page.
+const (
+	DefaultPerPage  = 20
+	DefaultMaxPages = 5
+	maxPerPage      = 100
+)
+
+// Issue status values, as returned by SeeClickFix.
+const (
+	StatusOpen         = "Open"
+	StatusAcknowledged = "Acknowledged"
+	StatusClosed       = "Closed"
+	StatusArchived     = "Archived"
+)
+
+// AllStatuses is the status filter matching every issue.  By default, SeeClickFix only
+// returns Open and Acknowledged issues.
+var AllStatuses = []string{StatusOpen, StatusAcknowledged, StatusClosed, StatusArchived}
+
+// ================================================================================================
+//                                      CLIENT
+// ================================================================================================
+
+// Client calls the SeeClickFix v2 API.  URL is the base API URL, e.g.
+// "https://seeclickfix.com/api/v2/".  Issues are created as the User, using Basic
+// authentication.
+type Client struct {
+	URL      string
+	User     string
+	Password string
+	MaxPages int
+	HTTP     *http.Client
+
+	ctx context.Context
+}
+
+// NewClient returns a Client for the API.
+func NewClient(endpoint, user, password string, maxPages int, timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	if maxPages <= 0 {
+		maxPages = DefaultMaxPages
+	}
+	if !strings.HasSuffix(endpoint, "/") {
+		endpoint += "/"
+	}
+	return &Client{
+		URL:      endpoint,
+		User:     user,
+		Password: password,
+		MaxPages: maxPages,
+		HTTP:     &http.Client{Timeout: timeout},
+	}
+}
+
+// WithContext returns a copy of the Client whose requests are abandoned when the context
+// is done, e.g. at the deadline of the Engine request.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	c2 := *c
+	c2.ctx = ctx
+	return &c2
+}
+
+// RequestTypes returns the Request Types (i.e. report categories) available at the
+// location.  The location is either a lat/lng, or an address.
+func (c *Client) RequestTypes(lat, lng float64, address string) ([]RequestType, error) {
+	params := url.Values{}
+	if address != "" {
+		params.Set("address", address)
+	} else {
+		params.Set("lat", strconv.FormatFloat(lat, 'f', -1, 64))
+		params.Set("lng", strconv.FormatFloat(lng, 'f', -1, 64))
+	}
+	var r struct {
+		RequestTypes []RequestType `json:"request_types"`
+	}
+	if err := c.call("GET", "issues/new", params, nil, &r); err != nil {
+		return nil, err
+	}
+	return r.RequestTypes, nil
+}
+
+// RequestType returns the Request Type, including its questions.
+func (c *Client) RequestType(id int) (*RequestType, error) {
+	var rt RequestType
+	if err := c.call("GET", "request_types/"+strconv.Itoa(id), nil, nil, &rt); err != nil {
+		return nil, err
+	}
+	return &rt, nil
+}
+
+// Issues returns up to max Issues matching the query, following the pagination links
+// until max issues have been read, there are no more pages, or MaxPages pages have
+// been read.
+func (c *Client) Issues(q Query, max int) ([]Issue, error) {
+	params := q.Values()
+	perPage := max
+	if perPage <= 0 || perPage > maxPerPage {
+		perPage = maxPerPage
+	}
+	params.Set("per_page", strconv.Itoa(perPage))
+
+	var list []Issue
+	for page, n := 1, 0; page > 0 && n < c.MaxPages; n++ {
+		params.Set("page", strconv.Itoa(page))
+		var r issuesResponse
+		if err := c.call("GET", "issues", params, nil, &r); err != nil {
+			return nil, err
+		}
+		list = append(list, r.Issues...)
+		if max > 0 && len(list) >= max {
+			return list[:max], nil
+		}
+		page = r.Metadata.Pagination.NextPage
+	}
+	return list, nil
+}
+
+// Issue returns the Issue with the specified ID.
+func (c *Client) Issue(id int) (*Issue, error) {
+	var i Issue
+	if err := c.call("GET", "issues/"+strconv.Itoa(id), nil, nil, &i); err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+// Create creates a new Issue, and returns it.
+func (c *Client) Create(p CreateParams) (*Issue, error) {
+	var i Issue
+	if err := c.call("POST", "issues", nil, p, &i); err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+// call sends the request, and decodes the JSON response into v.  The body, if any, is
+// sent as JSON.
+func (c *Client) call(method, path string, params url.Values, body, v interface{}) error {
+	u := c.URL + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+
+	var rdr io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rdr = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, u, rdr)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.User != "" {
+		req.SetBasicAuth(c.User, c.Password)
+	}
+
+	if c.ctx != nil {
+		req = req.WithContext(c.ctx)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return decodeError(resp)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// decodeError builds an Error from the response.  SeeClickFix returns errors as
+// either {"error": "msg"}, or {"errors": {"field": ["msg", ...]}}.
+func decodeError(resp *http.Response) error {
+	e := &Error{Status: resp.StatusCode}
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	var r struct {
+		Error  string              `json:"error"`
+		Errors map[string][]string `json:"errors"`
+	}
+	if json.Unmarshal(body, &r) != nil {
+		e.Messages = []string{strings.TrimSpace(string(body))}
+		return e
+	}
+	if r.Error != "" {
+		e.Messages = append(e.Messages, r.Error)
+	}
+	for field, msgs := range r.Errors {
+		for _, msg := range msgs {
+			if field != "base" {
+				msg = field + " " + msg
+			}
+			e.Messages = append(e.Messages, msg)
+		}
+	}
+	sort.Strings(e.Messages)
+	return e
+}
+
+// ================================================================================================
+//                                      TYPES
+// ================================================================================================
+
+// RequestType is a SeeClickFix Request Type (i.e. a report category).  The Questions
+// are only returned by Client.RequestType().
+type RequestType struct {
+	ID           int        `json:"id"`
+	Title        string     `json:"title"`
+	Organization string     `json:"organization"`
+	URL          string     `json:"url"`
+	Questions    []Question `json:"questions"`
+}
+
+// Standard question keys, asked for every Request Type.
+const (
+	QuestionSummary     = "summary"
+	QuestionDescription = "description"
+)
+
+// Question is a question that must (or may) be answered when creating an Issue.
+type Question struct {
+	Question     string        `json:"question"`
+	Type         string        `json:"question_type"` // text, textarea, select, multivaluelist, number, datetime, note, hidden
+	Required     bool          `json:"response_required"`
+	PrimaryKey   string        `json:"primary_key"`
+	SelectValues []SelectValue `json:"select_values"`
+}
+
+// SelectValue is an allowed answer for "select" and "multivaluelist" questions.
+type SelectValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Standard reports whether the question is one of the standard questions.
+func (q Question) Standard() bool {
+	return q.PrimaryKey == QuestionSummary || q.PrimaryKey == QuestionDescription
+}
+
+// Validate checks an answer for the question.  A blank answer is valid if the question
+// is not required.
+func (q Question) Validate(answer string) error {
+	if strings.TrimSpace(answer) == "" {
+		if q.Required {
+			return fmt.Errorf("%s is required", q.PrimaryKey)
+		}
+		return nil
+	}
+	switch q.Type {
+	case "number":
+		if _, err := strconv.ParseFloat(answer, 64); err != nil {
+			return fmt.Errorf("%s must be a number", q.PrimaryKey)
+		}
+	case "select":
+		if !q.hasValue(answer) {
+			return fmt.Errorf("%s has an invalid value: %q", q.PrimaryKey, answer)
+		}
+	case "multivaluelist":
+		for _, v := range strings.Split(answer, ",") {
+			if !q.hasValue(strings.TrimSpace(v)) {
+				return fmt.Errorf("%s has an invalid value: %q", q.PrimaryKey, v)
+			}
+		}
+	}
+	return nil
+}
+
+func (q Question) hasValue(v string) bool {
+	for _, sv := range q.SelectValues {
+		if sv.Value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Issue is a SeeClickFix issue (i.e. a report).
+type Issue struct {
+	ID             int         `json:"id"`
+	Status         string      `json:"status"`
+	Summary        string      `json:"summary"`
+	Description    string      `json:"description"`
+	Rating         int         `json:"rating"`
+	Lat            float64     `json:"lat"`
+	Lng            float64     `json:"lng"`
+	Address        string      `json:"address"`
+	CreatedAt      string      `json:"created_at"`
+	AcknowledgedAt string      `json:"acknowledged_at"`
+	ClosedAt       string      `json:"closed_at"`
+	UpdatedAt      string      `json:"updated_at"`
+	HTMLURL        string      `json:"html_url"`
+	ShortenedURL   string      `json:"shortened_url"`
+	RequestType    RequestType `json:"request_type"`
+	Reporter       struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"reporter"`
+	Media struct {
+		ImageFull  string `json:"image_full"`
+		ImageThumb string `json:"image_square_100x100"`
+	} `json:"media"`
+}
+
+type issuesResponse struct {
+	Issues   []Issue `json:"issues"`
+	Metadata struct {
+		Pagination Pagination `json:"pagination"`
+	} `json:"metadata"`
+}
+
+// Pagination is the paging metadata of a list of Issues.  NextPage is 0 on the last page.
+type Pagination struct {
+	Entries  int `json:"entries"`
+	Page     int `json:"page"`
+	PerPage  int `json:"per_page"`
+	Pages    int `json:"pages"`
+	NextPage int `json:"next_page"`
+}
+
+// Query is a search for Issues.  If Radius (meters) is set, the search is limited to
+// the bounding box around Lat/Lng - SeeClickFix has no radius search, so the results
+// should be filtered by distance.  Results are sorted by distance from Lat/Lng, or by
+// creation date if there is no location.
+type Query struct {
+	Lat     float64
+	Lng     float64
+	Radius  int
+	Address string
+	Status  []string
+}
+
+// Values returns the query parameters.
+func (q Query) Values() url.Values {
+	v := url.Values{}
+	if q.Lat != 0 || q.Lng != 0 {
+		v.Set("lat", strconv.FormatFloat(q.Lat, 'f', -1, 64))
+		v.Set("lng", strconv.FormatFloat(q.Lng, 'f', -1, 64))
+		v.Set("sort", "distance")
+		if q.Radius > 0 {
+			dLat := float64(q.Radius) / 111320.0
+			dLng := dLat / math.Cos(q.Lat*math.Pi/180)
+			v.Set("min_lat", strconv.FormatFloat(q.Lat-dLat, 'f', 6, 64))
+			v.Set("max_lat", strconv.FormatFloat(q.Lat+dLat, 'f', 6, 64))
+			v.Set("min_lng", strconv.FormatFloat(q.Lng-dLng, 'f', 6, 64))
+			v.Set("max_lng", strconv.FormatFloat(q.Lng+dLng, 'f', 6, 64))
+		}
+	} else {
+		v.Set("sort", "created_at")
+	}
+	if q.Address != "" {
+		v.Set("address", q.Address)
+	}
+	if len(q.Status) > 0 {
+		v.Set("status", strings.ToLower(strings.Join(q.Status, ",")))
+	}
+	return v
+}
+
+// CreateParams is the payload to create an Issue.  Answers holds the answers to the
+// Request Type questions, by primary key, including the summary and description.
+type CreateParams struct {
+	Lat           float64           `json:"lat"`
+	Lng           float64           `json:"lng"`
+	Address       string            `json:"address,omitempty"`
+	RequestTypeID int               `json:"request_type_id"`
+	Answers       map[string]string `json:"answers"`
+}
+
+// Error is an error returned by the SeeClickFix API.
+type Error struct {
+	Status   int
+	Messages []string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("SeeClickFix error (HTTP %d) - %s", e.Status, strings.Join(e.Messages, "; "))
+}
+
+// ================================================================================================
+//                                      STRINGS
+// ================================================================================================
+
+// String displays an Issue.
+func (i Issue) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddS("scf.Issue\n")
+	ls.AddF("ID: %d  Status: %s  Rating: %d\n", i.ID, i.Status, i.Rating)
+	ls.AddF("Request Type - id: %d  title: %q\n", i.RequestType.ID, i.RequestType.Title)
+	ls.AddF("Created: %s  Updated: %s\n", i.CreatedAt, i.UpdatedAt)
+	ls.AddF("Location - lat: %v  lng: %v  %s\n", i.Lat, i.Lng, i.Address)
+	ls.AddF("Summary: %q\n", i.Summary)
+	return ls.Box(80)
+}
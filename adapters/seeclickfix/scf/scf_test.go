@@ -0,0 +1,178 @@
+package scf_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/codeforsanjose/open311-gateway/adapters/seeclickfix/fixture"
+	"github.com/codeforsanjose/open311-gateway/adapters/seeclickfix/scf"
+)
+
+func newClient(t *testing.T, maxPages int) (*fixture.Server, *scf.Client) {
+	s, err := fixture.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, scf.NewClient(s.APIURL(), "311gateway@gmail.com", "secret", maxPages, 0)
+}
+
+func TestRequestTypes(t *testing.T) {
+	s, c := newClient(t, 0)
+	defer s.Close()
+
+	list, err := c.RequestTypes(0, 0, "San Francisco, CA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 || list[0].ID != 4052 || list[0].Title != "Street/Pothole" || list[0].Organization != "City of San Francisco" {
+		t.Errorf("unexpected request types: %+v", list)
+	}
+	if q := s.Last().Query; q.Get("address") != "San Francisco, CA" || q.Get("lat") != "" {
+		t.Errorf("unexpected query: %v", q)
+	}
+
+	rt, err := c.RequestType(4052)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rt.Questions) != 3 || !rt.Questions[0].Standard() || rt.Questions[2].Standard() || len(rt.Questions[2].SelectValues) != 3 {
+		t.Errorf("unexpected request type: %+v", rt)
+	}
+}
+
+func TestIssuesPaging(t *testing.T) {
+	s, c := newClient(t, 0)
+	defer s.Close()
+
+	q := scf.Query{Lat: 37.774271, Lng: -122.480027, Radius: 500, Status: scf.AllStatuses}
+	list, err := c.Issues(q, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 4 || list[3].ID != 1000004 || list[2].Status != scf.StatusClosed {
+		t.Fatalf("expected all 4 issues from both pages, got: %+v", list)
+	}
+	reqs := s.Requests()
+	if len(reqs) != 2 || reqs[0].Query.Get("page") != "1" || reqs[1].Query.Get("page") != "2" {
+		t.Errorf("expected 2 page requests, got: %+v", reqs)
+	}
+	p := reqs[0].Query
+	if p.Get("status") != "open,acknowledged,closed,archived" || p.Get("sort") != "distance" || p.Get("min_lat") == "" || p.Get("max_lng") == "" {
+		t.Errorf("unexpected query: %v", p)
+	}
+
+	// Paging stops as soon as there are enough issues.
+	list, err = c.Issues(q, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || len(s.Requests()) != 3 {
+		t.Errorf("expected 2 issues from 1 page, got: %d issues from %d requests", len(list), len(s.Requests())-2)
+	}
+	if s.Last().Query.Get("per_page") != "2" {
+		t.Errorf("unexpected per_page: %v", s.Last().Query)
+	}
+}
+
+func TestIssuesMaxPages(t *testing.T) {
+	s, c := newClient(t, 1)
+	defer s.Close()
+
+	list, err := c.Issues(scf.Query{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || len(s.Requests()) != 1 {
+		t.Errorf("expected 1 page, got: %d issues from %d requests", len(list), len(s.Requests()))
+	}
+	if s.Last().Query.Get("sort") != "created_at" {
+		t.Errorf("unexpected query: %v", s.Last().Query)
+	}
+}
+
+func TestIssue(t *testing.T) {
+	s, c := newClient(t, 0)
+	defer s.Close()
+
+	i, err := c.Issue(1000001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i.Status != scf.StatusOpen || i.RequestType.ID != 4052 || i.Reporter.Name != "311gateway" || !strings.HasSuffix(i.Media.ImageFull, "full.jpg") {
+		t.Errorf("unexpected issue: %+v", i)
+	}
+
+	_, err = c.Issue(42)
+	if e, ok := err.(*scf.Error); !ok || e.Status != 404 || e.Messages[0] != "Not found" {
+		t.Errorf("unexpected error: %#v", err)
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	s, c := newClient(t, 0)
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.WithContext(ctx).Issue(1000001); err == nil || len(s.Requests()) != 0 {
+		t.Errorf("expected a cancelled request not to be sent: %v", err)
+	}
+	if _, err := c.Issue(1000001); err != nil {
+		t.Errorf("the Client should not be changed: %v", err)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	s, c := newClient(t, 0)
+	defer s.Close()
+
+	p := scf.CreateParams{
+		Lat:           37.774271,
+		Lng:           -122.480027,
+		Address:       "717 21st Ave, San Francisco, CA",
+		RequestTypeID: 4052,
+		Answers:       map[string]string{"summary": "Found a pothole", "51__nature_of_request": "Pothole"},
+	}
+	i, err := c.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i.ID != 1000005 {
+		t.Errorf("unexpected issue: %+v", i)
+	}
+	last := s.Last()
+	var sent scf.CreateParams
+	if err := json.Unmarshal([]byte(last.Body), &sent); err != nil {
+		t.Fatal(err)
+	}
+	if last.Method != "POST" || last.User != "311gateway@gmail.com" || sent.RequestTypeID != 4052 || sent.Answers["51__nature_of_request"] != "Pothole" {
+		t.Errorf("unexpected request: %+v", last)
+	}
+
+	p.RequestTypeID = 4043
+	_, err = c.Create(p)
+	e, ok := err.(*scf.Error)
+	if !ok || e.Status != 422 || len(e.Messages) != 2 || e.Messages[0] != "This issue appears to be a duplicate" || e.Messages[1] != "lat is outside of the service area" {
+		t.Errorf("unexpected error: %#v", err)
+	}
+}
+
+func TestQuestionValidate(t *testing.T) {
+	q := scf.Question{PrimaryKey: "nature", Type: "select", Required: true,
+		SelectValues: []scf.SelectValue{{Key: "Pothole", Value: "Pothole"}, {Key: "Other", Value: "Street_Other"}}}
+	for _, tc := range []struct {
+		answer string
+		ok     bool
+	}{{"Pothole", true}, {"Street_Other", true}, {"Other", false}, {"", false}} {
+		if err := q.Validate(tc.answer); (err == nil) != tc.ok {
+			t.Errorf("Validate(%q) = %v", tc.answer, err)
+		}
+	}
+
+	q = scf.Question{PrimaryKey: "count", Type: "number"}
+	if q.Validate("") != nil || q.Validate("3") != nil || q.Validate("three") == nil {
+		t.Errorf("number validation failed")
+	}
+}
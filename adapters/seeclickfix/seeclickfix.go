@@ -0,0 +1,11 @@
+package main
+
+import (
+	"github.com/codeforsanjose/open311-gateway/adapters/seeclickfix/data"
+	"github.com/codeforsanjose/open311-gateway/adapters/seeclickfix/request"
+	"github.com/codeforsanjose/open311-gateway/common/adaptersdk"
+)
+
+func main() {
+	adaptersdk.Main(data.Adapter(), data.Init, &request.Report{})
+}
@@ -0,0 +1,110 @@
+package data
+
+import (
+	"net"
+
+	"github.com/codeforsanjose/open311-gateway/adapters/simulator/fault"
+	"github.com/codeforsanjose/open311-gateway/adapters/simulator/store"
+	"github.com/codeforsanjose/open311-gateway/common"
+	"github.com/codeforsanjose/open311-gateway/common/adaptersdk"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+var (
+	adp        = adaptersdk.New(func() adaptersdk.Provider { return new(Provider) })
+	configData ConfigData
+	reports    *store.Store
+)
+
+// ShowConfigData dumps configData using spew.
+func ShowConfigData() string {
+	return spew.Sdump(configData)
+}
+
+// Adapter returns the Adapter, with the config data common to all Adapters.
+func Adapter() *adaptersdk.Adapter {
+	return adp
+}
+
+// Reports returns the report store.
+func Reports() *store.Store {
+	return reports
+}
+
+// RouteProvider returns the Provider data for the specified NRoute.
+func RouteProvider(route structs.NRoute) (*Provider, error) {
+	p, err := adp.RouteProvider(route)
+	if err != nil {
+		return nil, err
+	}
+	return p.(*Provider), nil
+}
+
+// capabilities of the Simulator - all requests.
+var capabilities = structs.NCapabilities{
+	RequestTypes: []structs.NRequestType{structs.NRTCreate, structs.NRTSearchLL, structs.NRTSearchDID, structs.NRTSearchRID},
+	Media:        true,
+	Anonymous:    true,
+}
+
+// ==============================================================================================================================
+//                                      INIT
+// ==============================================================================================================================
+
+// Init loads the config file, and initializes the report store and faults.  The faults
+// are injected into every RPC method, and can drop the RPC connections.
+func Init(configFile string) error {
+	adp.Capabilities = capabilities
+	if err := adp.Init(configFile, &configData); err != nil {
+		return err
+	}
+	if err := fault.Init(configData.Faults); err != nil {
+		return err
+	}
+	reports = store.New(configData.Store.Size)
+	adp.Before = fault.Apply
+	adp.Listener = func(l net.Listener) net.Listener {
+		return fault.NewListener(l)
+	}
+	return nil
+}
+
+// ==============================================================================================================================
+//                                      ROUTE DATA
+// ==============================================================================================================================
+
+// ConfigData contains the Simulator specific settings in the config file.
+type ConfigData struct {
+	Store struct {
+		Size int `json:"size"` // Maximum number of reports kept
+	} `json:"store"`
+	Faults fault.Config `json:"faults"`
+}
+
+// String returns the represeentation of the ConfigData custom type.
+func (pd ConfigData) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddF("[%s] ConfigData\n", adp.Name())
+	ls.AddF("Store - size: %d\n", pd.Store.Size)
+	ls.AddS(fault.String())
+	return ls.Box(90)
+}
+
+// ------------------------------- Provider -------------------------------
+
+// Provider is a simulated Service Provider, with a static list of Services.
+type Provider struct {
+	adaptersdk.ProviderBase
+}
+
+func (p Provider) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddF("%s (ID: %d)\n", p.Name, p.ID)
+	ls.AddS("---SERVICES:\n")
+	for _, v := range p.Services {
+		ls.AddF("   %s\n", v)
+	}
+	return ls.Box(80)
+}
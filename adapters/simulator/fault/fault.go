@@ -0,0 +1,234 @@
+// Package fault injects latency, errors, timeouts and disconnects into the Simulator
+// RPC methods.  Faults are set per RPC method (e.g. "Report.Create"), with "*" as the
+// default for methods that have no Fault of their own.
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common"
+)
+
+// Default is the method name of the default Fault.
+const Default = "*"
+
+// dfltHang is how long a simulated timeout hangs, if not configured.
+const dfltHang = 60 * time.Second
+
+// Errors returned by Apply().
+var (
+	ErrInjected   = errors.New("simulated error")
+	ErrTimeout    = errors.New("simulated timeout")
+	ErrDisconnect = errors.New("simulated disconnect")
+)
+
+var (
+	faults = newInjector()
+)
+
+// Fault is the set of faults injected into an RPC method.  Latency and Jitter are in
+// milliseconds - each call is delayed by Latency, plus a random amount up to Jitter.
+// The rates are the probability (0.0 - 1.0) of each call failing in that way.
+type Fault struct {
+	Latency        int     `json:"latency"`
+	Jitter         int     `json:"jitter"`
+	ErrorRate      float64 `json:"errorRate"`
+	TimeoutRate    float64 `json:"timeoutRate"`
+	DisconnectRate float64 `json:"disconnectRate"`
+}
+
+// Validate checks the Fault settings.
+func (f Fault) Validate() error {
+	if f.Latency < 0 || f.Jitter < 0 {
+		return fmt.Errorf("latency and jitter must not be negative")
+	}
+	for _, r := range []float64{f.ErrorRate, f.TimeoutRate, f.DisconnectRate} {
+		if r < 0 || r > 1 {
+			return fmt.Errorf("rates must be between 0 and 1")
+		}
+	}
+	if f.ErrorRate+f.TimeoutRate+f.DisconnectRate > 1 {
+		return fmt.Errorf("the sum of the rates must not exceed 1")
+	}
+	return nil
+}
+
+// Config is the "faults" section of the config file.  Hang is the number of seconds a
+// simulated timeout hangs before failing - it should be longer than the Engine RPC
+// timeout.  If Seed is non-zero, the random faults are repeatable.
+type Config struct {
+	Hang    int              `json:"hang"`
+	Seed    int64            `json:"seed"`
+	Methods map[string]Fault `json:"methods"`
+}
+
+// ================================================================================================
+//                                      INJECTOR
+// ================================================================================================
+
+type injector struct {
+	config     Config
+	methods    map[string]Fault
+	hang       time.Duration
+	rnd        *rand.Rand
+	disconnect func()
+	calls      map[string]int64
+	injected   map[string]int64
+	sync.Mutex
+}
+
+func newInjector() *injector {
+	return &injector{
+		methods:  make(map[string]Fault),
+		hang:     dfltHang,
+		rnd:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		calls:    make(map[string]int64),
+		injected: make(map[string]int64),
+	}
+}
+
+// Init loads the faults from the config file settings.
+func Init(cfg Config) error {
+	for method, f := range cfg.Methods {
+		if err := f.Validate(); err != nil {
+			return fmt.Errorf("invalid fault for %q - %s", method, err)
+		}
+	}
+	faults.Lock()
+	defer faults.Unlock()
+	faults.config = cfg
+	faults.hang = dfltHang
+	if cfg.Hang > 0 {
+		faults.hang = time.Duration(cfg.Hang) * time.Second
+	}
+	if cfg.Seed != 0 {
+		faults.rnd = rand.New(rand.NewSource(cfg.Seed))
+	}
+	faults.methods = make(map[string]Fault)
+	for method, f := range cfg.Methods {
+		faults.methods[method] = f
+	}
+	return nil
+}
+
+// Reset restores the faults from the config file, and clears the counters.
+func Reset() {
+	faults.Lock()
+	cfg := faults.config
+	faults.calls = make(map[string]int64)
+	faults.injected = make(map[string]int64)
+	faults.Unlock()
+	_ = Init(cfg)
+}
+
+// Set sets the Fault for the method.  A zero Fault removes it.
+func Set(method string, f Fault) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	faults.Lock()
+	defer faults.Unlock()
+	if f == (Fault{}) {
+		delete(faults.methods, method)
+		return nil
+	}
+	faults.methods[method] = f
+	return nil
+}
+
+// Faults returns a copy of the current Faults.
+func Faults() map[string]Fault {
+	faults.Lock()
+	defer faults.Unlock()
+	m := make(map[string]Fault, len(faults.methods))
+	for k, v := range faults.methods {
+		m[k] = v
+	}
+	return m
+}
+
+// Counts returns the number of calls, and the number of injected failures, by method.
+func Counts() (calls, injected map[string]int64) {
+	faults.Lock()
+	defer faults.Unlock()
+	calls, injected = make(map[string]int64), make(map[string]int64)
+	for k, v := range faults.calls {
+		calls[k] = v
+	}
+	for k, v := range faults.injected {
+		injected[k] = v
+	}
+	return calls, injected
+}
+
+// SetDisconnect sets the function called to drop the Engine connections.
+func SetDisconnect(f func()) {
+	faults.Lock()
+	defer faults.Unlock()
+	faults.disconnect = f
+}
+
+// Apply injects the Fault for the method.  It delays the call, then returns nil if the
+// call should proceed, or the error the call should fail with.
+func Apply(method string) error {
+	faults.Lock()
+	f, ok := faults.methods[method]
+	if !ok {
+		f = faults.methods[Default]
+	}
+	delay := time.Duration(f.Latency) * time.Millisecond
+	if f.Jitter > 0 {
+		delay += time.Duration(faults.rnd.Intn(f.Jitter+1)) * time.Millisecond
+	}
+	r := faults.rnd.Float64()
+	hang, disconnect := faults.hang, faults.disconnect
+	faults.calls[method]++
+
+	var err error
+	switch {
+	case r < f.DisconnectRate:
+		err = ErrDisconnect
+	case r < f.DisconnectRate+f.TimeoutRate:
+		err = ErrTimeout
+	case r < f.DisconnectRate+f.TimeoutRate+f.ErrorRate:
+		err = ErrInjected
+	}
+	if err != nil {
+		faults.injected[method]++
+	}
+	faults.Unlock()
+
+	time.Sleep(delay)
+	switch err {
+	case ErrTimeout:
+		time.Sleep(hang)
+	case ErrDisconnect:
+		if disconnect != nil {
+			disconnect()
+		}
+	}
+	return err
+}
+
+// String displays the current Faults.
+func String() string {
+	m := Faults()
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	ls := new(common.FmtBoxer)
+	ls.AddS("Faults\n")
+	for _, k := range keys {
+		f := m[k]
+		ls.AddF("%-18s latency: %dms (+%dms)  error: %.2f  timeout: %.2f  disconnect: %.2f\n",
+			k, f.Latency, f.Jitter, f.ErrorRate, f.TimeoutRate, f.DisconnectRate)
+	}
+	return ls.Box(100)
+}
@@ -0,0 +1,153 @@
+package fault
+
+import (
+	"net"
+	"net/http"
+	"net/rpc"
+	"testing"
+	"time"
+)
+
+func TestApply(t *testing.T) {
+	if err := Init(Config{Seed: 1, Methods: map[string]Fault{
+		Default:         {Latency: 20},
+		"Report.Create": {ErrorRate: 1},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if err := Apply("Report.SearchLL"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Errorf("expected the default latency, got: %v", d)
+	}
+	if err := Apply("Report.Create"); err != ErrInjected {
+		t.Errorf("expected an injected error, got: %v", err)
+	}
+
+	calls, injected := Counts()
+	if calls["Report.Create"] != 1 || injected["Report.Create"] != 1 || injected["Report.SearchLL"] != 0 {
+		t.Errorf("unexpected counts: %v  %v", calls, injected)
+	}
+
+	// Remove the Report.Create fault - it falls back to the default.
+	if err := Set("Report.Create", Fault{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Apply("Report.Create"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	Reset()
+	if f := Faults(); f["Report.Create"].ErrorRate != 1 {
+		t.Errorf("Reset should restore the config faults, got: %v", f)
+	}
+	if calls, _ := Counts(); len(calls) != 0 {
+		t.Errorf("Reset should clear the counts, got: %v", calls)
+	}
+}
+
+func TestRates(t *testing.T) {
+	if err := Init(Config{Seed: 42, Methods: map[string]Fault{Default: {ErrorRate: 0.25}}}); err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for i := 0; i < 2000; i++ {
+		if Apply("x") != nil {
+			n++
+		}
+	}
+	if n < 400 || n > 600 {
+		t.Errorf("expected about 500 errors, got: %d", n)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	if err := Init(Config{Methods: map[string]Fault{Default: {TimeoutRate: 1}}}); err != nil {
+		t.Fatal(err)
+	}
+	faults.Lock()
+	faults.hang = 30 * time.Millisecond
+	faults.Unlock()
+
+	start := time.Now()
+	if err := Apply("x"); err != ErrTimeout {
+		t.Errorf("expected a timeout, got: %v", err)
+	}
+	if time.Since(start) < 30*time.Millisecond {
+		t.Errorf("the timeout should hang")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, f := range []Fault{{Latency: -1}, {ErrorRate: 1.5}, {ErrorRate: 0.6, TimeoutRate: 0.6}} {
+		if f.Validate() == nil {
+			t.Errorf("expected %+v to be invalid", f)
+		}
+		if Set("x", f) == nil {
+			t.Errorf("Set should reject %+v", f)
+		}
+	}
+	if err := Init(Config{Methods: map[string]Fault{"x": {DisconnectRate: 2}}}); err == nil {
+		t.Errorf("Init should reject invalid faults")
+	}
+}
+
+// Echo is an RPC service for the disconnect test.
+type Echo struct{}
+
+// Call applies the faults, and echoes the argument.
+func (e *Echo) Call(arg *string, reply *string) error {
+	if err := Apply("Echo.Call"); err != nil {
+		return err
+	}
+	*reply = *arg
+	return nil
+}
+
+func TestDisconnect(t *testing.T) {
+	if err := Init(Config{}); err != nil {
+		t.Fatal(err)
+	}
+	srv := rpc.NewServer()
+	srv.Register(new(Echo))
+	mux := http.NewServeMux()
+	mux.Handle(rpc.DefaultRPCPath, srv)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tl := NewListener(l)
+	defer tl.Close()
+	go http.Serve(tl, mux)
+
+	client, err := rpc.DialHTTP("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	arg, reply := "hello", ""
+	if err := client.Call("Echo.Call", &arg, &reply); err != nil || reply != arg {
+		t.Fatalf("Call = %q, %v", reply, err)
+	}
+	if tl.Len() != 1 {
+		t.Errorf("expected 1 connection, got: %d", tl.Len())
+	}
+
+	if err := Set("Echo.Call", Fault{DisconnectRate: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Call("Echo.Call", &arg, &reply); err == nil {
+		t.Errorf("expected the call to fail")
+	}
+	if err := client.Call("Echo.Call", &arg, &reply); err != rpc.ErrShutdown {
+		t.Errorf("expected the connection to be closed, got: %v", err)
+	}
+	if tl.Len() != 0 {
+		t.Errorf("expected no connections, got: %d", tl.Len())
+	}
+}
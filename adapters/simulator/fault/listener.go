@@ -0,0 +1,74 @@
+package fault
+
+import (
+	"net"
+	"sync"
+)
+
+// Listener tracks the open connections, so that they can be dropped to simulate a
+// disconnect.
+type Listener struct {
+	net.Listener
+	conns map[net.Conn]struct{}
+	sync.Mutex
+}
+
+// NewListener wraps the listener, and sets it to be disconnected by simulated
+// disconnects.
+func NewListener(l net.Listener) *Listener {
+	tl := &Listener{
+		Listener: l,
+		conns:    make(map[net.Conn]struct{}),
+	}
+	SetDisconnect(tl.CloseAll)
+	return tl
+}
+
+// Accept waits for and returns the next connection.
+func (l *Listener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	tc := &trackedConn{Conn: c, l: l}
+	l.Lock()
+	l.conns[tc] = struct{}{}
+	l.Unlock()
+	return tc, nil
+}
+
+// CloseAll closes all open connections.  The Listener is not closed.
+func (l *Listener) CloseAll() {
+	l.Lock()
+	conns := make([]net.Conn, 0, len(l.conns))
+	for c := range l.conns {
+		conns = append(conns, c)
+	}
+	l.Unlock()
+	for _, c := range conns {
+		c.Close()
+	}
+}
+
+// Len returns the number of open connections.
+func (l *Listener) Len() int {
+	l.Lock()
+	defer l.Unlock()
+	return len(l.conns)
+}
+
+type trackedConn struct {
+	net.Conn
+	l    *Listener
+	once sync.Once
+}
+
+// Close closes the connection, and stops tracking it.
+func (c *trackedConn) Close() error {
+	c.once.Do(func() {
+		c.l.Lock()
+		delete(c.l.conns, c)
+		c.l.Unlock()
+	})
+	return c.Conn.Close()
+}
@@ -0,0 +1,78 @@
+package request
+
+import (
+	"github.com/codeforsanjose/open311-gateway/adapters/simulator/data"
+	"github.com/codeforsanjose/open311-gateway/adapters/simulator/fault"
+	"github.com/codeforsanjose/open311-gateway/common"
+)
+
+// ================================================================================================
+//                                      ADMIN
+// ================================================================================================
+
+// Admin is the RPC container struct for the Simulator administration services.  These
+// set the injected faults, and reset the Simulator between test runs.
+type Admin struct{}
+
+// AdminRequest sets the Fault for an RPC method (e.g. "Report.Create", or "*" for all
+// methods without their own Fault).  A zero Fault removes the method's Fault.
+type AdminRequest struct {
+	Method string
+	Fault  fault.Fault
+}
+
+// AdminResponse is the current state of the Simulator.
+type AdminResponse struct {
+	Message  string
+	Reports  int
+	Faults   map[string]fault.Fault
+	Calls    map[string]int64
+	Injected map[string]int64
+}
+
+// SetFault sets the Fault for an RPC method.
+func (a *Admin) SetFault(rqst *AdminRequest, resp *AdminResponse) error {
+	log.Debugf("SetFault - %s: %+v", rqst.Method, rqst.Fault)
+	if err := fault.Set(rqst.Method, rqst.Fault); err != nil {
+		resp.Message = "Failed - " + err.Error()
+		return err
+	}
+	status(resp)
+	return nil
+}
+
+// Status returns the current state of the Simulator.
+func (a *Admin) Status(rqst *AdminRequest, resp *AdminResponse) error {
+	status(resp)
+	return nil
+}
+
+// Reset removes all reports, and restores the Faults from the config file.
+func (a *Admin) Reset(rqst *AdminRequest, resp *AdminResponse) error {
+	log.Debug("Reset")
+	data.Reports().Clear()
+	fault.Reset()
+	status(resp)
+	return nil
+}
+
+func status(resp *AdminResponse) {
+	resp.Message = "OK"
+	resp.Reports = data.Reports().Len()
+	resp.Faults = fault.Faults()
+	resp.Calls, resp.Injected = fault.Counts()
+}
+
+// String displays an AdminResponse.
+func (r AdminResponse) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddS("AdminResponse\n")
+	ls.AddF("Message: %s  Reports: %d\n", r.Message, r.Reports)
+	for k, f := range r.Faults {
+		ls.AddF("%-18s %+v\n", k, f)
+	}
+	for k, n := range r.Calls {
+		ls.AddF("%-18s calls: %d  injected: %d\n", k, n, r.Injected[k])
+	}
+	return ls.Box(90)
+}
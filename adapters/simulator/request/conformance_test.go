@@ -0,0 +1,39 @@
+package request
+
+import (
+	"testing"
+
+	"github.com/codeforsanjose/open311-gateway/adapters/simulator/data"
+	"github.com/codeforsanjose/open311-gateway/common/adaptersdk/conformance"
+)
+
+func TestConformance(t *testing.T) {
+	var admin AdminResponse
+	new(Admin).Reset(&AdminRequest{}, &admin)
+	defer new(Admin).Reset(&AdminRequest{}, &admin)
+	// Remove the config file fault on Search by DeviceID.
+	if err := new(Admin).SetFault(&AdminRequest{Method: "Report.SearchDID"}, &admin); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := data.Adapter().Handler(&Report{}, &Admin{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tr := range []struct {
+		name    string
+		jsonRPC bool
+	}{{"rpc", false}, {"jsonrpc", true}} {
+		t.Run(tr.name, func(t *testing.T) {
+			conformance.Run(t, conformance.Target{
+				Handler:   h,
+				JSONRPC:   tr.jsonRPC,
+				AdpID:     "SIM1",
+				Area:      "San Jose",
+				AreaID:    "SJ",
+				Latitude:  37.3395,
+				Longitude: -121.886329,
+			})
+		})
+	}
+}
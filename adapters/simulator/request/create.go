@@ -0,0 +1,102 @@
+package request
+
+import (
+	"github.com/codeforsanjose/open311-gateway/adapters/simulator/data"
+	"github.com/codeforsanjose/open311-gateway/adapters/simulator/store"
+	"github.com/codeforsanjose/open311-gateway/common"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+// ================================================================================================
+//                                      CREATE
+// ================================================================================================
+
+// Create fully processes the Create request.
+func (r *Report) Create(rqst *structs.NCreateRequest, resp *structs.NCreateResponse) error {
+	log.Debugf("Create - request: %p  resp: %p\n", rqst, resp)
+	// Make the Create Manager
+	cm := &createMgr{
+		nreq:  rqst,
+		nresp: resp,
+	}
+
+	return data.Adapter().Run("Report.Create", cm)
+}
+
+// createMgr conglomerates the Normal and Native structs and supervisor logic
+// for processing a request to Create a Report.
+//  1. Validates the Service, and converts the request to a stored Report.
+//  2. Adds the Report to the store.
+//  3. Returns the Normal Response, and any errors.
+type createMgr struct {
+	nreq  *structs.NCreateRequest
+	req   store.Report
+	resp  store.Report
+	nresp *structs.NCreateResponse
+}
+
+func (c *createMgr) ConvertRequest() error {
+	srv, err := data.Adapter().Service(c.nreq.MID)
+	if err != nil {
+		return err
+	}
+	c.req = store.Report{
+		Route:       c.nreq.GetRoute(),
+		ServiceID:   srv.ID,
+		ServiceName: srv.Name,
+		DeviceType:  c.nreq.DeviceType,
+		DeviceModel: c.nreq.DeviceModel,
+		DeviceID:    c.nreq.DeviceID,
+		Latitude:    c.nreq.Latitude,
+		Longitude:   c.nreq.Longitude,
+		Address:     c.nreq.Address,
+		City:        c.nreq.Area,
+		State:       c.nreq.State,
+		Zip:         c.nreq.Zip,
+		FirstName:   c.nreq.FirstName,
+		LastName:    c.nreq.LastName,
+		Email:       c.nreq.Email,
+		Phone:       c.nreq.Phone,
+		IsAnonymous: c.nreq.IsAnonymous,
+		Description: c.nreq.Description,
+		MediaURL:    c.nreq.MediaURL,
+	}
+	return nil
+}
+
+// Process adds the report to the store.
+func (c *createMgr) Process() error {
+	c.resp = data.Reports().Add(c.req)
+	return nil
+}
+
+func (c *createMgr) ConvertResponse() (int, error) {
+	c.nresp.SetIDF(c.nreq.GetID)
+	c.nresp.SetRoute(c.resp.Route)
+	c.nresp.RID = structs.NewRID(c.resp.Route, c.resp.ID)
+	c.nresp.Message = "Request successfully added"
+	return 1, nil
+}
+
+func (c *createMgr) Fail(err error) error {
+	c.nresp.Message = "Failed - " + err.Error()
+	c.nresp.RID = structs.ReportID{}
+	return err
+}
+
+func (c *createMgr) GetIDS() string {
+	return c.nreq.GetIDS()
+}
+
+func (c *createMgr) GetRoute() string {
+	return c.nreq.GetRoute().String()
+}
+
+func (c *createMgr) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddS("Create\n")
+	ls.AddS(c.nreq.String())
+	ls.AddF("Report: %+v\n", c.resp)
+	ls.AddS(c.nresp.String())
+	return ls.Box(90)
+}
@@ -0,0 +1,13 @@
+package request
+
+import (
+	"github.com/codeforsanjose/open311-gateway/common/adaptersdk/logs"
+)
+
+var (
+	log = logs.Log
+)
+
+// Report is the RPC container struct for the Report services.  These services create
+// and search for reports in the in-memory store.
+type Report struct{}
@@ -0,0 +1,163 @@
+package request
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codeforsanjose/open311-gateway/adapters/simulator/data"
+	"github.com/codeforsanjose/open311-gateway/adapters/simulator/fault"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+const testConfig = `{
+	"adapter": {"name": "SIM1", "type": "Simulator", "address": ":5006"},
+	"monitor": {"address": "127.0.0.1:5081"},
+	"faults": {"methods": {"Report.SearchDID": {"errorRate": 1}}},
+	"serviceAreas": {
+		"SJ": {
+			"name": "San Jose",
+			"providers": [{"id": 1, "name": "Simulator - SJ", "responseType": "realtime",
+				"services": [{"id": 1, "name": "Pothole", "group": "Street"}, {"id": 2, "name": "Graffiti", "group": "Graffiti"}]}]
+		}
+	}
+}`
+
+var route = structs.NRoute{AdpID: "SIM1", AreaID: "SJ", ProviderID: 1}
+
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "simulator")
+	if err != nil {
+		panic(err)
+	}
+	configFile := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(configFile, []byte(testConfig), 0644); err != nil {
+		panic(err)
+	}
+	if err := data.Init(configFile); err != nil {
+		panic(err)
+	}
+
+	rc := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(rc)
+}
+
+func create(t *testing.T, serviceID int, lat, lng float64, deviceID string) structs.NCreateResponse {
+	rqst := &structs.NCreateRequest{
+		MID:         structs.ServiceID{AdpID: "SIM1", AreaID: "SJ", ProviderID: 1, ID: serviceID},
+		DeviceType:  "IOS",
+		DeviceID:    deviceID,
+		Latitude:    lat,
+		Longitude:   lng,
+		FirstName:   "Jane",
+		Email:       "jane@example.com",
+		IsAnonymous: deviceID == "anon",
+		Description: "Test report",
+	}
+	rqst.SetRoute(route)
+	var resp structs.NCreateResponse
+	if err := new(Report).Create(rqst, &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestServices(t *testing.T) {
+	var resp structs.NServicesResponse
+	if err := data.Adapter().Services().Area(&structs.NServiceRequest{Area: "San Jose"}, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Services) != 2 || resp.Services[0].MID() != "SIM1-SJ-1-1" || resp.Services[0].ResponseType != "realtime" {
+		t.Errorf("unexpected services: %v", resp.Services)
+	}
+}
+
+func TestCreateSearch(t *testing.T) {
+	var admin AdminResponse
+	new(Admin).Reset(&AdminRequest{}, &admin)
+
+	near := create(t, 1, 37.3395, -121.886329, "D1")
+	create(t, 2, 37.3436, -121.886329, "anon")
+	create(t, 1, 37.3650, -121.886329, "D1")
+	if !strings.HasPrefix(near.RID.RID(), "SIM1-SJ-1-") {
+		t.Errorf("unexpected RID: %v", near.RID)
+	}
+
+	rqst := &structs.NSearchRequestLL{Latitude: 37.338208, Longitude: -121.886329, Radius: 1000, AreaID: "SJ"}
+	rqst.SetRoute(route)
+	var resp structs.NSearchResponse
+	if err := new(Report).SearchLL(rqst, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ReportCount != 2 || resp.Reports[0].RID != near.RID {
+		t.Fatalf("expected the 2 reports within 1km, closest first, got: %v", resp.Reports)
+	}
+	if r := resp.Reports[0]; r.RequestType != "Pothole" || r.City != "San Jose" || r.AuthorEmail != "jane@example.com" {
+		t.Errorf("unexpected report: %#v", r)
+	}
+	if r := resp.Reports[1]; r.AuthorEmail != "" || r.AuthorIsAnonymous != "true" {
+		t.Errorf("anonymous reports should not show the author: %#v", r)
+	}
+
+	rrqst := &structs.NSearchRequestRID{RID: near.RID}
+	rrqst.SetRoute(route)
+	resp = structs.NSearchResponse{}
+	if err := new(Report).SearchRID(rrqst, &resp); err != nil || resp.ReportCount != 1 || resp.Reports[0].RID != near.RID {
+		t.Errorf("SearchRID = %v, %v", resp, err)
+	}
+	rrqst.RID.ID = "999999"
+	if err := new(Report).SearchRID(rrqst, &resp); err == nil {
+		t.Errorf("expected an error for an unknown report")
+	}
+
+	new(Admin).Status(&AdminRequest{}, &admin)
+	if admin.Reports != 3 {
+		t.Errorf("expected 3 reports, got: %d", admin.Reports)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	var admin AdminResponse
+	new(Admin).Reset(&AdminRequest{}, &admin)
+	create(t, 1, 37.3395, -121.886329, "D1")
+
+	// Search by DeviceID always fails, as per the config file.
+	drqst := &structs.NSearchRequestDID{DeviceType: "IOS", DeviceID: "D1", AreaID: "SJ"}
+	drqst.SetRoute(route)
+	var resp structs.NSearchResponse
+	if err := new(Report).SearchDID(drqst, &resp); err != fault.ErrInjected || !strings.Contains(resp.Message, "simulated") {
+		t.Errorf("expected an injected error, got: %v  %q", err, resp.Message)
+	}
+
+	// Remove it with the admin RPC.
+	if err := new(Admin).SetFault(&AdminRequest{Method: "Report.SearchDID"}, &admin); err != nil {
+		t.Fatal(err)
+	}
+	resp = structs.NSearchResponse{}
+	if err := new(Report).SearchDID(drqst, &resp); err != nil || resp.ReportCount != 1 {
+		t.Errorf("SearchDID = %v, %v", resp, err)
+	}
+
+	if err := new(Admin).SetFault(&AdminRequest{Method: "Services.Area", Fault: fault.Fault{ErrorRate: 1}}, &admin); err != nil {
+		t.Fatal(err)
+	}
+	var sresp structs.NServicesResponse
+	if err := data.Adapter().Services().Area(&structs.NServiceRequest{Area: "San Jose"}, &sresp); err != fault.ErrInjected {
+		t.Errorf("expected an injected error, got: %v", err)
+	}
+	if admin.Calls["Report.SearchDID"] != 2 || admin.Injected["Report.SearchDID"] != 1 {
+		t.Errorf("unexpected counts: %v  %v", admin.Calls, admin.Injected)
+	}
+
+	if err := new(Admin).SetFault(&AdminRequest{Method: "Report.Create", Fault: fault.Fault{ErrorRate: 2}}, &admin); err == nil {
+		t.Errorf("expected an invalid fault error")
+	}
+
+	new(Admin).Reset(&AdminRequest{}, &admin)
+	if admin.Reports != 0 || admin.Faults["Report.SearchDID"].ErrorRate != 1 || len(admin.Faults) != 1 {
+		t.Errorf("unexpected state after Reset: %v", admin)
+	}
+}
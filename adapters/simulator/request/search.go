@@ -0,0 +1,259 @@
+package request
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/adapters/simulator/data"
+	"github.com/codeforsanjose/open311-gateway/adapters/simulator/store"
+	"github.com/codeforsanjose/open311-gateway/common"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+const (
+	dfltMaxResults int = 20
+	dfltRadius     int = 1000 // meters
+)
+
+// ================================================================================================
+//                                      SEARCH LL
+// ================================================================================================
+
+// SearchLL fully processes a "Search by Location" request.
+func (r *Report) SearchLL(rqst *structs.NSearchRequestLL, resp *structs.NSearchResponse) error {
+	log.Debugf("SearchLL - request: %p  resp: %p\n", rqst, resp)
+	// Make the Search Manager
+	cm := &searchLLMgr{
+		nreq:  rqst,
+		nresp: resp,
+	}
+
+	return data.Adapter().Run("Report.SearchLL", cm)
+}
+
+// searchLLMgr conglomerates the Normal and Native structs and supervisor logic
+// for processing a request to Search for reports by Location.  Reports within the
+// radius are returned, closest first.
+type searchLLMgr struct {
+	nreq  *structs.NSearchRequestLL
+	resp  []store.Report
+	nresp *structs.NSearchResponse
+}
+
+func (c *searchLLMgr) ConvertRequest() error {
+	_, err := data.RouteProvider(c.nreq.GetRoute())
+	return err
+}
+
+// Process searches the store for reports near the location.
+func (c *searchLLMgr) Process() error {
+	radius := c.nreq.Radius
+	if radius <= 0 {
+		radius = dfltRadius
+	}
+	c.resp = data.Reports().Near(c.nreq.GetRoute(), c.nreq.Latitude, c.nreq.Longitude, radius, maxResults(c.nreq.MaxResults))
+	return nil
+}
+
+func (c *searchLLMgr) ConvertResponse() (int, error) {
+	return convertReports(c.nresp, c.nreq.GetID, c.nreq.GetRoute(), c.resp), nil
+}
+
+func (c *searchLLMgr) Fail(err error) error {
+	c.nresp.Message = "Failed - " + err.Error()
+	return err
+}
+
+func (c *searchLLMgr) GetIDS() string {
+	return c.nreq.GetIDS()
+}
+
+func (c *searchLLMgr) GetRoute() string {
+	return c.nreq.GetRoute().String()
+}
+
+func (c *searchLLMgr) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddS("SearchLL\n")
+	ls.AddS(c.nreq.String())
+	ls.AddS(c.nresp.String())
+	return ls.Box(90)
+}
+
+// ================================================================================================
+//                                      SEARCH DID
+// ================================================================================================
+
+// SearchDID fully processes the Search by DeviceID request.
+func (r *Report) SearchDID(rqst *structs.NSearchRequestDID, resp *structs.NSearchResponse) error {
+	log.Debugf("SearchDID - request: %p  resp: %p\n", rqst, resp)
+	// Make the Search Manager
+	cm := &searchDIDMgr{
+		nreq:  rqst,
+		nresp: resp,
+	}
+
+	return data.Adapter().Run("Report.SearchDID", cm)
+}
+
+// searchDIDMgr conglomerates the Normal and Native structs and supervisor logic
+// for processing a request to Search for reports by Device ID.  Reports are returned
+// newest first.
+type searchDIDMgr struct {
+	nreq  *structs.NSearchRequestDID
+	resp  []store.Report
+	nresp *structs.NSearchResponse
+}
+
+func (c *searchDIDMgr) ConvertRequest() error {
+	_, err := data.RouteProvider(c.nreq.GetRoute())
+	return err
+}
+
+// Process searches the store for reports created by the device.
+func (c *searchDIDMgr) Process() error {
+	c.resp = data.Reports().Device(c.nreq.GetRoute(), c.nreq.DeviceType, c.nreq.DeviceID, maxResults(c.nreq.MaxResults))
+	return nil
+}
+
+func (c *searchDIDMgr) ConvertResponse() (int, error) {
+	return convertReports(c.nresp, c.nreq.GetID, c.nreq.GetRoute(), c.resp), nil
+}
+
+func (c *searchDIDMgr) Fail(err error) error {
+	c.nresp.Message = "Failed - " + err.Error()
+	return err
+}
+
+func (c *searchDIDMgr) GetIDS() string {
+	return c.nreq.GetIDS()
+}
+
+func (c *searchDIDMgr) GetRoute() string {
+	return c.nreq.GetRoute().String()
+}
+
+func (c *searchDIDMgr) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddS("SearchDID\n")
+	ls.AddS(c.nreq.String())
+	ls.AddS(c.nresp.String())
+	return ls.Box(90)
+}
+
+// ================================================================================================
+//                                      SEARCH RID
+// ================================================================================================
+
+// SearchRID fully processes the Search by ReportID request.
+func (r *Report) SearchRID(rqst *structs.NSearchRequestRID, resp *structs.NSearchResponse) error {
+	log.Debugf("SearchRID - request: %p  resp: %p\n", rqst, resp)
+	// Make the Search Manager
+	cm := &searchRIDMgr{
+		nreq:  rqst,
+		nresp: resp,
+	}
+
+	return data.Adapter().Run("Report.SearchRID", cm)
+}
+
+// searchRIDMgr conglomerates the Normal and Native structs and supervisor logic
+// for processing a request to Search for a report by ReportID.
+type searchRIDMgr struct {
+	nreq  *structs.NSearchRequestRID
+	resp  store.Report
+	nresp *structs.NSearchResponse
+}
+
+func (c *searchRIDMgr) ConvertRequest() error {
+	_, err := data.RouteProvider(c.nreq.GetRoute())
+	return err
+}
+
+// Process retrieves the report from the store.
+func (c *searchRIDMgr) Process() (err error) {
+	c.resp, err = data.Reports().Get(c.nreq.GetRoute(), c.nreq.RID.ID)
+	return err
+}
+
+func (c *searchRIDMgr) ConvertResponse() (int, error) {
+	return convertReports(c.nresp, c.nreq.GetID, c.nreq.GetRoute(), []store.Report{c.resp}), nil
+}
+
+func (c *searchRIDMgr) Fail(err error) error {
+	c.nresp.Message = "Failed - " + err.Error()
+	return err
+}
+
+func (c *searchRIDMgr) GetIDS() string {
+	return c.nreq.GetIDS()
+}
+
+func (c *searchRIDMgr) GetRoute() string {
+	return c.nreq.GetRoute().String()
+}
+
+func (c *searchRIDMgr) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddS("SearchRID\n")
+	ls.AddS(c.nreq.String())
+	ls.AddS(c.nresp.String())
+	return ls.Box(90)
+}
+
+// ================================================================================================
+//                                      CONVERSION
+// ================================================================================================
+
+func maxResults(n int) int {
+	if n <= 0 {
+		return dfltMaxResults
+	}
+	return n
+}
+
+// convertReports loads the stored reports into the NSearchResponse.  It returns the
+// number of reports.
+func convertReports(nresp *structs.NSearchResponse, idf func() (int64, int64), route structs.NRoute, list []store.Report) int {
+	nresp.SetIDF(idf)
+	nresp.SetRoute(route)
+	nresp.Message = "OK"
+	nresp.Reports = make([]structs.NSearchResponseReport, 0, len(list))
+	for _, r := range list {
+		nresp.Reports = append(nresp.Reports, convertReport(r))
+	}
+	nresp.ReportCount = len(nresp.Reports)
+	return nresp.ReportCount
+}
+
+func convertReport(r store.Report) structs.NSearchResponseReport {
+	rpt := structs.NSearchResponseReport{
+		RID:               structs.NewRID(r.Route, r.ID),
+		DateCreated:       r.Created.Format(time.RFC3339),
+		DateUpdated:       r.Updated.Format(time.RFC3339),
+		DeviceType:        r.DeviceType,
+		DeviceModel:       r.DeviceModel,
+		DeviceID:          r.DeviceID,
+		RequestType:       r.ServiceName,
+		RequestTypeID:     strconv.Itoa(r.ServiceID),
+		MediaURL:          r.MediaURL,
+		City:              r.City,
+		State:             r.State,
+		ZipCode:           r.Zip,
+		Latitude:          strconv.FormatFloat(r.Latitude, 'f', -1, 64),
+		Longitude:         strconv.FormatFloat(r.Longitude, 'f', -1, 64),
+		Description:       r.Description,
+		AuthorIsAnonymous: strconv.FormatBool(r.IsAnonymous),
+		StatusType:        r.Status,
+	}
+	if rpt.City == "" {
+		rpt.City = data.Adapter().AreaName(r.Route.AreaID)
+	}
+	if !r.IsAnonymous {
+		rpt.AuthorNameFirst = r.FirstName
+		rpt.AuthorNameLast = r.LastName
+		rpt.AuthorEmail = r.Email
+		rpt.AuthorTelephone = r.Phone
+	}
+	return rpt
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"github.com/codeforsanjose/open311-gateway/adapters/simulator/data"
+	"github.com/codeforsanjose/open311-gateway/adapters/simulator/request"
+	"github.com/codeforsanjose/open311-gateway/common/adaptersdk"
+)
+
+func main() {
+	adaptersdk.Main(data.Adapter(), data.Init, &request.Report{}, &request.Admin{})
+}
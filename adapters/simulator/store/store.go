@@ -0,0 +1,175 @@
+// Package store is the Simulator's in-memory report store.
+package store
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common/geo"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+// DefaultSize is the maximum number of reports kept, if not configured.
+const DefaultSize = 10000
+
+// Report is a stored report.
+type Report struct {
+	ID          string
+	Route       structs.NRoute
+	ServiceID   int
+	ServiceName string
+	DeviceType  string
+	DeviceModel string
+	DeviceID    string
+	Latitude    float64
+	Longitude   float64
+	Address     string
+	City        string
+	State       string
+	Zip         string
+	FirstName   string
+	LastName    string
+	Email       string
+	Phone       string
+	IsAnonymous bool
+	Description string
+	MediaURL    string
+	Status      string
+	Created     time.Time
+	Updated     time.Time
+
+	distance float64 // Set by Near()
+}
+
+// Distance returns the distance (meters) to the search location, if the Report was
+// returned by Near().
+func (r Report) Distance() float64 {
+	return r.distance
+}
+
+// Store holds the reports in memory.  When the Store is full, the oldest report is
+// dropped.  Report IDs are assigned sequentially, and are never reused.
+type Store struct {
+	size    int
+	nextID  int
+	reports map[string]*list.Element // "route-id" -> *Report
+	order   *list.List               // oldest first
+	sync.RWMutex
+}
+
+// New returns an empty Store, holding up to size reports.
+func New(size int) *Store {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	return &Store{
+		size:    size,
+		nextID:  1,
+		reports: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func key(route structs.NRoute, id string) string {
+	return route.String() + "-" + id
+}
+
+// Add stores a copy of the report, and returns it with its ID, Status and dates set.
+func (s *Store) Add(r Report) Report {
+	s.Lock()
+	defer s.Unlock()
+	r.ID = strconv.Itoa(s.nextID)
+	s.nextID++
+	if r.Status == "" {
+		r.Status = "open"
+	}
+	if r.Created.IsZero() {
+		r.Created = time.Now()
+	}
+	r.Updated = r.Created
+	s.reports[key(r.Route, r.ID)] = s.order.PushBack(&r)
+	for s.order.Len() > s.size {
+		oldest := s.order.Remove(s.order.Front()).(*Report)
+		delete(s.reports, key(oldest.Route, oldest.ID))
+	}
+	return r
+}
+
+// Get returns the report with the ID.
+func (s *Store) Get(route structs.NRoute, id string) (Report, error) {
+	s.RLock()
+	defer s.RUnlock()
+	e, ok := s.reports[key(route, id)]
+	if !ok {
+		return Report{}, fmt.Errorf("report %s-%s not found", route, id)
+	}
+	return *e.Value.(*Report), nil
+}
+
+// Near returns up to max reports within radius meters of the location, for the Area
+// and Provider of the route, closest first.  If max is 0, all are returned.
+func (s *Store) Near(route structs.NRoute, lat, lng float64, radius, max int) []Report {
+	s.RLock()
+	var found []Report
+	for e := s.order.Front(); e != nil; e = e.Next() {
+		r := e.Value.(*Report)
+		if !sameProvider(route, r.Route) {
+			continue
+		}
+		d := geo.Distance(lat, lng, r.Latitude, r.Longitude)
+		if d <= float64(radius) {
+			c := *r
+			c.distance = d
+			found = append(found, c)
+		}
+	}
+	s.RUnlock()
+
+	sort.SliceStable(found, func(i, j int) bool { return found[i].distance < found[j].distance })
+	return limit(found, max)
+}
+
+// Device returns up to max reports created by the device, for the Area and Provider of
+// the route, newest first.  If max is 0, all are returned.
+func (s *Store) Device(route structs.NRoute, deviceType, deviceID string, max int) []Report {
+	s.RLock()
+	defer s.RUnlock()
+	var found []Report
+	for e := s.order.Back(); e != nil; e = e.Prev() {
+		r := e.Value.(*Report)
+		if sameProvider(route, r.Route) && r.DeviceID == deviceID && (deviceType == "" || r.DeviceType == deviceType) {
+			found = append(found, *r)
+		}
+	}
+	return limit(found, max)
+}
+
+// Len returns the number of reports in the Store.
+func (s *Store) Len() int {
+	s.RLock()
+	defer s.RUnlock()
+	return s.order.Len()
+}
+
+// Clear removes all reports.  IDs are not reused.
+func (s *Store) Clear() {
+	s.Lock()
+	defer s.Unlock()
+	s.reports = make(map[string]*list.Element)
+	s.order.Init()
+}
+
+func sameProvider(a, b structs.NRoute) bool {
+	return a.AreaID == b.AreaID && a.ProviderID == b.ProviderID
+}
+
+func limit(list []Report, max int) []Report {
+	if max > 0 && len(list) > max {
+		return list[:max]
+	}
+	return list
+}
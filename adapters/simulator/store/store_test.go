@@ -0,0 +1,92 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+var (
+	sj1 = structs.NRoute{AdpID: "SIM1", AreaID: "SJ", ProviderID: 1}
+	sc1 = structs.NRoute{AdpID: "SIM1", AreaID: "SC", ProviderID: 1}
+)
+
+// City Hall, and points roughly 150m, 600m and 3km away.
+const (
+	lat, lng = 37.338208, -121.886329
+)
+
+func TestNear(t *testing.T) {
+	s := New(0)
+	far := s.Add(Report{Route: sj1, Latitude: 37.3650, Longitude: -121.886329})
+	mid := s.Add(Report{Route: sj1, Latitude: 37.3436, Longitude: -121.886329})
+	near := s.Add(Report{Route: sj1, Latitude: 37.3395, Longitude: -121.886329})
+	s.Add(Report{Route: sc1, Latitude: lat, Longitude: lng})
+
+	got := s.Near(sj1, lat, lng, 1000, 0)
+	if len(got) != 2 || got[0].ID != near.ID || got[1].ID != mid.ID {
+		t.Fatalf("expected the 2 closest SJ reports, closest first, got: %+v", got)
+	}
+	if d := got[0].Distance(); d < 100 || d > 200 {
+		t.Errorf("unexpected distance: %v", d)
+	}
+
+	if got := s.Near(sj1, lat, lng, 5000, 0); len(got) != 3 || got[2].ID != far.ID {
+		t.Errorf("expected all 3 SJ reports, got: %+v", got)
+	}
+	if got := s.Near(sj1, lat, lng, 5000, 1); len(got) != 1 || got[0].ID != near.ID {
+		t.Errorf("expected max 1 report, got: %+v", got)
+	}
+	if got := s.Near(sc1, lat, lng, 10, 0); len(got) != 1 {
+		t.Errorf("expected 1 SC report, got: %+v", got)
+	}
+}
+
+func TestDevice(t *testing.T) {
+	s := New(0)
+	now := time.Now()
+	a := s.Add(Report{Route: sj1, DeviceType: "IOS", DeviceID: "D1", Created: now.Add(-time.Hour)})
+	b := s.Add(Report{Route: sj1, DeviceType: "IOS", DeviceID: "D1", Created: now})
+	s.Add(Report{Route: sj1, DeviceType: "IOS", DeviceID: "D2"})
+	s.Add(Report{Route: sc1, DeviceType: "IOS", DeviceID: "D1"})
+
+	got := s.Device(sj1, "IOS", "D1", 0)
+	if len(got) != 2 || got[0].ID != b.ID || got[1].ID != a.ID {
+		t.Errorf("expected the 2 D1 reports, newest first, got: %+v", got)
+	}
+	if got := s.Device(sj1, "Android", "D1", 0); len(got) != 0 {
+		t.Errorf("expected no Android reports, got: %+v", got)
+	}
+}
+
+func TestGetEvictClear(t *testing.T) {
+	s := New(2)
+	a := s.Add(Report{Route: sj1})
+	b := s.Add(Report{Route: sj1})
+	c := s.Add(Report{Route: sj1})
+	if a.Status != "open" || a.Created.IsZero() || a.ID == b.ID {
+		t.Errorf("unexpected report: %+v", a)
+	}
+
+	if _, err := s.Get(sj1, a.ID); err == nil {
+		t.Errorf("the oldest report should have been dropped")
+	}
+	if r, err := s.Get(sj1, c.ID); err != nil || r.ID != c.ID {
+		t.Errorf("Get(%s) = %+v, %v", c.ID, r, err)
+	}
+	if _, err := s.Get(sc1, c.ID); err == nil {
+		t.Errorf("reports should only be found on their own route")
+	}
+	if s.Len() != 2 {
+		t.Errorf("expected 2 reports, got: %d", s.Len())
+	}
+
+	s.Clear()
+	if s.Len() != 0 {
+		t.Errorf("expected an empty store, got: %d", s.Len())
+	}
+	if d := s.Add(Report{Route: sj1}); d.ID == a.ID || d.ID == b.ID || d.ID == c.ID {
+		t.Errorf("IDs should not be reused, got: %s", d.ID)
+	}
+}
@@ -0,0 +1,177 @@
+// Package adaptersdk is the plumbing shared by all Adapters: config file loading, the
+// Area, Provider and Service indexes, the request processing pipeline and its telemetry,
+// the Services RPC, and the RPC server.
+//
+// An Adapter only needs to provide its Provider type (embedding ProviderBase), its
+// Capabilities, and the RPC services for its native requests, processed with
+// Adapter.Run().
+package adaptersdk
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common"
+	"github.com/codeforsanjose/open311-gateway/common/rpcauth"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+type areaProvider struct {
+	areaID     string
+	providerID int
+}
+
+// Adapter contains the config data and indexes of an Adapter.  It is created with New(),
+// and loaded with Init() or Load().
+type Adapter struct {
+	Loaded  bool
+	Adapter AdapterData
+	Engine  EngineData
+	RPCAuth rpcauth.Config // Security of the RPC server - see Serve().
+	Monitor struct {
+		Address string
+	}
+	Areas map[string]*Area
+
+	// Capabilities are the requests the Adapter can handle, returned by the
+	// "Adapter.Capabilities" RPC.  The Engine only routes supported requests to the Adapter.
+	Capabilities structs.NCapabilities
+
+	// Before, if set, is called with the RPC method name (e.g. "Report.Create") before
+	// each request is processed.  If it returns an error, the request fails with it.
+	Before func(method string) error
+	// Listener, if set, wraps the listener the RPC server is started on.
+	Listener func(l net.Listener) net.Listener
+
+	newProvider  func() Provider
+	areaCode     map[string]string // lowercase Area name -> AreaID
+	areaProvider map[areaProvider]Provider
+	areaServices map[string]structs.NServices     // AreaID -> List of Services
+	serviceMID   map[string]*structs.NService     // Service MID -> Service
+	loaded       map[Provider][]*structs.NService // Services loaded by ServiceLoaders
+	loadedAt     time.Time
+	tlmtry       *telemetry
+	reg          *registration
+	server       *http.Server // Set by Serve(), and shut down by Shutdown().
+	inflight     int64        // Requests in progress in Run().
+	sync.RWMutex
+}
+
+// New creates an Adapter.  newProvider must return a pointer to a new, empty instance
+// of the Adapter's Provider type - the Providers in the config file are decoded into it.
+func New(newProvider func() Provider) *Adapter {
+	return &Adapter{newProvider: newProvider}
+}
+
+// Name returns the adapter name.
+func (a *Adapter) Name() string {
+	return a.Adapter.Name
+}
+
+// MonitorAddress returns the Telemetry Address from the config file.
+func (a *Adapter) MonitorAddress() string {
+	return a.Monitor.Address
+}
+
+// ServicesArea returns a list of all services available for the specified Area.
+func (a *Adapter) ServicesArea(area string) (*structs.NServices, error) {
+	a.refresh()
+	log.Debugf("   Services for: %s...\n", strings.ToLower(area))
+	a.RLock()
+	defer a.RUnlock()
+	ccode, ok := a.areaCode[strings.ToLower(area)]
+	if !ok {
+		msg := fmt.Sprintf("The area: %q is not serviced by this Gateway", area)
+		log.Error(msg)
+		return nil, errors.New(msg)
+	}
+	services, ok := a.areaServices[ccode]
+	if !ok {
+		msg := fmt.Sprintf("Unable to find requested area: %q", area)
+		log.Warning(msg)
+		return nil, errors.New(msg)
+	}
+	return &services, nil
+}
+
+// ServicesAll returns a list of ALL services.
+func (a *Adapter) ServicesAll() (*structs.NServices, error) {
+	a.refresh()
+	a.RLock()
+	defer a.RUnlock()
+	resp := make(structs.NServices, 0)
+	for _, v := range a.areaServices {
+		resp = append(resp, v...)
+	}
+	return &resp, nil
+}
+
+// Service returns the NService data for the specified ServiceID.
+func (a *Adapter) Service(srvID structs.ServiceID) (structs.NService, error) {
+	log.Debugf("ServiceID: %s", srvID.MID())
+	a.RLock()
+	defer a.RUnlock()
+	s, ok := a.serviceMID[srvID.MID()]
+	if !ok {
+		return structs.NService{}, fmt.Errorf("invalid ServiceID: %s", srvID.MID())
+	}
+	return *s, nil
+}
+
+// Provider returns the Provider for the specified Area and Provider ID.
+func (a *Adapter) Provider(areaID string, providerID int) (Provider, error) {
+	log.Debugf("AreaID: %v  ProviderID: %v\n", areaID, providerID)
+	p, ok := a.areaProvider[areaProvider{areaID, providerID}]
+	if !ok {
+		return nil, fmt.Errorf("Unable to find Provider for %v-%v", areaID, providerID)
+	}
+	return p, nil
+}
+
+// MIDProvider returns the Provider for the specified ServiceID.
+func (a *Adapter) MIDProvider(mid structs.ServiceID) (Provider, error) {
+	return a.Provider(mid.AreaID, mid.ProviderID)
+}
+
+// RouteProvider returns the Provider for the specified NRoute.
+func (a *Adapter) RouteProvider(route structs.NRoute) (Provider, error) {
+	return a.Provider(route.AreaID, route.ProviderID)
+}
+
+// AreaName returns the name of the Area.
+func (a *Adapter) AreaName(areaID string) string {
+	if area, ok := a.Areas[areaID]; ok {
+		return area.Name
+	}
+	return ""
+}
+
+// String returns the represeentation of the Adapter.
+func (a *Adapter) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddF("[%s] Adapter\n", a.Adapter.Name)
+	ls.AddF("Loaded: %t\n", a.Loaded)
+	ls.AddS(a.Adapter.String())
+	ls.AddF("Monitor - address: %s\n", a.Monitor.Address)
+	a.RLock()
+	ls.AddS("\n-----------INDEX: areaServices-----------\n")
+	for k, v := range a.areaServices {
+		ls.AddF("   [[%s]]\n", k)
+		for _, sv := range v {
+			ls.AddF("      %s\n", sv)
+		}
+	}
+	a.RUnlock()
+	ls.AddS("\n---AREAS ---\n")
+	for _, v := range a.Areas {
+		ls.AddF("%s\n", v)
+	}
+	return ls.Box(90)
+}
@@ -0,0 +1,538 @@
+package adaptersdk
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/rpc"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common/jsonrpc"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+const testConfig = `{
+	"adapter": {"name": "TST1", "type": "Test", "address": ":5099", "refresh": 1},
+	"monitor": {"address": "127.0.0.1:5081"},
+	"extra": "hello",
+	"serviceAreas": {
+		"SJ": {
+			"name": "San Jose",
+			"providers": [
+				{"id": 1, "name": "Static", "url": "http://static", "responseType": "realtime",
+					"services": [{"id": 1, "name": "Pothole", "group": "Street"}, {"id": 2, "name": "Graffiti", "group": "Graffiti"}]},
+				{"id": 2, "name": "Loaded", "url": "http://loaded", "responseType": "blackbox"}
+			]
+		},
+		"SC": {
+			"name": "Santa Clara",
+			"providers": [{"id": 1, "name": "Static SC", "url": "http://sc", "services": [{"id": 1, "name": "Trash", "group": "Trash"}]}]
+		}
+	}
+}`
+
+type testProvider struct {
+	ProviderBase
+	URL string `json:"url"`
+
+	settled bool
+	loads   int
+	fail    bool
+}
+
+func (p *testProvider) Settle() error {
+	if p.URL == "" {
+		return errors.New("no url")
+	}
+	p.settled = true
+	return nil
+}
+
+func (p *testProvider) LoadServices() ([]*structs.NService, error) {
+	p.loads++
+	if p.fail {
+		return nil, errors.New("unavailable")
+	}
+	return []*structs.NService{{ServiceID: structs.ServiceID{ID: 100 + p.loads}, Name: "Loaded", Group: "Other"}}, nil
+}
+
+func load(t *testing.T, config string) (*Adapter, *struct{ Extra string }) {
+	a := New(func() Provider { return new(testProvider) })
+	ext := new(struct{ Extra string })
+	if err := a.Load([]byte(config), ext); err != nil {
+		t.Fatal(err)
+	}
+	return a, ext
+}
+
+func TestLoad(t *testing.T) {
+	a, ext := load(t, testConfig)
+	if a.Name() != "TST1" || a.MonitorAddress() != "127.0.0.1:5081" || ext.Extra != "hello" {
+		t.Errorf("unexpected config: %s  %q", a.Adapter.String(), ext.Extra)
+	}
+	if err := a.Load([]byte(testConfig), nil); err == nil {
+		t.Errorf("the config should only be loaded once")
+	}
+
+	list, err := a.ServicesArea("san jose")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*list) != 3 {
+		t.Fatalf("expected 3 services, got: %v", *list)
+	}
+	mids := make(map[string]structs.NService)
+	for _, s := range *list {
+		mids[s.MID()] = s
+	}
+	if s := mids["TST1-SJ-1-1"]; s.ResponseType != "realtime" {
+		t.Errorf("the provider response type should be the default: %v", s)
+	}
+	if s := mids["TST1-SJ-2-101"]; s.Name != "Loaded" || s.ResponseType != "blackbox" {
+		t.Errorf("expected the loaded service, got: %v", mids)
+	}
+
+	if _, err := a.ServicesArea("Sunnyvale"); err == nil {
+		t.Errorf("expected an error for an unknown area")
+	}
+	if all, _ := a.ServicesAll(); len(*all) != 4 {
+		t.Errorf("expected 4 services, got: %v", *all)
+	}
+
+	if s, err := a.Service(structs.ServiceID{AdpID: "TST1", AreaID: "SJ", ProviderID: 2, ID: 101}); err != nil || s.Name != "Loaded" {
+		t.Errorf("Service() = %v, %v", s, err)
+	}
+	if _, err := a.Service(structs.ServiceID{AdpID: "TST1", AreaID: "SJ", ProviderID: 1, ID: 101}); err == nil {
+		t.Errorf("expected an error for an unknown service")
+	}
+
+	p, err := a.RouteProvider(structs.NRoute{AdpID: "TST1", AreaID: "SC", ProviderID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tp := p.(*testProvider); tp.URL != "http://sc" || !tp.settled || tp.AreaID != "SC" || tp.AdpID != "TST1" {
+		t.Errorf("unexpected provider: %+v", tp)
+	}
+	if _, err := a.MIDProvider(structs.ServiceID{AreaID: "SC", ProviderID: 2}); err == nil {
+		t.Errorf("expected an error for an unknown provider")
+	}
+	if a.AreaName("SC") != "Santa Clara" || a.AreaName("XX") != "" {
+		t.Errorf("unexpected area names")
+	}
+}
+
+func TestSettleError(t *testing.T) {
+	a := New(func() Provider { return new(testProvider) })
+	err := a.Load([]byte(`{"adapter": {"name": "TST1"}, "serviceAreas": {"SJ": {"name": "San Jose", "providers": [{"id": 1}]}}}`), nil)
+	if err == nil {
+		t.Errorf("expected the Settle error")
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	a, _ := load(t, testConfig)
+	p, _ := a.Provider("SJ", 2)
+	tp := p.(*testProvider)
+
+	// Not stale - the list is not reloaded.
+	a.ServicesAll()
+	if tp.loads != 1 {
+		t.Errorf("expected 1 load, got: %d", tp.loads)
+	}
+
+	// A failed reload keeps the previous list.
+	a.loadedAt = a.loadedAt.Add(-2e9)
+	tp.fail = true
+	list, _ := a.ServicesArea("San Jose")
+	if tp.loads != 2 || len(*list) != 3 || (*list)[2].ID != 101 {
+		t.Errorf("expected the previous list, got: %v", *list)
+	}
+
+	a.loadedAt = a.loadedAt.Add(-2e9)
+	tp.fail = false
+	if _, err := a.Service(structs.ServiceID{AdpID: "TST1", AreaID: "SJ", ProviderID: 2, ID: 101}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	a.ServicesAll()
+	if _, err := a.Service(structs.ServiceID{AdpID: "TST1", AreaID: "SJ", ProviderID: 2, ID: 103}); err != nil {
+		t.Errorf("expected the reloaded service - %v", err)
+	}
+}
+
+// ------------------------------- Run -------------------------------
+
+type testMgr struct {
+	steps  []string
+	failAt string
+	err    error
+}
+
+func (c *testMgr) step(name string) error {
+	c.steps = append(c.steps, name)
+	if name == c.failAt {
+		return errors.New(name + " failed")
+	}
+	return nil
+}
+
+func (c *testMgr) ConvertRequest() error { return c.step("convertRequest") }
+func (c *testMgr) Process() error        { return c.step("process") }
+func (c *testMgr) ConvertResponse() (int, error) {
+	return 1, c.step("convertResponse")
+}
+func (c *testMgr) Fail(err error) error { c.err = err; return err }
+func (c *testMgr) GetIDS() string       { return "1-1" }
+func (c *testMgr) GetRoute() string     { return "TST1-SJ-1" }
+func (c *testMgr) String() string       { return "testMgr" }
+
+func TestRun(t *testing.T) {
+	a, _ := load(t, testConfig)
+
+	c := &testMgr{}
+	if err := a.Run("Report.Create", c); err != nil || len(c.steps) != 3 {
+		t.Errorf("Run() = %v  steps: %v", err, c.steps)
+	}
+
+	c = &testMgr{failAt: "process"}
+	if err := a.Run("Report.Create", c); err == nil || c.err != err || len(c.steps) != 2 {
+		t.Errorf("expected Fail() to be called - %v  steps: %v", err, c.steps)
+	}
+
+	var methods []string
+	a.Before = func(method string) error {
+		methods = append(methods, method)
+		return errors.New("injected")
+	}
+	c = &testMgr{}
+	if err := a.Run("Report.SearchLL", c); err == nil || c.err != err || len(c.steps) != 0 {
+		t.Errorf("expected Before() to stop the request - %v  steps: %v", err, c.steps)
+	}
+	var resp structs.NServicesResponse
+	if err := a.Services().All(&structs.NServiceRequest{}, &resp); err == nil {
+		t.Errorf("expected Before() to stop the Services request")
+	}
+	if len(methods) != 2 || methods[0] != "Report.SearchLL" || methods[1] != "Services.All" {
+		t.Errorf("unexpected methods: %v", methods)
+	}
+}
+
+// ------------------------------- Server -------------------------------
+
+// Echo is an RPC service for the server test.
+type Echo struct{}
+
+// Call echoes the argument.
+func (e *Echo) Call(arg *string, reply *string) error {
+	*reply = *arg
+	return nil
+}
+
+func TestServe(t *testing.T) {
+	a, _ := load(t, testConfig)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	h, err := a.Handler(&Echo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go http.Serve(l, h)
+
+	client, err := rpc.DialHTTP("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	rqst := &structs.NServiceRequest{Area: "Santa Clara"}
+	rqst.SetID(10, 20)
+	var resp structs.NServicesResponse
+	if err := client.Call("Services.Area", rqst, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.AdpID != "TST1" || resp.Message != "OK" || len(resp.Services) != 1 || resp.Services[0].MID() != "TST1-SC-1-1" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if rqstID, rpcID := resp.GetID(); rqstID != 10 || rpcID != 20 {
+		t.Errorf("the response IDs should match the request: %d-%d", rqstID, rpcID)
+	}
+
+	a.Capabilities = structs.NCapabilities{RequestTypes: []structs.NRequestType{structs.NRTCreate}, MaxRadius: 500}
+	var cresp structs.NCapabilitiesResponse
+	if err := client.Call("Adapter.Capabilities", &structs.NCapabilitiesRequest{}, &cresp); err != nil {
+		t.Fatal(err)
+	}
+	if cresp.AdpID != "TST1" || cresp.Capabilities.MaxRadius != 500 || !cresp.Capabilities.Supports(structs.NRTCreate) || cresp.Capabilities.Supports(structs.NRTSearchLL) {
+		t.Errorf("unexpected capabilities: %+v", cresp)
+	}
+
+	arg, reply := "hello", ""
+	if err := client.Call("Echo.Call", &arg, &reply); err != nil || reply != arg {
+		t.Errorf("Echo.Call = %q, %v", reply, err)
+	}
+}
+
+// Slow is an RPC service whose requests wait until released.
+type Slow struct {
+	adp     *Adapter
+	release chan struct{}
+}
+
+// slowMgr is a request manager whose Process waits until released.
+type slowMgr struct {
+	testMgr
+	release chan struct{}
+}
+
+func (c *slowMgr) Process() error { <-c.release; return nil }
+
+// Create runs a slow request.
+func (s *Slow) Create(arg *string, reply *string) error {
+	*reply = *arg
+	return s.adp.Run("Slow.Create", &slowMgr{release: s.release})
+}
+
+func TestShutdown(t *testing.T) {
+	a, _ := load(t, testConfig)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slow := &Slow{adp: a, release: make(chan struct{})}
+	served := make(chan error, 1)
+	go func() { served <- a.Serve(l, slow) }()
+
+	client, err := rpc.DialHTTP("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	arg, reply := "hello", ""
+	call := client.Go("Slow.Create", &arg, &reply, nil)
+	for atomic.LoadInt64(&a.inflight) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	stopped := make(chan struct{})
+	go func() { a.Shutdown(); close(stopped) }()
+	select {
+	case <-stopped:
+		t.Fatal("Shutdown should wait for the request in progress")
+	case <-time.After(time.Millisecond * 100):
+	}
+	if err := <-served; err != http.ErrServerClosed {
+		t.Errorf("expected Serve to return http.ErrServerClosed, got: %v", err)
+	}
+	if _, err := net.Dial("tcp", l.Addr().String()); err == nil {
+		t.Errorf("expected new connections to be refused")
+	}
+
+	close(slow.release)
+	<-call.Done
+	if call.Error != nil || reply != arg {
+		t.Errorf("the request in progress should complete: %q, %v", reply, call.Error)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Errorf("Shutdown should return when the requests finish")
+	}
+	a.SendRPC("1-1", "done", "TST1-SJ-1", "", 0, time.Now())
+}
+
+// ------------------------------- In-process -------------------------------
+
+// Mutator is an RPC service for the in-process test.
+type Mutator struct{}
+
+// Create changes the request, and fails or panics on request.
+func (m *Mutator) Create(rqst *structs.NCreateRequest, resp *structs.NCreateResponse) error {
+	rqst.Description = "changed"
+	resp.Message = "OK"
+	switch rqst.FirstName {
+	case "fail":
+		return errors.New("failed")
+	case "panic":
+		panic("boom")
+	}
+	return nil
+}
+
+func TestInproc(t *testing.T) {
+	a := New(func() Provider { return new(testProvider) })
+	if _, err := a.Inproc(); err == nil {
+		t.Errorf("expected an error for an unloaded adapter")
+	}
+	a, _ = load(t, testConfig)
+	if _, err := a.Inproc(&Echo{}, &testMgr{}); err == nil {
+		t.Errorf("expected an error for a type without RPC methods")
+	}
+	p, err := a.Inproc(&Mutator{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name() != "TST1" || len(p.Methods()) != 4 {
+		t.Errorf("unexpected methods: %v", p.Methods())
+	}
+
+	rqst := &structs.NServiceRequest{Area: "Santa Clara"}
+	rqst.SetID(10, 20)
+	var resp structs.NServicesResponse
+	if err := p.Call("Services.Area", rqst, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Services) != 1 || resp.Services[0].MID() != "TST1-SC-1-1" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if rqstID, rpcID := resp.GetID(); rqstID != 10 || rpcID != 20 {
+		t.Errorf("the response IDs should match the request: %d-%d", rqstID, rpcID)
+	}
+	var cresp structs.NCapabilitiesResponse
+	if err := p.Call("Adapter.Capabilities", &structs.NCapabilitiesRequest{}, &cresp); err != nil || cresp.AdpID != "TST1" {
+		t.Errorf("Adapter.Capabilities = %+v, %v", cresp, err)
+	}
+
+	// The method gets a copy of the request.
+	create := &structs.NCreateRequest{Description: "Pothole"}
+	var crResp structs.NCreateResponse
+	if err := p.Call("Mutator.Create", create, &crResp); err != nil || crResp.Message != "OK" {
+		t.Errorf("Mutator.Create = %+v, %v", crResp, err)
+	}
+	if create.Description != "Pothole" {
+		t.Errorf("the caller's request was changed: %q", create.Description)
+	}
+
+	// Errors are rpc.ServerErrors, and the reply is not set.
+	for _, name := range []string{"fail", "panic"} {
+		crResp = structs.NCreateResponse{}
+		err := p.Call("Mutator.Create", &structs.NCreateRequest{FirstName: name}, &crResp)
+		if _, ok := err.(rpc.ServerError); !ok || crResp.Message != "" {
+			t.Errorf("%s: expected an rpc.ServerError and no reply, got: %v  %+v", name, err, crResp)
+		}
+	}
+	if err := p.Call("Mutator.Delete", create, &crResp); err == nil {
+		t.Errorf("expected an error for an unknown method")
+	}
+	if err := p.Call("Mutator.Create", rqst, &crResp); err == nil {
+		t.Errorf("expected an error for the wrong argument type")
+	}
+	if err := p.Call("Mutator.Create", create, crResp); err == nil {
+		t.Errorf("expected an error for a reply that is not a pointer")
+	}
+}
+
+// ------------------------------- Registration -------------------------------
+
+func TestRegister(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		regs    []structs.NRegisterRequest
+		removed []string
+	)
+	engine := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Header.Get(regHeader) != "secret" {
+			http.Error(w, "invalid registration token", http.StatusBadRequest)
+			return
+		}
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/v1/adapters.json":
+			var reg structs.NRegisterRequest
+			if err := json.NewDecoder(r.Body).Decode(&reg); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			regs = append(regs, reg)
+			json.NewEncoder(w).Encode(structs.NRegisterResponse{AdpID: reg.AdpID, Lease: 1, Message: "OK"})
+		case r.Method == "DELETE":
+			removed = append(removed, r.URL.RequestURI())
+			json.NewEncoder(w).Encode(structs.NRegisterResponse{Message: "OK"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer engine.Close()
+
+	a, _ := load(t, testConfig)
+	a.Capabilities = structs.NCapabilities{RequestTypes: []structs.NRequestType{structs.NRTCreate}}
+	a.Engine = EngineData{URL: engine.URL + "/", Lease: 30, Token: "wrong"}
+	if _, err := a.Register(":5099"); err == nil {
+		t.Errorf("expected an invalid token error")
+	}
+
+	a.Engine.Token = "secret"
+	a.startRegistration("127.0.0.1:5099")
+	time.Sleep(time.Millisecond * 800)
+	a.Shutdown()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(regs) < 2 {
+		t.Fatalf("expected the registration to be renewed, got: %d", len(regs))
+	}
+	reg := regs[0]
+	if reg.AdpID != "TST1" || reg.Type != "Test" || reg.Address != "127.0.0.1:5099" || reg.Lease != 30 ||
+		len(reg.Areas) != 2 || reg.Areas[0].ID != "SC" || reg.Areas[1].Name != "San Jose" ||
+		!reg.Capabilities.Supports(structs.NRTCreate) || reg.Capabilities.Supports(structs.NRTSearchLL) {
+		t.Errorf("unexpected registration: %+v", reg)
+	}
+	if len(removed) != 1 || removed[0] != "/v1/adapters/TST1.json?address=127.0.0.1%3A5099" {
+		t.Errorf("expected the registration to be removed on shutdown, got: %v", removed)
+	}
+
+	a.Engine.Advertise = "gateway-adp:5099"
+	if r := a.RegisterRequest("[::]:5099"); r.Address != "gateway-adp:5099" {
+		t.Errorf("expected the advertised address, got: %q", r.Address)
+	}
+}
+
+func TestJSONRPC(t *testing.T) {
+	a, _ := load(t, testConfig)
+	h, err := a.Handler()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	c := jsonrpc.NewClient(srv.URL+jsonrpc.Path, time.Second*5)
+	rqst := &structs.NServiceRequest{Area: "Santa Clara"}
+	rqst.SetID(10, 20)
+	var resp structs.NServicesResponse
+	if err := c.Call("Services.Area", rqst, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Services) != 1 || resp.Services[0].MID() != "TST1-SC-1-1" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if rqstID, rpcID := resp.GetID(); rqstID != 10 || rpcID != 20 {
+		t.Errorf("the response IDs should match the request: %d-%d", rqstID, rpcID)
+	}
+	if err := c.Call("Report.Create", &structs.NCreateRequest{}, &structs.NCreateResponse{}); err == nil {
+		t.Errorf("expected an unknown method error")
+	}
+
+	// The params may be an array holding the request.
+	body := `{"jsonrpc": "2.0", "method": "Services.Area", "params": [{"area": "Santa Clara"}], "id": 1}`
+	r, err := http.Post(srv.URL+jsonrpc.Path, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Body.Close()
+	var jresp struct {
+		Result structs.NServicesResponse `json:"result"`
+		Error  *jsonrpc.Error            `json:"error"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&jresp); err != nil || jresp.Error != nil || len(jresp.Result.Services) != 1 {
+		t.Errorf("unexpected response: %+v, %v", jresp, err)
+	}
+}
@@ -0,0 +1,316 @@
+package adaptersdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common"
+	"github.com/codeforsanjose/open311-gateway/common/rpcauth"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+const (
+	dfltRefresh       = 3600 // seconds
+	dfltShutdownGrace = 10   // seconds
+)
+
+// ==============================================================================================================================
+//                                      CONFIG
+// ==============================================================================================================================
+
+// AdapterData contains the "adapter" section of the config file.
+type AdapterData struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Address string `json:"address"`
+	Refresh int    `json:"refresh"` // Seconds between Service list reloads
+
+	ShutdownGrace int `json:"shutdownGrace"` // Seconds Shutdown() waits for the requests in progress.
+}
+
+func (a *AdapterData) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddF("AdapterData\n")
+	ls.AddF("Name: %s   Type: %s   Address: %s\n", a.Name, a.Type, a.Address)
+	ls.AddF("Refresh: %ds   Shutdown grace: %ds\n", a.Refresh, a.ShutdownGrace)
+	return ls.Box(70)
+}
+
+// EngineData is the "engine" section of the config file.  If the URL is set, the Adapter
+// registers itself with the Engine when it starts serving, and renews the registration
+// until it is shut down.
+type EngineData struct {
+	URL       string `json:"url"`       // The Engine API, e.g. "http://localhost:8080".
+	Advertise string `json:"advertise"` // The RPC address the Engine connects to.  Defaults to the listener address.
+	Lease     int    `json:"lease"`     // Seconds - the requested lease.  0 is the Engine default.
+	Token     string `json:"token"`     // The Engine "registration.token".
+}
+
+// configFile is the part of the config file common to all Adapters.  The Providers are
+// decoded into the Adapter's own Provider type.
+type configFile struct {
+	Adapter AdapterData    `json:"adapter"`
+	Engine  EngineData     `json:"engine"`
+	RPCAuth rpcauth.Config `json:"rpcAuth"`
+	Monitor struct {
+		Address string `json:"address"`
+	} `json:"monitor"`
+	Areas map[string]struct {
+		Name      string            `json:"name"`
+		Providers []json.RawMessage `json:"providers"`
+	} `json:"serviceAreas"`
+}
+
+// ------------------------------- Area -------------------------------
+
+// Area is a Service Area.  It contains a list of all of the Service Providers for this Area.
+type Area struct {
+	ID        string
+	Name      string
+	Providers []Provider
+}
+
+func (a Area) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddF("%s (%s)\n", a.Name, a.ID)
+	for _, v := range a.Providers {
+		ls.AddF("%s\n", v)
+	}
+	return ls.Box(85)
+}
+
+// ------------------------------- Provider -------------------------------
+
+// Provider is implemented by the Provider type of each Adapter, by embedding a
+// ProviderBase.  The Adapter's Provider type holds the Provider specific config data,
+// e.g. the API URL and keys.
+type Provider interface {
+	Base() *ProviderBase
+}
+
+// Settler is implemented by Providers needing any setup after the config file is
+// loaded, e.g. creating an API client.  An error stops the Adapter from loading.
+type Settler interface {
+	Settle() error
+}
+
+// ServiceLoader is implemented by Providers with query-able Service lists.  If a
+// ServiceLoader has no static Service list in the config file, its Service list is
+// loaded at startup, and reloaded every "refresh" seconds.  LoadServices only needs to
+// set the Service ID and description fields - the routing fields are set by the
+// Adapter.
+type ServiceLoader interface {
+	LoadServices() ([]*structs.NService, error)
+}
+
+// ProviderBase is the config data common to all Providers.
+type ProviderBase struct {
+	ID           int                 `json:"id"`
+	Name         string              `json:"name"`
+	ResponseType string              `json:"responseType"`
+	Services     []*structs.NService `json:"services"` // Static Service list
+	AdpID        string              `json:"-"`
+	AreaID       string              `json:"-"`
+}
+
+// Base returns the ProviderBase.
+func (p *ProviderBase) Base() *ProviderBase {
+	return p
+}
+
+// Static returns true if the Provider has a static Service list.
+func (p *ProviderBase) Static() bool {
+	return len(p.Services) > 0
+}
+
+// settleService sets the routing fields of the Service.
+func (p *ProviderBase) settleService(srv *structs.NService) {
+	srv.AdpID = p.AdpID
+	srv.AreaID = p.AreaID
+	srv.ProviderID = p.ID
+	if srv.ResponseType == "" {
+		srv.ResponseType = p.ResponseType
+	}
+}
+
+// ==============================================================================================================================
+//                                      LOAD
+// ==============================================================================================================================
+
+// Init reads and loads the config file.  See Load().
+func (a *Adapter) Init(filePath string, ext interface{}) error {
+	file, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		msg := fmt.Sprintf("Unable to access the config file - %v.", err)
+		log.Error(msg)
+		return errors.New(msg)
+	}
+	return a.Load(file, ext)
+}
+
+// Load loads the config file contents, builds the indexes, and loads the Service lists
+// of all ServiceLoaders.  If ext is not nil, the config file is also decoded into it,
+// for any Adapter specific settings.
+func (a *Adapter) Load(file []byte, ext interface{}) error {
+	if a.Loaded {
+		msg := "Route Data is already loaded"
+		log.Error(msg)
+		return errors.New(msg)
+	}
+
+	var cf configFile
+	if err := json.Unmarshal(file, &cf); err != nil {
+		msg := fmt.Sprintf("Unable to parse JSON Route Data.\nError: %v", err)
+		log.Error(msg)
+		return errors.New(msg)
+	}
+	if ext != nil {
+		if err := json.Unmarshal(file, ext); err != nil {
+			msg := fmt.Sprintf("Unable to parse JSON Route Data.\nError: %v", err)
+			log.Error(msg)
+			return errors.New(msg)
+		}
+	}
+	log.Info("Initializing data...")
+
+	a.Adapter = cf.Adapter
+	if a.Adapter.Refresh <= 0 {
+		a.Adapter.Refresh = dfltRefresh
+	}
+	if a.Adapter.ShutdownGrace <= 0 {
+		a.Adapter.ShutdownGrace = dfltShutdownGrace
+	}
+	a.Engine = cf.Engine
+	a.RPCAuth = cf.RPCAuth
+	if err := a.RPCAuth.Load(); err != nil {
+		msg := fmt.Sprintf("Invalid rpcAuth in the config file - %s.", err)
+		log.Error(msg)
+		return errors.New(msg)
+	}
+	a.Monitor.Address = cf.Monitor.Address
+	a.Areas = make(map[string]*Area)
+	for areaID, ca := range cf.Areas {
+		area := &Area{ID: areaID, Name: ca.Name}
+		for i, raw := range ca.Providers {
+			p := a.newProvider()
+			if err := json.Unmarshal(raw, p); err != nil {
+				return fmt.Errorf("unable to parse provider %d of area %q - %s", i, areaID, err)
+			}
+			if err := a.settle(areaID, p); err != nil {
+				return err
+			}
+			area.Providers = append(area.Providers, p)
+		}
+		a.Areas[areaID] = area
+	}
+
+	a.index()
+	a.loadServices()
+	a.Loaded = true
+	log.Debug(a.String())
+	return nil
+}
+
+// settle denormalizes the service keys, and runs the Provider setup.
+func (a *Adapter) settle(areaID string, p Provider) error {
+	b := p.Base()
+	b.AdpID = a.Adapter.Name
+	b.AreaID = areaID
+	for _, service := range b.Services {
+		b.settleService(service)
+	}
+	if s, ok := p.(Settler); ok {
+		return s.Settle()
+	}
+	return nil
+}
+
+// index builds the Area and Provider indexes.
+func (a *Adapter) index() {
+	log.Info("   Building indexes...")
+	a.areaCode = make(map[string]string)
+	a.areaProvider = make(map[areaProvider]Provider)
+	for areaID, area := range a.Areas {
+		a.areaCode[strings.ToLower(area.Name)] = areaID
+		for _, p := range area.Providers {
+			a.areaProvider[areaProvider{areaID, p.Base().ID}] = p
+		}
+	}
+}
+
+// loadServices loads the Service list of each ServiceLoader without a static list, and
+// rebuilds the Service indexes.  If a Provider cannot be reached, its previous list is
+// kept.
+func (a *Adapter) loadServices() {
+	loaded := make(map[Provider][]*structs.NService)
+	for _, area := range a.Areas {
+		for _, p := range area.Providers {
+			sl, ok := p.(ServiceLoader)
+			if !ok || p.Base().Static() {
+				continue
+			}
+			list, err := sl.LoadServices()
+			if err != nil {
+				log.Warningf("Unable to load the services for %q - %s", p.Base().Name, err)
+				continue
+			}
+			for _, srv := range list {
+				p.Base().settleService(srv)
+			}
+			loaded[p] = list
+		}
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	if a.loaded == nil {
+		a.loaded = make(map[Provider][]*structs.NService)
+	}
+	for p, list := range loaded {
+		a.loaded[p] = list
+	}
+	a.serviceMID = make(map[string]*structs.NService)
+	a.areaServices = make(map[string]structs.NServices)
+	for areaID, area := range a.Areas {
+		a.areaServices[areaID] = make(structs.NServices, 0)
+		for _, p := range area.Providers {
+			list := p.Base().Services
+			if !p.Base().Static() {
+				list = a.loaded[p]
+			}
+			for _, service := range list {
+				a.serviceMID[service.MID()] = service
+				a.areaServices[areaID] = append(a.areaServices[areaID], *service)
+			}
+		}
+	}
+	a.loadedAt = time.Now()
+}
+
+// refresh reloads the Service lists if they are older than the refresh interval.
+func (a *Adapter) refresh() {
+	a.RLock()
+	stale := a.dynamic() && time.Since(a.loadedAt) > time.Duration(a.Adapter.Refresh)*time.Second
+	a.RUnlock()
+	if stale {
+		a.loadServices()
+	}
+}
+
+// dynamic returns true if any Provider loads its Service list.
+func (a *Adapter) dynamic() bool {
+	for _, area := range a.Areas {
+		for _, p := range area.Providers {
+			if _, ok := p.(ServiceLoader); ok && !p.Base().Static() {
+				return true
+			}
+		}
+	}
+	return false
+}
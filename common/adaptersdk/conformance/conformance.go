@@ -0,0 +1,477 @@
+// Package conformance is a test suite for the contract between the Engine and an
+// Adapter.  It drives every RPC method of an Adapter, and checks:
+//
+//   - Routing: every Service and Report ID carries the AdpID, AreaID and ProviderID of
+//     the Adapter and Provider returning it.
+//   - ID echoing: every response has the request ID (see NResponseCommon.SetIDF), and
+//     the request Route.
+//   - Report IDs: every Report has a valid RID, and the RID from Create finds the Report.
+//   - Empty results: a search with no matches succeeds, with no Reports.
+//   - Errors: requests for unknown Areas and Providers fail with an error message.
+//
+// The Report requests not listed in the Adapter Capabilities are skipped.
+//
+// The Adapter is either served in-process (see adaptersdk.Adapter.Handler), or running
+// at an address.  For example, in an Adapter test:
+//
+//	h, _ := data.Adapter().Handler(&request.Report{})
+//	conformance.Run(t, conformance.Target{Handler: h, AdpID: "SIM1", Area: "San Jose", AreaID: "SJ", ...})
+//
+// or against a running Adapter:
+//
+//	go test ./common/adaptersdk/conformance -address=:5006 -adapter=SIM1 -area="San Jose" -areaid=SJ
+//
+// Adapters using the JSON-RPC transport (e.g. those not written in Go) are tested with
+// -jsonrpc, or Target.JSONRPC.
+package conformance
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/rpc"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common/rpcauth"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+// Target describes the Adapter under test.
+type Target struct {
+	// Address is the RPC address of a running Adapter.  If Handler is set, it is served
+	// in-process instead.
+	Address string
+	Handler http.Handler
+
+	// JSONRPC calls the Adapter using JSON-RPC 2.0, instead of net/rpc.
+	JSONRPC bool
+
+	// Auth is the Engine "rpcAuth", for an Adapter requiring TLS or signed requests.  It
+	// must be loaded.
+	Auth *rpcauth.Config
+
+	AdpID  string // Adapter name, e.g. "SIM1"
+	Area   string // Area name for Services.Area, e.g. "San Jose"
+	AreaID string // ID of the Area, e.g. "SJ"
+
+	// Latitude and Longitude are a location in the Area, for Create and SearchLL.
+	Latitude  float64
+	Longitude float64
+
+	// Service is the ID of the Service used for Create.  Default: the first Service in
+	// the Area.
+	Service int
+
+	// Prepare, if set, is called with each Create request before it is sent, e.g. to
+	// add Service attributes.
+	Prepare func(rqst *structs.NCreateRequest)
+
+	// ReadOnly skips creating a Report, e.g. for an Adapter connected to a live Provider.
+	ReadOnly bool
+
+	// Skip lists the checks to skip, by subtest name (e.g. "Report.SearchDID/empty"),
+	// for Providers unable to support them (e.g. a stand-in returning fixed results).
+	Skip []string
+}
+
+// client is the connection to the Adapter - an *rpc.Client or a *jsonrpc.Client.
+type client interface {
+	Call(serviceMethod string, args interface{}, reply interface{}) error
+	Close() error
+}
+
+// suite holds the state of a conformance run.
+type suite struct {
+	Target
+	client   client
+	rqstID   int64
+	caps     structs.NCapabilities
+	services structs.NServices
+	created  structs.ReportID
+	deviceID string
+}
+
+// Run runs the conformance suite against the Target.
+func Run(t *testing.T, tg Target) {
+	c, done, err := dial(tg)
+	if err != nil {
+		t.Fatalf("unable to connect to the Adapter: %s", err)
+	}
+	defer done()
+
+	s := &suite{
+		Target:   tg,
+		client:   c,
+		deviceID: fmt.Sprintf("conformance-%d", time.Now().UnixNano()),
+	}
+	s.run(t, "Adapter.Capabilities", s.capabilities)
+	s.run(t, "Services.All", s.servicesAll)
+	s.run(t, "Services.Area", s.servicesArea)
+	s.run(t, "Services.Area/unknown", s.servicesAreaUnknown)
+	if len(s.services) == 0 {
+		t.Fatalf("no services in area %q - unable to continue", tg.Area)
+	}
+	if !tg.ReadOnly {
+		s.run(t, "Report.Create", s.create)
+	}
+	s.run(t, "Report.Create/unknown", s.createUnknown)
+	s.run(t, "Report.SearchLL", s.searchLL)
+	s.run(t, "Report.SearchLL/empty", s.searchLLEmpty)
+	s.run(t, "Report.SearchDID", s.searchDID)
+	s.run(t, "Report.SearchDID/empty", s.searchDIDEmpty)
+	s.run(t, "Report.SearchRID", s.searchRID)
+	s.run(t, "Report.SearchRID/unknown", s.searchRIDUnknown)
+}
+
+// dial connects to the Target, starting the in-process server if needed.  done closes
+// the connection and the server.
+func dial(tg Target) (c client, done func(), err error) {
+	addr := tg.Address
+	var l net.Listener
+	if tg.Handler != nil {
+		if l, err = net.Listen("tcp", "127.0.0.1:0"); err != nil {
+			return nil, nil, err
+		}
+		go http.Serve(l, tg.Handler)
+		addr = l.Addr().String()
+	}
+	if addr == "" {
+		return nil, nil, fmt.Errorf("the Target needs an Address or a Handler")
+	}
+	if tg.JSONRPC {
+		c = tg.Auth.JSONRPCClient(addr, time.Second*30)
+	} else if c, err = tg.Auth.DialHTTP(addr); err != nil {
+		if l != nil {
+			l.Close()
+		}
+		return nil, nil, err
+	}
+	return c, func() {
+		c.Close()
+		if l != nil {
+			l.Close()
+		}
+	}, nil
+}
+
+// methodTypes maps the Report RPC methods to their request type.
+var methodTypes = map[string]structs.NRequestType{
+	"Report.Create":    structs.NRTCreate,
+	"Report.SearchLL":  structs.NRTSearchLL,
+	"Report.SearchDID": structs.NRTSearchDID,
+	"Report.SearchRID": structs.NRTSearchRID,
+}
+
+func (s *suite) run(t *testing.T, name string, f func(t *testing.T)) {
+	t.Run(name, func(t *testing.T) {
+		for _, skip := range s.Skip {
+			if skip == name {
+				t.Skip("skipped by the Target")
+			}
+		}
+		if rtype, ok := methodTypes[strings.SplitN(name, "/", 2)[0]]; ok && !s.caps.Supports(rtype) {
+			t.Skipf("%s is not supported by the Adapter", rtype)
+		}
+		f(t)
+	})
+}
+
+// call makes an RPC call, with a new request ID.
+func (s *suite) call(method string, rqst structs.NRequester, resp interface{}) error {
+	s.rqstID++
+	rqst.SetID(1000+s.rqstID, 2000+s.rqstID)
+	return s.client.Call(method, rqst, resp)
+}
+
+// route returns the route for the Area, and the Provider of the Create Service.
+func (s *suite) route() structs.NRoute {
+	return s.service().GetRoute()
+}
+
+// service returns the Service used for Create.
+func (s *suite) service() structs.NService {
+	for _, srv := range s.services {
+		if srv.ID == s.Service {
+			return srv
+		}
+	}
+	return s.services[0]
+}
+
+// ================================================================================================
+//                                      CHECKS
+// ================================================================================================
+
+// checkID checks that the response echoes the request ID.
+func checkID(t *testing.T, rqst structs.NRequester, resp structs.NResponser) {
+	rqstID, rpcID := rqst.GetID()
+	if x, y := resp.GetID(); x != rqstID || y != rpcID {
+		t.Errorf("the response ID %d-%d should match the request ID %d-%d", x, y, rqstID, rpcID)
+	}
+}
+
+// checkRoute checks that the response carries the request Route.
+func checkRoute(t *testing.T, rqst structs.NRequester, resp structs.NResponser) {
+	if resp.GetRoute() != rqst.GetRoute() {
+		t.Errorf("the response route %q should match the request route %q", resp.GetRoute().String(), rqst.GetRoute().String())
+	}
+}
+
+// checkRID checks that a Report ID is valid, and routed to route.
+func checkRID(t *testing.T, rid structs.ReportID, route structs.NRoute) {
+	if rid.ID == "" {
+		t.Errorf("RID %q has no report ID", rid.RID())
+		return
+	}
+	if rid.NRoute != route {
+		t.Errorf("RID %q should have the route %q", rid.RID(), route.String())
+	}
+	if x, _, err := structs.RIDFromString(rid.RID()); err != nil || x != rid {
+		t.Errorf("RID %q does not survive a round trip: %v, %v", rid.RID(), x, err)
+	}
+}
+
+// checkError checks the shape of an expected error.
+func checkError(t *testing.T, method string, err error) {
+	switch err.(type) {
+	case nil:
+		t.Errorf("%s should fail", method)
+	case rpc.ServerError:
+		if err.Error() == "" {
+			t.Errorf("%s failed with an empty error message", method)
+		}
+	default:
+		t.Errorf("%s should fail with an Adapter error, got: %T %v", method, err, err)
+	}
+}
+
+// checkSearch checks a search response, and returns the RIDs.
+func checkSearch(t *testing.T, rqst structs.NRequester, resp *structs.NSearchResponse) map[structs.ReportID]bool {
+	checkID(t, rqst, resp)
+	checkRoute(t, rqst, resp)
+	if resp.ReportCount != len(resp.Reports) {
+		t.Errorf("ReportCount is %d, with %d reports", resp.ReportCount, len(resp.Reports))
+	}
+	rids := make(map[structs.ReportID]bool)
+	for _, r := range resp.Reports {
+		checkRID(t, r.RID, rqst.GetRoute())
+		if rids[r.RID] {
+			t.Errorf("duplicate report: %q", r.RID.RID())
+		}
+		rids[r.RID] = true
+	}
+	return rids
+}
+
+// checkEmpty checks a search response with no matches.
+func checkEmpty(t *testing.T, rqst structs.NRequester, resp *structs.NSearchResponse) {
+	checkSearch(t, rqst, resp)
+	if resp.ReportCount != 0 || len(resp.Reports) != 0 {
+		t.Errorf("expected no reports, got: %d", len(resp.Reports))
+	}
+}
+
+// ------------------------------- Capabilities -------------------------------
+
+func (s *suite) capabilities(t *testing.T) {
+	rqst := &structs.NCapabilitiesRequest{}
+	var resp structs.NCapabilitiesResponse
+	if err := s.call("Adapter.Capabilities", rqst, &resp); err != nil {
+		t.Fatal(err)
+	}
+	checkID(t, rqst, &resp)
+	if resp.AdpID != s.AdpID {
+		t.Errorf("the response AdpID is %q, expected %q", resp.AdpID, s.AdpID)
+	}
+	for _, rtype := range resp.Capabilities.RequestTypes {
+		if _, ok := methodTypes[serviceMethod(rtype)]; !ok {
+			t.Errorf("unknown request type: %s", rtype)
+		}
+	}
+	s.caps = resp.Capabilities
+}
+
+// serviceMethod returns the Report RPC method for a request type.
+func serviceMethod(rtype structs.NRequestType) string {
+	for m, t := range methodTypes {
+		if t == rtype {
+			return m
+		}
+	}
+	return ""
+}
+
+// ------------------------------- Services -------------------------------
+
+func (s *suite) checkServices(t *testing.T, rqst *structs.NServiceRequest, resp *structs.NServicesResponse) {
+	checkID(t, rqst, resp)
+	if resp.AdpID != s.AdpID {
+		t.Errorf("the response AdpID is %q, expected %q", resp.AdpID, s.AdpID)
+	}
+	mids := make(map[string]bool)
+	for _, srv := range resp.Services {
+		if srv.AdpID != s.AdpID || srv.AreaID == "" || srv.ProviderID <= 0 {
+			t.Errorf("service %q is not routed to the Adapter", srv.MID())
+		}
+		if srv.Name == "" {
+			t.Errorf("service %q has no name", srv.MID())
+		}
+		if mids[srv.MID()] {
+			t.Errorf("duplicate service: %q", srv.MID())
+		}
+		mids[srv.MID()] = true
+	}
+}
+
+func (s *suite) servicesAll(t *testing.T) {
+	rqst := &structs.NServiceRequest{}
+	var resp structs.NServicesResponse
+	if err := s.call("Services.All", rqst, &resp); err != nil {
+		t.Fatal(err)
+	}
+	s.checkServices(t, rqst, &resp)
+	if len(resp.Services) == 0 {
+		t.Errorf("the Adapter has no services")
+	}
+}
+
+func (s *suite) servicesArea(t *testing.T) {
+	rqst := &structs.NServiceRequest{Area: s.Area}
+	var resp structs.NServicesResponse
+	if err := s.call("Services.Area", rqst, &resp); err != nil {
+		t.Fatal(err)
+	}
+	s.checkServices(t, rqst, &resp)
+	for _, srv := range resp.Services {
+		if srv.AreaID != s.AreaID {
+			t.Errorf("service %q is not in area %q", srv.MID(), s.AreaID)
+		}
+	}
+	s.services = resp.Services
+}
+
+func (s *suite) servicesAreaUnknown(t *testing.T) {
+	var resp structs.NServicesResponse
+	checkError(t, "Services.Area", s.call("Services.Area", &structs.NServiceRequest{Area: "Conformance Unknown Area"}, &resp))
+}
+
+// ------------------------------- Create -------------------------------
+
+func (s *suite) createRequest(mid structs.ServiceID) *structs.NCreateRequest {
+	rqst := &structs.NCreateRequest{
+		MID:         mid,
+		ServiceName: s.service().Name,
+		DeviceType:  "IOS",
+		DeviceID:    s.deviceID,
+		Latitude:    s.Latitude,
+		Longitude:   s.Longitude,
+		FirstName:   "Conformance",
+		LastName:    "Test",
+		Email:       "conformance@example.com",
+		Description: "Conformance test report",
+	}
+	rqst.SetRoute(mid.GetRoute())
+	if s.Prepare != nil {
+		s.Prepare(rqst)
+	}
+	return rqst
+}
+
+func (s *suite) create(t *testing.T) {
+	rqst := s.createRequest(s.service().ServiceID)
+	var resp structs.NCreateResponse
+	if err := s.call("Report.Create", rqst, &resp); err != nil {
+		t.Fatal(err)
+	}
+	checkID(t, rqst, &resp)
+	checkRoute(t, rqst, &resp)
+	checkRID(t, resp.RID, s.route())
+	if resp.Message == "" {
+		t.Errorf("the response has no message")
+	}
+	s.created = resp.RID
+}
+
+func (s *suite) createUnknown(t *testing.T) {
+	mid := s.service().ServiceID
+	mid.ProviderID = 999
+	var resp structs.NCreateResponse
+	checkError(t, "Report.Create", s.call("Report.Create", s.createRequest(mid), &resp))
+}
+
+// ------------------------------- Search -------------------------------
+
+func (s *suite) searchLL(t *testing.T) {
+	rqst := &structs.NSearchRequestLL{Latitude: s.Latitude, Longitude: s.Longitude, Radius: 1000, AreaID: s.AreaID, MaxResults: 20}
+	rqst.SetRoute(s.route())
+	var resp structs.NSearchResponse
+	if err := s.call("Report.SearchLL", rqst, &resp); err != nil {
+		t.Fatal(err)
+	}
+	checkSearch(t, rqst, &resp)
+}
+
+func (s *suite) searchLLEmpty(t *testing.T) {
+	// The middle of the South Pacific.
+	rqst := &structs.NSearchRequestLL{Latitude: -48.8767, Longitude: -123.3933, Radius: 10, AreaID: s.AreaID, MaxResults: 20}
+	rqst.SetRoute(s.route())
+	var resp structs.NSearchResponse
+	if err := s.call("Report.SearchLL", rqst, &resp); err != nil {
+		t.Fatal(err)
+	}
+	checkEmpty(t, rqst, &resp)
+}
+
+func (s *suite) searchDID(t *testing.T) {
+	rqst := &structs.NSearchRequestDID{DeviceType: "IOS", DeviceID: s.deviceID, AreaID: s.AreaID, MaxResults: 20}
+	rqst.SetRoute(s.route())
+	var resp structs.NSearchResponse
+	if err := s.call("Report.SearchDID", rqst, &resp); err != nil {
+		t.Fatal(err)
+	}
+	rids := checkSearch(t, rqst, &resp)
+	if s.created.ID != "" && !rids[s.created] {
+		t.Errorf("the created report %q was not found", s.created.RID())
+	}
+}
+
+func (s *suite) searchDIDEmpty(t *testing.T) {
+	rqst := &structs.NSearchRequestDID{DeviceType: "IOS", DeviceID: s.deviceID + "-none", AreaID: s.AreaID, MaxResults: 20}
+	rqst.SetRoute(s.route())
+	var resp structs.NSearchResponse
+	if err := s.call("Report.SearchDID", rqst, &resp); err != nil {
+		t.Fatal(err)
+	}
+	checkEmpty(t, rqst, &resp)
+}
+
+func (s *suite) searchRID(t *testing.T) {
+	if s.created.ID == "" {
+		t.Skip("no report was created")
+	}
+	rqst := &structs.NSearchRequestRID{RID: s.created, AreaID: s.AreaID}
+	rqst.SetRoute(s.created.NRoute)
+	var resp structs.NSearchResponse
+	if err := s.call("Report.SearchRID", rqst, &resp); err != nil {
+		t.Fatal(err)
+	}
+	checkSearch(t, rqst, &resp)
+	if len(resp.Reports) != 1 || resp.Reports[0].RID != s.created {
+		t.Errorf("expected the created report %q, got: %v", s.created.RID(), resp.Reports)
+	}
+}
+
+// searchRIDUnknown checks a search for a Report that does not exist.  The Adapter can
+// either fail, or return no Reports.
+func (s *suite) searchRIDUnknown(t *testing.T) {
+	rqst := &structs.NSearchRequestRID{RID: structs.NewRID(s.route(), "999999999"), AreaID: s.AreaID}
+	rqst.SetRoute(s.route())
+	var resp structs.NSearchResponse
+	if err := s.call("Report.SearchRID", rqst, &resp); err != nil {
+		checkError(t, "Report.SearchRID", err)
+		return
+	}
+	checkEmpty(t, rqst, &resp)
+}
@@ -0,0 +1,48 @@
+package conformance
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/codeforsanjose/open311-gateway/common/rpcauth"
+)
+
+var (
+	address   = flag.String("address", "", "RPC address of a running Adapter.  The test is skipped if not set.")
+	useJSON   = flag.Bool("jsonrpc", false, "Call the Adapter using JSON-RPC 2.0.")
+	secret    = flag.String("secret", "", "The Adapter rpcAuth secret, for signed requests.")
+	adpID     = flag.String("adapter", "", "Adapter name, e.g. SIM1.")
+	area      = flag.String("area", "", "Area name, e.g. \"San Jose\".")
+	areaID    = flag.String("areaid", "", "Area ID, e.g. SJ.")
+	latitude  = flag.Float64("lat", 37.3382, "Latitude of a location in the Area.")
+	longitude = flag.Float64("lng", -121.8863, "Longitude of a location in the Area.")
+	service   = flag.Int("service", 0, "Service ID for creating a report.  Default: the first Service in the Area.")
+	readOnly  = flag.Bool("readonly", false, "Skip creating a report.")
+	skip      = flag.String("skip", "", "Comma separated list of checks to skip.")
+)
+
+// TestAddress runs the conformance suite against a running Adapter.
+func TestAddress(t *testing.T) {
+	if *address == "" {
+		t.Skip("no -address")
+	}
+	tg := Target{
+		Address:   *address,
+		JSONRPC:   *useJSON,
+		AdpID:     *adpID,
+		Area:      *area,
+		AreaID:    *areaID,
+		Latitude:  *latitude,
+		Longitude: *longitude,
+		Service:   *service,
+		ReadOnly:  *readOnly,
+	}
+	if *secret != "" {
+		tg.Auth = &rpcauth.Config{Secret: *secret}
+	}
+	if *skip != "" {
+		tg.Skip = strings.Split(*skip, ",")
+	}
+	Run(t, tg)
+}
@@ -0,0 +1,71 @@
+package adaptersdk
+
+import (
+	"errors"
+	"fmt"
+	"net/rpc"
+	"reflect"
+	"strings"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+// Inproc is an Adapter compiled into the Engine.  The Engine calls its RPC methods
+// directly, instead of over net/rpc - see the "inproc" transport in the Engine config.
+type Inproc struct {
+	adp     *Adapter
+	methods methodSet
+}
+
+// Inproc returns the in-process Adapter, with the same RPC services as Handler(), and
+// starts the telemetry.  The Adapter must already be loaded.
+func (a *Adapter) Inproc(rcvrs ...interface{}) (*Inproc, error) {
+	if !a.Loaded {
+		return nil, errors.New("the adapter config is not loaded")
+	}
+	ms, err := a.newMethodSet(rcvrs...)
+	if err != nil {
+		return nil, err
+	}
+	p := &Inproc{adp: a, methods: ms}
+	a.startTelemetry()
+	log.Debugf("In-process adapter %s methods: %s", a.Name(), strings.Join(p.Methods(), ", "))
+	return p, nil
+}
+
+// Name returns the adapter name.
+func (p *Inproc) Name() string {
+	return p.adp.Name()
+}
+
+// Methods returns the sorted list of RPC methods.
+func (p *Inproc) Methods() []string {
+	return p.methods.names()
+}
+
+// Call invokes the RPC method.  As with prepRPC() in the Engine, the method is passed a
+// copy of the args, so the Adapter cannot change the caller's request.  The reply is only
+// set if the method succeeds.  Errors, including a panic in the method, are returned as an
+// rpc.ServerError, as they would be over net/rpc.
+func (p *Inproc) Call(serviceMethod string, args interface{}, reply interface{}) error {
+	m, ok := p.methods[serviceMethod]
+	if !ok {
+		return rpc.ServerError("rpc: can't find service " + serviceMethod)
+	}
+	av, rv := reflect.ValueOf(args), reflect.ValueOf(reply)
+	if av.Type() != m.argType || av.IsNil() {
+		return rpc.ServerError(fmt.Sprintf("rpc: %s args must be %v, not %T", serviceMethod, m.argType, args))
+	}
+	if rv.Type() != m.replyType || rv.IsNil() {
+		return rpc.ServerError(fmt.Sprintf("rpc: %s reply must be %v, not %T", serviceMethod, m.replyType, reply))
+	}
+
+	argCopy := reflect.New(m.argType.Elem())
+	argCopy.Elem().Set(av.Elem())
+	replyNew, err := m.call(serviceMethod, argCopy)
+	if err != nil {
+		return err
+	}
+	rv.Elem().Set(replyNew.Elem())
+	return nil
+}
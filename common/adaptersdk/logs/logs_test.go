@@ -1,7 +1,7 @@
 package logs_test
 
 import (
-	"ACS/iDirectStat/logs"
+	"github.com/codeforsanjose/open311-gateway/common/adaptersdk/logs"
 
 	"time"
 
@@ -15,7 +15,7 @@ import (
 func TestConsole(t *testing.T) {
 	l := new(logs.FmtBoxer)
 
-	logs.Init(true, true)
+	logs.Init(true)
 
 	s1 := "hi there"
 	s2 := "bye now"
@@ -33,7 +33,7 @@ func TestConsole(t *testing.T) {
 
 	n1 := "James"
 	n2 := "Romo"
-	logs.LogDebug("This is a test by %q and %q\n", n1, n2)
+	logs.Log.Debugf("This is a test by %q and %q\n", n1, n2)
 
 	time.Sleep(3 * time.Second)
 }
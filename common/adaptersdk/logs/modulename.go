@@ -1,5 +1,5 @@
 package logs
 
 func init() {
-	modulename = "Engine"
+	modulename = "Adapter"
 }
@@ -0,0 +1,122 @@
+package adaptersdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/rpc"
+	"reflect"
+	"sort"
+
+	"github.com/codeforsanjose/open311-gateway/common/jsonrpc"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+// methodSet is the RPC methods of an Adapter, for the transports other than net/rpc (the
+// in-process Adapter, and JSON-RPC).
+type methodSet map[string]rpcMethod // Index: "Service.Method"
+
+// rpcMethod is an RPC method: func (rcvr *T) Method(args *A, reply *R) error.
+type rpcMethod struct {
+	rcvr      reflect.Value
+	fn        reflect.Method
+	argType   reflect.Type
+	replyType reflect.Type
+}
+
+var typeOfError = reflect.TypeOf((*error)(nil)).Elem()
+
+// newMethodSet returns the Services and Adapter RPC methods, and those of the rcvrs, as
+// registered by Handler().
+func (a *Adapter) newMethodSet(rcvrs ...interface{}) (methodSet, error) {
+	ms := make(methodSet)
+	if err := ms.register("Services", a.Services()); err != nil {
+		return nil, err
+	}
+	if err := ms.register("Adapter", &adapterService{adp: a}); err != nil {
+		return nil, err
+	}
+	for _, r := range rcvrs {
+		if err := ms.register(reflect.Indirect(reflect.ValueOf(r)).Type().Name(), r); err != nil {
+			return nil, err
+		}
+	}
+	return ms, nil
+}
+
+// register adds the exported methods of rcvr suitable for net/rpc.
+func (ms methodSet) register(name string, rcvr interface{}) error {
+	v := reflect.ValueOf(rcvr)
+	t := v.Type()
+	var n int
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i)
+		mt := m.Type
+		if m.PkgPath != "" || mt.NumIn() != 3 || mt.NumOut() != 1 || mt.Out(0) != typeOfError {
+			continue
+		}
+		if mt.In(1).Kind() != reflect.Ptr || mt.In(2).Kind() != reflect.Ptr {
+			continue
+		}
+		ms[name+"."+m.Name] = rpcMethod{rcvr: v, fn: m, argType: mt.In(1), replyType: mt.In(2)}
+		n++
+	}
+	if n == 0 {
+		return fmt.Errorf("type %s has no suitable RPC methods", name)
+	}
+	return nil
+}
+
+// names returns the sorted list of RPC methods.
+func (ms methodSet) names() []string {
+	l := make([]string, 0, len(ms))
+	for k := range ms {
+		l = append(l, k)
+	}
+	sort.Strings(l)
+	return l
+}
+
+// call runs the method with args, and returns the new reply.  Errors, including a panic
+// in the method, are returned as an rpc.ServerError, as they would be over net/rpc.
+func (m rpcMethod) call(serviceMethod string, args reflect.Value) (reply reflect.Value, err error) {
+	reply = reflect.New(m.replyType.Elem())
+	defer func() {
+		if rcvr := recover(); rcvr != nil {
+			log.Errorf("Call %s panicked - %v", serviceMethod, rcvr)
+			err = rpc.ServerError(fmt.Sprintf("%s panicked - %v", serviceMethod, rcvr))
+		}
+	}()
+	out := m.fn.Func.Call([]reflect.Value{m.rcvr, args, reply})
+	if e, _ := out[0].Interface().(error); e != nil {
+		return reply, rpc.ServerError(e.Error())
+	}
+	return reply, nil
+}
+
+// dispatch is the jsonrpc.Dispatcher for the methods.  The params are either the request
+// object, or an array holding it.
+func (ms methodSet) dispatch(method string, params json.RawMessage) (interface{}, *jsonrpc.Error) {
+	m, ok := ms[method]
+	if !ok {
+		return nil, &jsonrpc.Error{Code: jsonrpc.CodeMethodNotFound, Message: "rpc: can't find service " + method}
+	}
+	args := reflect.New(m.argType.Elem())
+	if len(params) > 0 && params[0] == '[' {
+		var l []json.RawMessage
+		if err := json.Unmarshal(params, &l); err != nil || len(l) != 1 {
+			return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: method + " params must be an object, or an array of one object"}
+		}
+		params = l[0]
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, args.Interface()); err != nil {
+			return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: fmt.Sprintf("invalid %s params - %s", method, err)}
+		}
+	}
+	reply, err := m.call(method, args)
+	if err != nil {
+		return nil, &jsonrpc.Error{Code: jsonrpc.CodeServer, Message: err.Error()}
+	}
+	return reply.Interface(), nil
+}
@@ -0,0 +1,155 @@
+package adaptersdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+const (
+	regRetry   = time.Second * 5 // Wait after a failed registration.
+	regTimeout = time.Second * 10
+	regHeader  = "X-Registration-Token"
+)
+
+// registration renews the Engine registration until it is stopped.
+type registration struct {
+	addr string
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// RegisterRequest returns the registration sent to the Engine, advertising addr as the
+// RPC address (unless "engine.advertise" is set).
+func (a *Adapter) RegisterRequest(addr string) *structs.NRegisterRequest {
+	if a.Engine.Advertise != "" {
+		addr = a.Engine.Advertise
+	}
+	rqst := &structs.NRegisterRequest{
+		AdpID:        a.Adapter.Name,
+		Type:         a.Adapter.Type,
+		Address:      addr,
+		Capabilities: a.Capabilities,
+		Lease:        a.Engine.Lease,
+	}
+	a.RLock()
+	for _, area := range a.Areas {
+		rqst.Areas = append(rqst.Areas, structs.NRegisterArea{ID: area.ID, Name: area.Name})
+	}
+	a.RUnlock()
+	sort.Slice(rqst.Areas, func(i, j int) bool { return rqst.Areas[i].ID < rqst.Areas[j].ID })
+	return rqst
+}
+
+// Register registers the Adapter with the Engine, or renews the registration, and
+// returns the lease granted by the Engine.
+func (a *Adapter) Register(addr string) (time.Duration, error) {
+	body, err := json.Marshal(a.RegisterRequest(addr))
+	if err != nil {
+		return 0, err
+	}
+	var resp structs.NRegisterResponse
+	if err := a.engineCall("POST", "/v1/adapters.json", body, &resp); err != nil {
+		return 0, err
+	}
+	return time.Duration(resp.Lease) * time.Second, nil
+}
+
+// Deregister removes the Adapter instance at addr from the Engine.  The Engine removes
+// the Adapter with its last instance, or if addr is empty.
+func (a *Adapter) Deregister(addr string) error {
+	path := "/v1/adapters/" + url.PathEscape(a.Adapter.Name) + ".json"
+	if addr != "" {
+		path += "?address=" + url.QueryEscape(a.RegisterRequest(addr).Address)
+	}
+	return a.engineCall("DELETE", path, nil, nil)
+}
+
+// engineCall sends a registration request to the Engine, and decodes the response into
+// resp (if not nil).
+func (a *Adapter) engineCall(method, path string, body []byte, resp interface{}) error {
+	rqst, err := http.NewRequest(method, strings.TrimSuffix(a.Engine.URL, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	rqst.Header.Set("Content-Type", "application/json")
+	if a.Engine.Token != "" {
+		rqst.Header.Set(regHeader, a.Engine.Token)
+	}
+	client := http.Client{Timeout: regTimeout}
+	r, err := client.Do(rqst)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if r.StatusCode != http.StatusOK {
+		return fmt.Errorf("engine returned %s - %s", r.Status, strings.TrimSpace(string(data)))
+	}
+	if resp == nil {
+		return nil
+	}
+	return json.Unmarshal(data, resp)
+}
+
+// startRegistration registers the Adapter with the Engine, if "engine.url" is set, and
+// renews the registration at a third of the lease.  A failed registration is retried.
+func (a *Adapter) startRegistration(addr string) {
+	if a.Engine.URL == "" || a.reg != nil {
+		return
+	}
+	r := &registration{addr: addr, stop: make(chan struct{}), done: make(chan struct{})}
+	a.reg = r
+	go func() {
+		defer close(r.done)
+		var wait time.Duration
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-time.After(wait):
+			}
+			lease, err := a.Register(addr)
+			if err != nil {
+				log.Warningf("Unable to register with the Engine at %s - %s", a.Engine.URL, err)
+				wait = regRetry
+				continue
+			}
+			log.Debugf("Registered with the Engine at %s - lease: %v", a.Engine.URL, lease)
+			wait = lease / 3
+			if wait <= 0 {
+				wait = regRetry
+			}
+		}
+	}()
+}
+
+// stopRegistration stops renewing the registration, and removes it from the Engine.
+func (a *Adapter) stopRegistration() {
+	r := a.reg
+	if r == nil {
+		return
+	}
+	r.once.Do(func() {
+		close(r.stop)
+		<-r.done
+		if err := a.Deregister(r.addr); err != nil {
+			log.Warningf("Unable to deregister from the Engine at %s - %s", a.Engine.URL, err)
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package adaptersdk
+
+import (
+	"sync/atomic"
+	"time"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+// Processer is the interface used to run all the common request processing steps (see
+// Run()).  It is implemented by each request manager of an Adapter, which holds the
+// Normal request and response, and their Native conversions.
+type Processer interface {
+	ConvertRequest() error
+	Process() error
+	ConvertResponse() (int, error)
+	Fail(err error) error
+	GetIDS() string
+	GetRoute() string
+	String() string
+}
+
+// Run runs all of the common request processing operations for the RPC method
+// (e.g. "Report.Create"), sending the telemetry for each step.
+func (a *Adapter) Run(method string, r Processer) error {
+	atomic.AddInt64(&a.inflight, 1)
+	defer atomic.AddInt64(&a.inflight, -1)
+	id := r.GetIDS()
+	a.SendRPC(id, "open", r.GetRoute(), "", 0, time.Now())
+
+	fail := func(err error) error {
+		a.SendRPC(id, "error", r.GetRoute(), "", 0, time.Now())
+		return r.Fail(err)
+	}
+	if a.Before != nil {
+		if err := a.Before(method); err != nil {
+			return fail(err)
+		}
+	}
+	if err := r.ConvertRequest(); err != nil {
+		return fail(err)
+	}
+	if err := r.Process(); err != nil {
+		return fail(err)
+	}
+	resultCount, err := r.ConvertResponse()
+	if err != nil {
+		return fail(err)
+	}
+	a.SendRPC(id, "done", r.GetRoute(), "", resultCount, time.Now())
+	log.Debugf("Request COMPLETED:%s\n", r.String())
+	return nil
+}
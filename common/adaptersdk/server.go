@@ -0,0 +1,159 @@
+package adaptersdk
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/rpc"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common/jsonrpc"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+// ==============================================================================================================================
+//                                      SERVER
+// ==============================================================================================================================
+
+// Handler creates an RPC server with the Services and Adapter RPCs and the rcvrs (e.g.
+// &request.Report{}) registered, and returns its HTTP handler.  The same methods are
+// served over JSON-RPC 2.0 at jsonrpc.Path, for the Engine "jsonrpc" transport.
+func (a *Adapter) Handler(rcvrs ...interface{}) (http.Handler, error) {
+	srv := rpc.NewServer()
+	if err := srv.Register(a.Services()); err != nil {
+		return nil, err
+	}
+	if err := srv.RegisterName("Adapter", &adapterService{adp: a}); err != nil {
+		return nil, err
+	}
+	for _, r := range rcvrs {
+		if err := srv.Register(r); err != nil {
+			return nil, err
+		}
+	}
+	ms, err := a.newMethodSet(rcvrs...)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle(rpc.DefaultRPCPath, srv)
+	mux.Handle(jsonrpc.Path, jsonrpc.Handler(ms.dispatch))
+	return mux, nil
+}
+
+// Serve starts the telemetry and the Engine registration, and serves the RPC requests on
+// l.  See Handler().  If "rpcAuth" is configured, callers must present a client
+// certificate signed by its CA, or sign their requests with its secret.
+func (a *Adapter) Serve(l net.Listener, rcvrs ...interface{}) error {
+	h, err := a.Handler(rcvrs...)
+	if err != nil {
+		return err
+	}
+	a.startTelemetry()
+	a.startRegistration(l.Addr().String())
+	if a.Listener != nil {
+		l = a.Listener(l)
+	}
+	log.Infof("RPC security: %s", &a.RPCAuth)
+	srv := &http.Server{Handler: a.RPCAuth.Handler(h)}
+	a.Lock()
+	a.server = srv
+	a.Unlock()
+	return srv.Serve(a.RPCAuth.Listener(l))
+}
+
+// Shutdown removes the Engine registration, stops accepting RPC requests, and waits up
+// to "adapter.shutdownGrace" for the requests in progress.  It then flushes and stops the
+// telemetry.
+func (a *Adapter) Shutdown() {
+	grace := time.Duration(a.Adapter.ShutdownGrace) * time.Second
+	if grace <= 0 {
+		grace = dfltShutdownGrace * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+
+	a.stopRegistration()
+	a.RLock()
+	srv := a.server
+	a.RUnlock()
+	if srv != nil {
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Warningf("RPC server shutdown - %s", err)
+		}
+	}
+	a.drain(ctx)
+	if t := a.tlmtry; t != nil {
+		t.stop(ctx)
+	}
+}
+
+// drain waits for the requests in progress to finish, or the context to be done.  The
+// RPC connections are hijacked from the HTTP server, so its Shutdown() does not wait
+// for them.
+func (a *Adapter) drain(ctx context.Context) {
+	t := time.NewTicker(time.Millisecond * 50)
+	defer t.Stop()
+	for n := atomic.LoadInt64(&a.inflight); n > 0; n = atomic.LoadInt64(&a.inflight) {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			log.Warningf("%d requests in progress were abandoned", n)
+			return
+		}
+	}
+}
+
+// ListenAndServe serves the RPC requests on the Adapter address.  See Serve().
+func (a *Adapter) ListenAndServe(rcvrs ...interface{}) error {
+	log.Infof("Listening at: %s\n", a.Adapter.Address)
+	l, err := net.Listen("tcp", a.Adapter.Address)
+	if err != nil {
+		return err
+	}
+	return a.Serve(l, rcvrs...)
+}
+
+// Main runs an Adapter.  It parses the command line, calls load with the config file
+// name, and serves the RPC requests until Ctrl-C is pressed (or SIGTERM is received).  load must call Init() (or
+// Load()), and do any other Adapter setup.
+func Main(a *Adapter, load func(configFile string) error, rcvrs ...interface{}) {
+	var (
+		debug      bool
+		configFile string
+	)
+	flag.BoolVar(&debug, "debug", false, "Activates debug logging. It is active if either this or the value in 'config.json' are set.")
+	flag.StringVar(&configFile, "config", "config.json", "Config file. This is a full or relative path.")
+	flag.Parse()
+
+	log.Setup(false, log.DebugLevel)
+	log.Debugf("Command line settings - debug: %t\nConfig file: %q", debug, configFile)
+
+	if err := load(configFile); err != nil {
+		log.Fatal("Unable to start - data initilization failed.\n")
+	}
+
+	stopped := make(chan struct{})
+	go signalHandler(a, make(chan os.Signal, 1), stopped)
+	fmt.Println("Press Ctrl-C to shutdown...")
+
+	if err := a.ListenAndServe(rcvrs...); err != http.ErrServerClosed {
+		log.Fatal("listen error:", err)
+	}
+	<-stopped
+}
+
+func signalHandler(a *Adapter, c chan os.Signal, stopped chan struct{}) {
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	s := <-c
+	fmt.Printf("%v Received - shutting down...\n", s)
+	a.Shutdown()
+	close(stopped)
+}
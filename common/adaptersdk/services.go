@@ -0,0 +1,73 @@
+package adaptersdk
+
+import (
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+// ================================================================================================
+//                                      SERVICES
+// ================================================================================================
+
+// Services is the RPC container struct for the Services service.  This service
+// provides a directory of services (i.e. report categories) available for each
+// Area.  It is registered by Serve().
+type Services struct {
+	adp *Adapter
+}
+
+// Services returns the Services RPC of the Adapter.
+func (a *Adapter) Services() *Services {
+	return &Services{adp: a}
+}
+
+// Area returns a list of services for the specifed city.
+func (c *Services) Area(rqst *structs.NServiceRequest, resp *structs.NServicesResponse) error {
+	log.Debug(rqst.String())
+	return c.respond("Services.Area", rqst, resp, func() (*structs.NServices, error) {
+		return c.adp.ServicesArea(rqst.Area)
+	})
+}
+
+// All fills resp with a list of all services.
+func (c *Services) All(rqst *structs.NServiceRequest, resp *structs.NServicesResponse) error {
+	log.Debug(rqst.String())
+	return c.respond("Services.All", rqst, resp, c.adp.ServicesAll)
+}
+
+func (c *Services) respond(method string, rqst *structs.NServiceRequest, resp *structs.NServicesResponse, list func() (*structs.NServices, error)) error {
+	if c.adp.Before != nil {
+		if err := c.adp.Before(method); err != nil {
+			return err
+		}
+	}
+	x, err := list()
+	if err != nil {
+		log.Warningf("[%s]: error: %s", method, err)
+		return err
+	}
+	resp.SetIDF(rqst.GetID)
+	resp.AdpID = c.adp.Name()
+	resp.Message = "OK"
+	resp.Services = *x
+	return nil
+}
+
+// ================================================================================================
+//                                      ADAPTER
+// ================================================================================================
+
+// adapterService is the "Adapter" RPC service, describing the Adapter.  It is registered
+// by Serve().
+type adapterService struct {
+	adp *Adapter
+}
+
+// Capabilities returns the Adapter Capabilities.
+func (c *adapterService) Capabilities(rqst *structs.NCapabilitiesRequest, resp *structs.NCapabilitiesResponse) error {
+	resp.SetIDF(rqst.GetID)
+	resp.AdpID = c.adp.Name()
+	resp.Capabilities = c.adp.Capabilities
+	return nil
+}
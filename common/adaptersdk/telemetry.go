@@ -0,0 +1,100 @@
+package adaptersdk
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	tm "github.com/codeforsanjose/open311-gateway/common/telemetry"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+// telemetry sends the Adapter status messages to the System Monitor.
+type telemetry struct {
+	chTQue chan tm.AdpRPCMsgType
+	done   chan struct{} // Closed when the queued messages are sent, after stop().
+	closed bool
+	sync.RWMutex
+}
+
+// SendRPC queues an RPC status message onto the send channel.  Messages are discarded
+// until the telemetry is started by Serve(), and after Shutdown().
+func (a *Adapter) SendRPC(id, status, route, url string, results int, at time.Time) {
+	t := a.tlmtry
+	if t == nil {
+		return
+	}
+	t.RLock()
+	defer t.RUnlock()
+	if t.closed {
+		return
+	}
+	t.chTQue <- tm.AdpRPCMsgType{
+		AdpID:   a.Name(),
+		ID:      id,
+		Status:  status,
+		Route:   route,
+		URL:     url,
+		Results: results,
+		At:      at,
+	}
+}
+
+// startTelemetry starts the telemetry sender on the Monitor address.
+func (a *Adapter) startTelemetry() {
+	if a.tlmtry != nil {
+		return
+	}
+	addr := a.MonitorAddress()
+	tlmtryServer, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		log.Errorf("Cannot start telemetry - %s", err)
+		return
+	}
+	conn, err := net.DialUDP("udp", nil, tlmtryServer)
+	if err != nil {
+		log.Errorf("Cannot start telemetry - %s", err)
+		return
+	}
+
+	t := &telemetry{chTQue: make(chan tm.AdpRPCMsgType, 100), done: make(chan struct{})}
+	go func() {
+		log.Debugf("Telemetry sender starting on: %v", addr)
+		defer func() {
+			log.Debug("Closing telemetry connection...")
+			_ = conn.Close()
+			close(t.done)
+		}()
+		for m := range t.chTQue {
+			msg, err := m.Marshal()
+			if err != nil {
+				log.Warningf("unable to send message - %s", err)
+				continue
+			}
+			log.Debug(string(msg))
+			if _, err := conn.Write(msg); err != nil {
+				log.Warning(err.Error())
+			}
+		}
+	}()
+	a.tlmtry = t
+}
+
+// stop stops the telemetry, after the queued messages are sent or the context is done.
+func (t *telemetry) stop(ctx context.Context) {
+	t.Lock()
+	if t.closed {
+		t.Unlock()
+		return
+	}
+	t.closed = true
+	close(t.chTQue)
+	t.Unlock()
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+		log.Warning("Telemetry queue not flushed - " + ctx.Err().Error())
+	}
+}
@@ -2,6 +2,7 @@ package cv
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/codeforsanjose/open311-gateway/_background/go/common"
 )
@@ -12,7 +13,8 @@ import (
 
 // ------------------ Validation System
 
-// ValidationDetail is a simple method for compiling validation results.
+// ValidationDetail is a simple method for compiling validation results.  The result is
+// the reason the validation failed.
 type ValidationDetail struct {
 	ok     bool
 	result string
@@ -26,7 +28,9 @@ func NewValidation() Validation {
 	return make(map[string]*ValidationDetail)
 }
 
-// Set creates a validation as ok (true) or not (false).
+// Set creates a validation as ok (true) or not (false).  If the validation failed, the
+// result is the reason.  Set an item's initial (unchecked) state with an empty result,
+// so that only a reason set when it fails is reported by Error().
 func (r Validation) Set(item, result string, isOK bool) {
 	v, ok := r[item]
 	if ok {
@@ -75,15 +79,26 @@ func (r Validation) String() string {
 }
 
 // Error is a standard error interface, returning a string listing any failed
-// validations.
+// validations, along with the reason for each.
 func (r Validation) Error() string {
+	keys := make([]string, 0, len(r))
+	for k := range r {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
 	validMsg := ""
-	for k, v := range r {
+	for _, k := range keys {
+		v := r[k]
 		if !v.ok {
+			msg := k
+			if v.result > "" {
+				msg = fmt.Sprintf("%s (%s)", k, v.result)
+			}
 			if validMsg == "" {
-				validMsg = k
+				validMsg = msg
 			} else {
-				validMsg = validMsg + ", " + k
+				validMsg = validMsg + ", " + msg
 			}
 		}
 	}
@@ -39,6 +39,15 @@ var _ = Describe("Common Suite", func() {
 				// fmt.Printf(v.String())
 				Ω(v.Ok()).Should(BeFalse())
 			})
+
+			It("reports the reason for each failed validation", func() {
+				v := NewValidation()
+				v.Set("item1", "item 1 is OK.", true)
+				v.Set("geo", "within a valid region", false)
+				v.Set("city", "", false)
+				v.Set("geo", "location 61.2,-149.9 is outside the valid regions: SJ", false)
+				Ω(v.Error()).Should(Equal("errors: city, geo (location 61.2,-149.9 is outside the valid regions: SJ)"))
+			})
 		})
 	})
 })
@@ -2,17 +2,18 @@ package geo
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/codeforsanjose/open311-gateway/_background/go/common"
 	"github.com/codeforsanjose/open311-gateway/_background/go/common/mystr"
 )
 
 var (
-	rxCityStateZip, rxCityStateLooseZip, rxGoogleFound, rxZip *mystr.MyRegexp
-	validZipLen                                               map[int]bool
+	rxGoogleFound *mystr.MyRegexp
+	validZipLen   map[int]bool
 )
 
-// Address represents a Google Address search.  RawAddr contains the requested
+// Address represents a Geocoder Address search.  RawAddr contains the requested
 // address.  Ok will be set to true if the address is found.  Found is the
 // found full address.
 type Address struct {
@@ -63,64 +64,72 @@ func (r *Address) Validate(addr, zip bool) bool {
 	return true
 }
 
+// setAddr builds Addr from the street number, route and subpremise.
+func (a *Address) setAddr() {
+	a.Addr = strings.TrimSpace(a.streetNumber + " " + a.route)
+	if a.subpremise > "" {
+		a.Addr += " #" + a.subpremise
+	}
+	a.Ok = a.Addr != "" && a.City != ""
+}
+
+// setZip splits a ZIP+4 code into Zip and zipSuffix.
+func (a *Address) setZip(zip string) {
+	zip = strings.TrimSpace(zip)
+	if i := strings.IndexAny(zip, "- "); i > 0 {
+		a.Zip, a.zipSuffix = zip[:i], strings.TrimSpace(zip[i+1:])
+		return
+	}
+	a.Zip = zip
+}
+
 // NewAddr returns a new address struct from a full, comma delimited street address.
 func NewAddr(fullAddr string) (addr *Address, err error) {
-	return GooAddr(fullAddr)
+	return GetDefault().Forward(fullAddr)
 }
 
 // NewAddrP returns a new address struct from the address, city, state, and option zipcode.
 func NewAddrP(streetAddr, city, state, zip string) (addr *Address, err error) {
-	return GooAddr(fmt.Sprintf("%s, %s, %s %s", streetAddr, city, state, zip))
+	return GetDefault().Forward(fmt.Sprintf("%s, %s, %s %s", streetAddr, city, state, zip))
 }
 
 // NewAddrLL returns a new address struct from a latitude and longitude.
 func NewAddrLL(lat, lng float64) (addr *Address, err error) {
-	return GooAddr(lat, lng)
+	return GetDefault().Reverse(lat, lng)
 }
 
 // AddrForLatLng finds the nearest address to the coordinates.
 func AddrForLatLng(lat, lng float64) (addr *Address, err error) {
-	if !ValidateLatLng(lat, lng) {
-		return nil, fmt.Errorf("coordinates must be in the continental US")
+	if err := CheckLatLng(lat, lng); err != nil {
+		return nil, err
 	}
-	addr, err = GooAddr(lat, lng)
+	addr, err = GetDefault().Reverse(lat, lng)
 	return
 }
 
 // ParseAddress accepts a comma delimited address string, and returns the
-// address, city, state and zip.  NOTE: this function does very little error checking.
+// address, city, state and zip, normalized by Normalize().  The city and state are
+// required.  If looseZip is false, the zip is also required.
 func ParseAddress(fullAddr string, looseZip bool) (a Address, err error) {
 	fail := func(status string) (Address, error) {
 		return a, fmt.Errorf("Failed to parse address %q - %s", fullAddr, status)
 	}
 
-	var (
-		e error
-		d map[string]string
-	)
-	if looseZip {
-		e = rxCityStateLooseZip.Match(fullAddr)
-		d = rxCityStateLooseZip.Named
-	} else {
-		e = rxCityStateZip.Match(fullAddr)
-		d = rxCityStateZip.Named
-	}
+	n, e := Normalize(fullAddr)
 	if e != nil {
 		return fail(e.Error())
 	}
-
-	a.Addr = d["addr"]
-	a.City = d["city"]
-	a.State = d["state"]
-	if z, ok := d["zip"]; ok {
-		if len(z) >= 5 {
-			e := rxZip.Match(z)
-			if e == nil && rxZip.Ok {
-				a.Zip = rxZip.Named["zip"]
-			}
-		}
+	if !n.Has(HasCity | HasState) {
+		return fail("missing city or state")
 	}
 
+	a.RawAddr = fullAddr
+	a.Addr = n.StreetLine()
+	a.City = n.City
+	a.State = n.State
+	a.Zip = n.Zip
+	a.zipSuffix = n.Zip4
+
 	if a.Zip == "" && !looseZip {
 		return fail("invalid zip code")
 	}
@@ -128,10 +137,7 @@ func ParseAddress(fullAddr string, looseZip bool) (a Address, err error) {
 }
 
 func init() {
-	rxCityStateZip = mystr.NewRegex(`(?i), *(?P<city>[A-Za-z .]{2,}), *(?P<state>[A-Z][A-Z])[, ]*(?P<zip>\d{5}(?:[-\s]\d{4})?)$`, "", "")
-	rxCityStateLooseZip = mystr.NewRegex(`(?i), *(?P<city>[A-Za-z .]{2,}), *(?P<state>[A-Z][A-Z])[, ]*(?P<zip>\d{5}(?:[-\s]\d{4})?)?$`, "", "")
 	rxGoogleFound = mystr.NewRegex(`(?i)(?P<addr>.*), (?P<city>[A-Za-z .]{2,}), (?P<state>[A-Z][A-Z]) (?P<zip>\d{5}), USA$`, "", "")
-	rxZip = mystr.NewRegex(`^(?P<zip>\d{5}(?:[-\s]*\d{4})?)$`, "", "- ")
 
 	validZipLen = map[int]bool{
 		0:  true,
@@ -0,0 +1,240 @@
+package geo
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+// minCompact is the number of lines in the cache file before it is compacted.  The file
+// is compacted when it has twice as many lines as there are cache entries.
+const minCompact = 1000
+
+// Cache wraps a Geocoder, caching successful lookups for the TTL.  If a file is
+// specified, the cache is persisted to disk, and reloaded when the Cache is created,
+// so lookups survive a restart.  Failed lookups are not cached.  Each lookup is
+// appended to the file, and the file is rewritten without the expired and replaced
+// entries as it grows, and when the Cache is closed.
+type Cache struct {
+	g       Geocoder
+	ttl     time.Duration
+	path    string
+	file    *os.File
+	lines   int // The number of entries in the file.
+	entries map[string]cacheEntry
+	hits    int64
+	misses  int64
+	sync.Mutex
+}
+
+type cacheEntry struct {
+	Key     string    `json:"key"`
+	Expires time.Time `json:"expires"`
+	Addr    Address   `json:"addr"`
+}
+
+// NewCache returns a Cache wrapping the Geocoder.  If file is blank, the cache is
+// kept in memory only.
+func NewCache(g Geocoder, ttl time.Duration, file string) (*Cache, error) {
+	c := &Cache{
+		g:       g,
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+	}
+	if file != "" {
+		if err := c.load(file); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Forward returns the cached Address for addr, or calls the wrapped Geocoder.  The
+// address is normalized, so equivalent addresses share a cache entry.
+func (c *Cache) Forward(addr string) (*Address, error) {
+	key := "f:" + streetKey(addr)
+	if n, err := Normalize(addr); err == nil {
+		key = "f:" + n.Key()
+	}
+	if a, ok := c.get(key); ok {
+		return a, nil
+	}
+	a, err := c.g.Forward(addr)
+	if err != nil {
+		return nil, err
+	}
+	c.put(key, a)
+	return a, nil
+}
+
+// Reverse returns the cached Address for the location, or calls the wrapped Geocoder.
+// Locations are rounded to 6 decimal places (about 10cm).
+func (c *Cache) Reverse(lat, lng float64) (*Address, error) {
+	key := fmt.Sprintf("r:%.6f,%.6f", lat, lng)
+	if a, ok := c.get(key); ok {
+		return a, nil
+	}
+	a, err := c.g.Reverse(lat, lng)
+	if err != nil {
+		return nil, err
+	}
+	c.put(key, a)
+	return a, nil
+}
+
+// Stats returns the number of cache hits and misses.
+func (c *Cache) Stats() (hits, misses int64) {
+	c.Lock()
+	defer c.Unlock()
+	return c.hits, c.misses
+}
+
+// Close compacts and closes the cache file.
+func (c *Cache) Close() error {
+	c.Lock()
+	defer c.Unlock()
+	if c.file == nil {
+		return nil
+	}
+	err := c.compact()
+	if cerr := c.file.Close(); err == nil {
+		err = cerr
+	}
+	c.file = nil
+	return err
+}
+
+func (c *Cache) get(key string) (*Address, bool) {
+	c.Lock()
+	defer c.Unlock()
+	e, ok := c.entries[key]
+	if !ok || time.Now().After(e.Expires) {
+		delete(c.entries, key)
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	a := e.Addr
+	return &a, true
+}
+
+func (c *Cache) put(key string, a *Address) {
+	c.Lock()
+	defer c.Unlock()
+	e := cacheEntry{
+		Key:     key,
+		Expires: time.Now().Add(c.ttl),
+		Addr:    *a,
+	}
+	c.entries[key] = e
+	if c.file == nil {
+		return
+	}
+	if err := c.write(e); err != nil {
+		log.Errorf("Unable to write the geocoder cache file %q, it will no longer be updated - %s", c.path, err)
+		c.file.Close()
+		c.file = nil
+		return
+	}
+	if c.lines >= minCompact && c.lines > 2*len(c.entries) {
+		if err := c.compact(); err != nil {
+			log.Errorf("Unable to compact the geocoder cache file %q - %s", c.path, err)
+		}
+	}
+}
+
+// write appends the entry to the cache file.  The cache must be locked.
+func (c *Cache) write(e cacheEntry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := c.file.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	c.lines++
+	return nil
+}
+
+// load reads the cache file, and compacts it.  Entries that cannot be decoded, or have
+// no location, are dropped, so they are looked up again.
+func (c *Cache) load(file string) error {
+	c.path = file
+	if f, err := os.Open(file); err == nil {
+		s := bufio.NewScanner(f)
+		s.Buffer(make([]byte, 64*1024), 1024*1024)
+		now := time.Now()
+		dropped := 0
+		for s.Scan() {
+			if strings.TrimSpace(s.Text()) == "" {
+				continue
+			}
+			var e cacheEntry
+			if err := json.Unmarshal(s.Bytes(), &e); err != nil || e.Key == "" || (e.Addr.Lat == 0 && e.Addr.Lng == 0) {
+				dropped++
+				continue
+			}
+			if now.Before(e.Expires) {
+				c.entries[e.Key] = e
+			}
+		}
+		f.Close()
+		if dropped > 0 {
+			log.Warnf("Dropped %d invalid entries from the geocoder cache file %q", dropped, file)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("unable to read geocoder cache file - %s", err)
+	}
+	return c.compact()
+}
+
+// compact drops the expired entries, rewrites the cache file with the remaining entries,
+// and reopens it for appending.  The cache must be locked.
+func (c *Cache) compact() error {
+	now := time.Now()
+	for k, e := range c.entries {
+		if now.After(e.Expires) {
+			delete(c.entries, k)
+		}
+	}
+
+	tmp := c.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("unable to write geocoder cache file - %s", err)
+	}
+	w := bufio.NewWriter(f)
+	for _, e := range c.entries {
+		if b, err := json.Marshal(e); err == nil {
+			w.Write(append(b, '\n'))
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("unable to write geocoder cache file - %s", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("unable to write geocoder cache file - %s", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("unable to write geocoder cache file - %s", err)
+	}
+
+	if c.file != nil {
+		c.file.Close()
+	}
+	c.lines = len(c.entries)
+	c.file, err = os.OpenFile(c.path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("unable to open geocoder cache file - %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Geocoder providers.
+const (
+	ProviderGoogle    = "google"
+	ProviderNominatim = "nominatim"
+	ProviderPelias    = "pelias"
+	ProviderOffline   = "offline"
+)
+
+const earthRadius = 6371000.0 // meters
+
+// Geocoder is implemented by every geocoding provider.  Forward finds the location
+// of a street address, and Reverse finds the nearest address to a location.  Both
+// return an Address with the Lat/Lng and the address parts populated.
+type Geocoder interface {
+	Forward(addr string) (*Address, error)
+	Reverse(lat, lng float64) (*Address, error)
+}
+
+// Config is the geocoder configuration.  It is typically loaded from the "geocoder"
+// section of a config file, and used by NewGeocoder() to build the Geocoder.
+type Config struct {
+	Provider string `json:"provider"`
+	URL      string `json:"url"`
+	Key      string `json:"key"`
+	Email    string `json:"email"`
+	File     string `json:"file"`
+	Cache    struct {
+		TTL  int    `json:"ttl"`
+		File string `json:"file"`
+	} `json:"cache"`
+}
+
+// NewGeocoder builds the Geocoder specified in the Config.  If Cache.TTL is greater
+// than zero, the Geocoder is wrapped in a Cache.  A blank Provider defaults to Google.
+func NewGeocoder(cfg Config) (Geocoder, error) {
+	var (
+		g   Geocoder
+		err error
+	)
+	switch strings.ToLower(cfg.Provider) {
+	case ProviderGoogle, "":
+		goo := NewGoogle(cfg.Key)
+		if cfg.URL != "" {
+			goo.URL = cfg.URL
+		}
+		g = goo
+	case ProviderNominatim:
+		g = NewNominatim(cfg.URL, cfg.Email)
+	case ProviderPelias:
+		g = NewPelias(cfg.URL, cfg.Key)
+	case ProviderOffline:
+		g, err = NewOffline(cfg.File)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid geocoder provider: %q", cfg.Provider)
+	}
+
+	if cfg.Cache.TTL > 0 {
+		return NewCache(g, time.Duration(cfg.Cache.TTL)*time.Second, cfg.Cache.File)
+	}
+	return g, nil
+}
+
+var (
+	defaultGeocoder Geocoder = NewGoogle("")
+	defaultMutex    sync.RWMutex
+)
+
+// SetDefault sets the Geocoder used by NewAddr(), NewAddrP(), NewAddrLL() and AddrForLatLng().
+func SetDefault(g Geocoder) {
+	defaultMutex.Lock()
+	defer defaultMutex.Unlock()
+	defaultGeocoder = g
+}
+
+// GetDefault returns the Geocoder used by the package level functions.
+func GetDefault() Geocoder {
+	defaultMutex.RLock()
+	defer defaultMutex.RUnlock()
+	return defaultGeocoder
+}
+
+// Distance returns the great circle distance in meters between two points.
+func Distance(lat1, lng1, lat2, lng2 float64) float64 {
+	rad := func(d float64) float64 { return d * math.Pi / 180.0 }
+	dLat := rad(lat2 - lat1)
+	dLng := rad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(rad(lat1))*math.Cos(rad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return earthRadius * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
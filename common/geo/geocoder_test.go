@@ -0,0 +1,344 @@
+package geo_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/codeforsanjose/open311-gateway/_background/go/common/geo"
+)
+
+const testAddrPoints = `LON,LAT,NUMBER,STREET,UNIT,CITY,DISTRICT,REGION,POSTCODE,ID,HASH
+-121.885961,37.3377501,200,E Santa Clara St,,San Jose,Santa Clara,CA,95113,,
+-121.8907,37.3352,1,Market St,,San Jose,Santa Clara,CA,95113,,
+-121.9552,37.3541,1500,Warburton Ave,,Santa Clara,Santa Clara,CA,95050,,
+-121.625339,37.1233549,16200,Condit Rd,,Morgan Hill,Santa Clara,CA,95037,,
+`
+
+const testAddrPointsGeoJSON = `{"type":"Feature","properties":{"number":"200","street":"E Santa Clara St","unit":"","city":"San Jose","district":"Santa Clara","region":"California","postcode":"95113-1905"},"geometry":{"type":"Point","coordinates":[-121.885961,37.3377501]}}
+{"type":"Feature","properties":{"number":"1500","street":"Warburton Ave","unit":"","city":"Santa Clara","district":"Santa Clara","region":"CA","postcode":"95050"},"geometry":{"type":"Point","coordinates":[-121.9552,37.3541]}}
+`
+
+// countGeocoder counts calls to the wrapped Geocoder.
+type countGeocoder struct {
+	Geocoder
+	calls int
+}
+
+func (r *countGeocoder) Forward(addr string) (*Address, error) {
+	r.calls++
+	return r.Geocoder.Forward(addr)
+}
+
+func (r *countGeocoder) Reverse(lat, lng float64) (*Address, error) {
+	r.calls++
+	return r.Geocoder.Reverse(lat, lng)
+}
+
+var _ = Describe("Geocoder", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "geocoder")
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	writeFile := func(name, contents string) string {
+		fn := filepath.Join(dir, name)
+		Ω(ioutil.WriteFile(fn, []byte(contents), 0644)).Should(Succeed())
+		return fn
+	}
+
+	Describe("Offline", func() {
+		var g *Offline
+
+		BeforeEach(func() {
+			var err error
+			g, err = NewOffline(writeFile("points.csv", testAddrPoints))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(g.Len()).Should(Equal(4))
+		})
+
+		It("finds an address", func() {
+			a, err := g.Forward("200 E. Santa Clara St, San Jose, CA 95113")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(a.FullAddr()).Should(Equal("200 E Santa Clara St, San Jose, CA 95113"))
+			Ω(a.Lat).Should(Equal(37.3377501))
+			Ω(a.Lng).Should(Equal(-121.885961))
+			Ω(a.Ok).Should(BeTrue())
+		})
+
+		It("finds an address without a state or zip", func() {
+			a, err := g.Forward("1500 warburton ave, santa clara")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(a.City).Should(Equal("Santa Clara"))
+		})
+
+		It("fails for an unknown address", func() {
+			_, err := g.Forward("1 Nowhere Ln, San Jose, CA 95113")
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("finds an address point that cannot be normalized", func() {
+			g, err := NewOfflineCSV(strings.NewReader(testAddrPoints + "-121.8863,37.3382,12,,,San Jose,Santa Clara,CA,95113,,\n"))
+			Ω(err).ShouldNot(HaveOccurred())
+			a, err := g.Forward("12")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(a.Lat).Should(Equal(37.3382))
+		})
+
+		It("finds the nearest address", func() {
+			a, err := g.Reverse(37.3378, -121.8861)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(a.Addr).Should(Equal("200 E Santa Clara St"))
+		})
+
+		It("fails when nothing is nearby", func() {
+			_, err := g.Reverse(40.0, -100.0)
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("loads an OpenAddresses GeoJSON file", func() {
+			g, err := NewOffline(writeFile("points.geojson", testAddrPointsGeoJSON))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(g.Len()).Should(Equal(2))
+			a, err := g.Forward("200 E Santa Clara St, San Jose, CA")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(a.State).Should(Equal("CA"))
+			Ω(a.Zip).Should(Equal("95113"))
+		})
+
+		It("rejects a file without coordinates", func() {
+			_, err := NewOffline(writeFile("bad.csv", "NUMBER,STREET\n1,Main St\n"))
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("HTTP providers", func() {
+		var (
+			srv   *httptest.Server
+			query string
+		)
+
+		AfterEach(func() {
+			srv.Close()
+		})
+
+		serve := func(body string) {
+			srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				query = r.URL.Path + "?" + r.URL.RawQuery
+				fmt.Fprint(w, body)
+			}))
+		}
+
+		It("Google", func() {
+			serve(`{"status": "OK", "results": [{"formatted_address": "200 E Santa Clara St, San Jose, CA 95113, USA",
+				"address_components": [
+					{"long_name": "200", "short_name": "200", "types": ["street_number"]},
+					{"long_name": "East Santa Clara Street", "short_name": "E Santa Clara St", "types": ["route"]},
+					{"long_name": "San Jose", "short_name": "San Jose", "types": ["locality"]},
+					{"long_name": "California", "short_name": "CA", "types": ["administrative_area_level_1"]},
+					{"long_name": "95113", "short_name": "95113", "types": ["postal_code"]}],
+				"geometry": {"location": {"lat": 37.3377501, "lng": -121.885961}}}]}`)
+			g := NewGoogle("abc")
+			g.URL = srv.URL
+			a, err := g.Forward("200 E Santa Clara St, San Jose")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(query).Should(ContainSubstring("key=abc"))
+			Ω(a.FullAddr()).Should(Equal("200 E Santa Clara St, San Jose, CA 95113"))
+			Ω(a.Lat).Should(Equal(37.3377501))
+		})
+
+		It("Google - no results", func() {
+			serve(`{"status": "ZERO_RESULTS", "results": []}`)
+			g := NewGoogle("")
+			g.URL = srv.URL
+			_, err := g.Reverse(37.3, -121.8)
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("Google - no street address", func() {
+			serve(`{"status": "OK", "results": [{"formatted_address": "San Jose, CA, USA",
+				"address_components": [{"long_name": "San Jose", "short_name": "San Jose", "types": ["locality"]}],
+				"geometry": {"location": {"lat": 37.3382, "lng": -121.8863}}}]}`)
+			g := NewGoogle("")
+			g.URL = srv.URL
+			_, err := g.Forward("San Jose, CA")
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("Nominatim", func() {
+			serve(`{"lat": "37.3377501", "lon": "-121.885961", "display_name": "200, East Santa Clara Street, San Jose",
+				"address": {"house_number": "200", "road": "East Santa Clara Street", "city": "San Jose",
+				"county": "Santa Clara County", "state": "California", "postcode": "95113"}}`)
+			a, err := NewNominatim(srv.URL, "").Reverse(37.3377501, -121.885961)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(query).Should(HavePrefix("/reverse?"))
+			Ω(a.FullAddr()).Should(Equal("200 East Santa Clara Street, San Jose, CA 95113"))
+		})
+
+		It("Nominatim - search", func() {
+			serve(`[{"lat": "37.3541", "lon": "-121.9552", "display_name": "1500, Warburton Avenue, Santa Clara",
+				"address": {"house_number": "1500", "road": "Warburton Avenue", "town": "Santa Clara",
+				"state": "California", "postcode": "95050"}}]`)
+			a, err := NewNominatim(srv.URL, "").Forward("1500 Warburton Ave, Santa Clara, CA")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(query).Should(HavePrefix("/search?"))
+			Ω(a.City).Should(Equal("Santa Clara"))
+			Ω(a.Lng).Should(Equal(-121.9552))
+		})
+
+		It("Pelias", func() {
+			serve(`{"features": [{"geometry": {"coordinates": [-121.885961, 37.3377501]},
+				"properties": {"label": "200 E Santa Clara St, San Jose, CA, USA", "housenumber": "200",
+				"street": "E Santa Clara St", "locality": "San Jose", "region_a": "CA", "postalcode": "95113"}}]}`)
+			a, err := NewPelias(srv.URL, "key1").Forward("200 E Santa Clara St, San Jose")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(query).Should(HavePrefix("/v1/search?"))
+			Ω(query).Should(ContainSubstring("api_key=key1"))
+			Ω(a.FullAddr()).Should(Equal("200 E Santa Clara St, San Jose, CA 95113"))
+		})
+	})
+
+	Describe("Cache", func() {
+		var cg *countGeocoder
+
+		BeforeEach(func() {
+			g, err := NewOfflineCSV(strings.NewReader(testAddrPoints))
+			Ω(err).ShouldNot(HaveOccurred())
+			cg = &countGeocoder{Geocoder: g}
+		})
+
+		It("caches lookups", func() {
+			c, err := NewCache(cg, time.Minute, "")
+			Ω(err).ShouldNot(HaveOccurred())
+			for i := 0; i < 3; i++ {
+				_, err := c.Forward("1 Market St, San Jose, CA 95113")
+				Ω(err).ShouldNot(HaveOccurred())
+				_, err = c.Reverse(37.3352, -121.8907)
+				Ω(err).ShouldNot(HaveOccurred())
+			}
+			Ω(cg.calls).Should(Equal(2))
+			hits, misses := c.Stats()
+			Ω(hits).Should(Equal(int64(4)))
+			Ω(misses).Should(Equal(int64(2)))
+		})
+
+		It("does not cache failures", func() {
+			c, _ := NewCache(cg, time.Minute, "")
+			c.Forward("1 Nowhere Ln, San Jose, CA 95113")
+			c.Forward("1 Nowhere Ln, San Jose, CA 95113")
+			Ω(cg.calls).Should(Equal(2))
+		})
+
+		It("expires entries", func() {
+			c, _ := NewCache(cg, time.Millisecond, "")
+			c.Forward("1 Market St, San Jose, CA 95113")
+			time.Sleep(5 * time.Millisecond)
+			c.Forward("1 Market St, San Jose, CA 95113")
+			Ω(cg.calls).Should(Equal(2))
+		})
+
+		It("persists to disk", func() {
+			fn := filepath.Join(dir, "cache.json")
+			c, err := NewCache(cg, time.Hour, fn)
+			Ω(err).ShouldNot(HaveOccurred())
+			_, err = c.Forward("1 Market St, San Jose, CA 95113")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(c.Close()).Should(Succeed())
+
+			cg2 := &countGeocoder{Geocoder: cg.Geocoder}
+			c2, err := NewCache(cg2, time.Hour, fn)
+			Ω(err).ShouldNot(HaveOccurred())
+			defer c2.Close()
+			a, err := c2.Forward("1 Market St, San Jose, CA 95113")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(a.City).Should(Equal("San Jose"))
+			Ω(a.Lat).Should(Equal(37.3352))
+			Ω(cg2.calls).Should(Equal(0))
+		})
+
+		It("drops invalid entries from the file", func() {
+			fn := filepath.Join(dir, "cache.json")
+			c, err := NewCache(cg, time.Hour, fn)
+			Ω(err).ShouldNot(HaveOccurred())
+			_, err = c.Forward("1 Market St, San Jose, CA 95113")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(c.Close()).Should(Succeed())
+
+			// Append an entry for the same address without a location, and one that
+			// cannot be decoded.
+			b, err := ioutil.ReadFile(fn)
+			Ω(err).ShouldNot(HaveOccurred())
+			var e struct {
+				Key     string    `json:"key"`
+				Expires time.Time `json:"expires"`
+			}
+			Ω(json.Unmarshal(b, &e)).Should(Succeed())
+			f, err := os.OpenFile(fn, os.O_APPEND|os.O_WRONLY, 0644)
+			Ω(err).ShouldNot(HaveOccurred())
+			fmt.Fprintf(f, `{"key": %q, "expires": %q, "addr": {}}`+"\n", e.Key, e.Expires.Format(time.RFC3339))
+			fmt.Fprintf(f, "not json\n")
+			f.Close()
+
+			cg2 := &countGeocoder{Geocoder: cg.Geocoder}
+			c2, err := NewCache(cg2, time.Hour, fn)
+			Ω(err).ShouldNot(HaveOccurred())
+			defer c2.Close()
+			a, err := c2.Forward("1 Market St, San Jose, CA 95113")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(a.Lat).Should(Equal(37.3352))
+			Ω(cg2.calls).Should(Equal(0))
+		})
+
+		It("compacts the file", func() {
+			fn := filepath.Join(dir, "cache.json")
+			c, err := NewCache(cg, time.Nanosecond, fn)
+			Ω(err).ShouldNot(HaveOccurred())
+			for i := 0; i < 2500; i++ {
+				_, err := c.Reverse(37.3352, -121.8907)
+				Ω(err).ShouldNot(HaveOccurred())
+			}
+			b, err := ioutil.ReadFile(fn)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(strings.Count(string(b), "\n")).Should(BeNumerically("<", 1000))
+
+			// The expired entries are dropped when the cache is closed.
+			Ω(c.Close()).Should(Succeed())
+			b, err = ioutil.ReadFile(fn)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(b).Should(BeEmpty())
+		})
+	})
+
+	Describe("NewGeocoder", func() {
+		It("builds a cached offline geocoder", func() {
+			var cfg Config
+			cfg.Provider = "offline"
+			cfg.File = writeFile("points.csv", testAddrPoints)
+			cfg.Cache.TTL = 60
+			g, err := NewGeocoder(cfg)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(g).Should(BeAssignableToTypeOf(&Cache{}))
+		})
+
+		It("rejects an unknown provider", func() {
+			_, err := NewGeocoder(Config{Provider: "bogus"})
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+})
@@ -1,52 +1,119 @@
 package geo
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
 
 const googleRespOK = "OK"
 
+var gooDefault = NewGoogle("")
+
+// Google is a Geocoder using the Google Geocode API.
+type Google struct {
+	URL    string
+	Key    string
+	Client *http.Client
+}
+
+// NewGoogle returns a Google Geocoder.  The API key is optional, though Google
+// limits the number of requests made without one.
+func NewGoogle(key string) *Google {
+	return &Google{
+		URL:    GOOGLE,
+		Key:    key,
+		Client: &http.Client{Timeout: REQUEST_TIMEOUT},
+	}
+}
+
+// Forward finds the location of the address.
+func (g *Google) Forward(addr string) (*Address, error) {
+	return g.lookup(addr)
+}
+
+// Reverse finds the nearest address to the location.
+func (g *Google) Reverse(lat, lng float64) (*Address, error) {
+	return g.lookup(lat, lng)
+}
+
 // GooAddr runs the Google Geocode API on the input address, and retuns a pointer
 // to an Address.
 func GooAddr(input ...interface{}) (*Address, error) {
-	var req *Request
+	return gooDefault.lookup(input...)
+}
 
+func (g *Google) lookup(input ...interface{}) (*Address, error) {
 	ga := Address{
 		Ok:     false,
 		Errors: make([]string, 0, 0),
 	}
 
 	// Create the request
+	v := make(url.Values)
 	switch inp := input[0].(type) {
 	case string:
 		ga.RawAddr = inp
-		req = &Request{
-			Address:  inp,
-			Provider: GOOGLE,
-		}
-	case float64, float32:
-		loc := Point{input[0].(float64), input[1].(float64)}
-		req = &Request{
-			Location: &loc,
-			Provider: GOOGLE,
+		v.Set("address", inp)
+	case float64:
+		lng, ok := input[1].(float64)
+		if !ok {
+			return nil, fmt.Errorf("invalid input type")
 		}
+		v.Set("latlng", Point{inp, lng}.String())
 	default:
 		return nil, fmt.Errorf("invalid input type")
 	}
+	if g.Key != "" {
+		v.Set("key", g.Key)
+	}
+	v.Set("sensor", "false")
 
 	// Send the request to Google
-	resp, err := req.Lookup(nil)
-	if err != nil || resp.Status != googleRespOK {
+	resp, err := g.send(v)
+	if err != nil || resp.Status != googleRespOK || len(resp.Results) == 0 {
 		return nil, fmt.Errorf("Unable to determine GeoLoc for %q", input)
 	}
 
 	// Parse the Google results back into Address
-	ga.Found = resp.Found
-	err = ga.unpackGResponse(resp.GoogleResponse)
-	// fmt.Printf("%s\n", ga.String())
+	ga.Found = resp.Results[0].Address
+	if err := ga.unpackGResponse(&resp.GoogleResponse); err != nil {
+		return nil, fmt.Errorf("Unable to determine GeoLoc for %q - %s", input, err)
+	}
 	return &ga, nil
 }
 
+type googleReply struct {
+	Status string `json:"status"`
+	GoogleResponse
+}
+
+func (g *Google) send(v url.Values) (*googleReply, error) {
+	c := g.Client
+	if c == nil {
+		c = &http.Client{Timeout: REQUEST_TIMEOUT}
+	}
+	resp, err := c.Get(fmt.Sprintf("%s?%s", g.URL, v.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("geocode request failed - status: %s", resp.Status)
+	}
+
+	reply := new(googleReply)
+	if err := json.NewDecoder(resp.Body).Decode(reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// unpackGResponse loads the first result with a street address.  It returns an error if
+// there is none.
 func (a *Address) unpackGResponse(resp *GoogleResponse) error {
-	loadAddr := func(ir int) error {
+	loadAddr := func(ir int) {
 		for _, ap := range resp.Results[ir].AddressParts {
 			for _, aptype := range ap.Types {
 				switch aptype {
@@ -76,27 +143,21 @@ func (a *Address) unpackGResponse(resp *GoogleResponse) error {
 		if a.subpremise > "" {
 			a.Addr += " #" + a.subpremise
 		}
-		return nil
+		a.Ok = true
 	}
 
 	// Parse the Google results back into Address
-	var err error
 	for ir, result := range resp.Results {
 		for _, apart := range result.AddressParts {
 			for _, t := range apart.Types {
 				if t == "street_number" {
-					err = loadAddr(ir)
-					break
+					loadAddr(ir)
+					return nil
 				}
 			}
 		}
 	}
-
-	if err != nil {
-		a.Ok = true
-		return err
-	}
-	return nil
+	return fmt.Errorf("no street address in the %d results", len(resp.Results))
 }
 
 // GooLatLngForAddr queries Google for the geolocation of an address.  It returns the lat, lng, and
@@ -0,0 +1,239 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	nominatimURL = "https://nominatim.openstreetmap.org"
+	peliasURL    = "https://api.geocode.earth"
+)
+
+// OSMGeocoder is a Geocoder for the Nominatim and Pelias geocoding APIs.  Both
+// public and self hosted instances are supported by setting the URL.
+type OSMGeocoder struct {
+	URL    string
+	Key    string // Pelias API key (optional)
+	Email  string // Nominatim contact email (optional)
+	pelias bool
+	Client *http.Client
+}
+
+// NewNominatim returns a Geocoder for a Nominatim server.  If url is blank, the
+// public OpenStreetMap server is used.
+func NewNominatim(url, email string) *OSMGeocoder {
+	if url == "" {
+		url = nominatimURL
+	}
+	return &OSMGeocoder{
+		URL:    strings.TrimRight(url, "/"),
+		Email:  email,
+		Client: &http.Client{Timeout: REQUEST_TIMEOUT},
+	}
+}
+
+// NewPelias returns a Geocoder for a Pelias server.  If url is blank, the
+// geocode.earth server is used.
+func NewPelias(url, key string) *OSMGeocoder {
+	if url == "" {
+		url = peliasURL
+	}
+	return &OSMGeocoder{
+		URL:    strings.TrimRight(url, "/"),
+		Key:    key,
+		pelias: true,
+		Client: &http.Client{Timeout: REQUEST_TIMEOUT},
+	}
+}
+
+// Forward finds the location of the address.
+func (g *OSMGeocoder) Forward(addr string) (*Address, error) {
+	v := make(url.Values)
+	var path string
+	if g.pelias {
+		path = "/v1/search"
+		v.Set("text", addr)
+		v.Set("size", "1")
+		v.Set("boundary.country", "USA")
+	} else {
+		path = "/search"
+		v.Set("q", addr)
+		v.Set("limit", "1")
+		v.Set("countrycodes", "us")
+	}
+	a, err := g.lookup(path, v)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to determine GeoLoc for %q - %s", addr, err)
+	}
+	a.RawAddr = addr
+	return a, nil
+}
+
+// Reverse finds the nearest address to the location.
+func (g *OSMGeocoder) Reverse(lat, lng float64) (*Address, error) {
+	v := make(url.Values)
+	var path string
+	if g.pelias {
+		path = "/v1/reverse"
+		v.Set("point.lat", strconv.FormatFloat(lat, 'f', -1, 64))
+		v.Set("point.lon", strconv.FormatFloat(lng, 'f', -1, 64))
+		v.Set("size", "1")
+	} else {
+		path = "/reverse"
+		v.Set("lat", strconv.FormatFloat(lat, 'f', -1, 64))
+		v.Set("lon", strconv.FormatFloat(lng, 'f', -1, 64))
+	}
+	a, err := g.lookup(path, v)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to determine the address for %v:%v - %s", lat, lng, err)
+	}
+	return a, nil
+}
+
+func (g *OSMGeocoder) lookup(path string, v url.Values) (*Address, error) {
+	if g.pelias {
+		if g.Key != "" {
+			v.Set("api_key", g.Key)
+		}
+	} else {
+		v.Set("format", "jsonv2")
+		v.Set("addressdetails", "1")
+		if g.Email != "" {
+			v.Set("email", g.Email)
+		}
+	}
+
+	c := g.Client
+	if c == nil {
+		c = &http.Client{Timeout: REQUEST_TIMEOUT}
+	}
+	resp, err := c.Get(fmt.Sprintf("%s%s?%s", g.URL, path, v.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status: %s", resp.Status)
+	}
+
+	if g.pelias {
+		var reply peliasReply
+		if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+			return nil, err
+		}
+		return reply.address()
+	}
+
+	// Nominatim search returns a list, reverse returns a single place.
+	var places []nominatimPlace
+	if path == "/search" {
+		if err := json.NewDecoder(resp.Body).Decode(&places); err != nil {
+			return nil, err
+		}
+	} else {
+		var place nominatimPlace
+		if err := json.NewDecoder(resp.Body).Decode(&place); err != nil {
+			return nil, err
+		}
+		places = append(places, place)
+	}
+	if len(places) == 0 || places[0].Error != "" {
+		return nil, fmt.Errorf("no results")
+	}
+	return places[0].address()
+}
+
+type nominatimPlace struct {
+	Lat     string `json:"lat"`
+	Lng     string `json:"lon"`
+	Display string `json:"display_name"`
+	Error   string `json:"error"`
+	Address struct {
+		HouseNumber string `json:"house_number"`
+		Road        string `json:"road"`
+		City        string `json:"city"`
+		Town        string `json:"town"`
+		Village     string `json:"village"`
+		County      string `json:"county"`
+		State       string `json:"state"`
+		Postcode    string `json:"postcode"`
+	} `json:"address"`
+}
+
+func (r nominatimPlace) address() (*Address, error) {
+	a := &Address{
+		Found:        r.Display,
+		streetNumber: r.Address.HouseNumber,
+		route:        r.Address.Road,
+		County:       r.Address.County,
+		State:        StateCode(r.Address.State),
+		Errors:       make([]string, 0),
+	}
+	for _, city := range []string{r.Address.City, r.Address.Town, r.Address.Village} {
+		if city != "" {
+			a.City = city
+			break
+		}
+	}
+	a.setZip(r.Address.Postcode)
+	var err error
+	if a.Lat, err = strconv.ParseFloat(r.Lat, 64); err != nil {
+		return nil, fmt.Errorf("invalid latitude: %q", r.Lat)
+	}
+	if a.Lng, err = strconv.ParseFloat(r.Lng, 64); err != nil {
+		return nil, fmt.Errorf("invalid longitude: %q", r.Lng)
+	}
+	a.setAddr()
+	return a, nil
+}
+
+type peliasReply struct {
+	Features []struct {
+		Geometry struct {
+			Coordinates []float64 `json:"coordinates"`
+		} `json:"geometry"`
+		Properties struct {
+			Label       string `json:"label"`
+			HouseNumber string `json:"housenumber"`
+			Street      string `json:"street"`
+			Locality    string `json:"locality"`
+			County      string `json:"county"`
+			Region      string `json:"region"`
+			RegionA     string `json:"region_a"`
+			PostalCode  string `json:"postalcode"`
+		} `json:"properties"`
+	} `json:"features"`
+}
+
+func (r peliasReply) address() (*Address, error) {
+	if len(r.Features) == 0 {
+		return nil, fmt.Errorf("no results")
+	}
+	f := r.Features[0]
+	if len(f.Geometry.Coordinates) < 2 {
+		return nil, fmt.Errorf("invalid coordinates")
+	}
+	p := f.Properties
+	a := &Address{
+		Found:        p.Label,
+		streetNumber: p.HouseNumber,
+		route:        p.Street,
+		City:         p.Locality,
+		County:       p.County,
+		State:        p.RegionA,
+		Lat:          f.Geometry.Coordinates[1],
+		Lng:          f.Geometry.Coordinates[0],
+		Errors:       make([]string, 0),
+	}
+	if a.State == "" {
+		a.State = StateCode(p.Region)
+	}
+	a.setZip(p.PostalCode)
+	a.setAddr()
+	return a, nil
+}
@@ -0,0 +1,602 @@
+package geo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ==============================================================================================================================
+//                                      NORMALIZED ADDRESS
+// ==============================================================================================================================
+
+// Confidence flags indicate which parts of an address were recognized by Normalize().
+type Confidence uint
+
+// Confidence flags.
+const (
+	HasNumber      Confidence = 1 << iota // House number
+	HasStreet                             // Street name
+	HasSuffix                             // A USPS street suffix (ST, AVE, etc.)
+	HasUnit                               // Unit or apartment
+	HasCity                               // City
+	HasState                              // A valid state code or name
+	HasZip                                // 5 digit ZIP
+	HasZip4                               // ZIP+4
+	IsIntersection                        // Two streets, e.g. "1st St & Santa Clara St"
+)
+
+// Street is a normalized street name, e.g. "E SANTA CLARA ST".
+type Street struct {
+	PreDir  string
+	Name    string
+	Suffix  string
+	PostDir string
+}
+
+func (r Street) String() string {
+	return joinNonEmpty(" ", r.PreDir, r.Name, r.Suffix, r.PostDir)
+}
+
+// NormAddress is a US street address normalized to the USPS standard: upper case,
+// with abbreviated directionals, street suffixes, unit designators and states.  An
+// intersection has a Cross street and no Number.
+type NormAddress struct {
+	Raw      string
+	Number   string
+	Street   Street
+	Cross    *Street
+	UnitType string
+	Unit     string
+	City     string
+	State    string
+	Zip      string
+	Zip4     string
+	Flags    Confidence
+}
+
+// Has returns true if all of the specified flags are set.
+func (r NormAddress) Has(flags Confidence) bool {
+	return r.Flags&flags == flags
+}
+
+// Complete returns true if the address has a street address (or an intersection),
+// a city and a state.
+func (r NormAddress) Complete() bool {
+	if !r.Has(HasCity | HasState) {
+		return false
+	}
+	return r.Has(HasNumber|HasStreet) || r.Has(IsIntersection)
+}
+
+// StreetLine returns the first line of the address, e.g. "200 E SANTA CLARA ST APT 3",
+// or "1ST ST & SANTA CLARA ST" for an intersection.
+func (r NormAddress) StreetLine() string {
+	if r.Cross != nil {
+		return r.Street.String() + " & " + r.Cross.String()
+	}
+	unit := ""
+	if r.Unit != "" {
+		if r.UnitType == "#" {
+			unit = "# " + r.Unit
+		} else {
+			unit = joinNonEmpty(" ", r.UnitType, r.Unit)
+		}
+	}
+	return joinNonEmpty(" ", r.Number, r.Street.String(), unit)
+}
+
+// ZipCode returns the ZIP, or ZIP+4 if available.
+func (r NormAddress) ZipCode() string {
+	if r.Zip4 != "" {
+		return r.Zip + "-" + r.Zip4
+	}
+	return r.Zip
+}
+
+// String returns the normalized address as a single line, e.g.
+// "200 E SANTA CLARA ST, SAN JOSE, CA 95113".
+func (r NormAddress) String() string {
+	return joinNonEmpty(", ", r.StreetLine(), r.City, joinNonEmpty(" ", r.State, r.ZipCode()))
+}
+
+// Key returns a string suitable for comparing addresses.  Equivalent addresses have
+// the same Key.  The ZIP+4 is not included, and intersections are ordered so
+// "A & B" and "B & A" are equal.
+func (r NormAddress) Key() string {
+	line := r.StreetLine()
+	if r.Cross != nil {
+		s1, s2 := r.Street.String(), r.Cross.String()
+		if s2 < s1 {
+			s1, s2 = s2, s1
+		}
+		line = s1 + " & " + s2
+	}
+	return joinNonEmpty("|", line, r.City, r.State, r.Zip)
+}
+
+// Equal returns true if the two addresses are equivalent.
+func (r NormAddress) Equal(a NormAddress) bool {
+	return r.Key() == a.Key()
+}
+
+// Normalize parses and normalizes a US street address.  Parts of the address are
+// separated by commas, e.g. "200 East Santa Clara Street, Apt 3, San Jose, California
+// 95113-1905".  The city cannot be reliably found without commas, but a trailing state
+// and ZIP are always recognized.  An error is returned only if nothing useful could be
+// parsed - check Flags or Complete() for the parts that were found.
+func Normalize(addr string) (*NormAddress, error) {
+	n := &NormAddress{Raw: addr}
+	s := strings.ToUpper(addr)
+	s = strings.NewReplacer(".", "", "\t", " ", "\n", " ").Replace(s)
+
+	parts := make([]string, 0)
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.Join(strings.Fields(p), " "); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("address is empty")
+	}
+
+	// Country
+	if last := parts[len(parts)-1]; last == "USA" || last == "US" || last == "UNITED STATES" || last == "UNITED STATES OF AMERICA" {
+		parts = parts[:len(parts)-1]
+	}
+
+	// State and ZIP, from the end of the last part.
+	if len(parts) > 0 {
+		tokens := strings.Fields(parts[len(parts)-1])
+		tokens = n.parseZip(tokens)
+		tokens = n.parseState(tokens, len(parts) > 1)
+		if len(tokens) == 0 {
+			parts = parts[:len(parts)-1]
+		} else {
+			parts[len(parts)-1] = strings.Join(tokens, " ")
+		}
+	}
+
+	// The first part is the street, and any part after it that is a unit is part of
+	// the street.  The next part is the city.
+	var street []string
+	if len(parts) > 0 {
+		street = strings.Fields(parts[0])
+		parts = parts[1:]
+	}
+	for len(parts) > 0 {
+		if t := strings.Fields(parts[0]); len(t) > 0 && (unitDesignators[t[0]] != "" || strings.HasPrefix(t[0], "#")) {
+			street = append(street, t...)
+			parts = parts[1:]
+			continue
+		}
+		break
+	}
+	if len(parts) > 0 {
+		n.City = parts[0]
+		n.Flags |= HasCity
+	}
+
+	if len(street) > 0 {
+		if rest := n.parseStreetLine(street); len(rest) > 0 && n.City == "" {
+			// No commas - anything following the street is the city.
+			n.City = strings.Join(rest, " ")
+			n.Flags |= HasCity
+		}
+	}
+
+	if n.Flags&(HasStreet|HasCity|HasZip|IsIntersection) == 0 {
+		return nil, fmt.Errorf("unable to parse address: %q", addr)
+	}
+	return n, nil
+}
+
+// parseZip removes a trailing ZIP or ZIP+4 from the tokens.
+func (r *NormAddress) parseZip(tokens []string) []string {
+	if len(tokens) == 0 {
+		return tokens
+	}
+	last := tokens[len(tokens)-1]
+	m := rxNormZip.FindStringSubmatch(last)
+	if m == nil && len(tokens) > 1 {
+		// ZIP+4 with a space: "95113 1905"
+		if m = rxNormZip.FindStringSubmatch(tokens[len(tokens)-2] + "-" + last); m != nil && rxNormZip5.MatchString(tokens[len(tokens)-2]) {
+			tokens = tokens[:len(tokens)-1]
+		} else {
+			m = nil
+		}
+	}
+	if m == nil {
+		return tokens
+	}
+	r.Zip = m[1]
+	r.Flags |= HasZip
+	if m[2] != "" {
+		r.Zip4 = m[2]
+		r.Flags |= HasZip4
+	}
+	return tokens[:len(tokens)-1]
+}
+
+// parseState removes a trailing state code or name from the tokens.  A code that is
+// also a street suffix, directional or unit (e.g. "CT", "NE", "FL") is only accepted
+// if it follows a comma or precedes a ZIP.
+func (r *NormAddress) parseState(tokens []string, ownPart bool) []string {
+	for i := 4; i >= 1; i-- {
+		if len(tokens) < i {
+			continue
+		}
+		name := strings.ToLower(strings.Join(tokens[len(tokens)-i:], " "))
+		if code, ok := stateCodes[name]; ok {
+			// Don't mistake "WASHINGTON" in "GEORGE WASHINGTON" for a state.
+			if i == len(tokens) || ownPart || r.Flags&HasZip != 0 {
+				r.State = code
+				r.Flags |= HasState
+				return tokens[:len(tokens)-i]
+			}
+		}
+	}
+	if len(tokens) > 0 {
+		last := tokens[len(tokens)-1]
+		_, isSuffix := streetSuffixes[last]
+		_, isDir := directionals[last]
+		ambiguous := isSuffix || isDir || unitDesignators[last] != ""
+		if len(last) == 2 && validStateCodes[last] && (ownPart || r.Has(HasZip) || !ambiguous) {
+			r.State = last
+			r.Flags |= HasState
+			return tokens[:len(tokens)-1]
+		}
+	}
+	return tokens
+}
+
+// parseStreetLine parses the house number, street(s) and unit.  Any tokens following
+// the street (i.e. the city, if the address does not have commas) are returned.
+func (r *NormAddress) parseStreetLine(tokens []string) []string {
+	bounded := r.Has(HasCity)
+
+	// Intersection
+	for i, t := range tokens {
+		if intersectionSeps[t] && i > 0 && i < len(tokens)-1 {
+			first, _ := parseStreet(tokens[:i], true)
+			cross, rest := parseStreet(tokens[i+1:], bounded)
+			r.Street = first
+			r.Cross = &cross
+			r.Flags |= IsIntersection | HasStreet
+			if first.Suffix != "" || cross.Suffix != "" {
+				r.Flags |= HasSuffix
+			}
+			return rest
+		}
+	}
+
+	if len(tokens) > 0 && rxHouseNumber.MatchString(tokens[0]) {
+		r.Number = tokens[0]
+		r.Flags |= HasNumber
+		tokens = tokens[1:]
+		// Fractional address: "123 1/2 MAIN ST"
+		if len(tokens) > 1 && rxFraction.MatchString(tokens[0]) {
+			r.Number += " " + tokens[0]
+			tokens = tokens[1:]
+		}
+	}
+
+	// Unit - a designator and (usually) a number.  The street precedes the unit.
+	for i, t := range tokens {
+		if i == 0 {
+			continue
+		}
+		var unitType, unit string
+		used := 1
+		switch {
+		case strings.HasPrefix(t, "#"):
+			unitType, unit = "#", strings.TrimPrefix(t, "#")
+		case unitDesignators[t] != "":
+			// "FRONT ST", "SPACE PARK DR" are streets, not units.
+			if i+1 < len(tokens) {
+				if _, ok := streetSuffixes[tokens[i+1]]; ok {
+					continue
+				}
+			}
+			unitType = unitDesignators[t]
+		default:
+			continue
+		}
+		if unit == "" && i+1 < len(tokens) && !unitNoNumber[unitType] {
+			unit = strings.TrimPrefix(tokens[i+1], "#")
+			used++
+		}
+		if unit == "" && !unitNoNumber[unitType] {
+			continue
+		}
+		r.UnitType, r.Unit = unitType, unit
+		r.Flags |= HasUnit
+		st, _ := parseStreet(tokens[:i], true)
+		r.setStreet(st)
+		return tokens[i+used:]
+	}
+
+	st, rest := parseStreet(tokens, bounded)
+	r.setStreet(st)
+	return rest
+}
+
+func (r *NormAddress) setStreet(st Street) {
+	r.Street = st
+	if st.Name != "" {
+		r.Flags |= HasStreet
+	}
+	if st.Suffix != "" {
+		r.Flags |= HasSuffix
+	}
+}
+
+// parseStreet parses a street name: [predirectional] name [suffix] [postdirectional].
+// If bounded is true, the tokens are only the street, so the suffix is the last word.
+// Otherwise, the street ends at the first suffix after the first word, and anything
+// after the suffix (and a postdirectional) is returned.
+func parseStreet(tokens []string, bounded bool) (st Street, rest []string) {
+	if len(tokens) == 0 {
+		return
+	}
+	// A directional is only a predirectional if a name follows it, e.g. "N 1ST ST",
+	// but not "NORTH ST".
+	if d, ok := directionals[tokens[0]]; ok && len(tokens) > 1 {
+		if _, isSuffix := streetSuffixes[tokens[1]]; !isSuffix || len(tokens) > 2 {
+			st.PreDir = d
+			tokens = tokens[1:]
+		}
+	}
+
+	end := len(tokens)
+	if bounded {
+		if d, ok := directionals[tokens[end-1]]; ok && end > 1 {
+			st.PostDir = d
+			end--
+		}
+		if sfx, ok := streetSuffixes[tokens[end-1]]; ok && end > 1 {
+			st.Suffix = sfx
+			end--
+		}
+	} else {
+		for i := 1; i < len(tokens); i++ {
+			if sfx, ok := streetSuffixes[tokens[i]]; ok {
+				st.Suffix = sfx
+				end = i
+				rest = tokens[i+1:]
+				if len(rest) > 0 {
+					if d, ok := directionals[rest[0]]; ok {
+						st.PostDir = d
+						rest = rest[1:]
+					}
+				}
+				break
+			}
+		}
+	}
+
+	name := make([]string, 0, end)
+	for _, t := range tokens[:end] {
+		if o, ok := ordinals[t]; ok {
+			t = o
+		}
+		name = append(name, t)
+	}
+	st.Name = strings.Join(name, " ")
+	return st, rest
+}
+
+func joinNonEmpty(sep string, s ...string) string {
+	l := make([]string, 0, len(s))
+	for _, x := range s {
+		if x != "" {
+			l = append(l, x)
+		}
+	}
+	return strings.Join(l, sep)
+}
+
+// ==============================================================================================================================
+//                                      TABLES
+// ==============================================================================================================================
+
+var (
+	rxNormZip       = regexp.MustCompile(`^(\d{5})(?:-?(\d{4}))?$`)
+	rxNormZip5      = regexp.MustCompile(`^\d{5}$`)
+	rxHouseNumber   = regexp.MustCompile(`^\d+[A-Z]?(?:-\d+[A-Z]?)?$`)
+	rxFraction      = regexp.MustCompile(`^\d/\d$`)
+	validStateCodes map[string]bool
+)
+
+var intersectionSeps = map[string]bool{
+	"&":   true,
+	"AND": true,
+	"AT":  true,
+	"@":   true,
+	"/":   true,
+}
+
+var directionals = map[string]string{
+	"N": "N", "NORTH": "N",
+	"S": "S", "SOUTH": "S",
+	"E": "E", "EAST": "E",
+	"W": "W", "WEST": "W",
+	"NE": "NE", "NORTHEAST": "NE",
+	"NW": "NW", "NORTHWEST": "NW",
+	"SE": "SE", "SOUTHEAST": "SE",
+	"SW": "SW", "SOUTHWEST": "SW",
+}
+
+var ordinals = map[string]string{
+	"FIRST":   "1ST",
+	"SECOND":  "2ND",
+	"THIRD":   "3RD",
+	"FOURTH":  "4TH",
+	"FIFTH":   "5TH",
+	"SIXTH":   "6TH",
+	"SEVENTH": "7TH",
+	"EIGHTH":  "8TH",
+	"NINTH":   "9TH",
+	"TENTH":   "10TH",
+}
+
+// unitDesignators are the USPS secondary unit designators (Publication 28, Appendix C2).
+var unitDesignators = map[string]string{
+	"APT": "APT", "APARTMENT": "APT",
+	"BSMT": "BSMT", "BASEMENT": "BSMT",
+	"BLDG": "BLDG", "BUILDING": "BLDG",
+	"DEPT": "DEPT", "DEPARTMENT": "DEPT",
+	"FL": "FL", "FLOOR": "FL",
+	"FRNT": "FRNT", "FRONT": "FRNT",
+	"HNGR": "HNGR", "HANGAR": "HNGR",
+	"LBBY": "LBBY", "LOBBY": "LBBY",
+	"LOT":  "LOT",
+	"LOWR": "LOWR", "LOWER": "LOWR",
+	"OFC": "OFC", "OFFICE": "OFC",
+	"PH": "PH", "PENTHOUSE": "PH",
+	"PIER": "PIER",
+	"REAR": "REAR",
+	"RM":   "RM", "ROOM": "RM",
+	"SIDE": "SIDE",
+	"SLIP": "SLIP",
+	"SPC":  "SPC", "SPACE": "SPC",
+	"STE": "STE", "SUITE": "STE",
+	"STOP": "STOP",
+	"TRLR": "TRLR", "TRAILER": "TRLR",
+	"UNIT": "UNIT",
+	"UPPR": "UPPR", "UPPER": "UPPR",
+}
+
+// unitNoNumber are the designators that do not require a number.
+var unitNoNumber = map[string]bool{
+	"BSMT": true,
+	"FRNT": true,
+	"LBBY": true,
+	"LOWR": true,
+	"OFC":  true,
+	"PH":   true,
+	"REAR": true,
+	"SIDE": true,
+	"UPPR": true,
+}
+
+// streetSuffixes maps the common street suffix spellings to the USPS standard
+// abbreviation (Publication 28, Appendix C1).
+var streetSuffixes = map[string]string{
+	"ALLEY": "ALY", "ALLY": "ALY", "ALY": "ALY",
+	"ANNEX": "ANX", "ANX": "ANX",
+	"ARCADE": "ARC", "ARC": "ARC",
+	"AVENUE": "AVE", "AVE": "AVE", "AV": "AVE", "AVEN": "AVE", "AVN": "AVE", "AVNUE": "AVE",
+	"BEACH": "BCH", "BCH": "BCH",
+	"BEND": "BND", "BND": "BND",
+	"BLUFF": "BLF", "BLF": "BLF",
+	"BOULEVARD": "BLVD", "BLVD": "BLVD", "BOUL": "BLVD", "BOULV": "BLVD",
+	"BRANCH": "BR", "BR": "BR",
+	"BRIDGE": "BRG", "BRG": "BRG",
+	"BROOK": "BRK", "BRK": "BRK",
+	"BYPASS": "BYP", "BYP": "BYP",
+	"CAMP": "CP", "CP": "CP",
+	"CANYON": "CYN", "CYN": "CYN",
+	"CAPE": "CPE", "CPE": "CPE",
+	"CAUSEWAY": "CSWY", "CSWY": "CSWY",
+	"CENTER": "CTR", "CENTRE": "CTR", "CTR": "CTR", "CNTR": "CTR",
+	"CIRCLE": "CIR", "CIR": "CIR", "CIRC": "CIR", "CRCL": "CIR",
+	"CLIFF": "CLF", "CLF": "CLF",
+	"CLUB": "CLB", "CLB": "CLB",
+	"COMMON": "CMN", "CMN": "CMN",
+	"CORNER": "COR", "COR": "COR",
+	"COURSE": "CRSE", "CRSE": "CRSE",
+	"COURT": "CT", "CT": "CT",
+	"COVE": "CV", "CV": "CV",
+	"CREEK": "CRK", "CRK": "CRK",
+	"CRESCENT": "CRES", "CRES": "CRES",
+	"CROSSING": "XING", "XING": "XING",
+	"DALE": "DL", "DL": "DL",
+	"DAM": "DM", "DM": "DM",
+	"DRIVE": "DR", "DR": "DR", "DRV": "DR",
+	"ESTATE": "EST", "EST": "EST",
+	"ESTATES": "ESTS", "ESTS": "ESTS",
+	"EXPRESSWAY": "EXPY", "EXPY": "EXPY", "EXPWY": "EXPY", "EXPR": "EXPY",
+	"EXTENSION": "EXT", "EXT": "EXT",
+	"FALLS": "FLS", "FLS": "FLS",
+	"FERRY": "FRY", "FRY": "FRY",
+	"FIELD": "FLD", "FLD": "FLD",
+	"FIELDS": "FLDS", "FLDS": "FLDS",
+	"FLAT": "FLT", "FLT": "FLT",
+	"FOREST": "FRST", "FRST": "FRST",
+	"FORK": "FRK", "FRK": "FRK",
+	"FORT": "FT", "FT": "FT",
+	"FREEWAY": "FWY", "FWY": "FWY",
+	"GARDEN": "GDN", "GDN": "GDN",
+	"GARDENS": "GDNS", "GDNS": "GDNS",
+	"GATEWAY": "GTWY", "GTWY": "GTWY",
+	"GLEN": "GLN", "GLN": "GLN",
+	"GREEN": "GRN", "GRN": "GRN",
+	"GROVE": "GRV", "GRV": "GRV",
+	"HARBOR": "HBR", "HBR": "HBR",
+	"HAVEN": "HVN", "HVN": "HVN",
+	"HEIGHTS": "HTS", "HTS": "HTS",
+	"HIGHWAY": "HWY", "HWY": "HWY", "HIWAY": "HWY",
+	"HILL": "HL", "HL": "HL",
+	"HILLS": "HLS", "HLS": "HLS",
+	"HOLLOW": "HOLW", "HOLW": "HOLW",
+	"ISLAND": "IS", "IS": "IS",
+	"JUNCTION": "JCT", "JCT": "JCT",
+	"KNOLL": "KNL", "KNL": "KNL",
+	"LAKE": "LK", "LK": "LK",
+	"LANDING": "LNDG", "LNDG": "LNDG",
+	"LANE": "LN", "LN": "LN",
+	"LOOP":  "LOOP",
+	"MALL":  "MALL",
+	"MANOR": "MNR", "MNR": "MNR",
+	"MEADOW": "MDW", "MDW": "MDW",
+	"MEADOWS": "MDWS", "MDWS": "MDWS",
+	"MILL": "ML", "ML": "ML",
+	"MISSION": "MSN", "MSN": "MSN",
+	"MOTORWAY": "MTWY", "MTWY": "MTWY",
+	"MOUNT": "MT", "MT": "MT",
+	"MOUNTAIN": "MTN", "MTN": "MTN",
+	"OVAL":    "OVAL",
+	"PARK":    "PARK",
+	"PARKWAY": "PKWY", "PKWY": "PKWY", "PKY": "PKWY",
+	"PASS": "PASS",
+	"PATH": "PATH",
+	"PIKE": "PIKE",
+	"PINE": "PNE", "PNE": "PNE",
+	"PLACE": "PL", "PL": "PL",
+	"PLAIN": "PLN", "PLN": "PLN",
+	"PLAZA": "PLZ", "PLZ": "PLZ",
+	"POINT": "PT", "PT": "PT",
+	"PORT": "PRT", "PRT": "PRT",
+	"RANCH": "RNCH", "RNCH": "RNCH",
+	"RIDGE": "RDG", "RDG": "RDG",
+	"RIVER": "RIV", "RIV": "RIV",
+	"ROAD": "RD", "RD": "RD",
+	"ROUTE": "RTE", "RTE": "RTE",
+	"ROW":   "ROW",
+	"RUN":   "RUN",
+	"SHORE": "SHR", "SHR": "SHR",
+	"SKYWAY": "SKWY", "SKWY": "SKWY",
+	"SPRING": "SPG", "SPG": "SPG",
+	"SQUARE": "SQ", "SQ": "SQ",
+	"STATION": "STA", "STA": "STA",
+	"STREET": "ST", "ST": "ST", "STR": "ST", "STRT": "ST",
+	"TERRACE": "TER", "TER": "TER",
+	"TRACE": "TRCE", "TRCE": "TRCE",
+	"TRAIL": "TRL", "TRL": "TRL",
+	"TUNNEL": "TUNL", "TUNL": "TUNL",
+	"TURNPIKE": "TPKE", "TPKE": "TPKE",
+	"VALLEY": "VLY", "VLY": "VLY",
+	"VIEW": "VW", "VW": "VW",
+	"VILLAGE": "VLG", "VLG": "VLG",
+	"VISTA": "VIS", "VIS": "VIS",
+	"WALK": "WALK",
+	"WAY":  "WAY", "WY": "WAY",
+}
+
+func init() {
+	validStateCodes = make(map[string]bool)
+	for _, code := range stateCodes {
+		validStateCodes[code] = true
+	}
+}
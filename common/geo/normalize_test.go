@@ -0,0 +1,97 @@
+package geo_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	. "github.com/codeforsanjose/open311-gateway/_background/go/common/geo"
+)
+
+var _ = Describe("Normalize", func() {
+	DescribeTable("addresses",
+		func(input, normalized string, flags Confidence) {
+			n, err := Normalize(input)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(n.String()).Should(Equal(normalized))
+			Ω(n.Has(flags)).Should(BeTrue(), "flags: %b", n.Flags)
+		},
+		Entry("simple", "200 E Santa Clara St, San Jose, CA 95113",
+			"200 E SANTA CLARA ST, SAN JOSE, CA 95113", HasNumber|HasStreet|HasSuffix|HasCity|HasState|HasZip),
+		Entry("spelled out", "200 East Santa Clara Street, San Jose, California 95113-1905",
+			"200 E SANTA CLARA ST, SAN JOSE, CA 95113-1905", HasZip4),
+		Entry("periods", "200 E. Santa Clara St., San Jose, C.A. 95113",
+			"200 E SANTA CLARA ST, SAN JOSE, CA 95113", HasNumber|HasStreet|HasCity|HasState),
+		Entry("apartment", "1500 Warburton Avenue Apartment 12, Santa Clara, CA",
+			"1500 WARBURTON AVE APT 12, SANTA CLARA, CA", HasUnit),
+		Entry("unit part", "1500 Warburton Ave, Suite 200, Santa Clara, CA 95050",
+			"1500 WARBURTON AVE STE 200, SANTA CLARA, CA 95050", HasUnit),
+		Entry("hash unit", "1500 Warburton Ave #3, Santa Clara, CA",
+			"1500 WARBURTON AVE # 3, SANTA CLARA, CA", HasUnit),
+		Entry("postdirectional", "1234 Main Street Northwest, Washington, DC 20001",
+			"1234 MAIN ST NW, WASHINGTON, DC 20001", HasNumber|HasStreet|HasState),
+		Entry("suffix word in name", "10123 N Stevens Creek Boulevard, Cupertino, CA",
+			"10123 N STEVENS CREEK BLVD, CUPERTINO, CA", HasSuffix),
+		Entry("directional as name", "100 North St, Sunnyvale, CA",
+			"100 NORTH ST, SUNNYVALE, CA", HasStreet),
+		Entry("ordinal", "55 North First Street, San Jose, CA",
+			"55 N 1ST ST, SAN JOSE, CA", HasStreet),
+		Entry("no commas", "200 E Santa Clara St San Jose CA 95113",
+			"200 E SANTA CLARA ST, SAN JOSE, CA 95113", HasNumber|HasStreet|HasCity|HasState|HasZip),
+		Entry("street only", "1 Market St",
+			"1 MARKET ST", HasNumber|HasStreet),
+		Entry("court is not Connecticut", "123 Main Ct",
+			"123 MAIN CT", HasStreet),
+		Entry("front is not a unit", "100 W Front St, Morgan Hill, CA",
+			"100 W FRONT ST, MORGAN HILL, CA", HasStreet),
+		Entry("country", "200 E Santa Clara St, San Jose, CA 95113, USA",
+			"200 E SANTA CLARA ST, SAN JOSE, CA 95113", HasZip),
+		Entry("intersection", "1st St & Santa Clara St, San Jose, CA",
+			"1ST ST & SANTA CLARA ST, SAN JOSE, CA", IsIntersection|HasCity|HasState),
+		Entry("intersection - and", "First Street and East Santa Clara Street, San Jose, CA",
+			"1ST ST & E SANTA CLARA ST, SAN JOSE, CA", IsIntersection),
+	)
+
+	It("compares equivalent addresses", func() {
+		a, _ := Normalize("200 East Santa Clara Street, San Jose, California 95113-1905")
+		b, _ := Normalize("200 E. Santa Clara St, san jose, CA 95113")
+		Ω(a.Equal(*b)).Should(BeTrue())
+
+		c, _ := Normalize("1st St & Santa Clara St, San Jose, CA")
+		d, _ := Normalize("Santa Clara Street at First Street, San Jose, CA")
+		Ω(c.Equal(*d)).Should(BeTrue())
+
+		e, _ := Normalize("202 E Santa Clara St, San Jose, CA 95113")
+		Ω(a.Equal(*e)).Should(BeFalse())
+	})
+
+	It("reports completeness", func() {
+		n, _ := Normalize("200 E Santa Clara St, San Jose, CA")
+		Ω(n.Complete()).Should(BeTrue())
+		n, _ = Normalize("200 E Santa Clara St")
+		Ω(n.Complete()).Should(BeFalse())
+	})
+
+	It("rejects an empty address", func() {
+		_, err := Normalize(" , ")
+		Ω(err).Should(HaveOccurred())
+	})
+
+	Describe("ParseAddress", func() {
+		It("parses a full address", func() {
+			a, err := ParseAddress("200 East Santa Clara Street, San Jose, CA 95113", false)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(a.Addr).Should(Equal("200 E SANTA CLARA ST"))
+			Ω(a.City).Should(Equal("SAN JOSE"))
+			Ω(a.State).Should(Equal("CA"))
+			Ω(a.Zip).Should(Equal("95113"))
+		})
+
+		It("requires a zip unless loose", func() {
+			_, err := ParseAddress("200 E Santa Clara St, San Jose, CA", false)
+			Ω(err).Should(HaveOccurred())
+			_, err = ParseAddress("200 E Santa Clara St, San Jose, CA", true)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,299 @@
+package geo
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	offlineCellSize    = 0.01   // degrees - roughly 1km
+	offlineMaxDistance = 1000.0 // meters
+)
+
+// Offline is a Geocoder backed by a local address point file.  The file can either
+// be a CSV file with a header row (the OpenAddresses CSV layout: LON, LAT, NUMBER,
+// STREET, UNIT, CITY, DISTRICT, REGION, POSTCODE), or an OpenAddresses GeoJSON file,
+// with one Feature per line.  No network access is required.
+type Offline struct {
+	points []*addrPoint
+	street map[string][]*addrPoint // Index: normalized "number street"
+	grid   map[gridCell][]*addrPoint
+}
+
+type addrPoint struct {
+	Number, Street, Unit, City, County, State, Zip string
+	Lat, Lng                                       float64
+}
+
+type gridCell struct {
+	lat, lng int
+}
+
+func cellFor(lat, lng float64) gridCell {
+	return gridCell{int(math.Floor(lat / offlineCellSize)), int(math.Floor(lng / offlineCellSize))}
+}
+
+// NewOffline loads the address point file, and returns an Offline Geocoder.
+func NewOffline(file string) (*Offline, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open address point file - %s", err)
+	}
+	defer f.Close()
+
+	lf := strings.ToLower(file)
+	if strings.HasSuffix(lf, ".geojson") || strings.HasSuffix(lf, ".geojsonl") {
+		return NewOfflineGeoJSON(f)
+	}
+	return NewOfflineCSV(f)
+}
+
+// NewOfflineCSV reads an address point CSV file.  Column names are not case
+// sensitive, and the common variations (e.g. "lng", "longitude", "zip", "state")
+// are recognized.
+func NewOfflineCSV(r io.Reader) (*Offline, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read address point header - %s", err)
+	}
+	col := make(map[string]int)
+	for i, h := range header {
+		name := strings.ToLower(strings.TrimSpace(h))
+		if n, ok := offlineColumns[name]; ok {
+			col[n] = i
+		}
+	}
+	for _, req := range []string{"lat", "lng", "number", "street"} {
+		if _, ok := col[req]; !ok {
+			return nil, fmt.Errorf("address point file is missing column: %q", req)
+		}
+	}
+	get := func(rec []string, name string) string {
+		if i, ok := col[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+
+	o := newOffline()
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("address point file line %d - %s", line, err)
+		}
+		p := &addrPoint{
+			Number: get(rec, "number"),
+			Street: get(rec, "street"),
+			Unit:   get(rec, "unit"),
+			City:   get(rec, "city"),
+			County: get(rec, "county"),
+			State:  StateCode(get(rec, "state")),
+			Zip:    get(rec, "zip"),
+		}
+		if p.Lat, err = strconv.ParseFloat(get(rec, "lat"), 64); err != nil {
+			continue
+		}
+		if p.Lng, err = strconv.ParseFloat(get(rec, "lng"), 64); err != nil {
+			continue
+		}
+		o.add(p)
+	}
+	return o, nil
+}
+
+// NewOfflineGeoJSON reads an OpenAddresses GeoJSON file, with one Feature per line.
+func NewOfflineGeoJSON(r io.Reader) (*Offline, error) {
+	var f struct {
+		Geometry struct {
+			Coordinates []float64 `json:"coordinates"`
+		} `json:"geometry"`
+		Properties struct {
+			Number   string `json:"number"`
+			Street   string `json:"street"`
+			Unit     string `json:"unit"`
+			City     string `json:"city"`
+			District string `json:"district"`
+			Region   string `json:"region"`
+			Postcode string `json:"postcode"`
+		} `json:"properties"`
+	}
+
+	o := newOffline()
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; s.Scan(); line++ {
+		b := s.Bytes()
+		if len(strings.TrimSpace(string(b))) == 0 {
+			continue
+		}
+		f.Geometry.Coordinates = nil
+		if err := json.Unmarshal(b, &f); err != nil {
+			return nil, fmt.Errorf("address point file line %d - %s", line, err)
+		}
+		if len(f.Geometry.Coordinates) < 2 {
+			continue
+		}
+		p := f.Properties
+		o.add(&addrPoint{
+			Number: p.Number,
+			Street: p.Street,
+			Unit:   p.Unit,
+			City:   p.City,
+			County: p.District,
+			State:  StateCode(p.Region),
+			Zip:    p.Postcode,
+			Lat:    f.Geometry.Coordinates[1],
+			Lng:    f.Geometry.Coordinates[0],
+		})
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func newOffline() *Offline {
+	return &Offline{
+		points: make([]*addrPoint, 0),
+		street: make(map[string][]*addrPoint),
+		grid:   make(map[gridCell][]*addrPoint),
+	}
+}
+
+func (o *Offline) add(p *addrPoint) {
+	o.points = append(o.points, p)
+	key, _ := addrKey(p.Number + " " + p.Street)
+	o.street[key] = append(o.street[key], p)
+	c := cellFor(p.Lat, p.Lng)
+	o.grid[c] = append(o.grid[c], p)
+}
+
+// Len returns the number of address points loaded.
+func (o *Offline) Len() int {
+	return len(o.points)
+}
+
+// Forward finds the address point matching the street address.  Addresses are
+// normalized, so "200 East Santa Clara Street" matches "200 E Santa Clara St".  The
+// city and zip are used to choose between address points on identically named streets.
+func (o *Offline) Forward(addr string) (*Address, error) {
+	key, n := addrKey(addr)
+	var city, zip string
+	if n != nil {
+		city, zip = n.City, n.Zip
+	}
+
+	candidates := o.street[key]
+	var found *addrPoint
+	for _, p := range candidates {
+		switch {
+		case zip != "" && strings.HasPrefix(p.Zip, zip):
+			found = p
+		case zip == "" && city != "" && strings.EqualFold(p.City, city):
+			found = p
+		case zip == "" && city == "":
+			found = p
+		}
+		if found != nil {
+			break
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("Unable to determine GeoLoc for %q", addr)
+	}
+	a := found.address()
+	a.RawAddr = addr
+	return a, nil
+}
+
+// Reverse finds the nearest address point to the location.
+func (o *Offline) Reverse(lat, lng float64) (*Address, error) {
+	var (
+		nearest *addrPoint
+		minDist = offlineMaxDistance
+	)
+	c := cellFor(lat, lng)
+	for dlat := -1; dlat <= 1; dlat++ {
+		for dlng := -1; dlng <= 1; dlng++ {
+			for _, p := range o.grid[gridCell{c.lat + dlat, c.lng + dlng}] {
+				if d := Distance(lat, lng, p.Lat, p.Lng); d <= minDist {
+					nearest, minDist = p, d
+				}
+			}
+		}
+	}
+	if nearest == nil {
+		return nil, fmt.Errorf("Unable to determine the address for %v:%v", lat, lng)
+	}
+	return nearest.address(), nil
+}
+
+func (p *addrPoint) address() *Address {
+	a := &Address{
+		streetNumber: p.Number,
+		route:        p.Street,
+		subpremise:   p.Unit,
+		City:         p.City,
+		County:       p.County,
+		State:        p.State,
+		Lat:          p.Lat,
+		Lng:          p.Lng,
+		Errors:       make([]string, 0),
+	}
+	a.setZip(p.Zip)
+	a.setAddr()
+	a.Found = a.FullAddr()
+	return a
+}
+
+// addrKey returns the key the address points are indexed by - the normalized number and
+// street.  If the address cannot be normalized, the key is the first part of the address
+// (before any comma), and the normalized address is nil.
+func addrKey(addr string) (string, *NormAddress) {
+	if n, err := Normalize(addr); err == nil {
+		return n.Number + " " + n.Street.String(), n
+	}
+	return streetKey(strings.SplitN(addr, ",", 2)[0]), nil
+}
+
+// streetKey normalizes a street address for matching.
+func streetKey(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case '.', ',', '#':
+			return ' '
+		}
+		return r
+	}, strings.ToLower(s))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+var offlineColumns = map[string]string{
+	"lat":       "lat",
+	"latitude":  "lat",
+	"lon":       "lng",
+	"lng":       "lng",
+	"longitude": "lng",
+	"number":    "number",
+	"street":    "street",
+	"unit":      "unit",
+	"city":      "city",
+	"district":  "county",
+	"county":    "county",
+	"region":    "state",
+	"state":     "state",
+	"postcode":  "zip",
+	"zip":       "zip",
+}
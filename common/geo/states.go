@@ -0,0 +1,77 @@
+package geo
+
+import "strings"
+
+// StateCode returns the two letter USPS code for a state name or code.  If the
+// state is not recognized, it is returned unchanged.
+func StateCode(state string) string {
+	s := strings.ToLower(strings.TrimSpace(state))
+	if code, ok := stateCodes[s]; ok {
+		return code
+	}
+	if len(s) == 2 {
+		return strings.ToUpper(s)
+	}
+	return state
+}
+
+var stateCodes = map[string]string{
+	"alabama":                      "AL",
+	"alaska":                       "AK",
+	"arizona":                      "AZ",
+	"arkansas":                     "AR",
+	"california":                   "CA",
+	"colorado":                     "CO",
+	"connecticut":                  "CT",
+	"delaware":                     "DE",
+	"district of columbia":         "DC",
+	"florida":                      "FL",
+	"georgia":                      "GA",
+	"hawaii":                       "HI",
+	"idaho":                        "ID",
+	"illinois":                     "IL",
+	"indiana":                      "IN",
+	"iowa":                         "IA",
+	"kansas":                       "KS",
+	"kentucky":                     "KY",
+	"louisiana":                    "LA",
+	"maine":                        "ME",
+	"maryland":                     "MD",
+	"massachusetts":                "MA",
+	"michigan":                     "MI",
+	"minnesota":                    "MN",
+	"mississippi":                  "MS",
+	"missouri":                     "MO",
+	"montana":                      "MT",
+	"nebraska":                     "NE",
+	"nevada":                       "NV",
+	"new hampshire":                "NH",
+	"new jersey":                   "NJ",
+	"new mexico":                   "NM",
+	"new york":                     "NY",
+	"north carolina":               "NC",
+	"north dakota":                 "ND",
+	"ohio":                         "OH",
+	"oklahoma":                     "OK",
+	"oregon":                       "OR",
+	"pennsylvania":                 "PA",
+	"rhode island":                 "RI",
+	"south carolina":               "SC",
+	"south dakota":                 "SD",
+	"tennessee":                    "TN",
+	"texas":                        "TX",
+	"utah":                         "UT",
+	"vermont":                      "VT",
+	"virginia":                     "VA",
+	"washington":                   "WA",
+	"west virginia":                "WV",
+	"wisconsin":                    "WI",
+	"wyoming":                      "WY",
+	"american samoa":               "AS",
+	"guam":                         "GU",
+	"northern mariana islands":     "MP",
+	"puerto rico":                  "PR",
+	"united states virgin islands": "VI",
+	"virgin islands":               "VI",
+	"washington dc":                "DC",
+}
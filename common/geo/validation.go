@@ -1,17 +1,134 @@
 package geo
 
-const (
-	// Continental US only!
-	latMin float64 = 18.0
-	latMax float64 = 49.0
-	lngMin float64 = -124.6
-	lngMax float64 = -62.3
+import (
+	"fmt"
+	"strings"
+	"sync"
 )
 
-// ValidateLatLng validates a lat/lng pair against the Continental US boundaries.
+// Region is a named geographic area where requests are valid.  It is defined by
+// either a bounding box or a polygon.  Polygon points are [lat, lng] pairs.
+type Region struct {
+	Name    string       `json:"name"`
+	Box     *Box         `json:"box,omitempty"`
+	Polygon [][2]float64 `json:"polygon,omitempty"`
+}
+
+// Box is a bounding box.
+type Box struct {
+	MinLat float64 `json:"minLat"`
+	MaxLat float64 `json:"maxLat"`
+	MinLng float64 `json:"minLng"`
+	MaxLng float64 `json:"maxLng"`
+}
+
+// Regions is a list of Region.
+type Regions []Region
+
+// DefaultRegions are used if no regions have been set - the US states and Puerto Rico.
+var DefaultRegions = Regions{
+	{Name: "Continental US", Box: &Box{MinLat: 18.0, MaxLat: 49.0, MinLng: -124.6, MaxLng: -62.3}},
+	{Name: "Alaska", Box: &Box{MinLat: 51.2, MaxLat: 71.5, MinLng: -179.2, MaxLng: -129.9}},
+	{Name: "Hawaii", Box: &Box{MinLat: 18.9, MaxLat: 22.3, MinLng: -160.3, MaxLng: -154.8}},
+	{Name: "Puerto Rico", Box: &Box{MinLat: 17.8, MaxLat: 18.6, MinLng: -67.3, MaxLng: -65.2}},
+}
+
+var (
+	regions     = DefaultRegions
+	regionMutex sync.RWMutex
+)
+
+// SetRegions sets the list of valid Regions used by ValidateLatLng() and CheckLatLng().
+// If the list is empty, the DefaultRegions are used.
+func SetRegions(r Regions) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	regionMutex.Lock()
+	defer regionMutex.Unlock()
+	if len(r) == 0 {
+		r = DefaultRegions
+	}
+	regions = r
+	return nil
+}
+
+// GetRegions returns the list of valid Regions.
+func GetRegions() Regions {
+	regionMutex.RLock()
+	defer regionMutex.RUnlock()
+	return regions
+}
+
+// ValidateLatLng validates a lat/lng pair against the valid Regions.
 func ValidateLatLng(lat, lng float64) bool {
-	if lat >= latMin && lat <= latMax && lng >= lngMin && lng <= lngMax {
-		return true
+	return CheckLatLng(lat, lng) == nil
+}
+
+// CheckLatLng validates a lat/lng pair against the valid Regions.  The error
+// describes the check that failed.
+func CheckLatLng(lat, lng float64) error {
+	switch {
+	case lat == 0 && lng == 0:
+		return fmt.Errorf("location coordinates are missing")
+	case lat < -90 || lat > 90:
+		return fmt.Errorf("latitude %v is out of range", lat)
+	case lng < -180 || lng > 180:
+		return fmt.Errorf("longitude %v is out of range", lng)
+	}
+	return GetRegions().Check(lat, lng)
+}
+
+// Check returns an error if the location is not within any of the Regions.
+func (r Regions) Check(lat, lng float64) error {
+	names := make([]string, 0, len(r))
+	for _, region := range r {
+		if region.Contains(lat, lng) {
+			return nil
+		}
+		names = append(names, region.Name)
+	}
+	return fmt.Errorf("location %v,%v is outside the valid regions: %s", lat, lng, strings.Join(names, ", "))
+}
+
+// Validate checks that each Region has a valid box or polygon.
+func (r Regions) Validate() error {
+	for i, region := range r {
+		if err := region.Validate(); err != nil {
+			return fmt.Errorf("region %d (%q) - %s", i, region.Name, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks the Region has a valid box or polygon.
+func (r Region) Validate() error {
+	switch {
+	case r.Box == nil && len(r.Polygon) == 0:
+		return fmt.Errorf("a box or polygon is required")
+	case r.Box != nil && len(r.Polygon) > 0:
+		return fmt.Errorf("only one of box or polygon may be specified")
+	case r.Box != nil && (r.Box.MinLat >= r.Box.MaxLat || r.Box.MinLng >= r.Box.MaxLng):
+		return fmt.Errorf("invalid box: %+v", *r.Box)
+	case r.Box == nil && len(r.Polygon) < 3:
+		return fmt.Errorf("a polygon must have at least 3 points")
+	}
+	return nil
+}
+
+// Contains returns true if the location is within the Region.
+func (r Region) Contains(lat, lng float64) bool {
+	if r.Box != nil {
+		return lat >= r.Box.MinLat && lat <= r.Box.MaxLat && lng >= r.Box.MinLng && lng <= r.Box.MaxLng
+	}
+	// Ray casting.
+	in := false
+	p := r.Polygon
+	for i, j := 0, len(p)-1; i < len(p); j, i = i, i+1 {
+		if (p[i][0] > lat) != (p[j][0] > lat) &&
+			lng < (p[j][1]-p[i][1])*(lat-p[i][0])/(p[j][0]-p[i][0])+p[i][1] {
+			in = !in
+		}
 	}
-	return false
+	return in
 }
@@ -0,0 +1,60 @@
+package geo_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	. "github.com/codeforsanjose/open311-gateway/_background/go/common/geo"
+)
+
+var _ = Describe("Regions", func() {
+	AfterEach(func() {
+		SetRegions(nil)
+	})
+
+	DescribeTable("default regions",
+		func(lat, lng float64, ok bool) {
+			Ω(ValidateLatLng(lat, lng)).Should(Equal(ok))
+		},
+		Entry("San Jose", 37.3377501, -121.885961, true),
+		Entry("Anchorage", 61.2181, -149.9003, true),
+		Entry("Honolulu", 21.3069, -157.8583, true),
+		Entry("San Juan", 18.4655, -66.1057, true),
+		Entry("London", 51.5074, -0.1278, false),
+		Entry("missing", 0.0, 0.0, false),
+	)
+
+	Describe("configured regions", func() {
+		BeforeEach(func() {
+			Ω(SetRegions(Regions{
+				{Name: "SJ", Box: &Box{MinLat: 37.1, MaxLat: 37.5, MinLng: -122.1, MaxLng: -121.6}},
+				{Name: "Triangle", Polygon: [][2]float64{{45.0, -75.0}, {46.0, -74.0}, {45.0, -73.0}}},
+			})).Should(Succeed())
+		})
+
+		It("accepts a location in a box", func() {
+			Ω(CheckLatLng(37.3377501, -121.885961)).Should(Succeed())
+		})
+
+		It("accepts a location in a polygon", func() {
+			Ω(CheckLatLng(45.3, -74.0)).Should(Succeed())
+		})
+
+		It("names the regions checked", func() {
+			err := CheckLatLng(45.9, -74.9)
+			Ω(err).Should(HaveOccurred())
+			Ω(err.Error()).Should(Equal("location 45.9,-74.9 is outside the valid regions: SJ, Triangle"))
+		})
+
+		It("reports coordinates out of range", func() {
+			Ω(CheckLatLng(95.0, -74.0).Error()).Should(ContainSubstring("latitude 95 is out of range"))
+		})
+	})
+
+	It("rejects an invalid region", func() {
+		Ω(SetRegions(Regions{{Name: "bad", Box: &Box{MinLat: 38, MaxLat: 37, MinLng: -122, MaxLng: -121}}})).ShouldNot(Succeed())
+		Ω(SetRegions(Regions{{Name: "line", Polygon: [][2]float64{{1, 1}, {2, 2}}}})).ShouldNot(Succeed())
+		Ω(SetRegions(Regions{{Name: "empty"}})).ShouldNot(Succeed())
+	})
+})
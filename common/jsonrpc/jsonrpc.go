@@ -0,0 +1,248 @@
+// Package jsonrpc is the JSON-RPC 2.0 transport between the Engine and the Adapters.  It
+// lets Adapters be written in any language.  Requests are POSTed to the Adapter at Path,
+// with the "method" set to the RPC method (e.g. "Report.Create"), and the "params" set to
+// the JSON encoding of the structs request (e.g. structs.NCreateRequest).  The "result" is
+// the JSON encoding of the structs response.  See "_Docs/Adapters/JSONRPC.md".
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/rpc"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Path is the HTTP path of the JSON-RPC endpoint.
+const Path = "/jsonrpc"
+
+// Version is the JSON-RPC version.
+const Version = "2.0"
+
+// JSON-RPC 2.0 error codes.
+const (
+	CodeParse          = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternal       = -32603
+	CodeServer         = -32000 // The method returned an error.
+)
+
+// Request is a JSON-RPC request.  A Request without an ID is a notification.
+type Request struct {
+	Version string           `json:"jsonrpc"`
+	Method  string           `json:"method"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+}
+
+// Response is a JSON-RPC response.  Only one of Result or Error is set.
+type Response struct {
+	Version string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// Error is a JSON-RPC error object.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (%d)", e.Message, e.Code)
+}
+
+// ==============================================================================================================================
+//                                      CLIENT
+// ==============================================================================================================================
+
+// Client calls the RPC methods of an Adapter.  It is safe for concurrent use.
+type Client struct {
+	url  string
+	http *http.Client
+	seq  uint64
+}
+
+// NewClient returns a Client for the Adapter address.  The address is either "host:port"
+// (":port" is localhost), or the full URL of the JSON-RPC endpoint.
+func NewClient(address string, timeout time.Duration) *Client {
+	url := address
+	if !strings.Contains(address, "://") {
+		if strings.HasPrefix(address, ":") {
+			address = "localhost" + address
+		}
+		url = "http://" + address + Path
+	}
+	return &Client{url: url, http: &http.Client{Timeout: timeout}}
+}
+
+// URL returns the URL of the JSON-RPC endpoint.
+func (c *Client) URL() string {
+	return c.url
+}
+
+// Call invokes the RPC method, and decodes the result into reply.  As with net/rpc, an
+// error returned by the Adapter is an rpc.ServerError, and reply is only set if the call
+// succeeds.
+func (c *Client) Call(serviceMethod string, args interface{}, reply interface{}) error {
+	return c.CallContext(context.Background(), serviceMethod, args, reply)
+}
+
+// CallContext is Call, abandoning the HTTP request when the context is done.
+func (c *Client) CallContext(ctx context.Context, serviceMethod string, args interface{}, reply interface{}) error {
+	params, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	id := json.RawMessage(fmt.Sprintf("%d", atomic.AddUint64(&c.seq, 1)))
+	body, err := json.Marshal(Request{Version: Version, Method: serviceMethod, Params: params, ID: &id})
+	if err != nil {
+		return err
+	}
+	rqst, err := http.NewRequest("POST", c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	rqst.Header.Set("Content-Type", "application/json")
+	r, err := c.http.Do(rqst.WithContext(ctx))
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	defer r.Body.Close()
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	var resp Response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		if r.StatusCode != http.StatusOK {
+			return fmt.Errorf("%s returned %s", c.url, r.Status)
+		}
+		return fmt.Errorf("invalid JSON-RPC response from %s - %s", c.url, err)
+	}
+	if resp.Error != nil {
+		return rpc.ServerError(resp.Error.Message)
+	}
+	if string(resp.ID) != string(id) {
+		return fmt.Errorf("JSON-RPC response id %s does not match the request id %s", resp.ID, id)
+	}
+	return json.Unmarshal(resp.Result, reply)
+}
+
+// SetTransport sets the HTTP transport, e.g. for TLS.  It must be called before the
+// first Call().
+func (c *Client) SetTransport(rt http.RoundTripper) {
+	c.http.Transport = rt
+}
+
+// Close closes any idle connections.
+func (c *Client) Close() error {
+	rt := c.http.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if t, ok := rt.(interface {
+		CloseIdleConnections()
+	}); ok {
+		t.CloseIdleConnections()
+	}
+	return nil
+}
+
+// ==============================================================================================================================
+//                                      SERVER
+// ==============================================================================================================================
+
+// Dispatcher runs an RPC method with the JSON params, and returns the result.
+type Dispatcher func(method string, params json.RawMessage) (interface{}, *Error)
+
+// Handler returns the HTTP handler for the JSON-RPC endpoint.  Batches are supported, and
+// notifications are run without a response.
+func Handler(dispatch Dispatcher) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.Error(w, "JSON-RPC requests must be POSTed", http.StatusMethodNotAllowed)
+			return
+		}
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeJSON(w, errorResponse(nil, CodeParse, err.Error()))
+			return
+		}
+		data = bytes.TrimSpace(data)
+
+		if len(data) > 0 && data[0] == '[' {
+			var batch []json.RawMessage
+			if err := json.Unmarshal(data, &batch); err != nil || len(batch) == 0 {
+				writeJSON(w, errorResponse(nil, CodeInvalidRequest, "invalid batch"))
+				return
+			}
+			resps := make([]*Response, 0, len(batch))
+			for _, b := range batch {
+				if resp := handle(dispatch, b); resp != nil {
+					resps = append(resps, resp)
+				}
+			}
+			if len(resps) == 0 {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			writeJSON(w, resps)
+			return
+		}
+
+		resp := handle(dispatch, data)
+		if resp == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeJSON(w, resp)
+	})
+}
+
+// handle runs one request.  It returns nil for a notification.
+func handle(dispatch Dispatcher, data []byte) *Response {
+	var rqst Request
+	if err := json.Unmarshal(data, &rqst); err != nil {
+		return errorResponse(nil, CodeParse, err.Error())
+	}
+	if rqst.Version != Version || rqst.Method == "" {
+		return errorResponse(rqst.ID, CodeInvalidRequest, "invalid JSON-RPC 2.0 request")
+	}
+	result, rerr := dispatch(rqst.Method, rqst.Params)
+	if rqst.ID == nil {
+		return nil
+	}
+	if rerr != nil {
+		return &Response{Version: Version, Error: rerr, ID: *rqst.ID}
+	}
+	b, err := json.Marshal(result)
+	if err != nil {
+		return errorResponse(rqst.ID, CodeInternal, err.Error())
+	}
+	return &Response{Version: Version, Result: b, ID: *rqst.ID}
+}
+
+func errorResponse(id *json.RawMessage, code int, msg string) *Response {
+	resp := &Response{Version: Version, Error: &Error{Code: code, Message: msg}, ID: json.RawMessage("null")}
+	if id != nil {
+		resp.ID = *id
+	}
+	return resp
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,126 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/rpc"
+	"strings"
+	"testing"
+	"time"
+)
+
+type echoArgs struct {
+	Text string `json:"text"`
+}
+
+type echoReply struct {
+	Text string `json:"text"`
+	N    int    `json:"n"`
+}
+
+func echo(method string, params json.RawMessage) (interface{}, *Error) {
+	switch method {
+	case "Echo.Text":
+		var a echoArgs
+		if err := json.Unmarshal(params, &a); err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		return &echoReply{Text: a.Text, N: len(a.Text)}, nil
+	case "Echo.Fail":
+		return nil, &Error{Code: CodeServer, Message: "failed"}
+	}
+	return nil, &Error{Code: CodeMethodNotFound, Message: "unknown method " + method}
+}
+
+func TestClient(t *testing.T) {
+	srv := httptest.NewServer(Handler(echo))
+	defer srv.Close()
+	c := NewClient(srv.URL+Path, time.Second*5)
+	defer c.Close()
+
+	var reply echoReply
+	if err := c.Call("Echo.Text", &echoArgs{Text: "hello"}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Text != "hello" || reply.N != 5 {
+		t.Errorf("unexpected reply: %+v", reply)
+	}
+
+	err := c.Call("Echo.Fail", &echoArgs{}, &reply)
+	if _, ok := err.(rpc.ServerError); !ok || err.Error() != "failed" {
+		t.Errorf("expected an rpc.ServerError, got: %T %v", err, err)
+	}
+	if reply.Text != "hello" {
+		t.Errorf("the reply should not change on an error: %+v", reply)
+	}
+
+	if err := NewClient("127.0.0.1:1", time.Second).Call("Echo.Text", &echoArgs{}, &reply); err == nil {
+		t.Errorf("expected a connection error")
+	} else if _, ok := err.(rpc.ServerError); ok {
+		t.Errorf("a connection error should not be an rpc.ServerError")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.CallContext(ctx, "Echo.Text", &echoArgs{Text: "hello"}, &reply); err != context.Canceled {
+		t.Errorf("expected a cancelled call, got: %v", err)
+	}
+
+	if u := NewClient(":5006", time.Second).URL(); u != "http://localhost:5006"+Path {
+		t.Errorf("unexpected URL: %s", u)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	srv := httptest.NewServer(Handler(echo))
+	defer srv.Close()
+
+	post := func(body string) (int, string) {
+		r, err := http.Post(srv.URL, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Body.Close()
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r.StatusCode, strings.TrimSpace(string(b))
+	}
+
+	for _, tc := range []struct {
+		name, rqst, want string
+	}{
+		{"call", `{"jsonrpc": "2.0", "method": "Echo.Text", "params": {"text": "hi"}, "id": "a"}`,
+			`{"jsonrpc":"2.0","result":{"text":"hi","n":2},"id":"a"}`},
+		{"unknown", `{"jsonrpc": "2.0", "method": "Echo.None", "id": 1}`,
+			`{"jsonrpc":"2.0","error":{"code":-32601,"message":"unknown method Echo.None"},"id":1}`},
+		{"version", `{"method": "Echo.Text", "id": 2}`,
+			`{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid JSON-RPC 2.0 request"},"id":2}`},
+		{"parse", `{"jsonrpc"`,
+			`{"jsonrpc":"2.0","error":{"code":-32700,"message":"unexpected end of JSON input"},"id":null}`},
+		{"notification", `{"jsonrpc": "2.0", "method": "Echo.Text", "params": {"text": "hi"}}`, ``},
+		{"batch", `[{"jsonrpc": "2.0", "method": "Echo.Text", "params": {"text": "a"}, "id": 1},
+			{"jsonrpc": "2.0", "method": "Echo.Text", "params": {"text": "b"}},
+			{"jsonrpc": "2.0", "method": "Echo.Fail", "id": 3}]`,
+			`[{"jsonrpc":"2.0","result":{"text":"a","n":1},"id":1},{"jsonrpc":"2.0","error":{"code":-32000,"message":"failed"},"id":3}]`},
+		{"empty batch", `[]`, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"invalid batch"},"id":null}`},
+	} {
+		code, got := post(tc.rqst)
+		if got != tc.want {
+			t.Errorf("%s: expected:\n%s\ngot (%d):\n%s", tc.name, tc.want, code, got)
+		}
+	}
+
+	r, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+	if r.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("expected GET to be rejected, got: %s", r.Status)
+	}
+}
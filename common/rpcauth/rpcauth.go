@@ -0,0 +1,371 @@
+// Package rpcauth secures the RPC channel between the Engine and the Adapters.  Both sides
+// are configured with the same "rpcAuth" config section:
+//
+//   - Mutual TLS: with "certFile", "keyFile" and "caFile" set, the Adapter serves TLS, and
+//     only accepts callers with a client certificate signed by the CA.  The Engine
+//     presents its certificate, and checks the Adapter certificate against the CA.
+//   - Shared secret: with "secret" set, each JSON-RPC request, and the CONNECT request
+//     opening each net/rpc connection, carries an HMAC-SHA256 signature with a nonce (see
+//     Sign).  The Adapter rejects requests with a missing, invalid, stale or replayed
+//     signature.  On net/rpc this authenticates the connection, not each call on it, so
+//     the calls are only protected from tampering with TLS.  This is for deployments not
+//     using certificates.
+//
+// Both can be used together.  If neither is set, the channel is plain HTTP, as before.
+package rpcauth
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/rpc"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common/jsonrpc"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+// Header is the HTTP header holding the request signature:
+// "{unix time}:{hex nonce}:{hex HMAC}".
+const Header = "X-RPC-Signature"
+
+// maxSkew is the largest difference between the signature time and the Adapter clock.
+const maxSkew = time.Minute * 5
+
+// connected is the net/rpc response to a successful CONNECT.
+const connected = "200 Connected to Go RPC"
+
+// Config is the "rpcAuth" section of the Engine and Adapter config files.  It must be
+// loaded with Load() before use.  A nil or empty Config is an unsecured channel.
+type Config struct {
+	CertFile string `json:"certFile"` // PEM certificate - the Adapter server certificate, or the Engine client certificate.
+	KeyFile  string `json:"keyFile"`  // PEM private key for the certificate.
+	CAFile   string `json:"caFile"`   // PEM CA certificate(s) the other side's certificate must be signed by.
+	Secret   string `json:"secret"`   // Shared secret for request signatures.
+
+	cert *tls.Certificate
+	pool *x509.CertPool
+}
+
+// Load reads the certificate files.  The certificate, key and CA must either all be set,
+// or none.
+func (c *Config) Load() error {
+	if c == nil {
+		return nil
+	}
+	set := 0
+	for _, f := range []string{c.CertFile, c.KeyFile, c.CAFile} {
+		if f != "" {
+			set++
+		}
+	}
+	switch set {
+	case 0:
+		return nil
+	case 3:
+	default:
+		return errors.New("rpcAuth needs all of certFile, keyFile and caFile for TLS")
+	}
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return fmt.Errorf("unable to load the rpcAuth certificate - %s", err)
+	}
+	ca, err := ioutil.ReadFile(c.CAFile)
+	if err != nil {
+		return fmt.Errorf("unable to read the rpcAuth CA file - %s", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return fmt.Errorf("no certificates found in the rpcAuth CA file %s", c.CAFile)
+	}
+	c.cert, c.pool = &cert, pool
+	return nil
+}
+
+// TLS returns true if mutual TLS is configured.
+func (c *Config) TLS() bool {
+	return c != nil && c.cert != nil
+}
+
+// Signed returns true if requests are signed with the shared secret.
+func (c *Config) Signed() bool {
+	return c != nil && c.Secret != ""
+}
+
+// String returns the security of the channel, for logging.
+func (c *Config) String() string {
+	var l []string
+	if c.TLS() {
+		l = append(l, "mutual TLS")
+	}
+	if c.Signed() {
+		l = append(l, "HMAC signatures")
+	}
+	if len(l) == 0 {
+		return "none"
+	}
+	return strings.Join(l, ", ")
+}
+
+// ==============================================================================================================================
+//                                      ADAPTER (SERVER)
+// ==============================================================================================================================
+
+// Listener returns l, wrapped in TLS requiring a client certificate signed by the CA.
+func (c *Config) Listener(l net.Listener) net.Listener {
+	if !c.TLS() {
+		return l
+	}
+	return tls.NewListener(l, &tls.Config{
+		Certificates: []tls.Certificate{*c.cert},
+		ClientCAs:    c.pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	})
+}
+
+// Handler returns h, rejecting requests without a valid signature.
+func (c *Config) Handler(h http.Handler) http.Handler {
+	if !c.Signed() {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Body.Close()
+		if err := Verify(c.Secret, r, body, time.Now()); err != nil {
+			log.WithFields(log.Fields{
+				"remote": r.RemoteAddr,
+				"path":   r.URL.Path,
+				"error":  err.Error(),
+			}).Warn("Rejected RPC request")
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		h.ServeHTTP(w, r)
+	})
+}
+
+// ==============================================================================================================================
+//                                      ENGINE (CLIENT)
+// ==============================================================================================================================
+
+// clientTLS returns the TLS config for connecting to an Adapter.
+func (c *Config) clientTLS() *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{*c.cert},
+		RootCAs:      c.pool,
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+// hostPort returns the address, with ":port" as "localhost:port", so it can be checked
+// against the Adapter certificate.
+func hostPort(address string) string {
+	if strings.HasPrefix(address, ":") {
+		return "localhost" + address
+	}
+	return address
+}
+
+// DialHTTP connects to a net/rpc server at address, as rpc.DialHTTP(), using TLS and
+// signing the CONNECT request as configured.
+func (c *Config) DialHTTP(address string) (*rpc.Client, error) {
+	if !c.TLS() && !c.Signed() {
+		return rpc.DialHTTP("tcp", address)
+	}
+	var conn net.Conn
+	var err error
+	if c.TLS() {
+		conn, err = tls.Dial("tcp", hostPort(address), c.clientTLS())
+	} else {
+		conn, err = net.Dial("tcp", address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rqst, _ := http.NewRequest("CONNECT", rpc.DefaultRPCPath, nil)
+	rqst.Host = address
+	if c.Signed() {
+		Sign(c.Secret, rqst, nil, time.Now())
+	}
+	if err := rqst.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), rqst)
+	if err == nil && resp.Status == connected {
+		return rpc.NewClient(conn), nil
+	}
+	if err == nil {
+		err = fmt.Errorf("unexpected HTTP response: %s", resp.Status)
+	}
+	conn.Close()
+	return nil, &net.OpError{Op: "dial-http", Net: "tcp " + address, Err: err}
+}
+
+// JSONRPCClient returns a JSON-RPC client for the Adapter at address, using TLS and
+// signing each request as configured.
+func (c *Config) JSONRPCClient(address string, timeout time.Duration) *jsonrpc.Client {
+	if !c.TLS() && !c.Signed() {
+		return jsonrpc.NewClient(address, timeout)
+	}
+	url := address
+	scheme := "http://"
+	if c.TLS() {
+		scheme = "https://"
+	}
+	if !strings.Contains(address, "://") {
+		url = scheme + hostPort(address) + jsonrpc.Path
+	}
+	t := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	if c.TLS() {
+		t.TLSClientConfig = c.clientTLS()
+	}
+	client := jsonrpc.NewClient(url, timeout)
+	client.SetTransport(&signer{secret: c.Secret, next: t})
+	return client
+}
+
+// signer is an http.RoundTripper signing each request.
+type signer struct {
+	secret string
+	next   *http.Transport
+}
+
+func (s *signer) RoundTrip(r *http.Request) (*http.Response, error) {
+	if s.secret == "" {
+		return s.next.RoundTrip(r)
+	}
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(r.Body); err != nil {
+			return nil, err
+		}
+		r.Body.Close()
+	}
+	// A RoundTripper must not change the request, so sign a copy.
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.Header = make(http.Header, len(r.Header)+1)
+	for k, v := range r.Header {
+		r2.Header[k] = v
+	}
+	r2.Body = ioutil.NopCloser(bytes.NewReader(body))
+	Sign(s.secret, r2, body, time.Now())
+	return s.next.RoundTrip(r2)
+}
+
+// CloseIdleConnections closes the idle connections of the transport.
+func (s *signer) CloseIdleConnections() {
+	s.next.CloseIdleConnections()
+}
+
+// ==============================================================================================================================
+//                                      SIGNATURES
+// ==============================================================================================================================
+
+// signature returns the hex HMAC-SHA256 of the request, keyed by the secret.  The signed
+// text is the unix time, nonce, method, host, path and body, separated by newlines.
+func signature(secret string, ts int64, nonce string, r *http.Request, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	io.WriteString(mac, strconv.FormatInt(ts, 10)+"\n"+nonce+"\n"+r.Method+"\n"+host(r)+"\n"+r.URL.Path+"\n")
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// host returns the host the request is sent to.
+func host(r *http.Request) string {
+	if r.Host != "" {
+		return r.Host
+	}
+	return r.URL.Host
+}
+
+// Sign sets the Header of the request, with a new random nonce.
+func Sign(secret string, r *http.Request, body []byte, now time.Time) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("rpcauth: unable to read random bytes - " + err.Error())
+	}
+	ts, nonce := now.Unix(), hex.EncodeToString(b)
+	r.Header.Set(Header, strconv.FormatInt(ts, 10)+":"+nonce+":"+signature(secret, ts, nonce, r, body))
+}
+
+// Verify checks the Header of the request.  Each nonce is accepted once while its
+// signature is current, so a captured request cannot be replayed.
+func Verify(secret string, r *http.Request, body []byte, now time.Time) error {
+	h := r.Header.Get(Header)
+	if h == "" {
+		return errors.New("the request is not signed")
+	}
+	parts := strings.SplitN(h, ":", 3)
+	if len(parts) != 3 || parts[1] == "" {
+		return errors.New("invalid request signature")
+	}
+	ts, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return errors.New("invalid request signature")
+	}
+	signed := time.Unix(ts, 0)
+	if d := now.Sub(signed); d > maxSkew || d < -maxSkew {
+		return errors.New("the request signature has expired")
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(signature(secret, ts, parts[1], r, body))) {
+		return errors.New("invalid request signature")
+	}
+	if !nonces.add(parts[1], signed.Add(maxSkew), now) {
+		return errors.New("the request signature has already been used")
+	}
+	return nil
+}
+
+// nonceCache is the nonces of the signatures accepted by Verify, until they expire.
+type nonceCache struct {
+	seen  map[string]time.Time // Index: nonce - when the signature expires.
+	swept time.Time
+	sync.Mutex
+}
+
+var nonces = nonceCache{seen: make(map[string]time.Time)}
+
+// add records the nonce until it expires, and returns false if it was already used.  The
+// expired nonces are dropped once a minute.
+func (c *nonceCache) add(nonce string, expires, now time.Time) bool {
+	c.Lock()
+	defer c.Unlock()
+	if now.Sub(c.swept) > time.Minute {
+		for k, t := range c.seen {
+			if now.After(t) {
+				delete(c.seen, k)
+			}
+		}
+		c.swept = now
+	}
+	if _, ok := c.seen[nonce]; ok {
+		return false
+	}
+	c.seen[nonce] = expires
+	return true
+}
@@ -0,0 +1,250 @@
+package rpcauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common/jsonrpc"
+)
+
+// ------------------------------- Certificates -------------------------------
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string
+}
+
+var serial int64
+
+// writePEM writes the certificate and key files, and returns their names.
+func writePEM(t *testing.T, dir, name string, der []byte, key *ecdsa.PrivateKey) (string, string) {
+	certFile, keyFile := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	b, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: b}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func newCA(t *testing.T, dir, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial++
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	file, _ := writePEM(t, dir, name, der, key)
+	return &testCA{cert: cert, key: key, file: file}
+}
+
+// issue returns a Config with a certificate signed by the CA, trusting the trust CA.
+func (ca *testCA) issue(t *testing.T, dir, name string, trust *testCA) *Config {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial++
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile := writePEM(t, dir, name, der, key)
+	c := &Config{CertFile: certFile, KeyFile: keyFile, CAFile: trust.file}
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// ------------------------------- Adapter -------------------------------
+
+type Args struct{ A, B int }
+
+type Arith struct{}
+
+func (Arith) Add(args *Args, reply *int) error {
+	*reply = args.A + args.B
+	return nil
+}
+
+// serve starts an RPC server, with net/rpc and a JSON-RPC "Arith.Add", secured by c.
+func serve(t *testing.T, c *Config) (string, func()) {
+	srv := rpc.NewServer()
+	if err := srv.Register(Arith{}); err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle(rpc.DefaultRPCPath, srv)
+	mux.Handle(jsonrpc.Path, jsonrpc.Handler(func(method string, params json.RawMessage) (interface{}, *jsonrpc.Error) {
+		var args Args
+		if err := json.Unmarshal(params, &args); err != nil {
+			return nil, &jsonrpc.Error{Code: jsonrpc.CodeInvalidParams, Message: err.Error()}
+		}
+		return args.A + args.B, nil
+	}))
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go http.Serve(c.Listener(l), c.Handler(mux))
+	return l.Addr().String(), func() { l.Close() }
+}
+
+// check calls Arith.Add over net/rpc and JSON-RPC, and returns the errors.
+func check(c *Config, addr string) (rpcErr, jsonErr error) {
+	var sum int
+	client, err := c.DialHTTP(addr)
+	if err == nil {
+		err = client.Call("Arith.Add", &Args{2, 3}, &sum)
+		client.Close()
+	}
+	if err == nil && sum != 5 {
+		err = rpc.ServerError("wrong sum")
+	}
+	rpcErr = err
+
+	sum = 0
+	jc := c.JSONRPCClient(addr, time.Second*5)
+	defer jc.Close()
+	if err = jc.Call("Arith.Add", &Args{2, 3}, &sum); err == nil && sum != 5 {
+		err = rpc.ServerError("wrong sum")
+	}
+	return rpcErr, err
+}
+
+// ------------------------------- Tests -------------------------------
+
+func TestTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpcauth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca := newCA(t, dir, "ca")
+	rogue := newCA(t, dir, "rogue")
+	adapter := ca.issue(t, dir, "adapter", ca)
+	engine := ca.issue(t, dir, "engine", ca)
+	stranger := rogue.issue(t, dir, "stranger", ca)
+
+	addr, done := serve(t, adapter)
+	defer done()
+
+	if rpcErr, jsonErr := check(engine, addr); rpcErr != nil || jsonErr != nil {
+		t.Errorf("expected the engine to connect, got: %v, %v", rpcErr, jsonErr)
+	}
+	if rpcErr, jsonErr := check(stranger, addr); rpcErr == nil || jsonErr == nil {
+		t.Errorf("expected a certificate from another CA to be rejected, got: %v, %v", rpcErr, jsonErr)
+	}
+	if rpcErr, jsonErr := check(nil, addr); rpcErr == nil || jsonErr == nil {
+		t.Errorf("expected a plain connection to be rejected, got: %v, %v", rpcErr, jsonErr)
+	}
+
+	// The engine checks the adapter certificate.
+	addr2, done2 := serve(t, rogue.issue(t, dir, "impostor", ca))
+	defer done2()
+	if rpcErr, jsonErr := check(engine, addr2); rpcErr == nil || jsonErr == nil {
+		t.Errorf("expected an adapter certificate from another CA to be rejected, got: %v, %v", rpcErr, jsonErr)
+	}
+}
+
+func TestSecret(t *testing.T) {
+	addr, done := serve(t, &Config{Secret: "open sesame"})
+	defer done()
+
+	if rpcErr, jsonErr := check(&Config{Secret: "open sesame"}, addr); rpcErr != nil || jsonErr != nil {
+		t.Errorf("expected signed requests to succeed, got: %v, %v", rpcErr, jsonErr)
+	}
+	if rpcErr, jsonErr := check(&Config{Secret: "guess"}, addr); rpcErr == nil || jsonErr == nil {
+		t.Errorf("expected the wrong secret to be rejected, got: %v, %v", rpcErr, jsonErr)
+	}
+	if rpcErr, jsonErr := check(&Config{}, addr); rpcErr == nil || jsonErr == nil {
+		t.Errorf("expected unsigned requests to be rejected, got: %v, %v", rpcErr, jsonErr)
+	}
+
+	r, _ := http.NewRequest("POST", "http://localhost/jsonrpc", nil)
+	now := time.Now()
+	Sign("s", r, []byte("body"), now)
+	r2, _ := http.NewRequest("POST", "http://other/jsonrpc", nil)
+	r2.Header.Set(Header, r.Header.Get(Header))
+	if err := Verify("s", r2, []byte("body"), now); err == nil {
+		t.Errorf("expected a signature for another host to be rejected")
+	}
+	if err := Verify("s", r, []byte("body"), now.Add(time.Minute)); err != nil {
+		t.Errorf("expected a valid signature, got: %v", err)
+	}
+	if err := Verify("s", r, []byte("body"), now.Add(time.Minute)); err == nil {
+		t.Errorf("expected a replayed signature to be rejected")
+	}
+	Sign("s", r, []byte("body"), now)
+	if err := Verify("s", r, []byte("body"), now); err != nil {
+		t.Errorf("expected a new nonce to be accepted, got: %v", err)
+	}
+	if err := Verify("s", r, []byte("changed"), now); err == nil {
+		t.Errorf("expected a changed body to be rejected")
+	}
+	if err := Verify("s", r, []byte("body"), now.Add(time.Hour)); err == nil {
+		t.Errorf("expected a stale signature to be rejected")
+	}
+	r.Header.Set(Header, "garbage")
+	if err := Verify("s", r, []byte("body"), now); err == nil {
+		t.Errorf("expected an invalid signature to be rejected")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	var c *Config
+	if err := c.Load(); err != nil || c.TLS() || c.Signed() || c.String() != "none" {
+		t.Errorf("a nil Config should be unsecured")
+	}
+	if err := (&Config{CertFile: "a.crt", KeyFile: "a.key"}).Load(); err == nil {
+		t.Errorf("expected an error for a missing caFile")
+	}
+	if err := (&Config{CertFile: "none.crt", KeyFile: "none.key", CAFile: "none.crt"}).Load(); err == nil {
+		t.Errorf("expected an error for missing files")
+	}
+}
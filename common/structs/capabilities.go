@@ -0,0 +1,118 @@
+package structs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codeforsanjose/open311-gateway/common"
+)
+
+// =======================================================================================
+//                                      CAPABILITIES
+// =======================================================================================
+
+// NCapabilitiesRequest requests the capabilities of an Adapter ("Adapter.Capabilities").
+type NCapabilitiesRequest struct {
+	NRequestCommon
+}
+
+// NCapabilitiesResponse lists the capabilities of an Adapter.
+type NCapabilitiesResponse struct {
+	NResponseCommon
+	AdpID        string
+	Capabilities NCapabilities
+}
+
+// NCapabilities describes the requests an Adapter can handle.
+type NCapabilities struct {
+	RequestTypes []NRequestType // Supported Report requests.  Services requests are always supported.
+	MaxRadius    int            // Meters - the maximum Search radius.  0 is unlimited.
+	Media        bool           // Accepts a MediaURL on Create.
+	Anonymous    bool           // Accepts anonymous reports.
+	Votes        bool           // Reports include vote counts.
+	Required     []string       // NCreateRequest fields required on Create, e.g. "Email".
+}
+
+// Supports returns true if the request type is supported.
+func (c NCapabilities) Supports(rtype NRequestType) bool {
+	switch rtype {
+	case NRTServicesAll, NRTServicesArea, NRTCapabilities:
+		return true
+	}
+	for _, t := range c.RequestTypes {
+		if t == rtype {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckCreate verifies the Create request can be honored.
+func (c NCapabilities) CheckCreate(r *NCreateRequest) error {
+	if !c.Supports(NRTCreate) {
+		return fmt.Errorf("the provider does not accept new reports")
+	}
+	if r.MediaURL != "" && !c.Media {
+		return fmt.Errorf("the provider does not accept media")
+	}
+	if r.IsAnonymous && !c.Anonymous {
+		return fmt.Errorf("the provider does not accept anonymous reports")
+	}
+	var missing []string
+	for _, f := range c.Required {
+		if r.field(f) == "" {
+			missing = append(missing, f)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("the provider requires: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// field returns the value of an NCreateRequest field, for CheckCreate.
+func (r *NCreateRequest) field(name string) string {
+	switch name {
+	case "DeviceType":
+		return r.DeviceType
+	case "DeviceID":
+		return r.DeviceID
+	case "FullAddress":
+		return r.FullAddress
+	case "Address":
+		return r.Address
+	case "Area":
+		return r.Area
+	case "State":
+		return r.State
+	case "Zip":
+		return r.Zip
+	case "FirstName":
+		return r.FirstName
+	case "LastName":
+		return r.LastName
+	case "Email":
+		return r.Email
+	case "Phone":
+		return r.Phone
+	case "Description":
+		return r.Description
+	case "MediaURL":
+		return r.MediaURL
+	}
+	return r.Attributes[name]
+}
+
+// =======================================================================================
+//                                      STRINGS
+// =======================================================================================
+
+// String returns a representation of the NCapabilities custom type.
+func (c NCapabilities) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddS("Capabilities\n")
+	ls.AddF("Request types: %v\n", c.RequestTypes)
+	ls.AddF("MaxRadius: %d  Media: %t  Anonymous: %t  Votes: %t\n", c.MaxRadius, c.Media, c.Anonymous, c.Votes)
+	ls.AddF("Required: %v\n", c.Required)
+	return ls.Box(80)
+}
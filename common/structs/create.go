@@ -29,6 +29,7 @@ type NCreateRequest struct {
 	IsAnonymous bool
 	Description string
 	MediaURL    string
+	Attributes  map[string]string // Service attribute values, by attribute code
 }
 
 // GetRoutes returns the routing data.
@@ -38,9 +39,9 @@ func (r NCreateRequest) GetRoutes() NRoutes {
 
 // NCreateResponse is the response to creating or updating a report.
 type NCreateResponse struct {
-	NResponseCommon `json:"-"`
-	Message         string   `json:"Message" xml:"Message"`
-	RID             ReportID `json:"ReportId" xml:"ReportId"`
+	NResponseCommon
+	Message string   `json:"Message" xml:"Message"`
+	RID     ReportID `json:"ReportId" xml:"ReportId"`
 	// ID              string `json:"ReportId" xml:"ReportId"`
 	AccountID string `json:"AuthorId" xml:"AuthorId"`
 }
@@ -61,6 +62,9 @@ func (r NCreateRequest) String() string {
 	ls.AddF("          %s\n", r.Address)
 	ls.AddF("          %s, %s   %s\n", r.Area, r.State, r.Zip)
 	ls.AddF("Description: %q\n", r.Description)
+	if len(r.Attributes) > 0 {
+		ls.AddF("Attributes: %v\n", r.Attributes)
+	}
 	ls.AddF("Author(anon: %t) %s %s  Email: %s  Tel: %s\n", r.IsAnonymous, r.FirstName, r.LastName, r.Email, r.Phone)
 	return ls.Box(80)
 }
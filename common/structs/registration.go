@@ -0,0 +1,88 @@
+package structs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/codeforsanjose/open311-gateway/common"
+)
+
+// =======================================================================================
+//                                      REGISTRATION
+// =======================================================================================
+
+// NRegisterRequest registers an Adapter with the Engine, or renews its lease.  It is
+// posted to the Engine "/v1/adapters.json" endpoint by the Adapter.
+type NRegisterRequest struct {
+	AdpID        string          `json:"id"`
+	Type         string          `json:"type"`
+	Address      string          `json:"address"`   // The RPC address the Engine connects to.
+	Transport    string          `json:"transport"` // "rpc" (the default) or "jsonrpc".
+	Areas        []NRegisterArea `json:"areas"`
+	Capabilities NCapabilities   `json:"capabilities"`
+	Lease        int             `json:"lease"` // Seconds - the requested lease.  0 is the Engine default.
+}
+
+// NRegisterArea is a Service Area handled by a registering Adapter.
+type NRegisterArea struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+}
+
+// NRegisterResponse is the Engine response to an NRegisterRequest.
+type NRegisterResponse struct {
+	AdpID   string `json:"id"`
+	Lease   int    `json:"lease"` // Seconds - the registration expires unless renewed within the lease.
+	Message string `json:"message"`
+}
+
+// Validate checks the required fields of the registration.
+func (r *NRegisterRequest) Validate() error {
+	var missing []string
+	if r.AdpID == "" {
+		missing = append(missing, "id")
+	}
+	if r.Address == "" {
+		missing = append(missing, "address")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("invalid registration - missing: %s", strings.Join(missing, ", "))
+	}
+	switch strings.ToLower(r.Transport) {
+	case "", "rpc", "jsonrpc":
+	default:
+		return fmt.Errorf("invalid registration - transport %q must be \"rpc\" or \"jsonrpc\"", r.Transport)
+	}
+	for _, a := range r.Areas {
+		if a.ID == "" {
+			return fmt.Errorf("invalid registration - an area of %q has no id", r.AdpID)
+		}
+	}
+	return nil
+}
+
+// AreaIDs returns the IDs of the registered Areas.
+func (r *NRegisterRequest) AreaIDs() []string {
+	ids := make([]string, 0, len(r.Areas))
+	for _, a := range r.Areas {
+		ids = append(ids, a.ID)
+	}
+	return ids
+}
+
+// =======================================================================================
+//                                      STRINGS
+// =======================================================================================
+
+// String returns a representation of the NRegisterRequest custom type.
+func (r NRegisterRequest) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddF("NRegisterRequest - %s\n", r.AdpID)
+	ls.AddF("Type: %s  Address: %s  Transport: %s  Lease: %ds\n", r.Type, r.Address, r.Transport, r.Lease)
+	for _, a := range r.Areas {
+		ls.AddF("Area: %s (%s)  aliases: %v\n", a.Name, a.ID, a.Aliases)
+	}
+	ls.AddS(r.Capabilities.String())
+	return ls.Box(90)
+}
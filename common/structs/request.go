@@ -1,7 +1,9 @@
 package structs
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/codeforsanjose/open311-gateway/common"
 )
@@ -40,13 +42,15 @@ func (r NID) String() string {
 //                                      REQUEST
 // =======================================================================================
 
-// NRequestCommon represents properties common to all requests.
+// NRequestCommon represents properties common to all requests.  The Deadline is when the
+// Engine stops waiting for the response - see Context().
 type NRequestCommon struct {
-	ID    NID
-	Route NRoute
-	Rtype NRequestType
-	NRouter
-	NRequester
+	ID         NID
+	Route      NRoute
+	Rtype      NRequestType
+	Deadline   time.Time
+	NRouter    `json:"-"`
+	NRequester `json:"-"`
 }
 
 // GetID returns the Request ID
@@ -85,6 +89,21 @@ func (r *NRequestCommon) SetRoute(route NRoute) {
 	r.Route = route
 }
 
+// SetDeadline sets NRequestCommon.Deadline.
+func (r *NRequestCommon) SetDeadline(t time.Time) {
+	r.Deadline = t
+}
+
+// Context returns a context that is done at the Deadline, for the upstream calls made by
+// an Adapter, so abandoned requests do not run to completion.  It has no deadline if the
+// Deadline is not set.  The cancel function must be called when the request is done.
+func (r NRequestCommon) Context() (context.Context, context.CancelFunc) {
+	if r.Deadline.IsZero() {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithDeadline(context.Background(), r.Deadline)
+}
+
 // -----------------------------------NRequester --------------------------------------
 
 // NRequester defines the behavior of a Request Package.
@@ -94,6 +113,7 @@ type NRequester interface {
 	SetID(int64, int64)
 	GetRoute() NRoute
 	SetRoute(route NRoute)
+	SetDeadline(t time.Time)
 	RouteType() NRouteType
 	GetType() NRequestType
 	GetTypeS() string
@@ -115,6 +135,7 @@ const (
 	NRTSearchLL
 	NRTSearchDID
 	NRTSearchRID
+	NRTCapabilities
 )
 
 // =======================================================================================
@@ -12,10 +12,10 @@ import (
 
 // NResponseCommon represents properties common to all requests.
 type NResponseCommon struct {
-	ID    NID
-	Route NRoute
-	Rtype NResponseType
-	NResponser
+	ID         NID
+	Route      NRoute
+	Rtype      NResponseType
+	NResponser `json:"-"`
 }
 
 // GetID returns the Request ID
@@ -36,7 +36,16 @@ func (s *ReportID) UnmarshalJSON(value []byte) error {
 		y, _ := strconv.ParseInt(x, 10, 64)
 		return int(y)
 	}
-	parts := strings.Split(strings.Trim(string(value), "\" "), "-")
+	rid := strings.Trim(string(value), "\" ")
+	if rid == "" || rid == "null" {
+		*s = ReportID{}
+		return nil
+	}
+	// The report ID is the remainder, as it may contain dashes.
+	parts := strings.SplitN(rid, "-", 4)
+	if len(parts) != 4 {
+		return fmt.Errorf(emInvalidRid, string(value))
+	}
 	// log.Debug("[UnmarshalJSON] parts: %+v\n", parts)
 	s.AdpID = parts[0]
 	s.AreaID = parts[1]
@@ -57,19 +66,24 @@ func RIDFromString(rids string) (ReportID, NRoute, error) {
 	if rids == "" {
 		return ReportID{}, NRoute{}, fmt.Errorf("empty RID: %q", rids)
 	}
-	adpID, areaID, providerID, reportID, err := SplitRID(rids)
+	// The report ID is not necessarily numeric, and may contain dashes.
+	parts := strings.SplitN(rids, "-", 4)
+	if len(parts) != 4 || parts[3] == "" {
+		return ReportID{}, NRoute{}, fmt.Errorf(emInvalidRid, rids)
+	}
+	providerID, err := strconv.Atoi(parts[2])
 	if err != nil {
 		return ReportID{}, NRoute{}, fmt.Errorf(emInvalidRid, rids)
 	}
 	nr := NRoute{
-		AdpID:      adpID,
-		AreaID:     areaID,
+		AdpID:      parts[0],
+		AreaID:     parts[1],
 		ProviderID: providerID,
 	}
 
 	return ReportID{
 		NRoute: nr,
-		ID:     fmt.Sprintf("%v", reportID),
+		ID:     parts[3],
 	}, nr, nil
 }
 
@@ -63,11 +63,11 @@ func (r NSearchRequestRID) GetRoutes() NRoutes {
 
 // NSearchResponse contains the search results.
 type NSearchResponse struct {
-	NResponseCommon `json:"-"`
-	Message         string
-	ReportCount     int
-	ResponseTime    string
-	Reports         []NSearchResponseReport
+	NResponseCommon
+	Message      string
+	ReportCount  int
+	ResponseTime string
+	Reports      []NSearchResponseReport
 }
 
 // NSearchResponseReport represents a report.
@@ -56,13 +56,16 @@ type NService struct {
 	Group         string   `json:"group"`
 }
 
-// UnmarshalJSON implements the conversion from the JSON "ID" to the ServiceID struct.
+// UnmarshalJSON implements the conversion from the JSON "id" to the ServiceID struct.  The
+// "id" is either the Service ID (in the Adapter config files), or the full MID (from
+// MarshalJSON).
 func (srv *NService) UnmarshalJSON(value []byte) error {
 	type T struct {
-		ID            int
+		ID            json.RawMessage
 		Name          string
 		Description   string
 		Metadata      bool
+		ResponseType  string
 		Group         string
 		Keywords      []string
 		ServiceNotice string `json:"service_notice"`
@@ -72,16 +75,51 @@ func (srv *NService) UnmarshalJSON(value []byte) error {
 	if err != nil {
 		return err
 	}
-	srv.ID = t.ID
+	srv.ServiceID = ServiceID{}
+	if id := strings.TrimSpace(string(t.ID)); id != "" && id != "null" {
+		if strings.HasPrefix(id, "\"") {
+			if err := srv.ServiceID.UnmarshalJSON(t.ID); err != nil {
+				return err
+			}
+		} else if err := json.Unmarshal(t.ID, &srv.ID); err != nil {
+			return fmt.Errorf("invalid service id: %s", id)
+		}
+	}
 	srv.Name = t.Name
 	srv.Description = t.Description
 	srv.Metadata = t.Metadata
+	srv.ResponseType = t.ResponseType
 	srv.ServiceNotice = t.ServiceNotice
 	srv.Keywords = t.Keywords
 	srv.Group = t.Group
 	return nil
 }
 
+// MarshalJSON converts the NService to JSON, with the full MID as the "id".  Without it,
+// the ServiceID MarshalJSON would be used, and only the MID would be sent.
+func (srv NService) MarshalJSON() ([]byte, error) {
+	type T struct {
+		ID            ServiceID `json:"id"`
+		Name          string    `json:"name"`
+		Description   string    `json:"description"`
+		Metadata      bool      `json:"metadata"`
+		ResponseType  string    `json:"responseType"`
+		ServiceNotice string    `json:"service_notice"`
+		Keywords      []string  `json:"keywords"`
+		Group         string    `json:"group"`
+	}
+	return json.Marshal(T{
+		ID:            srv.ServiceID,
+		Name:          srv.Name,
+		Description:   srv.Description,
+		Metadata:      srv.Metadata,
+		ResponseType:  srv.ResponseType,
+		ServiceNotice: srv.ServiceNotice,
+		Keywords:      srv.Keywords,
+		Group:         srv.Group,
+	})
+}
+
 // ------------------------------- ServiceID -------------------------------
 
 // ServiceID provides the JSON marshalling conversion between the JSON "ID" and
@@ -108,7 +146,15 @@ func (s *ServiceID) UnmarshalJSON(value []byte) error {
 		y, _ := strconv.ParseInt(x, 10, 64)
 		return int(y)
 	}
-	parts := strings.Split(strings.Trim(string(value), "\" "), "-")
+	mid := strings.Trim(string(value), "\" ")
+	if mid == "" || mid == "null" {
+		*s = ServiceID{}
+		return nil
+	}
+	parts := strings.Split(mid, "-")
+	if len(parts) != 4 {
+		return fmt.Errorf("invalid ServiceID: %q", mid)
+	}
 	// log.Debug("[UnmarshalJSON] parts: %+v\n", parts)
 	s.AdpID = parts[0]
 	s.AreaID = parts[1]
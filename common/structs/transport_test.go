@@ -0,0 +1,97 @@
+package structs
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+var (
+	testRoute = NRoute{AdpID: "SIM1", AreaID: "SJ", ProviderID: 1}
+	testRqst  = NRequestCommon{ID: NID{RqstID: 10, RPCID: 20}, Route: testRoute, Rtype: NRTCreate}
+	testResp  = NResponseCommon{ID: NID{RqstID: 10, RPCID: 20}, Route: testRoute, Rtype: NRspTCreate}
+	testRID   = ReportID{NRoute: testRoute, ID: "1234-56"}
+)
+
+// transportValues are the RPC requests and responses, fully populated, and empty.
+func transportValues() []interface{} {
+	return []interface{}{
+		&NServiceRequest{NRequestCommon: testRqst, Area: "San Jose"},
+		&NServicesResponse{NResponseCommon: testResp, AdpID: "SIM1", Message: "OK", Services: NServices{
+			{ServiceID: ServiceID{AdpID: "SIM1", AreaID: "SJ", ProviderID: 1, ID: 7}, Name: "Pothole", Description: "A hole",
+				Metadata: true, ResponseType: "realtime", ServiceNotice: "2 days", Keywords: []string{"street", "road"}, Group: "Street"},
+		}},
+		&NCreateRequest{NRequestCommon: testRqst, MID: ServiceID{AdpID: "SIM1", AreaID: "SJ", ProviderID: 1, ID: 7},
+			ServiceName: "Pothole", DeviceType: "IPhone", DeviceModel: "7", DeviceID: "D1", Latitude: 37.3395, Longitude: -121.886329,
+			FullAddress: "200 E Santa Clara St, San Jose, CA 95113", Address: "200 E Santa Clara St", Area: "San Jose", State: "CA",
+			Zip: "95113", FirstName: "Jane", LastName: "Doe", Email: "jane@example.com", Phone: "408-555-1212", IsAnonymous: true,
+			Description: "Large pothole", MediaURL: "http://img.example.com/1.jpg", Attributes: map[string]string{"size": "large"}},
+		&NCreateResponse{NResponseCommon: testResp, Message: "OK", RID: testRID, AccountID: "A1"},
+		&NSearchRequestLL{NRequestCommon: testRqst, Latitude: 37.3395, Longitude: -121.886329, Radius: 200, AreaID: "SJ", MaxResults: 20},
+		&NSearchRequestDID{NRequestCommon: testRqst, DeviceType: "IPhone", DeviceID: "D1", MaxResults: 20, RouteList: NRoutes{testRoute}, AreaID: "SJ"},
+		&NSearchRequestRID{NRequestCommon: testRqst, RID: testRID, RouteList: NRoutes{testRoute}, AreaID: "SJ"},
+		&NSearchResponse{NResponseCommon: testResp, Message: "OK", ReportCount: 1, ResponseTime: "10ms", Reports: []NSearchResponseReport{
+			{RID: testRID, DateCreated: "2016-01-02", DeviceID: "D1", RequestType: "Pothole", RequestTypeID: "7", Latitude: "37.3395",
+				Longitude: "-121.886329", Description: "Large pothole", AuthorIsAnonymous: "true", Votes: "3", StatusType: "Open"},
+		}},
+		&NCapabilitiesRequest{NRequestCommon: testRqst},
+		&NCapabilitiesResponse{NResponseCommon: testResp, AdpID: "SIM1", Capabilities: NCapabilities{
+			RequestTypes: []NRequestType{NRTCreate, NRTSearchLL}, MaxRadius: 500, Media: true, Votes: true, Required: []string{"Email"}}},
+
+		&NServiceRequest{}, &NServicesResponse{}, &NCreateRequest{}, &NCreateResponse{}, &NSearchRequestLL{},
+		&NSearchRequestDID{}, &NSearchRequestRID{}, &NSearchResponse{}, &NCapabilitiesRequest{}, &NCapabilitiesResponse{},
+	}
+}
+
+// TestTransportRoundTrip verifies the RPC types are decoded identically from gob (net/rpc)
+// and JSON (JSON-RPC).
+func TestTransportRoundTrip(t *testing.T) {
+	for _, v := range transportValues() {
+		typ := reflect.TypeOf(v).Elem()
+
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+			t.Fatalf("%v: gob encode - %v", typ, err)
+		}
+		fromGob := reflect.New(typ).Interface()
+		if err := gob.NewDecoder(&buf).Decode(fromGob); err != nil {
+			t.Fatalf("%v: gob decode - %v", typ, err)
+		}
+
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("%v: json encode - %v", typ, err)
+		}
+		fromJSON := reflect.New(typ).Interface()
+		if err := json.Unmarshal(b, fromJSON); err != nil {
+			t.Fatalf("%v: json decode - %v\n%s", typ, err, b)
+		}
+
+		if !reflect.DeepEqual(fromGob, fromJSON) {
+			t.Errorf("%v: gob and JSON differ:\ngob:  %+v\njson: %+v\n%s", typ, fromGob, fromJSON, b)
+		}
+		if !reflect.DeepEqual(v, fromJSON) {
+			t.Errorf("%v: JSON did not round trip:\nsent: %+v\ngot:  %+v\n%s", typ, v, fromJSON, b)
+		}
+	}
+}
+
+func TestServiceJSON(t *testing.T) {
+	// Services in the Adapter config files only have the Service ID.
+	var srv NService
+	if err := json.Unmarshal([]byte(`{"id": 3, "name": "Graffiti", "responseType": "batch", "group": "Graffiti"}`), &srv); err != nil {
+		t.Fatal(err)
+	}
+	if srv.ID != 3 || srv.AdpID != "" || srv.ResponseType != "batch" || srv.Group != "Graffiti" {
+		t.Errorf("unexpected service: %+v", srv)
+	}
+	if err := json.Unmarshal([]byte(`{"id": "SIM1-SJ", "name": "Graffiti"}`), &srv); err == nil {
+		t.Errorf("expected an invalid MID error")
+	}
+	var rid ReportID
+	if err := json.Unmarshal([]byte(`"SIM1-SJ-1"`), &rid); err == nil {
+		t.Errorf("expected an invalid RID error")
+	}
+}
@@ -16,6 +16,8 @@ const (
 	MsgTypeES   = "ES"   // Engine Status
 	MsgTypeER   = "ER"   // Engine Request
 	MsgTypeERPC = "ERPC" // Engine RPC
+	MsgTypeEC   = "EC"   // Engine Cache
+	MsgTypeESC  = "ESC"  // Engine Service Change
 
 	MsgTypeAS   = "AS"   // Adapter Status
 	MsgTypeARPC = "ARPC" // Adapter RPC
@@ -39,6 +41,8 @@ func initMsgKeys() {
 	msgKeys[MsgTypeES] = esName
 	msgKeys[MsgTypeER] = erID
 	msgKeys[MsgTypeERPC] = erpcID
+	msgKeys[MsgTypeEC] = ecName
+	msgKeys[MsgTypeESC] = escMID
 	msgKeys[MsgTypeAS] = asName
 	msgKeys[MsgTypeARPC] = arpcID
 }
@@ -48,6 +52,8 @@ func initMsgLen() {
 	msgLen[MsgTypeES] = esLength
 	msgLen[MsgTypeER] = erLength
 	msgLen[MsgTypeERPC] = erpcLength
+	msgLen[MsgTypeEC] = ecLength
+	msgLen[MsgTypeESC] = escLength
 	msgLen[MsgTypeAS] = asLength
 	msgLen[MsgTypeARPC] = arpcLength
 }
@@ -244,6 +250,120 @@ func (r EngRPCMsgType) Marshal() ([]byte, error) {
 	return []byte(fmt.Sprintf("%s%s%s%s%s%s%s%s%s", MsgTypeERPC, msgDelimiter, r.ID, msgDelimiter, r.Status, msgDelimiter, r.Route, msgDelimiter, r.At.Format(time.RFC3339Nano))), nil
 }
 
+// -------------------------------------------- EngCacheMsgType --------------------------------------------------------------------
+
+// EngCacheMsgType represents the Engine Cache statistics messages.
+type EngCacheMsgType struct {
+	Name      string
+	Hits      int64
+	Misses    int64
+	Size      int64
+	Evictions int64
+	At        time.Time
+}
+
+const (
+	ecName int = 1 + iota
+	ecHits
+	ecMisses
+	ecSize
+	ecEvictions
+	ecAt
+	ecLength
+)
+
+// UnmarshalEngCacheMsg converts a Raw Message to an EngCacheMsgType instance
+func UnmarshalEngCacheMsg(m Message) (*EngCacheMsgType, error) {
+	if m.mType != MsgTypeEC {
+		return &EngCacheMsgType{}, fmt.Errorf("invalid message type: %q sent to EngineCache - message: %v", m.mType, m)
+	}
+	if !m.valid() {
+		return &EngCacheMsgType{}, fmt.Errorf("invalid message: %#v", m)
+	}
+
+	s := EngCacheMsgType{
+		Name: m.data[ecName],
+	}
+	for _, f := range []struct {
+		i int
+		v *int64
+	}{{ecHits, &s.Hits}, {ecMisses, &s.Misses}, {ecSize, &s.Size}, {ecEvictions, &s.Evictions}} {
+		if n, err := strconv.ParseInt(m.data[f.i], 10, 64); err == nil {
+			*f.v = n
+		}
+	}
+	if at, err := time.Parse(time.RFC3339Nano, m.data[ecAt]); err == nil {
+		s.At = at
+	} else {
+		s.At = time.Now()
+	}
+	return &s, nil
+}
+
+// HitRate returns the percentage of lookups that were cache hits.
+func (r EngCacheMsgType) HitRate() float64 {
+	if r.Hits+r.Misses == 0 {
+		return 0
+	}
+	return 100 * float64(r.Hits) / float64(r.Hits+r.Misses)
+}
+
+// Marshal converts a EngCacheMsgType to a Raw Message.
+func (r EngCacheMsgType) Marshal() ([]byte, error) {
+	return []byte(fmt.Sprintf("%s%s%s%s%d%s%d%s%d%s%d%s%s", MsgTypeEC, msgDelimiter, r.Name, msgDelimiter, r.Hits, msgDelimiter, r.Misses, msgDelimiter, r.Size, msgDelimiter, r.Evictions, msgDelimiter, r.At.Format(time.RFC3339Nano))), nil
+}
+
+// -------------------------------------------- EngServiceChangeMsgType --------------------------------------------------------------------
+
+// EngServiceChangeMsgType represents the Engine Service change messages, sent when a Services
+// refresh adds, removes, renames or changes a Service.
+type EngServiceChangeMsgType struct {
+	MID    string
+	AreaID string
+	Change string
+	Name   string
+	At     time.Time
+}
+
+const (
+	escMID int = 1 + iota
+	escAreaID
+	escChange
+	escName
+	escAt
+	escLength
+)
+
+// UnmarshalEngServiceChangeMsg converts a Raw Message to an EngServiceChangeMsgType instance
+func UnmarshalEngServiceChangeMsg(m Message) (*EngServiceChangeMsgType, error) {
+	if m.mType != MsgTypeESC {
+		return &EngServiceChangeMsgType{}, fmt.Errorf("invalid message type: %q sent to EngineServiceChange - message: %v", m.mType, m)
+	}
+	if !m.valid() {
+		return &EngServiceChangeMsgType{}, fmt.Errorf("invalid message: %#v", m)
+	}
+
+	s := EngServiceChangeMsgType{
+		MID:    m.data[escMID],
+		AreaID: m.data[escAreaID],
+		Change: m.data[escChange],
+		Name:   m.data[escName],
+	}
+	if at, err := time.Parse(time.RFC3339Nano, m.data[escAt]); err == nil {
+		s.At = at
+	} else {
+		s.At = time.Now()
+	}
+	return &s, nil
+}
+
+// Marshal converts a EngServiceChangeMsgType to a Raw Message.  The delimiter is removed from
+// the Name.
+func (r EngServiceChangeMsgType) Marshal() ([]byte, error) {
+	name := strings.Replace(r.Name, msgDelimiter, " ", -1)
+	return []byte(fmt.Sprintf("%s%s%s%s%s%s%s%s%s%s%s", MsgTypeESC, msgDelimiter, r.MID, msgDelimiter, r.AreaID, msgDelimiter, r.Change, msgDelimiter, name, msgDelimiter, r.At.Format(time.RFC3339Nano))), nil
+}
+
 // -------------------------------------------- AdpStatusMsgType --------------------------------------------------------------------
 
 // AdpStatusMsgType represents the Engine Status messages.
@@ -1,11 +1,13 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/codeforsanjose/open311-gateway/engine/request"
@@ -20,6 +22,11 @@ import (
 var (
 	configFile string
 
+	// server is the REST API server.  It is shut down by stop().
+	server *http.Server
+	// stopped is closed when the shutdown is complete.
+	stopped = make(chan struct{})
+
 	// Debug switches on some debugging statements.
 	Debug = false
 )
@@ -43,8 +50,13 @@ func main() {
 
 	restrouter, err := rest.MakeRouter(
 		rest.Get("/v1/services.json", request.Services),
+		rest.Get("/v1/services/changes.json", request.ServiceChanges),
 		rest.Post("/v1/requests.json", request.Create),
 		rest.Get("/v1/requests.json", request.Search),
+		rest.Post("/v1/adapters.json", request.Register),
+		rest.Delete("/v1/adapters/:id.json", request.Deregister),
+		rest.Post("/v1/admin/reload.json", request.ReloadConfig),
+		rest.Post("/v1/admin/refresh.json", request.RefreshServices),
 	)
 	if err != nil {
 		log.Fatal(err)
@@ -52,14 +64,19 @@ func main() {
 	api.SetApp(restrouter)
 
 	addr, prot, cert, key := router.GetNetworkConfig()
+	server = &http.Server{Addr: addr, Handler: api.MakeHandler()}
 	switch prot {
 	case "http":
-		log.Fatal(http.ListenAndServe(addr, api.MakeHandler()))
+		err = server.ListenAndServe()
 	case "https":
-		log.Fatal(http.ListenAndServeTLS(addr, cert, key, api.MakeHandler()))
+		err = server.ListenAndServeTLS(cert, key)
 	default:
 		log.Fatalf("Invalid network protocol: %s specified in config.", prot)
 	}
+	if err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+	<-stopped
 }
 
 func init() {
@@ -71,6 +88,7 @@ func init() {
 
 	fmt.Printf("Debug: %v  Config: %v\n", Debug, configFile)
 
+	registerInproc()
 	if err := router.Init(configFile); err != nil {
 		log.Fatal("Unable to start - data initilization failed.\n")
 	}
@@ -85,25 +103,44 @@ func init() {
 	fmt.Println("Press Ctrl-C to shutdown...")
 
 	time.Sleep(time.Second * 2)
-	services.Refresh()
+	services.Init()
 }
 
 func signalHandler(c chan os.Signal) {
-	signal.Notify(c, os.Interrupt)
-	for s := <-c; ; s = <-c {
-		switch s {
-		case os.Interrupt:
-			fmt.Println("Ctrl-C Received!")
-			stop()
-			os.Exit(0)
-		case os.Kill:
-			fmt.Println("SIGKILL Received!")
-			stop()
-			os.Exit(1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	for s := range c {
+		if s == syscall.SIGHUP {
+			fmt.Println("SIGHUP Received - reloading the config file...")
+			if _, err := request.Reload(); err != nil {
+				log.Errorf("Config reload failed - %s", err)
+			}
+			continue
+		}
+		fmt.Printf("%v Received - shutting down...\n", s)
+		if err := stop(); err != nil {
+			log.Errorf("Shutdown failed - %s", err)
 		}
+		close(stopped)
+		return
 	}
 }
 
+// stop stops accepting requests, and waits up to "network.shutdownGrace" for the requests
+// in progress (and their Adapter calls) to finish.  It then closes the Geocoder, flushes the
+// telemetry, closes the Adapter connections, and stops the Adapters started by the Engine.
 func stop() error {
-	return nil
+	ctx, cancel := context.WithTimeout(context.Background(), router.GetShutdownGrace())
+	defer cancel()
+
+	var err error
+	if server != nil {
+		if err = server.Shutdown(ctx); err != nil {
+			log.Warningf("Requests in progress were abandoned - %s", err)
+		}
+	}
+	services.Shutdown()
+	request.Shutdown()
+	telemetry.Shutdown(ctx)
+	router.Shutdown(ctx)
+	return err
 }
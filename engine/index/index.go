@@ -0,0 +1,267 @@
+// Package index is the full text index of the Services of each Area, used to find the
+// Services matching a search, e.g. "broken street light".  The words are stemmed, and
+// misspelled or partial words match with a lower score.
+package index
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+// The weight of a match in each field of a Service.
+const (
+	wName        = 3.0
+	wKeywords    = 2.0
+	wSynonyms    = 2.0
+	wDescription = 1.0
+)
+
+// The score of a query term matching an indexed term.
+const (
+	sExact  = 1.0
+	sPrefix = 0.8 // The query term is the start of the indexed term, e.g. "pot" - "pothole".
+	sTypo   = 0.6 // One edit, e.g. "grafiti" - "graffiti".
+	sTypo2  = 0.4 // Two edits, for terms of 8 or more letters.
+)
+
+// minPrefix is the shortest last query term matching the start of an indexed term.
+const minPrefix = 3
+
+// stopWords are not indexed or searched.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "at": true, "by": true, "for": true,
+	"has": true, "in": true, "is": true, "it": true, "my": true, "near": true, "of": true,
+	"on": true, "or": true, "the": true, "there": true, "to": true, "with": true,
+}
+
+// ==============================================================================================================================
+//                                      INDEX
+// ==============================================================================================================================
+
+// Index is the full text index of the Services of each Area.  It is built with the
+// Catalog, and never changed.
+type Index struct {
+	areas map[string]*area // Index: AreaID
+}
+
+// Match is a Service matching a search.
+type Match struct {
+	MID   string
+	Score float64
+}
+
+// area is the index of the Services of an Area.
+type area struct {
+	mids  []string             // The documents - the MID of each Service.
+	terms map[string][]posting // Index: stemmed term
+}
+
+// posting is a document containing a term, with the weight of the field it is in.
+type posting struct {
+	doc    int
+	weight float64
+}
+
+// Build indexes the Name, Keywords and Description of the Services of each Area.  The
+// synonyms function returns the extra terms for a Service (e.g. its taxonomy synonyms),
+// and may be nil.
+func Build(services map[string]structs.NServices, synonyms func(ns structs.NService) []string) *Index {
+	x := &Index{areas: make(map[string]*area)}
+	for areaID, list := range services {
+		a := &area{terms: make(map[string][]posting)}
+		for _, ns := range list {
+			doc := len(a.mids)
+			a.mids = append(a.mids, ns.MID())
+			weights := make(map[string]float64)
+			add := func(s string, w float64) {
+				for _, t := range Terms(s) {
+					if w > weights[t] {
+						weights[t] = w
+					}
+				}
+			}
+			add(ns.Description, wDescription)
+			if synonyms != nil {
+				add(strings.Join(synonyms(ns), " "), wSynonyms)
+			}
+			add(strings.Join(ns.Keywords, " "), wKeywords)
+			add(ns.Name, wName)
+			for t, w := range weights {
+				a.terms[t] = append(a.terms[t], posting{doc: doc, weight: w})
+			}
+		}
+		x.areas[areaID] = a
+	}
+	return x
+}
+
+// Search returns the Services of the Area matching the query, best match first.  Each
+// term of the query adds the score of its best match in a Service.  The last term also
+// matches the start of a word, so the results can be shown while the query is typed.
+func (x *Index) Search(areaID, query string) []Match {
+	if x == nil {
+		return nil
+	}
+	a, ok := x.areas[areaID]
+	if !ok {
+		return nil
+	}
+	scores := make(map[int]float64)
+	terms := Terms(query)
+	for i, q := range terms {
+		best := make(map[int]float64)
+		for t, postings := range a.terms {
+			s := similarity(q, t, i == len(terms)-1)
+			if s == 0 {
+				continue
+			}
+			for _, p := range postings {
+				if v := s * p.weight; v > best[p.doc] {
+					best[p.doc] = v
+				}
+			}
+		}
+		for doc, v := range best {
+			scores[doc] += v
+		}
+	}
+	list := make([]Match, 0, len(scores))
+	for doc, score := range scores {
+		list = append(list, Match{MID: a.mids[doc], Score: score})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Score != list[j].Score {
+			return list[i].Score > list[j].Score
+		}
+		return list[i].MID < list[j].MID
+	})
+	return list
+}
+
+// ==============================================================================================================================
+//                                      TERMS
+// ==============================================================================================================================
+
+// Terms returns the stemmed, lower case words of s, without the stop words.
+func Terms(s string) []string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	l := make([]string, 0, len(words))
+	for _, w := range words {
+		if !stopWords[w] {
+			l = append(l, stem(w))
+		}
+	}
+	return l
+}
+
+// stem removes the common English suffixes, so that e.g. "lights", "lighting" and
+// "lighted" all match "light".
+func stem(w string) string {
+	has := func(suffix string, min int) bool {
+		return strings.HasSuffix(w, suffix) && len(w)-len(suffix) >= min
+	}
+	switch {
+	case has("ies", 2):
+		w = w[:len(w)-3] + "y"
+	case has("es", 2) && (has("ses", 1) || has("xes", 1) || has("zes", 1) || has("ches", 1) || has("shes", 1)):
+		w = w[:len(w)-2]
+	case has("s", 3) && !has("ss", 0) && !has("us", 0) && !has("is", 0):
+		w = w[:len(w)-1]
+	}
+	switch {
+	case has("ing", 3):
+		w = undouble(w[:len(w)-3])
+	case has("ed", 3):
+		w = undouble(w[:len(w)-2])
+	}
+	if has("e", 4) {
+		w = w[:len(w)-1]
+	}
+	return w
+}
+
+// undouble removes the doubled last consonant of a stem, e.g. "tagg" (from "tagging")
+// becomes "tag".
+func undouble(w string) string {
+	n := len(w)
+	if n >= 4 && w[n-1] == w[n-2] && !strings.ContainsRune("aeioulsz", rune(w[n-1])) {
+		return w[:n-1]
+	}
+	return w
+}
+
+// similarity returns the score of the query term q matching the indexed term t, or 0.
+func similarity(q, t string, prefix bool) float64 {
+	switch {
+	case q == t:
+		return sExact
+	case prefix && len(q) >= minPrefix && strings.HasPrefix(t, q):
+		return sPrefix
+	}
+	n := len([]rune(q))
+	switch {
+	case n >= 8 && distance(q, t, 2) <= 2:
+		if distance(q, t, 1) <= 1 {
+			return sTypo
+		}
+		return sTypo2
+	case n >= 4 && distance(q, t, 1) <= 1:
+		return sTypo
+	}
+	return 0
+}
+
+// distance returns the edit distance (insertions, deletions, substitutions and
+// transpositions) between a and b, or max+1 if it is more than max.
+func distance(a, b string, max int) int {
+	s, t := []rune(a), []rune(b)
+	if d := len(s) - len(t); d > max || -d > max {
+		return max + 1
+	}
+	prev2 := make([]int, len(t)+1)
+	prev := make([]int, len(t)+1)
+	cur := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		cur[0] = i
+		low := cur[0]
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] && prev2[j-2]+1 < cur[j] {
+				cur[j] = prev2[j-2] + 1
+			}
+			if cur[j] < low {
+				low = cur[j]
+			}
+		}
+		if low > max {
+			return max + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	if prev[len(t)] > max {
+		return max + 1
+	}
+	return prev[len(t)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,103 @@
+package index
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+// svc returns a CS1 Service for SJ.
+func svc(id int, name, description string, keywords ...string) structs.NService {
+	return structs.NService{ServiceID: structs.ServiceID{AdpID: "CS1", AreaID: "SJ", ProviderID: 1, ID: id}, Name: name, Description: description, Keywords: keywords}
+}
+
+func TestTerms(t *testing.T) {
+	cases := map[string]string{
+		"The streetlights are out":  "[streetlight out]",
+		"Lighting, lighted, lights": "[light light light]",
+		"Tagging potholes":          "[tag pothol]",
+		"Damaged trees":             "[damag tree]",
+		"Illegal dumping of boxes":  "[illegal dump box]",
+		"":                          "[]",
+	}
+	for s, want := range cases {
+		if got := fmt.Sprint(Terms(s)); got != want {
+			t.Errorf("Terms(%q) = %s, want %s", s, got, want)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	x := Build(map[string]structs.NServices{
+		"SJ": {
+			svc(1, "Streetlight Out", "A streetlight is not working", "lamp"),
+			svc(2, "Pothole", "A hole in the street"),
+			svc(3, "Graffiti Removal", "Paint or tagging on public property"),
+			svc(4, "Illegal Dumping", "Trash left on the street or sidewalk", "garbage"),
+			svc(5, "Abandoned Vehicle", ""),
+		},
+		"SC": {svc(6, "Pothole", "")},
+	}, func(ns structs.NService) []string {
+		if ns.ID == 1 {
+			return []string{"light", "dark"}
+		}
+		return nil
+	})
+
+	cases := []struct {
+		q    string
+		want string // The MIDs, best match first.
+	}{
+		{"pothole", "[CS1-SJ-1-2]"},
+		{"potholes", "[CS1-SJ-1-2]"},               // Stemmed.
+		{"pot", "[CS1-SJ-1-2]"},                    // Prefix.
+		{"grafiti", "[CS1-SJ-1-3]"},                // Typo.
+		{"abandonned vehicel", "[CS1-SJ-1-5]"},     // Typos.
+		{"dark lamps", "[CS1-SJ-1-1]"},             // Synonym and keyword.
+		{"street hole", "[CS1-SJ-1-2 CS1-SJ-1-4]"}, // Ranked by score.
+		{"street", "[CS1-SJ-1-1 CS1-SJ-1-2 CS1-SJ-1-4]"},
+		{"pot hole", "[CS1-SJ-1-2]"},
+		{"the", "[]"},
+		{"xyzzy", "[]"},
+	}
+	for _, c := range cases {
+		var mids []string
+		for _, m := range x.Search("SJ", c.q) {
+			mids = append(mids, m.MID)
+		}
+		if got := fmt.Sprint(mids); got != c.want {
+			t.Errorf("Search(%q) = %s, want %s", c.q, got, c.want)
+		}
+	}
+	if l := x.Search("SC", "pothole"); len(l) != 1 || l[0].MID != "CS1-SJ-1-6" {
+		t.Errorf("expected only the SC pothole, got: %v", l)
+	}
+	if l := x.Search("XX", "pothole"); len(l) != 0 {
+		t.Errorf("expected no matches for an unknown area, got: %v", l)
+	}
+	var none *Index
+	if l := none.Search("SJ", "pothole"); len(l) != 0 {
+		t.Errorf("expected no matches without an index, got: %v", l)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	cases := []struct {
+		a, b string
+		max  int
+		want int
+	}{
+		{"graffiti", "graffiti", 1, 0},
+		{"grafiti", "graffiti", 1, 1},
+		{"grafitti", "graffiti", 2, 2},
+		{"vehicel", "vehicl", 1, 1},
+		{"hte", "the", 1, 1}, // Transposition.
+		{"light", "trash", 2, 3},
+	}
+	for _, c := range cases {
+		if got := distance(c.a, c.b, c.max); got != c.want {
+			t.Errorf("distance(%q, %q, %d) = %d, want %d", c.a, c.b, c.max, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	emaildata "github.com/codeforsanjose/open311-gateway/adapters/email/data"
+	emailrqst "github.com/codeforsanjose/open311-gateway/adapters/email/request"
+	o311data "github.com/codeforsanjose/open311-gateway/adapters/open311/data"
+	o311rqst "github.com/codeforsanjose/open311-gateway/adapters/open311/request"
+	scfdata "github.com/codeforsanjose/open311-gateway/adapters/seeclickfix/data"
+	scfrqst "github.com/codeforsanjose/open311-gateway/adapters/seeclickfix/request"
+	simdata "github.com/codeforsanjose/open311-gateway/adapters/simulator/data"
+	simrqst "github.com/codeforsanjose/open311-gateway/adapters/simulator/request"
+	"github.com/codeforsanjose/open311-gateway/common/adaptersdk"
+	"github.com/codeforsanjose/open311-gateway/engine/router"
+)
+
+// registerInproc makes the Adapters compiled into the Engine available to the "inproc"
+// transport.  Each type can only be loaded once.
+func registerInproc() {
+	router.RegisterInproc("Email", inprocStarter(emaildata.Adapter(), emaildata.Init, &emailrqst.Report{}))
+	router.RegisterInproc("Open311", inprocStarter(o311data.Adapter(), o311data.Init, &o311rqst.Report{}))
+	router.RegisterInproc("SeeClickFix", inprocStarter(scfdata.Adapter(), scfdata.Init, &scfrqst.Report{}))
+	router.RegisterInproc("Simulator", inprocStarter(simdata.Adapter(), simdata.Init, &simrqst.Report{}, &simrqst.Admin{}))
+}
+
+// inprocStarter returns a router.InprocStarter, loading the Adapter config file with load.
+// The arguments are the same as adaptersdk.Main().
+func inprocStarter(a *adaptersdk.Adapter, load func(configFile string) error, rcvrs ...interface{}) router.InprocStarter {
+	return func(configFile string) (router.InprocCaller, error) {
+		if err := load(configFile); err != nil {
+			return nil, err
+		}
+		return a.Inproc(rcvrs...)
+	}
+}
@@ -0,0 +1,44 @@
+package request
+
+import (
+	"fmt"
+
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+	"github.com/codeforsanjose/open311-gateway/engine/router"
+
+	"github.com/ant0ine/go-json-rest/rest"
+	log "github.com/jeffizhungry/logrus"
+)
+
+// hdrRegToken is the header carrying the "registration.token" from the config file.
+const hdrRegToken = "X-Registration-Token"
+
+// Register registers an Adapter with the Engine, or renews its lease.  The payload is a
+// structs.NRegisterRequest.
+func Register(w rest.ResponseWriter, r *rest.Request) {
+	runRequest(w, r, processRegister)
+}
+
+// Deregister removes a registered Adapter.
+func Deregister(w rest.ResponseWriter, r *rest.Request) {
+	runRequest(w, r, processDeregister)
+}
+
+func processRegister(rqst *rest.Request) (interface{}, error) {
+	var reg structs.NRegisterRequest
+	if err := rqst.DecodeJsonPayload(&reg); err != nil {
+		if err.Error() != greEmpty {
+			return nil, fmt.Errorf("unable to process the registration - %s", err)
+		}
+	}
+	log.Debug(reg.String())
+	return router.RegisterAdapter(&reg, rqst.Header.Get(hdrRegToken))
+}
+
+func processDeregister(rqst *rest.Request) (interface{}, error) {
+	adpID := rqst.PathParam("id")
+	if err := router.DeregisterAdapter(adpID, rqst.URL.Query().Get("address"), rqst.Header.Get(hdrRegToken)); err != nil {
+		return nil, err
+	}
+	return &structs.NRegisterResponse{AdpID: adpID, Message: "OK"}, nil
+}
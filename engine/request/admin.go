@@ -0,0 +1,96 @@
+package request
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/codeforsanjose/open311-gateway/common/geo"
+	"github.com/codeforsanjose/open311-gateway/engine/router"
+	"github.com/codeforsanjose/open311-gateway/engine/services"
+	"github.com/codeforsanjose/open311-gateway/engine/taxonomy"
+
+	"github.com/ant0ine/go-json-rest/rest"
+	log "github.com/jeffizhungry/logrus"
+)
+
+// hdrAdminToken is the header carrying the "admin.token" from the config file.
+const hdrAdminToken = "X-Admin-Token"
+
+// Reload re-reads the config file (see router.Reload()), and applies the new regions and
+// taxonomy.  If the taxonomy file is invalid, the previous taxonomy is kept.
+func Reload() (*router.ConfigChanges, error) {
+	changes, err := router.Reload()
+	if err != nil {
+		return nil, err
+	}
+	if err := geo.SetRegions(router.GetRegions()); err != nil {
+		log.Error("Invalid regions - " + err.Error())
+		return nil, err
+	}
+	if err := taxonomy.Load(router.GetTaxonomyFile()); err != nil {
+		log.Warn("The taxonomy was not reloaded - " + err.Error())
+	} else {
+		services.Reclassify()
+	}
+	return changes, nil
+}
+
+// ReloadConfig reloads the config file, and returns the changes.
+func ReloadConfig(w rest.ResponseWriter, r *rest.Request) {
+	runRequest(w, r, processReload)
+}
+
+func processReload(rqst *rest.Request) (interface{}, error) {
+	if err := router.CheckAdminToken(rqst.Header.Get(hdrAdminToken)); err != nil {
+		return nil, err
+	}
+	return Reload()
+}
+
+// RefreshServices refreshes the Services of an Area ("area" query parameter - an AreaID or
+// alias) or of an Adapter ("adapter" query parameter).
+func RefreshServices(w rest.ResponseWriter, r *rest.Request) {
+	runRequest(w, r, processRefresh)
+}
+
+// refreshResponse is the response to a Services refresh.
+type refreshResponse struct {
+	AreaID     string              `json:"areaID,omitempty"`
+	AdpID      string              `json:"adapterID,omitempty"`
+	Message    string              `json:"message"`
+	Generation uint64              `json:"generation"`         // The Catalog published by the refresh.
+	Unmapped   map[string][]string `json:"unmapped,omitempty"` // Index: AreaID - the MIDs without a taxonomy Category.
+}
+
+func processRefresh(rqst *rest.Request) (interface{}, error) {
+	if err := router.CheckAdminToken(rqst.Header.Get(hdrAdminToken)); err != nil {
+		return nil, err
+	}
+	q := rqst.URL.Query()
+	area, adpID := q.Get("area"), q.Get("adapter")
+	switch {
+	case adpID != "" && area == "":
+		if err := services.RefreshAdapter(adpID); err != nil {
+			return nil, err
+		}
+		return &refreshResponse{AdpID: adpID, Message: "OK", Generation: router.GetCatalog().Generation, Unmapped: services.Unmapped(nil)}, nil
+	case area != "" && adpID == "":
+		areaID, err := resolveArea(area)
+		if err != nil {
+			return nil, err
+		}
+		services.RefreshAreas([]string{areaID})
+		return &refreshResponse{AreaID: areaID, Message: "OK", Generation: router.GetCatalog().Generation, Unmapped: services.Unmapped([]string{areaID})}, nil
+	}
+	return nil, errors.New("either an area or an adapter must be specified")
+}
+
+// resolveArea returns the AreaID for an AreaID or alias.
+func resolveArea(area string) (string, error) {
+	for _, id := range router.GetAreaIDs() {
+		if strings.EqualFold(id, area) {
+			return id, nil
+		}
+	}
+	return router.GetAreaID(area)
+}
@@ -0,0 +1,48 @@
+package request
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/engine/services"
+
+	"github.com/ant0ine/go-json-rest/rest"
+)
+
+// ServiceChanges returns the changes to the Services after the "since" query parameter (an
+// RFC3339 time), optionally for one Area ("area" query parameter - an AreaID or alias).
+func ServiceChanges(w rest.ResponseWriter, r *rest.Request) {
+	runRequest(w, r, processServiceChanges)
+}
+
+// changesResponse is the response to a Service changes request.  Apps should send AsOf as
+// "since" in the next request.  If Complete is false, some of the changes after Since were
+// dropped, and the Services should be reloaded.
+type changesResponse struct {
+	Since    time.Time         `json:"since"`
+	AsOf     time.Time         `json:"as_of"`
+	Complete bool              `json:"complete"`
+	Changes  []services.Change `json:"changes"`
+}
+
+func processServiceChanges(rqst *rest.Request) (interface{}, error) {
+	q := rqst.URL.Query()
+	resp := &changesResponse{AsOf: time.Now()}
+	if s := q.Get("since"); s != "" {
+		since, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid since: %q - it must be an RFC3339 time", s)
+		}
+		resp.Since = since
+	}
+	var areaID string
+	if area := q.Get("area"); area != "" {
+		id, err := resolveArea(area)
+		if err != nil {
+			return nil, err
+		}
+		areaID = id
+	}
+	resp.Changes, resp.Complete = services.Changes(resp.Since, areaID)
+	return resp, nil
+}
@@ -3,6 +3,7 @@ package request
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/codeforsanjose/open311-gateway/common"
@@ -84,10 +85,15 @@ func processCreate(rqst *rest.Request) (fresp interface{}, ferr error) {
 
 	mgr.convertRequest()
 
+	if err := mgr.validateCapabilities(); err != nil {
+		return fail(err)
+	}
+
 	if err := mgr.callRPC(); err != nil {
 		log.Warn("processCreate.callRPC() failed - " + err.Error())
 		return fail(err)
 	}
+	searchResults.invalidate(mgr.req.MID.AreaID)
 
 	mgr.convertResponse()
 
@@ -146,12 +152,12 @@ func (r *createMgr) validate() error {
 	}
 
 	v := r.valid
-	v.Set("qryParms", "Query parms parsed and loaded ok", false)
-	v.Set("inputs", "Type conversion of inputs is OK", false)
-	v.Set("SrvID", "The ServiceID is valid", false)
-	v.Set("geo", "Location coordinates are within the continental US", false)
-	v.Set("city", "We have a city", false)
-	v.Set("SrvArea", "The Service ID corresponds to the location", false)
+	v.Set("qryParms", "", false) // Query parms parsed and loaded ok
+	v.Set("inputs", "", false)   // Type conversion of inputs is OK
+	v.Set("SrvID", "", false)    // The ServiceID is valid
+	v.Set("geo", "", false)      // Location coordinates are within a valid region
+	v.Set("city", "", false)     // We have a city
+	v.Set("SrvArea", "", false)  // The Service ID corresponds to the location
 
 	// Load Query Parms.
 	if err := r.parseQP(); err != nil {
@@ -182,15 +188,21 @@ func (r *createMgr) validate() error {
 	v.Set("city", "", true)
 
 	// Verify the ServiceID (MID) matches the location.
-	if err := r.req.validateLocationMID(); err == nil {
+	if err := r.req.validateLocationMID(); err != nil {
+		v.Set("SrvArea", err.Error(), false)
+	} else {
 		v.Set("SrvArea", "", true)
 	}
 	v.Set("SrvID", "", true)
 
 	// Location - the AreaID in the MID must match the location specified by the address
 	// or the lat/lng.
-	r.valid.Set("geo", "", geo.ValidateLatLng(r.req.LatitudeV, r.req.LongitudeV))
-	log.Debug("After ValidateLatLng: " + v.String() + r.req.String())
+	if err := geo.CheckLatLng(r.req.LatitudeV, r.req.LongitudeV); err != nil {
+		v.Set("geo", err.Error(), false)
+	} else {
+		v.Set("geo", "", true)
+	}
+	log.Debug("After CheckLatLng: " + v.String() + r.req.String())
 
 	// Is the Request routable?
 	if err := r.setRoute(); err != nil {
@@ -246,6 +258,15 @@ func (r *createMgr) parseQP() error {
 				r.req.State = value
 			case "zip":
 				r.req.Zip = value
+
+			default:
+				// Open311 service attributes: "attribute[CODE]=value".
+				if strings.HasPrefix(key, "attribute[") && strings.HasSuffix(key, "]") {
+					if r.req.Attributes == nil {
+						r.req.Attributes = make(map[string]string)
+					}
+					r.req.Attributes[key[len("attribute["):len(key)-1]] = value
+				}
 			}
 		}
 	}
@@ -280,6 +301,7 @@ func (r *createMgr) convertRequest() {
 		State:       r.req.State,
 		Zip:         r.req.Zip,
 		IsAnonymous: r.req.isAnonymous,
+		Attributes:  r.req.Attributes,
 	}
 }
 
@@ -305,6 +327,17 @@ func (r *createMgr) setRoute() error {
 	return nil
 }
 
+// validateCapabilities verifies the Provider can honor the request (e.g. it accepts
+// media, or anonymous reports).
+func (r *createMgr) validateCapabilities() error {
+	for _, route := range r.routes {
+		if err := router.ValidateCreate(route, r.nreq); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // -------------------------------------------------------------------------------
 //                        RPC
 // -------------------------------------------------------------------------------
@@ -317,7 +350,7 @@ func (r *createMgr) callRPC() (err error) {
 	}
 
 	log.Debug("Before RPC\n" + r.String())
-	if err = r.rpc.Run(); err != nil {
+	if err = r.rpc.Run(r.rqst.Context()); err != nil {
 		log.Error(err.Error())
 		return err
 	}
@@ -390,6 +423,8 @@ type CreateRequest struct {
 
 	DeviceType  string `json:"device_type" xml:"device_type"`
 	DeviceModel string `json:"device_model" xml:"device_model"`
+
+	Attributes map[string]string `json:"attributes" xml:"-"` // Service attributes, by code
 }
 
 // convert the unmarshaled data.
@@ -408,7 +443,7 @@ func (r *CreateRequest) validateServiceID() (err error) {
 }
 
 // validateLocation does the following:
-// 1. If there is a non-blank full address, attempt to use it by calling validateAddress()
+// 1. If there is a non-blank full address, normalize it and attempt to geocode it.
 // 2. If validateAddress() is successful, set the Lat/Long to the address' location and return.
 // 3. If validateAddress() fails, then try to find the location using the LongitudeV and LatitudeV.
 // 4. If LongitudeV and LatitudeV are invalid, return error.
@@ -435,27 +470,29 @@ func (r *CreateRequest) validateLocation() (err error) {
 	// Try the FullAddress first.
 	if len(r.FullAddress) > 0 {
 		log.Debug("Trying FullAddress...")
-		addr, err = geo.NewAddr(r.FullAddress)
+		addr, err = geocoder.Forward(normalizeAddress(r.FullAddress))
 		if err == nil {
 			return success()
 		}
 	}
 
 	// Try the address parts next.
-	log.Debug("Trying AddressParts...")
-	addr, err = geo.NewAddrP(r.Address, r.City, r.State, r.Zip)
-	if err == nil {
-		return success()
+	if len(r.Address) > 0 {
+		log.Debug("Trying AddressParts...")
+		addr, err = geocoder.Forward(normalizeAddress(fmt.Sprintf("%s, %s, %s %s", r.Address, r.City, r.State, r.Zip)))
+		if err == nil {
+			return success()
+		}
 	}
 
 	// Finally, try reversing the Lat/Long to an address.
 	log.Debug("Validate LatLong...")
-	if !geo.ValidateLatLng(r.LatitudeV, r.LongitudeV) {
-		return fail("unable to determine the request location")
+	if err := geo.CheckLatLng(r.LatitudeV, r.LongitudeV); err != nil {
+		return fail("unable to determine the request location - " + err.Error())
 	}
 
 	log.Debug("Getting Address for Lat/Long...")
-	addr, err = geo.AddrForLatLng(r.LatitudeV, r.LongitudeV)
+	addr, err = geocoder.Reverse(r.LatitudeV, r.LongitudeV)
 	if err == nil {
 		return success()
 	}
@@ -530,7 +567,7 @@ func (r *CreateResponse) emptyToNil() {
 func (r CreateResponse) String() string {
 	ls := new(common.FmtBoxer)
 	ls.AddF("CreateResponse - %d\n", r.ID)
-	ls.AddF("AccountID: %s\n", r.AccountID)
+	ls.AddF("AccountID: %s\n", strValue(r.AccountID))
 	return ls.Box(80)
 }
 
@@ -0,0 +1,62 @@
+package request
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codeforsanjose/open311-gateway/common/cv"
+	"github.com/codeforsanjose/open311-gateway/common/geo"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+	"github.com/codeforsanjose/open311-gateway/engine/router"
+
+	"github.com/ant0ine/go-json-rest/rest"
+)
+
+// testGeocoder returns the same Address for every lookup.
+type testGeocoder struct {
+	addr *geo.Address
+}
+
+func (r testGeocoder) Forward(addr string) (*geo.Address, error) {
+	if r.addr == nil {
+		return nil, errors.New("not found")
+	}
+	return r.addr, nil
+}
+
+func (r testGeocoder) Reverse(lat, lng float64) (*geo.Address, error) {
+	return r.Forward("")
+}
+
+func TestCreateValidation(t *testing.T) {
+	mid := structs.ServiceID{AdpID: "A1", AreaID: "SJ", ProviderID: 1, ID: 1}
+	saved := router.GetCatalog()
+	defer router.PublishCatalog(saved)
+	router.PublishCatalog(router.NewCatalog(map[string]structs.NServices{
+		"SJ": {{ServiceID: mid, Name: "Pothole"}},
+	}, nil))
+
+	defer func(g geo.Geocoder) { geocoder = g }(geocoder)
+	geocoder = testGeocoder{addr: &geo.Address{Addr: "632 W 6th Ave", City: "Anchorage", State: "AK", Lat: 61.2, Lng: -149.9}}
+
+	if err := geo.SetRegions(geo.Regions{{Name: "SJ", Box: &geo.Box{MinLat: 37.1, MaxLat: 37.5, MinLng: -122.1, MaxLng: -121.6}}}); err != nil {
+		t.Fatal(err)
+	}
+	defer geo.SetRegions(nil)
+
+	mgr := createMgr{
+		rqst:  &rest.Request{Request: httptest.NewRequest("POST", "/v1/requests.json", nil)},
+		req:   &CreateRequest{MID: mid, FullAddress: "632 W 6th Ave, Anchorage, AK"},
+		valid: cv.NewValidation(),
+	}
+	err := mgr.validate()
+	if err == nil {
+		t.Fatal("expected the create to fail")
+	}
+	// Only the failed validations are reported, with the reason each failed.
+	want := `errors: SrvArea (Cannot find area: "Anchorage"), geo (location 61.2,-149.9 is outside the valid regions: SJ)`
+	if err.Error() != want {
+		t.Errorf("unexpected error:\n got: %s\nwant: %s", err, want)
+	}
+}
@@ -0,0 +1,136 @@
+package request
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common/geo"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+// Reports returned by different providers are considered to be the same report if
+// they are for the same service, within dupDistance meters of each other, and were
+// created within dupInterval of each other.
+const (
+	dupDistance = 25.0 // meters
+	dupInterval = 10 * time.Minute
+)
+
+// dateLayouts are the formats used by the providers for DateCreated.
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05.999999999",
+	"01/02/2006 15:04:05",
+	"1/2/2006 3:04:05 PM",
+}
+
+// mergeItem is a report with the values used to merge and rank it.
+type mergeItem struct {
+	rpt      structs.NSearchResponseReport
+	rid      string
+	lat, lng float64
+	hasLoc   bool
+	created  time.Time
+	distance float64
+}
+
+// mergeReports de-duplicates the reports returned by all routes, sorts them by distance
+// from the query point (if any), then newest first, then by RID, and truncates the list
+// to max reports.  If max is 0, all reports are returned.
+func mergeReports(reports []structs.NSearchResponseReport, lat, lng float64, max int) []structs.NSearchResponseReport {
+	hasQuery := lat != 0 || lng != 0
+	items := make([]*mergeItem, 0, len(reports))
+	for _, rpt := range reports {
+		m := &mergeItem{
+			rpt:     rpt,
+			rid:     rpt.RID.RID(),
+			created: parseDate(rpt.DateCreated),
+		}
+		var errLat, errLng error
+		m.lat, errLat = strconv.ParseFloat(rpt.Latitude, 64)
+		m.lng, errLng = strconv.ParseFloat(rpt.Longitude, 64)
+		m.hasLoc = errLat == nil && errLng == nil && (m.lat != 0 || m.lng != 0)
+		if hasQuery && m.hasLoc {
+			m.distance = geo.Distance(lat, lng, m.lat, m.lng)
+		}
+		items = append(items, m)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.hasLoc != b.hasLoc {
+			return a.hasLoc
+		}
+		if a.distance != b.distance {
+			return a.distance < b.distance
+		}
+		if !a.created.Equal(b.created) {
+			return a.created.After(b.created)
+		}
+		return a.rid < b.rid
+	})
+
+	merged := make([]structs.NSearchResponseReport, 0, len(items))
+	kept := make([]*mergeItem, 0, len(items))
+	seen := make(map[string]bool)
+	for _, m := range items {
+		if m.rid != "" && seen[m.rid] {
+			continue
+		}
+		if isDuplicate(m, kept) {
+			continue
+		}
+		seen[m.rid] = true
+		kept = append(kept, m)
+		merged = append(merged, m.rpt)
+		if max > 0 && len(merged) >= max {
+			break
+		}
+	}
+	return merged
+}
+
+// isDuplicate returns true if the report is the same as one of the kept reports,
+// returned by a different provider.
+func isDuplicate(m *mergeItem, kept []*mergeItem) bool {
+	if !m.hasLoc || m.created.IsZero() {
+		return false
+	}
+	for _, k := range kept {
+		if !k.hasLoc || k.created.IsZero() || k.rpt.RID.NRoute == m.rpt.RID.NRoute {
+			continue
+		}
+		if !sameService(k.rpt, m.rpt) {
+			continue
+		}
+		d := k.created.Sub(m.created)
+		if d < 0 {
+			d = -d
+		}
+		if d <= dupInterval && geo.Distance(k.lat, k.lng, m.lat, m.lng) <= dupDistance {
+			return true
+		}
+	}
+	return false
+}
+
+// sameService compares the service names, as service IDs are specific to each provider.
+func sameService(a, b structs.NSearchResponseReport) bool {
+	if a.RID.AdpID == b.RID.AdpID && a.RequestTypeID != "" && a.RequestTypeID == b.RequestTypeID {
+		return true
+	}
+	return a.RequestType != "" && strings.EqualFold(strings.TrimSpace(a.RequestType), strings.TrimSpace(b.RequestType))
+}
+
+func parseDate(s string) time.Time {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,44 @@
+package request
+
+import (
+	"testing"
+
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+func report(adp, id, svc, lat, lng, created string) structs.NSearchResponseReport {
+	return structs.NSearchResponseReport{
+		RID:         structs.ReportID{NRoute: structs.NRoute{AdpID: adp, AreaID: "SJ", ProviderID: 1}, ID: id},
+		RequestType: svc,
+		Latitude:    lat,
+		Longitude:   lng,
+		DateCreated: created,
+	}
+}
+
+func TestMergeReports(t *testing.T) {
+	reports := []structs.NSearchResponseReport{
+		report("CS1", "3", "Pothole", "37.3400", "-121.8900", "2016-03-01T10:00:00Z"),
+		report("CS1", "1", "Graffiti", "37.3382", "-121.8863", "2016-03-01T10:00:00Z"),
+		report("CS1", "1", "Graffiti", "37.3382", "-121.8863", "2016-03-01T10:00:00Z"), // Same RID
+		report("OT1", "A", "graffiti", "37.3383", "-121.8863", "2016-03-01T10:05:00Z"), // Same report, other provider
+		report("OT1", "B", "Graffiti", "37.3383", "-121.8863", "2016-03-02T10:05:00Z"), // Different day
+		report("CS1", "2", "Pothole", "", "", "2016-03-01T10:00:00Z"),
+	}
+
+	merged := mergeReports(reports, 37.3382, -121.8863, 0)
+	want := []string{"CS1-SJ-1-1", "OT1-SJ-1-B", "CS1-SJ-1-3", "CS1-SJ-1-2"}
+	if len(merged) != len(want) {
+		t.Fatalf("got %d reports, want %d: %v", len(merged), len(want), merged)
+	}
+	for i, rpt := range merged {
+		if rpt.RID.RID() != want[i] {
+			t.Errorf("report %d: got %s, want %s", i, rpt.RID.RID(), want[i])
+		}
+	}
+
+	merged = mergeReports(reports, 37.3382, -121.8863, 2)
+	if len(merged) != 2 {
+		t.Errorf("got %d reports, want 2", len(merged))
+	}
+}
@@ -1,14 +1,21 @@
 package request
 
 import (
+	"github.com/codeforsanjose/open311-gateway/common/geo"
 	"github.com/codeforsanjose/open311-gateway/engine/router"
+	"github.com/codeforsanjose/open311-gateway/engine/taxonomy"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/ant0ine/go-json-rest/rest"
 	log "github.com/jeffizhungry/logrus"
 )
 
+var geocoder geo.Geocoder = geo.GetDefault()
+
 const (
 	debugRecover = false
 
@@ -49,11 +56,19 @@ func runRequest(w rest.ResponseWriter, r *rest.Request, f func(*rest.Request) (i
 		errorResp(w, newErrorsResponseJ().errorJ(400, err.Error()), http.StatusBadRequest)
 		return
 	}
+	if h, ok := response.(headerer); ok {
+		h.setHeaders(w.Header())
+	}
 	if err := w.WriteJson(&response); err != nil {
 		log.Error(err.Error())
 	}
 }
 
+// headerer is implemented by the responses that send HTTP headers.
+type headerer interface {
+	setHeaders(h http.Header)
+}
+
 func errorResp(w rest.ResponseWriter, errResp ErrorsResponseJ, code int) {
 	w.WriteHeader(code)
 	err := w.WriteJson(errResp)
@@ -64,6 +79,63 @@ func errorResp(w rest.ResponseWriter, errResp ErrorsResponseJ, code int) {
 
 // Init initializes the router package.
 func Init() error {
-	searchRadiusMin, searchRadiusMax = router.GetSearchRadius()
+	if err := geo.SetRegions(router.GetRegions()); err != nil {
+		log.Error("Invalid regions - " + err.Error())
+		return err
+	}
+
+	g, err := geo.NewGeocoder(router.GetGeocoderConfig())
+	if err != nil {
+		log.Error("Unable to create the Geocoder - " + err.Error())
+		return err
+	}
+	geocoder = g
+	geo.SetDefault(g)
+
+	if err := taxonomy.Load(router.GetTaxonomyFile()); err != nil {
+		return err
+	}
+
+	searchResults = newSearchCache(router.GetSearchCacheConfig(), func(areaID string) time.Duration {
+		return time.Duration(router.GetAreaSearchCacheTTL(areaID)) * time.Second
+	})
 	return nil
 }
+
+// Shutdown closes the Geocoder (and its cache file) at system shutdown.
+func Shutdown() {
+	if c, ok := geocoder.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			log.Errorf("Unable to close the Geocoder - %s", err)
+		}
+	}
+}
+
+// normalizeAddress returns the USPS normalized form of the address, so that equivalent
+// addresses are sent to the Geocoder (and cached) identically.  If the address cannot
+// be parsed, it is returned unchanged.
+func normalizeAddress(addr string) string {
+	n, err := geo.Normalize(addr)
+	if err != nil || !(n.Has(geo.HasStreet) || n.Has(geo.IsIntersection)) {
+		return addr
+	}
+	log.Debugf("Normalized address %q to %q", addr, n.String())
+	return n.String()
+}
+
+// cityForLatLng uses the Geocoder to find the city at the specified location.
+func cityForLatLng(lat, lng float64) (string, error) {
+	addr, err := geocoder.Reverse(lat, lng)
+	if err != nil {
+		return "", fmt.Errorf("unable to find the city for this location - %s", err)
+	}
+	return addr.City, nil
+}
+
+// strValue returns the string, or "" if it is nil.
+func strValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -17,11 +17,6 @@ import (
 	log "github.com/jeffizhungry/logrus"
 )
 
-var (
-	searchRadiusMin int
-	searchRadiusMax int
-)
-
 // =======================================================================================
 //                                      SEARCH MANAGER
 // =======================================================================================
@@ -137,13 +132,13 @@ func (r *searchMgr) validate() error {
 	}
 
 	v := r.valid
-	v.Set("qryParms", "Query parms parsed and loaded ok", false)
-	v.Set("inputs", "Type conversion of inputs is OK", false)
-	v.Set("RID", "Has a Report ID", false)
-	v.Set("DID", "Has a Device ID", false)
-	v.Set("geo", "Location coordinates are within the continental US", false)
-	v.Set("city", "We have a serviced city", false)
-	v.Set("route", "Has a viable route", false)
+	v.Set("qryParms", "", false) // Query parms parsed and loaded ok
+	v.Set("inputs", "", false)   // Type conversion of inputs is OK
+	v.Set("RID", "", false)      // Has a Report ID
+	v.Set("DID", "", false)      // Has a Device ID
+	v.Set("geo", "", false)      // Location coordinates are within a valid region
+	v.Set("city", "", false)     // We have a serviced city
+	v.Set("route", "", false)    // Has a viable route
 
 	// Load Query Parms.
 	if err := r.parseQP(); err != nil {
@@ -167,10 +162,19 @@ func (r *searchMgr) validate() error {
 		v.Set("DID", "", true)
 	}
 
-	// Location
-	v.Set("geo", "", geo.ValidateLatLng(r.req.LatitudeV, r.req.LongitudeV))
+	// Location - if there isn't a valid location, try the address.
+	err := geo.CheckLatLng(r.req.LatitudeV, r.req.LongitudeV)
+	if err != nil && r.req.hasAddress() {
+		err = r.req.locate()
+	}
+	if err != nil {
+		v.Set("geo", err.Error(), false)
+	} else {
+		v.Set("geo", "", true)
+	}
 
-	// Range-check the search radius.
+	// Range-check the search radius.  The limits can be changed by a config reload.
+	searchRadiusMin, searchRadiusMax := router.GetSearchRadius()
 	log.Debugf("Search radius min/max: %v-%v", searchRadiusMin, searchRadiusMax)
 	switch {
 	case r.req.RadiusV < searchRadiusMin:
@@ -182,7 +186,7 @@ func (r *searchMgr) validate() error {
 	// Do we have a valid request?  We must have a ReportID, DeviceID, OR a valid location.
 	// If none of those are present, then the request is invalid
 	if !(v.IsOK("RID") || v.IsOK("geo") || v.IsOK("DID")) {
-		return fail("invalid Search request", nil)
+		return fail("invalid Search request - "+v.Error(), nil)
 	}
 
 	// Is the Request routable?
@@ -208,6 +212,9 @@ func (r *searchMgr) parseQP() error {
 	r.req.Latitude = r.rqst.URL.Query().Get("lat")
 	r.req.Longitude = r.rqst.URL.Query().Get("lng")
 	r.req.Radius = r.rqst.URL.Query().Get("radius")
+	if addr := r.rqst.URL.Query().Get("address_string"); addr != "" {
+		r.req.FullAddress = addr
+	}
 	return nil
 }
 
@@ -225,7 +232,7 @@ func (r *searchMgr) setRoute() error {
 		return nil
 
 	case v.IsOK("geo"):
-		if city, err := geo.GooCityForLatLng(r.req.LatitudeV, r.req.LongitudeV); err == nil {
+		if city, err := cityForLatLng(r.req.LatitudeV, r.req.LongitudeV); err == nil {
 			log.Debug("City: " + city)
 			r.req.City = city
 		}
@@ -266,9 +273,21 @@ func (r *searchMgr) setSearchType() error {
 		return fmt.Errorf("invalid query parameters for Search request")
 	}
 	r.nreq.(structs.NRequester).SetID(r.id, 0)
+
+	// Only use the routes able to handle the search type.
+	if r.routes = router.FilterRoutes(r.routes, r.reqType); len(r.routes) == 0 {
+		return fmt.Errorf("%s is not supported for this area", searchNames[r.reqType])
+	}
 	return nil
 }
 
+// searchNames are the descriptions of the search types, for error messages.
+var searchNames = map[structs.NRequestType]string{
+	structs.NRTSearchLL:  "search by location",
+	structs.NRTSearchDID: "search by device ID",
+	structs.NRTSearchRID: "search by report ID",
+}
+
 // -------------------------------------------------------------------------------
 //                        RPC
 // -------------------------------------------------------------------------------
@@ -280,11 +299,45 @@ func (r *searchMgr) callRPC() (err error) {
 		return err
 	}
 
-	if err = r.rpc.Run(); err != nil {
-		log.Error(err.Error())
-		return err
+	// Lat/Lng searches are cached.  A cache entry is shared by the searches near the same
+	// location, so the Adapters are searched around the grid cell with the widest radius
+	// of the entry, and the reports are filtered and merged for each request, as they are
+	// ranked by the distance from the requested location.
+	var (
+		cacheKey string
+		cached   bool
+	)
+	if r.reqType == structs.NRTSearchLL && searchResults != nil {
+		nreq := r.nreq.(*structs.NSearchRequestLL)
+		cacheKey, nreq.Latitude, nreq.Longitude, nreq.Radius, nreq.MaxResults = searchResults.query(r.req.AreaID, r.req.LatitudeV, r.req.LongitudeV, r.req.RadiusV, r.req.MaxResultsV)
+		defer searchResults.sendTelemetry()
+		var reports []structs.NSearchResponseReport
+		if reports, cached = searchResults.get(cacheKey); cached {
+			log.Debugf("Search cache hit: %q", cacheKey)
+			r.nresp.Reports = reports
+		}
 	}
+	if !cached {
+		if err = r.rpc.Run(r.rqst.Context()); err != nil {
+			log.Error(err.Error())
+			return err
+		}
+		// The replies are not cached if an Adapter timed out, as they are incomplete.
+		if cacheKey != "" && !r.rpc.TimedOut() {
+			searchResults.put(cacheKey, r.req.AreaID, r.nresp.Reports)
+		}
+	}
+	max := r.req.MaxResultsV
+	if cacheKey != "" {
+		// The cached searches ask the Adapters for more results - limit them here.
+		r.nresp.Reports = withinRadius(r.nresp.Reports, r.req.LatitudeV, r.req.LongitudeV, r.req.RadiusV)
+		if max <= 0 {
+			max = searchCacheMaxResults
+		}
+	}
+	r.nresp.Reports = mergeReports(r.nresp.Reports, r.req.LatitudeV, r.req.LongitudeV, max)
 	r.nresp.ReportCount = len(r.nresp.Reports)
+	r.nresp.ResponseTime = time.Since(r.start).String()
 	if r.nresp.ReportCount > 0 {
 		r.nresp.Message = "OK"
 	} else {
@@ -431,6 +484,7 @@ type SearchRequest struct {
 	LongitudeV  float64          //
 	Radius      string           `json:"radius" xml:"radius"`
 	RadiusV     int              // in meters
+	FullAddress string           `json:"address_string" xml:"address_string"`
 	Address     string           `json:"address" xml:"address"`
 	City        string           `json:"city" xml:"city"`
 	AreaID      string           //
@@ -453,6 +507,26 @@ func (r *SearchRequest) convert() error {
 	return nil
 }
 
+// hasAddress returns true if the request has a full address, or an address and city.
+func (r *SearchRequest) hasAddress() bool {
+	return len(r.FullAddress) > 0 || (len(r.Address) > 0 && len(r.City) > 0)
+}
+
+// locate normalizes the address, and uses the Geocoder to find its location.
+func (r *SearchRequest) locate() error {
+	addr := r.FullAddress
+	if addr == "" {
+		addr = fmt.Sprintf("%s, %s, %s %s", r.Address, r.City, r.State, r.Zip)
+	}
+	a, err := geocoder.Forward(normalizeAddress(addr))
+	if err != nil {
+		return fmt.Errorf("unable to determine the search location - %s", err)
+	}
+	r.LatitudeV, r.LongitudeV = a.Lat, a.Lng
+	r.City = a.City
+	return geo.CheckLatLng(r.LatitudeV, r.LongitudeV)
+}
+
 // String displays the contents of the SearchRequest custom type.
 func (r SearchRequest) String() string {
 	ls := new(common.FmtBoxer)
@@ -460,6 +534,7 @@ func (r SearchRequest) String() string {
 	ls.AddF("RID: %s\n", r.RID)
 	ls.AddF("Device Type: %q    ID: %q\n", r.DeviceType, r.DeviceID)
 	ls.AddF("Lat: %v (%f)  Lng: %v (%f)\n", r.Latitude, r.LatitudeV, r.Longitude, r.LongitudeV)
+	ls.AddF("Address: %q\n", r.FullAddress)
 	ls.AddF("Radius: %v (%d) AreaID: %q\n", r.Radius, r.RadiusV, r.AreaID)
 	ls.AddF("MaxResults: %v\n", r.MaxResults)
 	return ls.Box(80)
@@ -560,12 +635,12 @@ func (r *SearchResponseReport) emptyToNil() {
 func (r SearchResponseReport) String() string {
 	ls := new(common.FmtBoxer)
 	ls.AddF("ServiceRequestID: %s\n", r.RID.RID())
-	ls.AddF("Service - id: %q   name: %q\n", r.ServiceCode, r.ServiceName)
+	ls.AddF("Service - id: %q   name: %q\n", strValue(r.ServiceCode), strValue(r.ServiceName))
 	ls.AddF("Created: %v   Updated: %v   Expected: %v\n", r.RequestedAt, r.UpdatedAt, r.ExpectedAt)
-	ls.AddF("Description: %q\n", r.Description)
-	ls.AddF("Agency: %s\n", r.AgencyResponsible)
+	ls.AddF("Description: %q\n", strValue(r.Description))
+	ls.AddF("Agency: %s\n", strValue(r.AgencyResponsible))
 	ls.AddF("Location - lat: %v  lon: %v \n", r.Latitude, r.Longitude)
-	ls.AddF("          %s  zip: %s\n", r.Address, r.ZipCode)
-	ls.AddF("MediaURL: %s\n", r.MediaURL)
+	ls.AddF("          %s  zip: %s\n", strValue(r.Address), strValue(r.ZipCode))
+	ls.AddF("MediaURL: %s\n", strValue(r.MediaURL))
 	return ls.Box(80)
 }
@@ -0,0 +1,213 @@
+package request
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common/geo"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+	"github.com/codeforsanjose/open311-gateway/engine/router"
+	"github.com/codeforsanjose/open311-gateway/engine/telemetry"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+const (
+	searchCacheName         = "search"
+	searchCacheGrid         = 25 // meters
+	searchCacheRadiusBucket = 50 // meters
+	searchCacheMaxResults   = 20 // The Adapters' default MaxResults.
+	metersPerDegree         = 111320.0
+)
+
+var searchResults *searchCache
+
+// =======================================================================================
+//                                      SEARCH CACHE
+// =======================================================================================
+
+// searchCache is a size limited LRU cache of Lat/Lng Search results, indexed by a
+// normalized query.  The location is snapped to a grid, and the radius rounded up to
+// a bucket, so near-identical searches (e.g. panning a map) share an entry.  Each
+// Area has its own TTL, and all entries for an Area are dropped when a report is
+// created in that Area.  A nil *searchCache is valid, and caches nothing.
+type searchCache struct {
+	size   int
+	grid   float64
+	bucket int
+	ttl    func(areaID string) time.Duration
+
+	lru     *list.List
+	entries map[string]*list.Element
+
+	hits      int64
+	misses    int64
+	evictions int64
+	sync.Mutex
+}
+
+type searchCacheEntry struct {
+	key     string
+	areaID  string
+	expires time.Time
+	reports []structs.NSearchResponseReport
+}
+
+// newSearchCache returns a searchCache for the config, or nil if the cache is disabled.
+func newSearchCache(cfg router.SearchCacheConfig, ttl func(areaID string) time.Duration) *searchCache {
+	if cfg.Size <= 0 || cfg.TTL <= 0 {
+		return nil
+	}
+	c := &searchCache{
+		size:    cfg.Size,
+		grid:    float64(cfg.Grid),
+		bucket:  cfg.RadiusBucket,
+		ttl:     ttl,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+	if c.grid <= 0 {
+		c.grid = searchCacheGrid
+	}
+	if c.bucket <= 0 {
+		c.bucket = searchCacheRadiusBucket
+	}
+	return c
+}
+
+// key returns the normalized query key.
+func (c *searchCache) key(areaID string, lat, lng float64, radius, maxResults int) string {
+	key, _, _, _, _ := c.query(areaID, lat, lng, radius, maxResults)
+	return key
+}
+
+// query returns the normalized query key, and the search sent to the Adapters when the
+// key is not cached.  That search is centred on the grid cell, and its radius is the
+// top of the radius bucket plus the size of the cell, so it finds the reports for any
+// search sharing the key.  As it covers a wider area, it asks for more results, scaled
+// by its area over the area of the smallest search sharing the key.  The results are
+// then filtered by withinRadius, and limited by mergeReports, for each request.
+func (c *searchCache) query(areaID string, lat, lng float64, radius, maxResults int) (key string, qLat, qLng float64, qRadius, qMax int) {
+	latStep := c.grid / metersPerDegree
+	latCell := math.Floor(lat / latStep)
+	lngStep := c.grid / (metersPerDegree * math.Cos((latCell+0.5)*latStep*math.Pi/180))
+	lngCell := math.Floor(lng / lngStep)
+	radiusBucket := (radius + c.bucket - 1) / c.bucket
+	key = fmt.Sprintf("%s|%.0f,%.0f|%d|%d", areaID, latCell, lngCell, radiusBucket, maxResults)
+
+	qRadius = radiusBucket*c.bucket + int(math.Ceil(c.grid))
+	smallest := math.Max(float64((radiusBucket-1)*c.bucket), c.grid)
+	if qMax = maxResults; qMax <= 0 {
+		qMax = searchCacheMaxResults
+	}
+	qMax = int(math.Ceil(float64(qMax) * math.Pow(float64(qRadius)/smallest, 2)))
+	return key, (latCell + 0.5) * latStep, (lngCell + 0.5) * lngStep, qRadius, qMax
+}
+
+// withinRadius returns the reports within radius meters of lat/lng.  Reports without a
+// location are kept, as the Adapter returned them for the search.
+func withinRadius(reports []structs.NSearchResponseReport, lat, lng float64, radius int) []structs.NSearchResponseReport {
+	list := make([]structs.NSearchResponseReport, 0, len(reports))
+	for _, rpt := range reports {
+		rLat, errLat := strconv.ParseFloat(rpt.Latitude, 64)
+		rLng, errLng := strconv.ParseFloat(rpt.Longitude, 64)
+		if errLat == nil && errLng == nil && (rLat != 0 || rLng != 0) && geo.Distance(lat, lng, rLat, rLng) > float64(radius) {
+			continue
+		}
+		list = append(list, rpt)
+	}
+	return list
+}
+
+// get returns a copy of the cached reports for the key.
+func (c *searchCache) get(key string) ([]structs.NSearchResponseReport, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.Lock()
+	defer c.Unlock()
+	e, ok := c.entries[key]
+	if ok && time.Now().After(e.Value.(*searchCacheEntry).expires) {
+		c.remove(e)
+		ok = false
+	}
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.lru.MoveToFront(e)
+	reports := e.Value.(*searchCacheEntry).reports
+	return append(make([]structs.NSearchResponseReport, 0, len(reports)), reports...), true
+}
+
+// put caches a copy of the reports, evicting the least recently used entries if the
+// cache is full.
+func (c *searchCache) put(key, areaID string, reports []structs.NSearchResponseReport) {
+	if c == nil {
+		return
+	}
+	ttl := c.ttl(areaID)
+	if ttl <= 0 {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.remove(e)
+	}
+	c.entries[key] = c.lru.PushFront(&searchCacheEntry{
+		key:     key,
+		areaID:  areaID,
+		expires: time.Now().Add(ttl),
+		reports: append(make([]structs.NSearchResponseReport, 0, len(reports)), reports...),
+	})
+	for c.lru.Len() > c.size {
+		c.remove(c.lru.Back())
+		c.evictions++
+	}
+}
+
+// invalidate drops all cached searches for the Area.
+func (c *searchCache) invalidate(areaID string) {
+	if c == nil {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	n := 0
+	for e := c.lru.Front(); e != nil; {
+		next := e.Next()
+		if e.Value.(*searchCacheEntry).areaID == areaID {
+			c.remove(e)
+			n++
+		}
+		e = next
+	}
+	log.Debugf("Search cache - invalidated %d searches for area: %q", n, areaID)
+}
+
+func (c *searchCache) remove(e *list.Element) {
+	c.lru.Remove(e)
+	delete(c.entries, e.Value.(*searchCacheEntry).key)
+}
+
+// stats returns the cache statistics.
+func (c *searchCache) stats() (hits, misses, size, evictions int64) {
+	c.Lock()
+	defer c.Unlock()
+	return c.hits, c.misses, int64(c.lru.Len()), c.evictions
+}
+
+// sendTelemetry sends the cache statistics to the monitor.
+func (c *searchCache) sendTelemetry() {
+	if c == nil {
+		return
+	}
+	hits, misses, size, evictions := c.stats()
+	telemetry.SendCache(searchCacheName, hits, misses, size, evictions)
+}
@@ -0,0 +1,129 @@
+package request
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common/geo"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+	"github.com/codeforsanjose/open311-gateway/engine/router"
+)
+
+func newTestSearchCache(size int) *searchCache {
+	return newSearchCache(router.SearchCacheConfig{Size: size, TTL: 60}, func(areaID string) time.Duration {
+		if areaID == "NOCACHE" {
+			return 0
+		}
+		return time.Minute
+	})
+}
+
+func TestSearchCacheKey(t *testing.T) {
+	c := newTestSearchCache(10)
+	a := c.key("SJ", 37.33820, -121.88630, 100, 10)
+	if b := c.key("SJ", 37.33821, -121.88631, 90, 10); a != b {
+		t.Errorf("nearby searches should share a key: %q %q", a, b)
+	}
+	for _, b := range []string{
+		c.key("SJ", 37.3392, -121.8863, 100, 10), // ~110m north
+		c.key("SJ", 37.3382, -121.8863, 200, 10),
+		c.key("SJ", 37.3382, -121.8863, 100, 20),
+		c.key("SC", 37.3382, -121.8863, 100, 10),
+	} {
+		if a == b {
+			t.Errorf("searches should not share a key: %q", a)
+		}
+	}
+}
+
+func TestSearchCacheQuery(t *testing.T) {
+	c := newTestSearchCache(10)
+	key, qLat, qLng, qRadius, qMax := c.query("SJ", 37.33820, -121.88630, 90, 10)
+
+	// The wider search asks for more results: 125m over the 50m smallest search in the bucket.
+	if qRadius != 125 || qMax != 63 {
+		t.Errorf("unexpected search - radius: %d max results: %d", qRadius, qMax)
+	}
+	if _, _, _, _, qMax := c.query("SJ", 37.33820, -121.88630, 90, 0); qMax != 125 {
+		t.Errorf("expected the default max results to be scaled, got: %d", qMax)
+	}
+
+	// The search sent to the Adapters must cover every search sharing the key.
+	for _, s := range []struct {
+		lat, lng float64
+		radius   int
+	}{
+		{37.33820, -121.88630, 90},
+		{37.33821, -121.88631, 100},
+		{37.33812, -121.88640, 60},
+	} {
+		if k := c.key("SJ", s.lat, s.lng, s.radius, 10); k != key {
+			t.Fatalf("%v should share the key %q, got: %q", s, key, k)
+		}
+		if d := geo.Distance(qLat, qLng, s.lat, s.lng) + float64(s.radius); d > float64(qRadius) {
+			t.Errorf("%v is not covered by the search: %v,%v radius: %d (%.1f)", s, qLat, qLng, qRadius, d)
+		}
+	}
+
+	// The cached reports are filtered by the radius of each request.
+	reports := []structs.NSearchResponseReport{
+		{Description: "near", Latitude: "37.3382", Longitude: "-121.8863"},
+		{Description: "80m", Latitude: "37.33892", Longitude: "-121.8863"},
+		{Description: "110m", Latitude: "37.3392", Longitude: "-121.8863"},
+		{Description: "no location"},
+	}
+	for radius, want := range map[int]string{
+		50:  "[near no location]",
+		100: "[near 80m no location]",
+		200: "[near 80m 110m no location]",
+	} {
+		var got []string
+		for _, rpt := range withinRadius(reports, 37.3382, -121.8863, radius) {
+			got = append(got, rpt.Description)
+		}
+		if fmt.Sprint(got) != want {
+			t.Errorf("radius %d - got: %v want: %s", radius, got, want)
+		}
+	}
+}
+
+func TestSearchCache(t *testing.T) {
+	c := newTestSearchCache(2)
+	reports := []structs.NSearchResponseReport{{Description: "one"}}
+
+	if _, ok := c.get("a"); ok {
+		t.Errorf("empty cache returned a hit")
+	}
+	c.put("a", "SJ", reports)
+	c.put("b", "SC", reports)
+	reports[0].Description = "changed"
+	if r, ok := c.get("a"); !ok || r[0].Description != "one" {
+		t.Errorf("get(a) = %v, %v", r, ok)
+	}
+
+	// "b" is the least recently used.
+	c.put("c", "SJ", reports)
+	if _, ok := c.get("b"); ok {
+		t.Errorf("b should have been evicted")
+	}
+
+	c.invalidate("SJ")
+	if _, ok := c.get("a"); ok {
+		t.Errorf("a should have been invalidated")
+	}
+
+	c.put("d", "NOCACHE", reports)
+	if _, ok := c.get("d"); ok {
+		t.Errorf("d should not have been cached")
+	}
+
+	hits, misses, size, evictions := c.stats()
+	if hits != 1 || misses != 4 || size != 0 || evictions != 1 {
+		t.Errorf("stats - hits: %d misses: %d size: %d evictions: %d", hits, misses, size, evictions)
+	}
+
+	if c := newSearchCache(router.SearchCacheConfig{}, nil); c != nil {
+		t.Errorf("cache should be disabled")
+	}
+}
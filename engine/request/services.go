@@ -1,8 +1,10 @@
 package request
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
@@ -12,7 +14,7 @@ import (
 	"github.com/codeforsanjose/open311-gateway/common/geo"
 	"github.com/codeforsanjose/open311-gateway/common/sid"
 	"github.com/codeforsanjose/open311-gateway/engine/router"
-	"github.com/codeforsanjose/open311-gateway/engine/services"
+	"github.com/codeforsanjose/open311-gateway/engine/taxonomy"
 	"github.com/codeforsanjose/open311-gateway/common/structs"
 	"github.com/codeforsanjose/open311-gateway/engine/telemetry"
 
@@ -38,7 +40,9 @@ type serviceMgr struct {
 
 	// routes structs.NRoutes
 
-	resp *ServicesResp
+	resp       *ServicesResp
+	stale      bool   // The Services are from the snapshot.
+	generation uint64 // The Catalog the Services are from.
 }
 
 func processServices(rqst *rest.Request) (fresp interface{}, ferr error) {
@@ -79,7 +83,34 @@ func processServices(rqst *rest.Request) (fresp interface{}, ferr error) {
 		return fail(err)
 	}
 
-	return mgr.resp, nil
+	return &servicesResult{list: mgr.resp, stale: mgr.stale, generation: mgr.generation}, nil
+}
+
+const (
+	// hdrServicesStale is sent with the Services loaded from the snapshot, until their
+	// Adapters are refreshed.
+	hdrServicesStale = "X-Services-Stale"
+	// hdrServicesGeneration is the Generation of the Catalog the Services are from.
+	hdrServicesGeneration = "X-Services-Generation"
+)
+
+// servicesResult is the Services response, with the state of the Services cache in the
+// headers.
+type servicesResult struct {
+	list       *ServicesResp
+	stale      bool
+	generation uint64
+}
+
+func (r *servicesResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.list)
+}
+
+func (r *servicesResult) setHeaders(h http.Header) {
+	h.Set(hdrServicesGeneration, strconv.FormatUint(r.generation, 10))
+	if r.stale {
+		h.Set(hdrServicesStale, "true")
+	}
 }
 
 // -------------------------------------------------------------------------------
@@ -96,9 +127,9 @@ func (r *serviceMgr) validate() error {
 	}
 
 	v := r.valid
-	v.Set("qryParms", "Query parms parsed and loaded ok", false)
-	v.Set("inputs", "Type conversion of inputs is OK", false)
-	v.Set("areaID", "We have a valid AreaID", false)
+	v.Set("qryParms", "", false) // Query parms parsed and loaded ok
+	v.Set("inputs", "", false)   // Type conversion of inputs is OK
+	v.Set("areaID", "", false)   // We have a valid AreaID
 
 	// Load Query Parms.
 	if err := r.parseQP(); err != nil {
@@ -115,7 +146,7 @@ func (r *serviceMgr) validate() error {
 	// Location
 	switch {
 	case geo.ValidateLatLng(r.req.LatitudeV, r.req.LongitudeV):
-		r.req.City, _ = geo.GooCityForLatLng(r.req.LatitudeV, r.req.LongitudeV)
+		r.req.City, _ = cityForLatLng(r.req.LatitudeV, r.req.LongitudeV)
 		fallthrough
 
 	case len(r.req.FullAddress) > 0:
@@ -156,6 +187,14 @@ func (r *serviceMgr) parseQP() error {
 	r.req.City = r.rqst.URL.Query().Get("city")
 	r.req.State = r.rqst.URL.Query().Get("state")
 	r.req.Zip = r.rqst.URL.Query().Get("zip")
+	r.req.Category = r.rqst.URL.Query().Get("category")
+	r.req.Query = r.rqst.URL.Query().Get("q")
+	r.req.Lang = r.rqst.URL.Query().Get("lang")
+	if r.req.Lang == "" {
+		// The first language in the Accept-Language header, e.g. "es-MX,es;q=0.9,en;q=0.8".
+		lang := strings.SplitN(r.rqst.Header.Get("Accept-Language"), ",", 2)[0]
+		r.req.Lang = strings.TrimSpace(strings.SplitN(lang, ";", 2)[0])
+	}
 	return nil
 }
 
@@ -165,16 +204,34 @@ func (r *serviceMgr) parseQP() error {
 
 func (r *serviceMgr) run() error {
 	log.Debug(r.req.String())
-	services, err := services.GetArea(r.req.areaID)
+	cat := router.GetCatalog()
+	var list structs.NServices
+	var err error
+	if r.req.Query != "" {
+		list, err = cat.Search(r.req.areaID, r.req.Query)
+	} else {
+		list, err = cat.AreaServices(r.req.areaID)
+	}
 	if err != nil {
 		return fmt.Errorf("Cannot find services for %v - %v", r.req.City, err.Error())
 	}
-	// log.Debugf("***Services:\n%v", services.String())
-	resp, err := newServiceResp("OK", services)
+	t := taxonomy.Get()
+	if r.req.Category != "" {
+		c, ok := t.Category(r.req.Category)
+		if !ok {
+			return fmt.Errorf("Invalid category: %q", r.req.Category)
+		}
+		list = inCategory(list, cat.Categories, c.ID)
+	}
+	// log.Debugf("***Services:\n%v", list.String())
+	resp, err := newServiceResp("OK", list)
 	if err != nil {
 		return err
 	}
+	resp.categorize(cat.Categories, t, r.req.Lang)
 	r.resp = resp
+	r.stale = cat.Stale[r.req.areaID]
+	r.generation = cat.Generation
 	return err
 }
 
@@ -193,6 +250,9 @@ type ServicesReq struct {
 	City        string  `json:"city" xml:"city"`
 	State       string  `json:"state" xml:"state"`
 	Zip         string  `json:"zip" xml:"zip"`
+	Category    string  `json:"category" xml:"category"` // A taxonomy Category ID.
+	Lang        string  `json:"lang" xml:"lang"`         // The language of the Category names.
+	Query       string  `json:"q" xml:"q"`               // Search text - the matching Services are returned, best match first.
 	areaID      string
 }
 
@@ -242,15 +302,43 @@ func newServiceResp(msg string, ns structs.NServices) (*ServicesResp, error) {
 	return &newSR, nil
 }
 
-// ServicesRespS represents a service in a service list.
+// categorize sets the taxonomy Category of each service, with its name in the language.
+func (r ServicesResp) categorize(categories map[string]string, t *taxonomy.Taxonomy, lang string) {
+	for _, sr := range r {
+		c, ok := t.Category(categories[sr.ID])
+		if !ok {
+			continue
+		}
+		id, name, icon := c.ID, t.Label(c, lang), c.Icon
+		sr.Category, sr.CategoryName, sr.Icon = &id, &name, &icon
+		sr.emptyToNil()
+	}
+}
+
+// inCategory returns the Services in the taxonomy Category.
+func inCategory(list structs.NServices, categories map[string]string, id string) structs.NServices {
+	l := make(structs.NServices, 0)
+	for _, ns := range list {
+		if categories[ns.MID()] == id {
+			l = append(l, ns)
+		}
+	}
+	return l
+}
+
+// ServicesRespS represents a service in a service list.  The Category is the taxonomy
+// Category of the service.
 type ServicesRespS struct {
-	ID          string  `json:"service_code" xml:"service_code"`
-	Name        *string `json:"service_name" xml:"service_name"`
-	Description *string `json:"description" xml:"description"`
-	Metadata    bool    `json:"metadata" xml:"metadata"`
-	Stype       *string `json:"type" xml:"type"`
-	Keywords    *string `json:"keywords" xml:"keywords"`
-	Group       *string `json:"group" xml:"group"`
+	ID           string  `json:"service_code" xml:"service_code"`
+	Name         *string `json:"service_name" xml:"service_name"`
+	Description  *string `json:"description" xml:"description"`
+	Metadata     bool    `json:"metadata" xml:"metadata"`
+	Stype        *string `json:"type" xml:"type"`
+	Keywords     *string `json:"keywords" xml:"keywords"`
+	Group        *string `json:"group" xml:"group"`
+	Category     *string `json:"category" xml:"category"`
+	CategoryName *string `json:"category_name" xml:"category_name"`
+	Icon         *string `json:"icon" xml:"icon"`
 }
 
 func newServicesRespS(s structs.NService) (sr *ServicesRespS) {
@@ -290,6 +378,9 @@ func (r *ServicesRespS) emptyToNil() {
 	if r.Group != nil && *r.Group == "" {
 		r.Group = nil
 	}
+	if r.Icon != nil && *r.Icon == "" {
+		r.Icon = nil
+	}
 }
 
 // =======================================================================================
@@ -0,0 +1,81 @@
+package router
+
+import (
+	"fmt"
+
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+// allCapabilities are assumed for Adapters not answering the "Adapter.Capabilities" RPC.
+var allCapabilities = structs.NCapabilities{
+	RequestTypes: []structs.NRequestType{structs.NRTCreate, structs.NRTSearchLL, structs.NRTSearchDID, structs.NRTSearchRID},
+	Media:        true,
+	Anonymous:    true,
+}
+
+// GetRouteCapabilities returns the Capabilities of the Adapter for the specified route.
+func GetRouteCapabilities(route structs.NRoute) (structs.NCapabilities, error) {
+	adp, err := adapters.getRouteAdapter(route)
+	if err != nil {
+		return structs.NCapabilities{}, err
+	}
+	return adp.Capabilities(), nil
+}
+
+// FilterRoutes returns the routes able to handle the request type.
+func FilterRoutes(routes structs.NRoutes, rtype structs.NRequestType) structs.NRoutes {
+	filtered := make(structs.NRoutes, 0, len(routes))
+	for _, route := range routes {
+		caps, err := GetRouteCapabilities(route)
+		if err == nil && !caps.Supports(rtype) {
+			log.WithFields(log.Fields{
+				"route": route.String(),
+				"type":  rtype.String(),
+			}).Debug("Route skipped - request type not supported.")
+			continue
+		}
+		filtered = append(filtered, route)
+	}
+	return filtered
+}
+
+// ValidateCreate verifies the Provider for the route can honor the Create request.
+func ValidateCreate(route structs.NRoute, rqst *structs.NCreateRequest) error {
+	caps, err := GetRouteCapabilities(route)
+	if err != nil {
+		return err
+	}
+	if err := caps.CheckCreate(rqst); err != nil {
+		return fmt.Errorf("unable to create the report - %s", err)
+	}
+	return nil
+}
+
+// ------------------------------- Adapter -------------------------------
+
+// Capabilities returns the Capabilities reported by the Adapter.
+func (adp *Adapter) Capabilities() structs.NCapabilities {
+	if caps, ok := adp.caps.Load().(structs.NCapabilities); ok {
+		return caps
+	}
+	return allCapabilities
+}
+
+// loadCapabilities asks the Adapter for its Capabilities.  If the Adapter does not
+// support the "Adapter.Capabilities" RPC, it is assumed to handle all requests.
+func (adp *Adapter) loadCapabilities() {
+	var resp structs.NCapabilitiesResponse
+	if err := adp.Call("Adapter.Capabilities", &structs.NCapabilitiesRequest{}, &resp); err != nil {
+		log.WithFields(log.Fields{
+			"adapter": adp.ID,
+			"error":   err.Error(),
+		}).Warn("Unable to get the adapter capabilities - assuming all requests are supported")
+		return
+	}
+	log.WithFields(log.Fields{
+		"adapter": adp.ID,
+	}).Debug("Adapter " + resp.Capabilities.String())
+	adp.caps.Store(resp.Capabilities)
+}
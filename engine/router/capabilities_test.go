@@ -0,0 +1,78 @@
+package router
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/codeforsanjose/open311-gateway/common/adaptersdk"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+// serveAdapter starts an SDK Adapter with the capabilities, and returns its address.
+func serveAdapter(t *testing.T, caps structs.NCapabilities) (string, func()) {
+	a := adaptersdk.New(func() adaptersdk.Provider { return new(adaptersdk.ProviderBase) })
+	if err := a.Load([]byte(`{"adapter": {"name": "TST1"}, "serviceAreas": {}}`), nil); err != nil {
+		t.Fatal(err)
+	}
+	a.Capabilities = caps
+	h, err := a.Handler()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go http.Serve(l, h)
+	return l.Addr().String(), func() { l.Close() }
+}
+
+func TestCapabilities(t *testing.T) {
+	addr, done := serveAdapter(t, structs.NCapabilities{
+		RequestTypes: []structs.NRequestType{structs.NRTCreate},
+		Required:     []string{"Email"},
+	})
+	defer done()
+
+	email := &Adapter{ID: "EM1", Address: addr}
+	legacy := &Adapter{ID: "CS1", Address: "127.0.0.1:1"}
+	if err := email.connect(); err != nil {
+		t.Fatal(err)
+	}
+	saved := adapters.Adapters
+	defer func() { adapters.Adapters = saved }()
+	adapters.Adapters = map[string]*Adapter{"EM1": email, "CS1": legacy}
+
+	emailRoute := structs.NRoute{AdpID: "EM1", AreaID: "SJ", ProviderID: 1}
+	legacyRoute := structs.NRoute{AdpID: "CS1", AreaID: "SJ", ProviderID: 1}
+	routes := structs.NRoutes{emailRoute, legacyRoute}
+
+	// An Adapter without the Capabilities RPC is assumed to support everything.
+	if r := FilterRoutes(routes, structs.NRTSearchLL); len(r) != 1 || r[0] != legacyRoute {
+		t.Errorf("expected only the legacy route, got: %v", r)
+	}
+	if r := FilterRoutes(routes, structs.NRTCreate); len(r) != 2 {
+		t.Errorf("expected both routes, got: %v", r)
+	}
+	if r := FilterRoutes(structs.NRoutes{{AdpID: "EM1", AreaID: "all"}}, structs.NRTServicesAll); len(r) != 1 {
+		t.Errorf("Services requests should always be routed, got: %v", r)
+	}
+
+	rqst := &structs.NCreateRequest{Description: "Pothole"}
+	if err := ValidateCreate(emailRoute, rqst); err == nil || !strings.Contains(err.Error(), "Email") {
+		t.Errorf("expected a missing Email error, got: %v", err)
+	}
+	rqst.Email = "jane@example.com"
+	if err := ValidateCreate(emailRoute, rqst); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	rqst.MediaURL = "http://img.example.com/1.jpg"
+	if err := ValidateCreate(emailRoute, rqst); err == nil || !strings.Contains(err.Error(), "media") {
+		t.Errorf("expected a media error, got: %v", err)
+	}
+	if err := ValidateCreate(legacyRoute, rqst); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package router
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+	"github.com/codeforsanjose/open311-gateway/engine/index"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+var (
+	catalog    atomic.Value // *Catalog - the published Catalog.
+	publishing sync.Mutex   // Serializes the Catalog updates.
+)
+
+// ==============================================================================================================================
+//                                      CATALOG
+// ==============================================================================================================================
+
+// Catalog is the Services cache, and the route and Area Adapter indexes built from it.  A
+// Catalog is never changed once it is published - each update publishes a new Catalog, so
+// a request reading one Catalog sees Services, routes and Adapters from the same refresh.
+type Catalog struct {
+	Generation   uint64                       // Incremented by each PublishCatalog().
+	Built        time.Time                    //
+	Services     map[string]structs.NServices // Index: AreaID
+	MIDs         map[string]bool              // Index: MID
+	AreaAdapters map[string][]string          // Index: AreaID - the sorted AdpIDs.
+	AreaRoutes   map[string]structs.NRoutes   // Index: AreaID
+	Routes       structs.NRoutes              // All Routes.
+	Stale        map[string]bool              // Index: AreaID - Areas with Services from the snapshot.
+	Categories   map[string]string            // Index: MID - the taxonomy Category ID of the mapped Services.
+	Index        *index.Index                 // The full text index of the Services.
+}
+
+// NewCatalog builds the Catalog for the Services of each Area.  The Services must not be
+// changed after the Catalog is built.
+func NewCatalog(services map[string]structs.NServices, stale map[string]bool) *Catalog {
+	c := &Catalog{
+		Built:        time.Now(),
+		Services:     services,
+		MIDs:         make(map[string]bool),
+		AreaAdapters: make(map[string][]string),
+		AreaRoutes:   make(map[string]structs.NRoutes),
+		Routes:       structs.NewNRoutes(),
+		Stale:        stale,
+		Categories:   make(map[string]string),
+	}
+	if c.Services == nil {
+		c.Services = make(map[string]structs.NServices)
+	}
+	if c.Stale == nil {
+		c.Stale = make(map[string]bool)
+	}
+	ids := make([]string, 0, len(c.Services))
+	for areaID := range c.Services {
+		ids = append(ids, areaID)
+	}
+	sort.Strings(ids)
+	for _, areaID := range ids {
+		seenAdp := make(map[string]bool)
+		seenRoute := make(map[structs.NRoute]bool)
+		routes := structs.NewNRoutes()
+		for _, ns := range c.Services[areaID] {
+			c.MIDs[ns.MID()] = true
+			if !seenAdp[ns.AdpID] {
+				seenAdp[ns.AdpID] = true
+				c.AreaAdapters[areaID] = append(c.AreaAdapters[areaID], ns.AdpID)
+			}
+			if route := ns.GetRoute(); !seenRoute[route] {
+				seenRoute[route] = true
+				routes = append(routes, route)
+			}
+		}
+		sort.Strings(c.AreaAdapters[areaID])
+		sort.Slice(routes, func(i, j int) bool {
+			if routes[i].AdpID != routes[j].AdpID {
+				return routes[i].AdpID < routes[j].AdpID
+			}
+			return routes[i].ProviderID < routes[j].ProviderID
+		})
+		c.AreaRoutes[areaID] = routes
+		c.Routes = append(c.Routes, routes...)
+	}
+	return c
+}
+
+// GetCatalog returns the published Catalog.  It must not be changed.
+func GetCatalog() *Catalog {
+	if c, ok := catalog.Load().(*Catalog); ok {
+		return c
+	}
+	return emptyCatalog
+}
+
+// emptyCatalog is returned until a Catalog is published.
+var emptyCatalog = NewCatalog(nil, nil)
+
+// PublishCatalog assigns the next Generation to the Catalog, and makes it the published
+// Catalog.
+func PublishCatalog(c *Catalog) {
+	publishing.Lock()
+	defer publishing.Unlock()
+	c.Generation = GetCatalog().Generation + 1
+	catalog.Store(c)
+	log.WithFields(log.Fields{
+		"generation": c.Generation,
+		"areas":      len(c.Services),
+		"routes":     len(c.Routes),
+	}).Debug("Published the Services catalog")
+}
+
+// OnAdapterRemoved sets the function called when an Adapter is removed, to remove its
+// Services and routes from the Catalog.  It is set by the Services cache, which publishes
+// all the Catalogs, and must be set before the Adapters are loaded.
+func OnAdapterRemoved(f func(adpID string)) {
+	adapterRemoved = f
+}
+
+var adapterRemoved func(adpID string)
+
+// removeCatalogAdapter asks the Services cache to remove the Services and routes of the
+// Adapter from the Catalog.
+func removeCatalogAdapter(adpID string) {
+	if adapterRemoved == nil {
+		log.Warningf("The Services of adapter %s cannot be removed - the Services cache is not running.", adpID)
+		return
+	}
+	adapterRemoved(adpID)
+}
+
+// AreaServices returns the Services for the Area.
+func (c *Catalog) AreaServices(areaID string) (structs.NServices, error) {
+	l, ok := c.Services[areaID]
+	if !ok {
+		return nil, fmt.Errorf("The requested AreaID: %q is not serviced by this gateway.", areaID)
+	}
+	return l, nil
+}
+
+// Search returns the Services of the Area matching the query, best match first.
+func (c *Catalog) Search(areaID, query string) (structs.NServices, error) {
+	list, err := c.AreaServices(areaID)
+	if err != nil {
+		return nil, err
+	}
+	byMID := make(map[string]structs.NService)
+	for _, ns := range list {
+		byMID[ns.MID()] = ns
+	}
+	l := make(structs.NServices, 0)
+	for _, m := range c.Index.Search(areaID, query) {
+		if ns, ok := byMID[m.MID]; ok {
+			l = append(l, ns)
+		}
+	}
+	return l, nil
+}
+
+// ValidService returns true if the Service is in the Catalog.
+func (c *Catalog) ValidService(srvID structs.ServiceID) bool {
+	return c.MIDs[srvID.MID()]
+}
+
+// areaRoutes returns the Routes for the Area.
+func (c *Catalog) areaRoutes(areaID string) (structs.NRoutes, error) {
+	l, ok := c.AreaRoutes[areaID]
+	if !ok {
+		return nil, fmt.Errorf("There are no routes for AreaID: %q", areaID)
+	}
+	return l, nil
+}
+
+// String displays the contents of the Catalog.
+func (c *Catalog) String() string {
+	ls := new(common.FmtBoxer)
+	ls.AddF("Catalog - generation: %d  built: %v\n", c.Generation, c.Built.Format(time.RFC3339))
+	ls.AddF("All Routes: %v\n", c.Routes)
+	for k, v := range c.AreaRoutes {
+		ls.AddF("<<<<<Area: %s  adapters: %s  stale: %t>>>>>%s", k, strings.Join(c.AreaAdapters[k], ", "), c.Stale[k], v)
+	}
+	return ls.Box(90)
+}
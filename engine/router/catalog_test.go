@@ -0,0 +1,70 @@
+package router
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+	"github.com/codeforsanjose/open311-gateway/engine/index"
+)
+
+// svc returns a Service of the Adapter for the Area and provider.
+func svc(adpID, areaID string, providerID, id int) structs.NService {
+	return structs.NService{ServiceID: structs.ServiceID{AdpID: adpID, AreaID: areaID, ProviderID: providerID, ID: id}}
+}
+
+// named returns a Service of the Adapter for SJ, with the name.
+func named(adpID string, id int, name string) structs.NService {
+	ns := svc(adpID, "SJ", 1, id)
+	ns.Name = name
+	return ns
+}
+
+func TestCatalog(t *testing.T) {
+	saved := GetCatalog()
+	defer catalog.Store(saved)
+
+	c := NewCatalog(map[string]structs.NServices{
+		"SJ": {svc("A2", "SJ", 1, 1), svc("A1", "SJ", 2, 2), svc("A1", "SJ", 1, 3), svc("A1", "SJ", 1, 4)},
+		"SC": {svc("A1", "SC", 1, 5)},
+	}, map[string]bool{"SC": true})
+	if fmt.Sprint(c.AreaAdapters["SJ"]) != "[A1 A2]" {
+		t.Errorf("unexpected SJ adapters: %v", c.AreaAdapters["SJ"])
+	}
+	if l := c.AreaRoutes["SJ"]; len(l) != 3 || l[0].String() != "A1-SJ-1" || l[2].String() != "A2-SJ-1" {
+		t.Errorf("unexpected SJ routes: %v", l)
+	}
+	if len(c.Routes) != 4 || !c.ValidService(svc("A1", "SC", 1, 5).ServiceID) || c.ValidService(svc("A1", "SC", 1, 6).ServiceID) {
+		t.Errorf("unexpected catalog: %v", c)
+	}
+
+	// Each published Catalog has the next Generation.
+	gen := GetCatalog().Generation
+	PublishCatalog(c)
+	if GetCatalog() != c || c.Generation != gen+1 {
+		t.Errorf("expected generation %d to be published, got: %d", gen+1, GetCatalog().Generation)
+	}
+
+	// Removing an Adapter is left to the Services cache, which publishes all Catalogs.
+	defer OnAdapterRemoved(adapterRemoved)
+	var removed []string
+	OnAdapterRemoved(func(adpID string) { removed = append(removed, adpID) })
+	removeCatalogAdapter("A1")
+	if fmt.Sprint(removed) != "[A1]" || GetCatalog() != c {
+		t.Errorf("expected the removal to be passed to the Services cache, got: %v", removed)
+	}
+}
+
+func TestCatalogSearch(t *testing.T) {
+	services := map[string]structs.NServices{
+		"SJ": {named("A1", 1, "Pothole"), named("A1", 2, "Graffiti"), named("A2", 3, "Pothole Repair")},
+	}
+	c := NewCatalog(services, nil)
+	c.Index = index.Build(services, nil)
+	if l, err := c.Search("SJ", "potholes"); err != nil || len(l) != 2 || l[0].MID() != "A1-SJ-1-1" {
+		t.Errorf("unexpected search results: %v (%v)", l, err)
+	}
+	if _, err := c.Search("XX", "pothole"); err == nil {
+		t.Errorf("expected an error for an unknown area")
+	}
+}
@@ -1,36 +1,27 @@
 package router
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/rpc"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/codeforsanjose/open311-gateway/common"
+	"github.com/codeforsanjose/open311-gateway/common/geo"
+	"github.com/codeforsanjose/open311-gateway/common/rpcauth"
 	"github.com/codeforsanjose/open311-gateway/common/structs"
 
 	log "github.com/jeffizhungry/logrus"
 )
 
-var (
-	adapters Adapters
-	routes   routeData
-)
-
-// GetChAreaAdp returns the channel used to update the areaAdapters index.
-func GetChAreaAdp() chan map[string][]string {
-	return adapters.chUpdate
-}
-
-// GetChRouteUpd returns the channel used to update the Route Data indexed by AreaID (received from Services).
-func GetChRouteUpd() chan map[structs.NRoute]bool {
-	return routes.chUpdate
-}
+var adapters Adapters
 
 // GetAreaAdapters returns a list of the Adapters that provide services to the specified
 // Area.
@@ -55,13 +46,13 @@ func GetAdapter(id string) (*Adapter, error) {
 
 // GetAreaRoutes returns a list of Routes (structs.NRoutes) for the specified Area.
 func GetAreaRoutes(areaID string) (structs.NRoutes, error) {
-	return routes.getAreaRoutes(areaID)
+	return GetCatalog().areaRoutes(areaID)
 }
 
 // GetAllActiveRoutes returns a list of Routes that have been returned by the Service Cache
 // queries - hence all "active" routes.
 func GetAllActiveRoutes() structs.NRoutes {
-	return routes.getAllRoutes()
+	return GetCatalog().Routes
 }
 
 // GetAllRoutes returns a list of all configured routes.
@@ -81,111 +72,87 @@ func GetMonitorAddress() string {
 
 // GetNetworkConfig returns the network configuration for the Engine.
 func GetNetworkConfig() (address, protocol, certFile, keyFile string) {
+	adapters.RLock()
+	defer adapters.RUnlock()
 	n := adapters.Network
 	return n.Address, n.Protocol, n.CertFile, n.KeyFile
 }
 
+// GetShutdownGrace returns how long to wait for requests in progress on shutdown.
+func GetShutdownGrace() time.Duration {
+	adapters.RLock()
+	defer adapters.RUnlock()
+	return time.Duration(adapters.Network.ShutdownGrace) * time.Second
+}
+
+// GetRegistrationLease returns the lease of the registered Adapters, or 0 if registration
+// is disabled.
+func GetRegistrationLease() time.Duration {
+	adapters.RLock()
+	defer adapters.RUnlock()
+	if !adapters.Registration.Enabled {
+		return 0
+	}
+	return time.Duration(adapters.Registration.Lease) * time.Second
+}
+
 // GetSearchRadius returns the Min and Max Search Radius values.
 func GetSearchRadius() (min, max int) {
+	adapters.RLock()
+	defer adapters.RUnlock()
 	n := adapters.General
 	return n.SearchRadiusMin, n.SearchRadiusMax
 }
 
-// ==============================================================================================================================
-//                                      ROUTES
-// ==============================================================================================================================
-
-// routeData is the list of currently active Routes.
-type routeData struct {
-	indArea   [2]map[string]structs.NRoutes // Index: AreaID
-	all       [2][]structs.NRoute           // Index: All Routes
-	activeSet int
-	chUpdate  chan map[structs.NRoute]bool // Channel to receive Area indexed updates from Services.
-	sync.RWMutex
+// GetGeocoderConfig returns the Geocoder configuration.
+func GetGeocoderConfig() geo.Config {
+	return adapters.Geocoder
 }
 
-// getArea retrieves the RouteList for the specified area.
-func (r *routeData) getAllRoutes() structs.NRoutes {
-	r.RLock()
-	defer r.RUnlock()
-	log.WithFields(log.Fields{
-		"activeSet":  r.activeSet,
-		"len(r.all)": len(r.all[r.activeSet]),
-	}).Debug("getAllRoutes")
-	return r.all[r.activeSet]
+// GetRegions returns the geographic regions where requests are valid.  If the "regions"
+// are not specified in the config file, they are derived from the bounds of the Areas.
+func GetRegions() geo.Regions {
+	adapters.RLock()
+	defer adapters.RUnlock()
+	return adapters.getRegions()
 }
 
-// getArea retrieves the RouteList for the specified area.
-func (r *routeData) getAreaRoutes(areaID string) (structs.NRoutes, error) {
-	r.RLock()
-	defer r.RUnlock()
-	l, ok := r.indArea[r.activeSet][areaID]
-	if !ok {
-		return nil, fmt.Errorf("There are no routes for AreaID: %q", areaID)
-	}
-	return l, nil
-}
-
-// getArea retrieves the ServiceList for the specified area.
-func (r *routeData) validateRoute(route structs.NRoute) bool {
-	r.RLock()
-	defer r.RUnlock()
-
-	return false
+// GetRefreshConfig returns the Services refresh schedule.
+func GetRefreshConfig() RefreshConfig {
+	adapters.RLock()
+	defer adapters.RUnlock()
+	return adapters.Refresh
 }
 
-func (r *routeData) update(upd map[structs.NRoute]bool) {
-	r.Lock()
-	defer r.Unlock()
-
-	ls := r.loadSet()
-
-	r.clearLoadSet(ls)
-
-	for route := range upd {
-		log.Debugf("Route: %v", route)
-		r.all[ls] = append(r.all[ls], route)
-		if _, ok := r.indArea[ls][route.AreaID]; !ok {
-			r.indArea[ls][route.AreaID] = make(structs.NRoutes, 0)
-		}
-		r.indArea[ls][route.AreaID] = append(r.indArea[ls][route.AreaID], route)
-	}
-
-	r.switchSet()
-	log.Debug("Updated routeData: " + r.String())
+// GetTaxonomyFile returns the Service category taxonomy file.
+func GetTaxonomyFile() string {
+	adapters.RLock()
+	defer adapters.RUnlock()
+	return adapters.Taxonomy
 }
 
-func (r *routeData) loadSet() (ls int) {
-	if r.activeSet == 0 {
-		ls = 1
+// GetAreaIDs returns the sorted list of the AreaIDs, including the Areas of registered
+// Adapters.
+func GetAreaIDs() []string {
+	adapters.RLock()
+	defer adapters.RUnlock()
+	l := make([]string, 0, len(adapters.Areas))
+	for id := range adapters.Areas {
+		l = append(l, id)
 	}
-	return
+	sort.Strings(l)
+	return l
 }
 
-func (r *routeData) clearLoadSet(ds int) {
-	r.all[r.loadSet()] = make([]structs.NRoute, 0)
-	r.indArea[r.loadSet()] = make(map[string]structs.NRoutes)
+// GetSearchCacheConfig returns the Search cache configuration.
+func GetSearchCacheConfig() SearchCacheConfig {
+	return adapters.SearchCache
 }
 
-func (r *routeData) switchSet() {
-	if r.activeSet == 0 {
-		log.Debug("[RouteData] Switched from list 0 to 1")
-		r.activeSet = 1
-	} else {
-		log.Debug("[RouteData] Switched from list 1 to 0")
-		r.activeSet = 0
-	}
-}
-
-// String displays the contents of the Spec_Type custom type.
-func (r routeData) String() string {
-	ls := new(common.FmtBoxer)
-	ls.AddF("routeData [%d]\n", r.activeSet)
-	ls.AddF("All Routes: %v\n", r.all[r.activeSet])
-	for k, v := range r.indArea[r.activeSet] {
-		ls.AddF("<<<<<Area: %s >>>>>%s", k, v)
-	}
-	return ls.Box(90)
+// GetAreaSearchCacheTTL returns the Search cache TTL (in seconds) for the Area.  If the
+// Area does not specify a TTL, the "searchCache" TTL is returned.
+func GetAreaSearchCacheTTL(areaID string) int {
+	return adapters.searchCacheTTL(areaID)
 }
 
 // ==============================================================================================================================
@@ -202,6 +169,8 @@ type Adapters struct {
 		Protocol string `json:"protocol"`
 		CertFile string `json:"certFile"`
 		KeyFile  string `json:"keyFile"`
+
+		ShutdownGrace int `json:"shutdownGrace"` // Seconds to wait for requests in progress on shutdown.
 	} `json:"network"`
 	Monitor struct {
 		Address string `json:"address"`
@@ -210,16 +179,25 @@ type Adapters struct {
 		SearchRadiusMin int `json:"searchRadiusMin"`
 		SearchRadiusMax int `json:"searchRadiusMax"`
 	} `json:"general"`
-	Adapters map[string]*Adapter `json:"adapters"` // Index: AdpID
-	Areas    map[string]*Area    `json:"areas"`    // Index: AreaID
-	chUpdate chan map[string][]string
-
-	areaAlias    map[string]*Area      // Index: an alias for an area
-	areaAdapters map[string][]*Adapter // Index: AreaID
+	Geocoder     geo.Config          `json:"geocoder"`
+	Regions      geo.Regions         `json:"regions"`
+	SearchCache  SearchCacheConfig   `json:"searchCache"`
+	Refresh      RefreshConfig       `json:"servicesRefresh"`
+	Taxonomy     string              `json:"taxonomy"` // The Service category taxonomy file.
+	Registration RegistrationConfig  `json:"registration"`
+	Admin        AdminConfig         `json:"admin"`
+	RPCAuth      rpcauth.Config      `json:"rpcAuth"`  // Security of the RPC connections to the Adapters.
+	Adapters     map[string]*Adapter `json:"adapters"` // Index: AdpID
+	Areas        map[string]*Area    `json:"areas"`    // Index: AreaID
+	chRefresh    chan []string       // Services refresh requests, for the AreaIDs of registered Adapters.
+
+	areaAlias map[string]*Area // Index: an alias for an area
 	sync.RWMutex
 }
 
 func (r *Adapters) getAllRoutes() (routes structs.NRoutes) {
+	r.RLock()
+	defer r.RUnlock()
 	for _, adp := range r.Adapters {
 		routes = append(routes, structs.NRoute{
 			AdpID:      adp.ID,
@@ -240,6 +218,16 @@ func (r *Adapters) areaID(alias string) (string, error) {
 	return area.ID, nil
 }
 
+// searchCacheTTL returns the Search cache TTL for the Area.
+func (r *Adapters) searchCacheTTL(areaID string) int {
+	r.RLock()
+	defer r.RUnlock()
+	if a, ok := r.Areas[areaID]; ok && a.SearchCacheTTL > 0 {
+		return a.SearchCacheTTL
+	}
+	return r.SearchCache.TTL
+}
+
 // getRouteAdapter gets a pointer to the Adapter servicing the specifed NRoute.
 func (r *Adapters) getRouteAdapter(route structs.NRoute) (*Adapter, error) {
 	r.RLock()
@@ -251,14 +239,21 @@ func (r *Adapters) getRouteAdapter(route structs.NRoute) (*Adapter, error) {
 	return adp, nil
 }
 
-// getArea retrieves the ServiceList for the specified area.
+// getAreaAdapters returns the Adapters with Services for the area in the published
+// Catalog.  Adapters removed since the Catalog was built are skipped.
 func (r *Adapters) getAreaAdapters(areaID string) ([]*Adapter, error) {
-	r.RLock()
-	defer r.RUnlock()
-	l, ok := r.areaAdapters[areaID]
+	ids, ok := GetCatalog().AreaAdapters[areaID]
 	if !ok {
 		return nil, fmt.Errorf("The requested AreaID: %q is not serviced by this gateway.", areaID)
 	}
+	r.RLock()
+	defer r.RUnlock()
+	l := make([]*Adapter, 0, len(ids))
+	for _, id := range ids {
+		if adp, ok := r.Adapters[id]; ok {
+			l = append(l, adp)
+		}
+	}
 	return l, nil
 }
 
@@ -305,10 +300,60 @@ func (r *Adapters) load(file []byte) error {
 		r.Network.Protocol = "http"
 	}
 	r.Network.Protocol = strings.ToLower(r.Network.Protocol)
+	if r.Registration.Lease <= 0 {
+		r.Registration.Lease = dfltLease
+	}
+	if r.Refresh.Interval <= 0 {
+		r.Refresh.Interval = dfltRefreshInterval
+	}
+	if r.Refresh.Jitter < 0 {
+		r.Refresh.Jitter = 0
+	}
+	if r.Network.ShutdownGrace <= 0 {
+		r.Network.ShutdownGrace = dfltShutdownGrace
+	}
+	if r.Adapters == nil {
+		r.Adapters = make(map[string]*Adapter)
+	}
+	if err := r.RPCAuth.Load(); err != nil {
+		msg := fmt.Sprintf("Invalid rpcAuth in config data file - %s.", err)
+		log.Error(msg)
+		return errors.New(msg)
+	}
 
 	// Denormalize the Adapters.
 	for k, v := range r.Adapters {
 		v.ID = k
+		v.auth = &r.RPCAuth
+		v.Transport = strings.ToLower(v.Transport)
+		switch v.Transport {
+		case "":
+			v.Transport = transportRPC
+		case transportRPC, transportJSONRPC:
+		case transportInproc:
+			if v.Config == "" {
+				msg := fmt.Sprintf("In-process adapter %q does not have a config file.", k)
+				log.Error(msg)
+				return errors.New(msg)
+			}
+			if len(v.Addresses) > 0 {
+				msg := fmt.Sprintf("In-process adapter %q cannot have a pool of addresses.", k)
+				log.Error(msg)
+				return errors.New(msg)
+			}
+		default:
+			msg := fmt.Sprintf("Invalid transport %q for adapter %q in config data file.", v.Transport, k)
+			log.Error(msg)
+			return errors.New(msg)
+		}
+		v.Balance = strings.ToLower(v.Balance)
+		switch v.Balance {
+		case "", balanceRoundRobin, balanceLeastLoaded:
+		default:
+			msg := fmt.Sprintf("Invalid balance %q for adapter %q in config data file.", v.Balance, k)
+			log.Error(msg)
+			return errors.New(msg)
+		}
 	}
 
 	// Denormalize the Areas.
@@ -320,6 +365,12 @@ func (r *Adapters) load(file []byte) error {
 		log.Error("Data load failed - " + err.Error())
 	}
 
+	if err := r.getRegions().Validate(); err != nil {
+		msg := fmt.Sprintf("Invalid regions in config data file - %s", err)
+		log.Error(msg)
+		return errors.New(msg)
+	}
+
 	r.loaded = true
 	r.loadedAt = time.Now()
 
@@ -339,30 +390,46 @@ func (r *Adapters) indexAreaAlias() error {
 	return nil
 }
 
-func (r *Adapters) updateAreaAdapters(input map[string][]string) {
-	r.Lock()
-	defer r.Unlock()
-	r.areaAdapters = make(map[string][]*Adapter)
-
-	for areaID, adpList := range input {
-		for _, adpID := range adpList {
-			if _, ok := r.areaAdapters[areaID]; !ok {
-				r.areaAdapters[areaID] = make([]*Adapter, 0)
-			}
-			r.areaAdapters[areaID] = append(r.areaAdapters[areaID], r.Adapters[adpID])
+// getRegions returns the configured Regions, or the Area bounds if no Regions are
+// configured.  If any Area does not have bounds, nil is returned and the default
+// regions will be used.  Areas are sorted by ID so the region list is stable.
+func (r *Adapters) getRegions() geo.Regions {
+	if len(r.Regions) > 0 {
+		return r.Regions
+	}
+	ids := make([]string, 0, len(r.Areas))
+	for id := range r.Areas {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	regions := make(geo.Regions, 0)
+	for _, id := range ids {
+		b := r.Areas[id].Bounds
+		if b == nil {
+			log.Warningf("Area %q does not have bounds - using the default regions.", id)
+			return nil
+		}
+		region := *b
+		if region.Name == "" {
+			region.Name = id
 		}
+		regions = append(regions, region)
 	}
-
-	log.Debugf("After updateAreaAdapters...\n" + r.String() + "\n")
-
-	return
+	return regions
 }
 
 // Connect asks each adapter to Dial it's Server.
 func (r *Adapters) connect() error {
+	connectAdapters(r.Adapters)
+	return nil
+}
+
+// connectAdapters connects the Adapters.  Adapters that cannot be connected are started,
+// if they have "autostart" set, and connected again.
+func connectAdapters(adps map[string]*Adapter) {
 	var startup bool
-	for _, v := range r.Adapters {
-		if err := v.connect(); err != nil {
+	for _, v := range adps {
+		if err := v.connect(); err != nil && v.Transport != transportInproc {
 			v.start()
 			startup = true
 			time.Sleep(time.Second * 1)
@@ -370,13 +437,12 @@ func (r *Adapters) connect() error {
 	}
 	if startup {
 		time.Sleep(time.Second * 2)
-		for _, v := range r.Adapters {
-			if !v.connected {
+		for _, v := range adps {
+			if !v.Connected() && v.Transport != transportInproc {
 				_ = v.connect()
 			}
 		}
 	}
-	return nil
 }
 
 // ==============================================================================================================================
@@ -388,25 +454,47 @@ type Adapter struct {
 	ID        string     //
 	Type      string     `json:"type"`
 	Address   string     `json:"address"`
+	Addresses []string   `json:"addresses"` // More instances of the Adapter, for a pool - see pool.
+	Balance   string     `json:"balance"`   // "roundrobin" (default) or "leastloaded", for a pool.
+	Transport string     `json:"transport"` // "rpc" (default), "jsonrpc" or "inproc"
+	Config    string     `json:"config"`    // The Adapter config file, for the "inproc" transport.
 	Startup   AdpStartup `json:"startup"`
 	connected bool
-	client    *rpc.Client
+	client    rpcCaller // A *pool, or an InprocCaller for the "inproc" Transport.
+	pool      *pool
+	auth      *rpcauth.Config
+	caps      atomic.Value // structs.NCapabilities - see loadCapabilities()
+
+	// Registered Adapters - see RegisterAdapter().
+	registered bool
+	areas      []string // The registered AreaIDs.
 }
 
 func (adp *Adapter) connect() error {
-	client, err := rpc.DialHTTP("tcp", adp.Address)
-	if err != nil {
-		log.WithFields(log.Fields{
-			"adapter": adp.ID,
-			"error":   err.Error(),
-		}).Error("Failed to connect to adapter")
-		return err
+	if adp.Transport == transportInproc {
+		if err := adp.connectInproc(); err != nil {
+			log.WithFields(log.Fields{
+				"adapter": adp.ID,
+				"error":   err.Error(),
+			}).Error("Failed to load adapter")
+			return err
+		}
+		return nil
+	}
+	if adp.pool == nil {
+		adp.pool = newPool(adp)
+		adp.client = adp.pool
+		for _, address := range adp.addresses() {
+			_ = adp.pool.add(address, time.Time{})
+		}
+	} else {
+		adp.pool.reconnectAll()
+	}
+	if adp.pool.healthy() == 0 {
+		return fmt.Errorf("unable to connect to adapter %q at %s", adp.ID, strings.Join(adp.addresses(), ", "))
 	}
-	log.WithFields(log.Fields{
-		"adapter": adp.ID,
-	}).Info("Established connection to adapter")
-	adp.client = client
 	adp.connected = true
+	adp.loadCapabilities()
 	return nil
 }
 
@@ -431,20 +519,11 @@ func (adp *Adapter) start() {
 		}
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
-		err := cmd.Start()
-		if err != nil {
-			log.WithFields(log.Fields{
-				"adapter": adp.ID,
-				"dir":     adp.Startup.Dir,
-				"cmd":     adp.Startup.Cmd,
-				"args":    adp.Startup.Args,
-			}).Error("Adapter failed to start")
-		}
 		log.WithFields(log.Fields{
 			"adapter": adp.ID,
 		}).Info("Adapter started!")
 
-		err = cmd.Wait()
+		err := children.run(adp.ID, cmd)
 		if err != nil {
 			log.WithFields(log.Fields{
 				"adapter": adp.ID,
@@ -475,7 +554,9 @@ type AdpStartup struct {
 type AdpRPCer interface {
 	AdpID() string
 	Connected() bool
+	Capabilities() structs.NCapabilities
 	Call(serviceMethod string, args interface{}, reply interface{}) error
+	CallContext(ctx context.Context, serviceMethod string, args interface{}, reply interface{}) error
 }
 
 // AdpID returns the Adapter ID
@@ -483,25 +564,62 @@ func (adp *Adapter) AdpID() string {
 	return adp.ID
 }
 
-// Connected returns the current connection status of the adapter RPC connection.
+// Connected returns the current connection status of the adapter RPC connection.  A pool
+// is connected while it has a healthy instance.
 func (adp *Adapter) Connected() bool {
+	if adp.pool != nil {
+		return adp.pool.healthy() > 0
+	}
 	return adp.connected
 }
 
 // Call invokes the RPC Client.Call() function (see https://golang.org/pkg/net/rpc/#Client)
+// or the JSON-RPC Client.Call() on an instance of the pool, or calls the in-process
+// Adapter directly.
 func (adp *Adapter) Call(serviceMethod string, args interface{}, reply interface{}) error {
 	return adp.client.Call(serviceMethod, args, reply)
 }
 
+// CallContext is Call, returning when the context is done - see callContext().
+func (adp *Adapter) CallContext(ctx context.Context, serviceMethod string, args interface{}, reply interface{}) error {
+	return callContext(ctx, adp.client, serviceMethod, args, reply)
+}
+
 // ==============================================================================================================================
 //                                      AREA
 // ==============================================================================================================================
 
 // Area represents a Service Area.
 type Area struct {
-	ID      string   //
-	Name    string   `json:"name"`
-	Aliases []string `json:"aliases"`
+	ID             string      //
+	Name           string      `json:"name"`
+	Aliases        []string    `json:"aliases"`
+	Bounds         *geo.Region `json:"bounds"`
+	SearchCacheTTL int         `json:"searchCacheTTL"` // Seconds - overrides searchCache.ttl
+}
+
+// dfltRefreshInterval is the Services refresh interval, if "servicesRefresh.interval" is
+// not set.
+const dfltRefreshInterval = 3600 // seconds
+
+// RefreshConfig is the schedule for refreshing the Services of each Area.  Each refresh
+// is delayed by a random Jitter, so the Areas are not all refreshed at once.  The Services
+// cache is saved to the Snapshot file after each refresh, and loaded from it at startup.
+// The changes to the Services found by each refresh are appended to the ChangeLog.
+type RefreshConfig struct {
+	Interval  int    `json:"interval"`  // Seconds
+	Jitter    int    `json:"jitter"`    // Seconds - the maximum random delay added to the Interval.
+	Snapshot  string `json:"snapshot"`  // The Services cache snapshot file.  Not saved if empty.
+	ChangeLog string `json:"changeLog"` // The Service changes are appended to this file.  Not saved if empty.
+}
+
+// SearchCacheConfig is the configuration for the Search response cache.  The cache is
+// disabled if the Size or TTL is 0.
+type SearchCacheConfig struct {
+	Size         int `json:"size"`         // Maximum number of cached searches
+	TTL          int `json:"ttl"`          // Seconds
+	Grid         int `json:"grid"`         // Meters - the query location is snapped to this grid
+	RadiusBucket int `json:"radiusBucket"` // Meters - the search radius is rounded up to this size
 }
 
 // ==============================================================================================================================
@@ -509,31 +627,7 @@ type Area struct {
 // ==============================================================================================================================
 
 func init() {
-	adapters.chUpdate = make(chan map[string][]string, 1)
-	go func() {
-		for {
-			select {
-			case aa, ok := <-adapters.chUpdate:
-				if !ok {
-					break
-				}
-				adapters.updateAreaAdapters(aa)
-			}
-		}
-	}()
-
-	routes.chUpdate = make(chan map[structs.NRoute]bool, 1)
-	go func() {
-		for {
-			select {
-			case upd, ok := <-routes.chUpdate:
-				if !ok {
-					break
-				}
-				routes.update(upd)
-			}
-		}
-	}()
+	adapters.chRefresh = make(chan []string, 10)
 }
 
 // ==============================================================================================================================
@@ -568,20 +662,11 @@ func (r auxiliaryProgs) start() error {
 			}
 			cmd.Stdout = os.Stdout
 			cmd.Stderr = os.Stderr
-			err := cmd.Start()
-			if err != nil {
-				log.WithFields(log.Fields{
-					"name": name,
-					"dir":  dir,
-					"cmd":  prog,
-					"args": args,
-				}).Error("Auxiliary program failed to start")
-			}
 			log.WithFields(log.Fields{
 				"name": name,
 			}).Info("Auxiliary program started!")
 
-			err = cmd.Wait()
+			err := children.run(name, cmd)
 			if err != nil {
 				log.WithFields(log.Fields{
 					"name":  name,
@@ -609,6 +694,7 @@ func (r Adapters) String() string {
 	lsn.AddS("Network\n")
 	lsn.AddF("Address: %q  protocol: %q\n", r.Network.Address, r.Network.Protocol)
 	lsn.AddF("CertFile: %q  KeyFile: %q\n", r.Network.CertFile, r.Network.KeyFile)
+	lsn.AddF("Shutdown grace: %ds\n", r.Network.ShutdownGrace)
 	ls.AddS(lsn.Box(60))
 	ls.AddF("Monitor - address: %s\n", r.Monitor.Address)
 	for _, v := range r.Adapters {
@@ -624,12 +710,8 @@ func (r Adapters) String() string {
 		ls.AddF("   %-20s  %s\n", k, v.ID)
 	}
 	ls.AddS("\n-------AreaAdapters--------\n")
-	for k, v := range r.areaAdapters {
-		var s []string
-		for _, a := range v {
-			s = append(s, a.ID)
-		}
-		ls.AddF("   %-5s  %s\n", k, strings.Join(s, ", "))
+	for k, v := range GetCatalog().AreaAdapters {
+		ls.AddF("   %-5s  %s\n", k, strings.Join(v, ", "))
 	}
 	return ls.Box(90)
 }
@@ -639,15 +721,25 @@ func (adp Adapter) String() string {
 	// ls := new(common.FmtBoxer)
 	ls := common.NewFmtBoxer()
 	ls.AddF("%s\n", adp.ID)
-	ls.AddF("%-17s   Type: %s  Address: %s  Autostart: %t\n",
-		ls.ColorBool(adp.connected, "CONNECTED  ", "UNCONNECTED", "green", "red"),
+	ls.AddF("%-17s   Type: %s  Transport: %s  Address: %s  Autostart: %t\n",
+		ls.ColorBool(adp.Connected(), "CONNECTED  ", "UNCONNECTED", "green", "red"),
 		adp.Type,
+		adp.Transport,
 		adp.Address,
 		adp.Startup.Autostart,
 	)
+	if adp.Transport == transportInproc {
+		ls.AddF("Config: %s\n", adp.Config)
+	}
 	ls.AddF("Working directory: %s\n", adp.Startup.Dir)
 	ls.AddF("Command: %s\n", adp.Startup.Cmd)
 	ls.AddF("Args: %#v\n", adp.Startup.Args)
+	if adp.registered {
+		ls.AddF("Registered - areas: %v\n", adp.areas)
+	}
+	if adp.pool != nil {
+		ls.AddS(adp.pool.String())
+	}
 	return ls.Box(80)
 }
 
@@ -0,0 +1,113 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/rpc"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+// Adapter transports, set by "transport" in the config file.
+const (
+	transportRPC     = "rpc"     // net/rpc over HTTP to a separate Adapter process (the default).
+	transportJSONRPC = "jsonrpc" // JSON-RPC 2.0 over HTTP to a separate Adapter process - see connectJSONRPC().
+	transportInproc  = "inproc"  // An Adapter compiled into the Engine - see RegisterInproc().
+)
+
+// rpcCaller is the connection to an Adapter, for each transport.
+type rpcCaller interface {
+	Call(serviceMethod string, args interface{}, reply interface{}) error
+}
+
+// contextCaller is an rpcCaller that abandons the call when the context is done.
+type contextCaller interface {
+	CallContext(ctx context.Context, serviceMethod string, args interface{}, reply interface{}) error
+}
+
+// callContext invokes the RPC method on the client, and returns the context error if the
+// context is done first.  An abandoned net/rpc call finishes in the background - the
+// Adapter stops its upstream calls at the request Deadline.  In-process calls are not
+// interrupted.
+func callContext(ctx context.Context, client rpcCaller, serviceMethod string, args interface{}, reply interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	switch c := client.(type) {
+	case contextCaller:
+		return c.CallContext(ctx, serviceMethod, args, reply)
+	case *rpc.Client:
+		call := c.Go(serviceMethod, args, reply, make(chan *rpc.Call, 1))
+		select {
+		case <-call.Done:
+			return call.Error
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return client.Call(serviceMethod, args, reply)
+}
+
+// InprocCaller is an Adapter compiled into the Engine (see adaptersdk.Inproc).  The
+// Engine calls its RPC methods directly.
+type InprocCaller interface {
+	Name() string
+	Call(serviceMethod string, args interface{}, reply interface{}) error
+}
+
+// InprocStarter loads an in-process Adapter from its config file.
+type InprocStarter func(configFile string) (InprocCaller, error)
+
+var inproc = struct {
+	starters map[string]InprocStarter // Index: Adapter type
+	sync.Mutex
+}{starters: make(map[string]InprocStarter)}
+
+// RegisterInproc makes the Adapter type available to the "inproc" transport.  It must be
+// called before Init().
+func RegisterInproc(adpType string, start InprocStarter) {
+	inproc.Lock()
+	defer inproc.Unlock()
+	inproc.starters[strings.ToLower(adpType)] = start
+}
+
+// inprocTypes returns the sorted list of Adapter types available in-process.
+func inprocTypes() []string {
+	inproc.Lock()
+	defer inproc.Unlock()
+	l := make([]string, 0, len(inproc.starters))
+	for k := range inproc.starters {
+		l = append(l, k)
+	}
+	sort.Strings(l)
+	return l
+}
+
+// connectInproc loads the in-process Adapter.  The Adapter name in its config file must
+// match the Adapter ID.
+func (adp *Adapter) connectInproc() error {
+	inproc.Lock()
+	start, ok := inproc.starters[strings.ToLower(adp.Type)]
+	inproc.Unlock()
+	if !ok {
+		return fmt.Errorf("adapter type %q is not available in-process - available: %s", adp.Type, strings.Join(inprocTypes(), ", "))
+	}
+	caller, err := start(adp.Config)
+	if err != nil {
+		return fmt.Errorf("unable to load in-process adapter %q - %s", adp.ID, err)
+	}
+	if caller.Name() != adp.ID {
+		return fmt.Errorf("in-process adapter %q is named %q in %s", adp.ID, caller.Name(), adp.Config)
+	}
+	log.WithFields(log.Fields{
+		"adapter": adp.ID,
+		"config":  adp.Config,
+	}).Info("Loaded in-process adapter")
+	adp.client = caller
+	adp.connected = true
+	adp.loadCapabilities()
+	return nil
+}
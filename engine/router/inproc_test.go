@@ -0,0 +1,56 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/codeforsanjose/open311-gateway/common/adaptersdk"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+func TestInproc(t *testing.T) {
+	RegisterInproc("InprocTest", func(configFile string) (InprocCaller, error) {
+		a := adaptersdk.New(func() adaptersdk.Provider { return new(adaptersdk.ProviderBase) })
+		config := `{"adapter": {"name": "` + configFile + `"}, "serviceAreas": {"SJ": {"name": "San Jose", "providers": [
+			{"id": 1, "name": "Static", "services": [{"id": 1, "name": "Pothole", "group": "Street"}]}]}}}`
+		if err := a.Load([]byte(config), nil); err != nil {
+			return nil, err
+		}
+		a.Capabilities = structs.NCapabilities{RequestTypes: []structs.NRequestType{structs.NRTCreate}}
+		return a.Inproc()
+	})
+
+	adp := &Adapter{ID: "IP1", Type: "inproctest", Transport: transportInproc, Config: "IP1"}
+	if err := adp.connect(); err != nil {
+		t.Fatal(err)
+	}
+	if !adp.Connected() || adp.Capabilities().Supports(structs.NRTSearchLL) {
+		t.Errorf("expected the in-process adapter capabilities: %v", adp.Capabilities())
+	}
+	rqst := &structs.NServiceRequest{Area: "all"}
+	var resp structs.NServicesResponse
+	if err := adp.Call("Services.All", rqst, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Services) != 1 || resp.Services[0].MID() != "IP1-SJ-1-1" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	// The Adapter name in the config file must match the ID.
+	if err := (&Adapter{ID: "IP2", Type: "InprocTest", Transport: transportInproc, Config: "IP1"}).connect(); err == nil {
+		t.Errorf("expected an adapter name error")
+	}
+	if err := (&Adapter{ID: "XX1", Type: "Unknown", Transport: transportInproc, Config: "XX1"}).connect(); err == nil {
+		t.Errorf("expected an unknown type error")
+	}
+
+	if err := new(Adapters).load([]byte(`{"adapters": {"IP1": {"type": "InprocTest", "transport": "INPROC"}}}`)); err == nil {
+		t.Errorf("expected a missing config file error")
+	}
+	if err := new(Adapters).load([]byte(`{"adapters": {"IP1": {"type": "InprocTest", "transport": "pigeon"}}}`)); err == nil {
+		t.Errorf("expected an invalid transport error")
+	}
+	r := new(Adapters)
+	if err := r.load([]byte(`{"adapters": {"CS1": {"type": "CitySourced", "address": ":5001"}}}`)); err != nil || r.Adapters["CS1"].Transport != transportRPC {
+		t.Errorf("expected the default rpc transport, got: %v", err)
+	}
+}
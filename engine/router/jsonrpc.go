@@ -0,0 +1,27 @@
+package router
+
+import (
+	"fmt"
+	"net/rpc"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+// jsonrpcTimeout limits each JSON-RPC call.  The RPCCallMgr gives up on an Adapter after
+// rpcTimeout; this only stops abandoned calls from waiting forever.
+const jsonrpcTimeout = time.Second * 30
+
+// dialJSONRPC connects to an Adapter instance using JSON-RPC 2.0 (see common/jsonrpc).
+// As there is no persistent connection, the instance is probed with
+// "Adapter.Capabilities".  An instance that returns an error for the probe is up, but
+// does not support the RPC.
+func (adp *Adapter) dialJSONRPC(address string) (rpcCaller, error) {
+	client := adp.auth.JSONRPCClient(address, jsonrpcTimeout)
+	var resp structs.NCapabilitiesResponse
+	err := client.Call("Adapter.Capabilities", &structs.NCapabilitiesRequest{}, &resp)
+	if _, ok := err.(rpc.ServerError); err != nil && !ok {
+		return nil, fmt.Errorf("%s - %s", client.URL(), err)
+	}
+	return client, nil
+}
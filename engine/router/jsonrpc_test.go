@@ -0,0 +1,44 @@
+package router
+
+import (
+	"net/rpc"
+	"testing"
+
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+func TestJSONRPC(t *testing.T) {
+	addr, done := serveAdapter(t, structs.NCapabilities{RequestTypes: []structs.NRequestType{structs.NRTCreate}})
+	defer done()
+
+	adp := &Adapter{ID: "TST1", Address: addr, Transport: transportJSONRPC}
+	if err := adp.connect(); err != nil {
+		t.Fatal(err)
+	}
+	if !adp.Connected() || adp.Capabilities().Supports(structs.NRTSearchLL) {
+		t.Errorf("expected the adapter capabilities: %v", adp.Capabilities())
+	}
+	var resp structs.NServicesResponse
+	if err := adp.Call("Services.All", &structs.NServiceRequest{Area: "all"}, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.AdpID != "TST1" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if err := adp.Call("Report.Create", &structs.NCreateRequest{}, &structs.NCreateResponse{}); err == nil {
+		t.Errorf("expected an unknown method error")
+	} else if _, ok := err.(rpc.ServerError); !ok {
+		t.Errorf("expected an rpc.ServerError, got: %T %v", err, err)
+	}
+	adp.close()
+
+	done()
+	if err := (&Adapter{ID: "TST1", Address: addr, Transport: transportJSONRPC}).connect(); err == nil {
+		t.Errorf("expected a connection error")
+	}
+
+	r := new(Adapters)
+	if err := r.load([]byte(`{"adapters": {"PY1": {"type": "Python", "address": ":5010", "transport": "JSONRPC"}}}`)); err != nil || r.Adapters["PY1"].Transport != transportJSONRPC {
+		t.Errorf("expected the jsonrpc transport, got: %v", err)
+	}
+}
@@ -0,0 +1,363 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/rpc"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+// Load balancing of an Adapter pool, set by "balance" in the config file.
+const (
+	balanceRoundRobin  = "roundrobin"  // Each call goes to the next instance (the default).
+	balanceLeastLoaded = "leastloaded" // Each call goes to the instance with the fewest calls in flight.
+)
+
+// healthCheck is how often the ejected instances of a pool are reconnected.
+const healthCheck = time.Second * 10
+
+var errNoInstance = errors.New("no healthy adapter instance")
+
+// pool is the connections to the instances of an Adapter - identical Adapter processes at
+// different addresses.  It is the rpcCaller of the "rpc" and "jsonrpc" transports.  An
+// instance failing with a connection error is ejected, and reconnected by the health
+// check.  A removed or ejected instance is closed after its calls in flight finish.
+type pool struct {
+	adp     *Adapter
+	balance string
+
+	sync.Mutex
+	instances []*instance
+	next      int // The instance the next round robin search starts at.
+	stop      chan struct{}
+}
+
+// instance is one Adapter process.
+type instance struct {
+	address  string
+	client   rpcCaller
+	healthy  bool      // false if ejected.  The pool must be locked.
+	expires  time.Time // The lease of a registered instance.
+	inflight int64     // Calls in flight (atomic).
+	calls    sync.WaitGroup
+	closed   sync.Once
+}
+
+// newPool returns an empty pool for the Adapter, and starts its health check.
+func newPool(adp *Adapter) *pool {
+	p := &pool{adp: adp, balance: adp.Balance, stop: make(chan struct{})}
+	if p.balance == "" {
+		p.balance = balanceRoundRobin
+	}
+	go p.watch()
+	return p
+}
+
+// add connects to the address, and adds the instance.  An instance that cannot be
+// connected is added ejected, and retried by the health check.  If the address is already
+// in the pool, only its lease is updated.
+func (p *pool) add(address string, expires time.Time) error {
+	p.Lock()
+	for _, inst := range p.instances {
+		if inst.address == address {
+			inst.expires = expires
+			p.Unlock()
+			return nil
+		}
+	}
+	inst := &instance{address: address, expires: expires}
+	p.instances = append(p.instances, inst)
+	p.Unlock()
+	return p.reconnect(inst)
+}
+
+// reconnect connects to the ejected instance.
+func (p *pool) reconnect(inst *instance) error {
+	client, err := p.adp.dial(inst.address)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"adapter": p.adp.ID,
+			"address": inst.address,
+			"error":   err.Error(),
+		}).Error("Failed to connect to adapter")
+		return err
+	}
+	p.Lock()
+	i := p.find(inst)
+	if i < 0 || inst.healthy {
+		p.Unlock()
+		closeCaller(client)
+		return nil
+	}
+	// Replace the instance, so calls still draining from the old client are not counted.
+	p.instances[i] = &instance{address: inst.address, client: client, healthy: true, expires: inst.expires}
+	p.Unlock()
+	log.WithFields(log.Fields{
+		"adapter": p.adp.ID,
+		"address": inst.address,
+	}).Info("Established connection to adapter")
+	if p.adp.caps.Load() == nil {
+		p.adp.loadCapabilities()
+	}
+	return nil
+}
+
+// has returns true if the address is in the pool.
+func (p *pool) has(address string) bool {
+	p.Lock()
+	defer p.Unlock()
+	for _, inst := range p.instances {
+		if inst.address == address {
+			return true
+		}
+	}
+	return false
+}
+
+// find returns the index of the instance, or -1.  The pool must be locked.
+func (p *pool) find(inst *instance) int {
+	for i, v := range p.instances {
+		if v == inst {
+			return i
+		}
+	}
+	return -1
+}
+
+// pick returns the healthy instance for the next call, and counts the call as in flight.
+func (p *pool) pick() *instance {
+	p.Lock()
+	defer p.Unlock()
+	n := len(p.instances)
+	var best *instance
+	var bestIdx int
+	for i := 0; i < n; i++ {
+		idx := (p.next + i) % n
+		inst := p.instances[idx]
+		if !inst.healthy {
+			continue
+		}
+		if best == nil || (p.balance == balanceLeastLoaded && atomic.LoadInt64(&inst.inflight) < atomic.LoadInt64(&best.inflight)) {
+			best, bestIdx = inst, idx
+			if p.balance != balanceLeastLoaded {
+				break
+			}
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	p.next = bestIdx + 1
+	atomic.AddInt64(&best.inflight, 1)
+	best.calls.Add(1)
+	return best
+}
+
+// Call invokes the RPC method on the next instance.
+func (p *pool) Call(serviceMethod string, args interface{}, reply interface{}) error {
+	return p.CallContext(context.Background(), serviceMethod, args, reply)
+}
+
+// CallContext invokes the RPC method on the next instance, until the context is done.  An
+// instance failing with anything other than an error returned by the Adapter (an
+// rpc.ServerError) is ejected, unless the call was abandoned.
+func (p *pool) CallContext(ctx context.Context, serviceMethod string, args interface{}, reply interface{}) error {
+	inst := p.pick()
+	if inst == nil {
+		return fmt.Errorf("adapter %q - %s", p.adp.ID, errNoInstance)
+	}
+	err := callContext(ctx, inst.client, serviceMethod, args, reply)
+	atomic.AddInt64(&inst.inflight, -1)
+	inst.calls.Done()
+	if _, ok := err.(rpc.ServerError); err != nil && !ok && ctx.Err() == nil {
+		p.eject(inst, err)
+	}
+	return err
+}
+
+// eject takes the instance out of rotation, and closes it once its calls finish.
+func (p *pool) eject(inst *instance, err error) {
+	p.Lock()
+	if !inst.healthy {
+		p.Unlock()
+		return
+	}
+	inst.healthy = false
+	p.Unlock()
+	log.WithFields(log.Fields{
+		"adapter": p.adp.ID,
+		"address": inst.address,
+		"error":   err.Error(),
+	}).Warn("Adapter instance ejected")
+	go inst.drain()
+}
+
+// remove takes the instance at the address out of the pool, and closes it once its calls
+// finish.  It returns false if the address is not in the pool.
+func (p *pool) remove(address string) bool {
+	p.Lock()
+	var inst *instance
+	for i, v := range p.instances {
+		if v.address == address {
+			inst = v
+			p.instances = append(p.instances[:i:i], p.instances[i+1:]...)
+			break
+		}
+	}
+	p.Unlock()
+	if inst == nil {
+		return false
+	}
+	log.WithFields(log.Fields{
+		"adapter": p.adp.ID,
+		"address": address,
+	}).Info("Adapter instance removed")
+	go inst.drain()
+	return true
+}
+
+// expire removes the instances whose lease expired before now, and returns the number
+// left.
+func (p *pool) expire(now time.Time) int {
+	p.Lock()
+	var expired []string
+	for _, inst := range p.instances {
+		if !inst.expires.IsZero() && now.After(inst.expires) {
+			expired = append(expired, inst.address)
+		}
+	}
+	p.Unlock()
+	for _, address := range expired {
+		p.remove(address)
+	}
+	return p.size()
+}
+
+// drain waits for the calls in flight, and closes the client.
+func (inst *instance) drain() {
+	inst.calls.Wait()
+	inst.closed.Do(func() {
+		closeCaller(inst.client)
+	})
+}
+
+// size returns the number of instances.
+func (p *pool) size() int {
+	p.Lock()
+	defer p.Unlock()
+	return len(p.instances)
+}
+
+// healthy returns the number of healthy instances.
+func (p *pool) healthy() int {
+	p.Lock()
+	defer p.Unlock()
+	var n int
+	for _, inst := range p.instances {
+		if inst.healthy {
+			n++
+		}
+	}
+	return n
+}
+
+// reconnectAll reconnects the ejected instances.
+func (p *pool) reconnectAll() {
+	p.Lock()
+	var ejected []*instance
+	for _, inst := range p.instances {
+		if !inst.healthy {
+			ejected = append(ejected, inst)
+		}
+	}
+	p.Unlock()
+	for _, inst := range ejected {
+		_ = p.reconnect(inst)
+	}
+}
+
+// watch reconnects the ejected instances every healthCheck, until the pool is closed.
+func (p *pool) watch() {
+	t := time.NewTicker(healthCheck)
+	defer t.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-t.C:
+			p.reconnectAll()
+		}
+	}
+}
+
+// Close stops the health check, and closes all instances once their calls finish.
+func (p *pool) Close() error {
+	p.Lock()
+	select {
+	case <-p.stop:
+	default:
+		close(p.stop)
+	}
+	l := p.instances
+	p.instances = nil
+	p.Unlock()
+	for _, inst := range l {
+		go inst.drain()
+	}
+	return nil
+}
+
+// String returns the status of each instance.
+func (p *pool) String() string {
+	p.Lock()
+	defer p.Unlock()
+	ls := common.NewFmtBoxer()
+	ls.AddF("Pool - balance: %s\n", p.balance)
+	for _, inst := range p.instances {
+		ls.AddF("%-9s %s  in flight: %d", ls.ColorBool(inst.healthy, "HEALTHY", "EJECTED", "green", "red"), inst.address, atomic.LoadInt64(&inst.inflight))
+		if !inst.expires.IsZero() {
+			ls.AddF("  lease expires: %s", inst.expires.Format(time.RFC3339))
+		}
+		ls.AddS("\n")
+	}
+	return ls.Box(80)
+}
+
+// dial connects to one instance of the Adapter.
+func (adp *Adapter) dial(address string) (rpcCaller, error) {
+	if adp.Transport == transportJSONRPC {
+		return adp.dialJSONRPC(address)
+	}
+	return adp.auth.DialHTTP(address)
+}
+
+// addresses returns the addresses of the Adapter instances: the "address", and the
+// "addresses" of a pool.
+func (adp *Adapter) addresses() []string {
+	var l []string
+	seen := make(map[string]bool)
+	for _, a := range append([]string{adp.Address}, adp.Addresses...) {
+		if a != "" && !seen[a] {
+			seen[a] = true
+			l = append(l, a)
+		}
+	}
+	return l
+}
+
+// closeCaller closes the client, if it can be closed.
+func closeCaller(client rpcCaller) {
+	if c, ok := client.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			log.Debugf("Closing adapter connection - %s", err)
+		}
+	}
+}
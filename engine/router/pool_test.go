@@ -0,0 +1,202 @@
+package router
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common/adaptersdk"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+// countedAdapter is an SDK Adapter counting its JSON-RPC calls, that can be taken down.
+type countedAdapter struct {
+	addr  string
+	calls int32
+	down  int32
+	done  func()
+}
+
+func serveCounted(t *testing.T) *countedAdapter {
+	a := adaptersdk.New(func() adaptersdk.Provider { return new(adaptersdk.ProviderBase) })
+	if err := a.Load([]byte(`{"adapter": {"name": "TST1"}, "serviceAreas": {}}`), nil); err != nil {
+		t.Fatal(err)
+	}
+	h, err := a.Handler()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &countedAdapter{addr: l.Addr().String(), done: func() { l.Close() }}
+	go http.Serve(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&c.down) == 1 {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		atomic.AddInt32(&c.calls, 1)
+		h.ServeHTTP(w, r)
+	}))
+	return c
+}
+
+func (c *countedAdapter) reset() int32 { return atomic.SwapInt32(&c.calls, 0) }
+
+func TestPool(t *testing.T) {
+	a1, a2 := serveCounted(t), serveCounted(t)
+	defer a1.done()
+	defer a2.done()
+
+	adp := &Adapter{ID: "TST1", Address: a1.addr, Addresses: []string{a2.addr, a1.addr}, Transport: transportJSONRPC}
+	if err := adp.connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer adp.close()
+	if adp.pool.size() != 2 || adp.pool.healthy() != 2 {
+		t.Fatalf("expected 2 healthy instances:\n%s", adp.pool)
+	}
+	call := func(n int) (failed int) {
+		for i := 0; i < n; i++ {
+			var resp structs.NServicesResponse
+			if err := adp.Call("Services.All", &structs.NServiceRequest{Area: "all"}, &resp); err != nil {
+				failed++
+			}
+		}
+		return failed
+	}
+
+	// Round robin.
+	a1.reset()
+	a2.reset()
+	if failed := call(10); failed != 0 {
+		t.Errorf("unexpected failed calls: %d", failed)
+	}
+	if n1, n2 := a1.reset(), a2.reset(); n1 != 5 || n2 != 5 {
+		t.Errorf("expected the calls to be spread evenly, got: %d, %d", n1, n2)
+	}
+
+	// Least loaded.
+	adp.pool.balance = balanceLeastLoaded
+	adp.pool.Lock()
+	busy := adp.pool.instances[0]
+	adp.pool.Unlock()
+	atomic.AddInt64(&busy.inflight, 3)
+	call(4)
+	atomic.AddInt64(&busy.inflight, -3)
+	if n1, n2 := a1.reset(), a2.reset(); n1 != 0 || n2 != 4 {
+		t.Errorf("expected the calls to go to the idle instance, got: %d, %d", n1, n2)
+	}
+	adp.pool.balance = balanceRoundRobin
+
+	// A failed instance is ejected, and reconnected by the health check.
+	atomic.StoreInt32(&a1.down, 1)
+	if failed := call(10); failed > 1 {
+		t.Errorf("expected one failed call before the instance is ejected, got: %d", failed)
+	}
+	if adp.pool.healthy() != 1 || !adp.Connected() {
+		t.Errorf("expected 1 healthy instance:\n%s", adp.pool)
+	}
+	adp.pool.reconnectAll()
+	if adp.pool.healthy() != 1 {
+		t.Errorf("a down instance should not be reconnected")
+	}
+	atomic.StoreInt32(&a1.down, 0)
+	adp.pool.reconnectAll()
+	if adp.pool.healthy() != 2 {
+		t.Errorf("expected the instance to be reconnected:\n%s", adp.pool)
+	}
+
+	atomic.StoreInt32(&a1.down, 1)
+	atomic.StoreInt32(&a2.down, 1)
+	call(2)
+	if adp.Connected() {
+		t.Errorf("expected no healthy instance")
+	}
+	if err := adp.Call("Services.All", &structs.NServiceRequest{}, &structs.NServicesResponse{}); err == nil {
+		t.Errorf("expected an error with no healthy instance")
+	}
+}
+
+// blockingCaller is an rpcCaller whose calls wait until released.
+type blockingCaller struct {
+	release chan struct{}
+	mu      sync.Mutex
+	closed  bool
+}
+
+func (c *blockingCaller) Call(serviceMethod string, args interface{}, reply interface{}) error {
+	<-c.release
+	return nil
+}
+
+func (c *blockingCaller) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *blockingCaller) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+func TestPoolDrain(t *testing.T) {
+	p := newPool(&Adapter{ID: "TST1"})
+	defer p.Close()
+	c := &blockingCaller{release: make(chan struct{})}
+	p.instances = []*instance{{address: "a:1", client: c, healthy: true}}
+
+	done := make(chan error)
+	go func() { done <- p.Call("Services.All", nil, nil) }()
+	for atomic.LoadInt64(&p.instances[0].inflight) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if !p.remove("a:1") || p.size() != 0 || p.remove("a:1") {
+		t.Fatalf("expected the instance to be removed once")
+	}
+	time.Sleep(time.Millisecond * 20)
+	if c.isClosed() {
+		t.Errorf("the instance should not be closed with a call in flight")
+	}
+	close(c.release)
+	if err := <-done; err != nil {
+		t.Errorf("the call in flight should complete: %v", err)
+	}
+	for i := 0; i < 100 && !c.isClosed(); i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if !c.isClosed() {
+		t.Errorf("expected the instance to be closed after its calls finish")
+	}
+
+	// Leases.
+	p.instances = []*instance{{address: "a:1", expires: time.Now()}, {address: "a:2", expires: time.Now().Add(time.Hour)}, {address: "a:3"}}
+	if n := p.expire(time.Now().Add(time.Minute)); n != 2 || p.has("a:1") {
+		t.Errorf("expected a:1 to expire, got: %d", n)
+	}
+}
+
+func TestPoolConfig(t *testing.T) {
+	r := new(Adapters)
+	if err := r.load([]byte(`{"adapters": {"TST1": {"type": "Test", "address": ":5001", "addresses": [":5002"], "balance": "LeastLoaded"}}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if adp := r.Adapters["TST1"]; adp.Balance != balanceLeastLoaded || len(adp.addresses()) != 2 {
+		t.Errorf("unexpected adapter: %+v", adp)
+	}
+	for _, cfg := range []string{
+		`{"adapters": {"TST1": {"type": "Test", "address": ":5001", "balance": "random"}}}`,
+		`{"adapters": {"TST1": {"type": "Test", "transport": "inproc", "addresses": [":5002"]}}}`,
+	} {
+		if err := new(Adapters).load([]byte(cfg)); err == nil {
+			t.Errorf("expected an error for: %s", cfg)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package router
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+const (
+	dfltLease  = 60              // seconds
+	leaseCheck = time.Second * 5 // How often expired leases are removed.
+)
+
+// RegistrationConfig is the "registration" section of the config file.  Adapters can only
+// register themselves with the Engine if it is enabled.
+type RegistrationConfig struct {
+	Enabled bool   `json:"enabled"`
+	Lease   int    `json:"lease"` // Seconds - the default, and maximum, lease.
+	Token   string `json:"token"` // If set, Adapters must send it in the "X-Registration-Token" header.
+}
+
+// GetChRefresh returns the channel used to request a Services refresh for a list of
+// AreaIDs, after an Adapter is registered or removed.
+func GetChRefresh() chan []string {
+	return adapters.chRefresh
+}
+
+// RegisterAdapter adds the Adapter to the active Adapters, or renews its lease.  The
+// token must match the "registration.token" in the config file.
+func RegisterAdapter(rqst *structs.NRegisterRequest, token string) (*structs.NRegisterResponse, error) {
+	if err := adapters.checkRegistration(token); err != nil {
+		return nil, err
+	}
+	lease, err := adapters.register(rqst)
+	if err != nil {
+		return nil, err
+	}
+	return &structs.NRegisterResponse{AdpID: rqst.AdpID, Lease: lease, Message: "OK"}, nil
+}
+
+// DeregisterAdapter removes the instance of a registered Adapter at the address, or the
+// Adapter if the address is empty or its last instance.  Adapters in the config file
+// cannot be removed.
+func DeregisterAdapter(adpID, address, token string) error {
+	if err := adapters.checkRegistration(token); err != nil {
+		return err
+	}
+	return adapters.deregister(adpID, address)
+}
+
+// checkRegistration verifies registration is enabled, and the token is valid.
+func (r *Adapters) checkRegistration(token string) error {
+	r.RLock()
+	defer r.RUnlock()
+	if !r.Registration.Enabled {
+		return errors.New("adapter registration is not enabled")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(r.Registration.Token)) != 1 {
+		return errors.New("invalid registration token")
+	}
+	return nil
+}
+
+// register adds or renews a registered Adapter, and returns the granted lease in seconds.
+// A new Adapter, or one with a changed type, transport or Area list, is connected before
+// it is added, and a Services refresh is requested for its Areas.  Otherwise, a new
+// address is connected and added to the Adapter pool, and each address has its own lease.
+func (r *Adapters) register(rqst *structs.NRegisterRequest) (int, error) {
+	if err := rqst.Validate(); err != nil {
+		return 0, err
+	}
+	r.RLock()
+	lease := r.Registration.Lease
+	r.RUnlock()
+	if rqst.Lease > 0 && rqst.Lease < lease {
+		lease = rqst.Lease
+	}
+	expires := time.Now().Add(time.Duration(lease) * time.Second)
+	areas := rqst.AreaIDs()
+	sort.Strings(areas)
+	transport := strings.ToLower(rqst.Transport)
+	if transport == "" {
+		transport = transportRPC
+	}
+
+	r.Lock()
+	old, ok := r.Adapters[rqst.AdpID]
+	if ok && !old.registered {
+		r.Unlock()
+		return 0, fmt.Errorf("adapter %q is in the config file and cannot be registered", rqst.AdpID)
+	}
+	if ok && old.Type == rqst.Type && old.Transport == transport && sameList(old.areas, areas) {
+		r.Unlock()
+		known := old.pool.has(rqst.Address)
+		if err := old.pool.add(rqst.Address, expires); err != nil {
+			old.pool.remove(rqst.Address)
+			return 0, fmt.Errorf("unable to connect to adapter %q at %s - %s", rqst.AdpID, rqst.Address, err)
+		}
+		if !known {
+			log.WithFields(log.Fields{
+				"adapter": rqst.AdpID,
+				"address": rqst.Address,
+				"lease":   lease,
+			}).Info("Adapter instance registered")
+			return lease, nil
+		}
+		log.WithFields(log.Fields{
+			"adapter": rqst.AdpID,
+			"address": rqst.Address,
+			"lease":   lease,
+		}).Debug("Adapter lease renewed")
+		return lease, nil
+	}
+	r.Unlock()
+
+	adp := &Adapter{
+		ID:         rqst.AdpID,
+		Type:       rqst.Type,
+		Address:    rqst.Address,
+		Transport:  transport,
+		auth:       &r.RPCAuth,
+		registered: true,
+		areas:      areas,
+	}
+	if err := adp.connect(); err != nil {
+		adp.close()
+		return 0, fmt.Errorf("unable to connect to adapter %q at %s - %s", rqst.AdpID, rqst.Address, err)
+	}
+	_ = adp.pool.add(rqst.Address, expires)
+	if adp.caps.Load() == nil && len(rqst.Capabilities.RequestTypes) > 0 {
+		adp.caps.Store(rqst.Capabilities)
+	}
+
+	r.Lock()
+	if prev, ok := r.Adapters[rqst.AdpID]; ok && !prev.registered {
+		r.Unlock()
+		adp.close()
+		return 0, fmt.Errorf("adapter %q is in the config file and cannot be registered", rqst.AdpID)
+	}
+	old = r.Adapters[rqst.AdpID]
+	if r.Adapters == nil {
+		r.Adapters = make(map[string]*Adapter)
+	}
+	r.Adapters[rqst.AdpID] = adp
+	r.addAreas(rqst.Areas)
+	r.Unlock()
+
+	affected := append([]string{}, areas...)
+	if old != nil {
+		old.close()
+		affected = append(affected, old.areas...)
+	}
+	log.WithFields(log.Fields{
+		"adapter": adp.ID,
+		"address": adp.Address,
+		"areas":   strings.Join(areas, ", "),
+		"lease":   lease,
+	}).Info("Adapter registered")
+	r.requestRefresh(affected)
+	return lease, nil
+}
+
+// deregister removes the instance of a registered Adapter at the address.  If the address
+// is empty, or the last instance, it removes the Adapter and its routes, and requests a
+// Services refresh for its Areas.
+func (r *Adapters) deregister(adpID, address string) error {
+	r.Lock()
+	adp, ok := r.Adapters[adpID]
+	switch {
+	case !ok:
+		r.Unlock()
+		return fmt.Errorf("Adapter: %q was not found.", adpID)
+	case !adp.registered:
+		r.Unlock()
+		return fmt.Errorf("adapter %q is in the config file and cannot be removed", adpID)
+	case address != "" && !adp.pool.has(address):
+		r.Unlock()
+		return fmt.Errorf("adapter %q has no instance at %s", adpID, address)
+	case address != "" && adp.pool.size() > 1:
+		r.Unlock()
+		if !adp.pool.remove(address) {
+			return fmt.Errorf("adapter %q has no instance at %s", adpID, address)
+		}
+		return nil
+	}
+	delete(r.Adapters, adpID)
+	r.Unlock()
+
+	adp.close()
+	removeCatalogAdapter(adpID)
+	log.WithFields(log.Fields{
+		"adapter": adpID,
+	}).Info("Adapter removed")
+	r.requestRefresh(adp.areas)
+	return nil
+}
+
+// expireLeases removes the instances of registered Adapters whose lease has expired.
+// Adapters left without an instance are removed, and their IDs are returned.
+func (r *Adapters) expireLeases(now time.Time) []string {
+	r.RLock()
+	registered := make(map[string]*Adapter)
+	for id, adp := range r.Adapters {
+		if adp.registered {
+			registered[id] = adp
+		}
+	}
+	r.RUnlock()
+
+	var expired []string
+	for id, adp := range registered {
+		if adp.pool.expire(now) > 0 {
+			continue
+		}
+		log.WithFields(log.Fields{
+			"adapter": id,
+		}).Warn("Adapter lease expired")
+		if err := r.deregister(id, ""); err != nil {
+			log.Warn(err.Error())
+		}
+		expired = append(expired, id)
+	}
+	return expired
+}
+
+// leaseWatch starts watchLeases() once, when registration is enabled at startup or by
+// a config reload.
+var leaseWatch sync.Once
+
+func startLeaseWatch() {
+	leaseWatch.Do(func() { go adapters.watchLeases() })
+}
+
+// watchLeases periodically removes the Adapters with an expired lease.
+func (r *Adapters) watchLeases() {
+	for now := range time.Tick(leaseCheck) {
+		r.expireLeases(now)
+	}
+}
+
+// addAreas adds the registered Areas not in the config file, and indexes their aliases.
+// The regions are not changed.  The Adapters must be locked.
+func (r *Adapters) addAreas(areas []structs.NRegisterArea) {
+	if r.Areas == nil {
+		r.Areas = make(map[string]*Area)
+	}
+	if r.areaAlias == nil {
+		r.areaAlias = make(map[string]*Area)
+	}
+	for _, ra := range areas {
+		if _, ok := r.Areas[ra.ID]; ok {
+			continue
+		}
+		area := &Area{ID: ra.ID, Name: ra.Name}
+		for _, alias := range append([]string{ra.Name, ra.ID}, ra.Aliases...) {
+			alias = strings.ToLower(alias)
+			if _, ok := r.areaAlias[alias]; alias == "" || ok {
+				continue
+			}
+			area.Aliases = append(area.Aliases, alias)
+			r.areaAlias[alias] = area
+		}
+		r.Areas[ra.ID] = area
+		log.Infof("Added area: %s (%s)", area.Name, area.ID)
+	}
+}
+
+// requestRefresh asks the Services cache to refresh the Areas.
+func (r *Adapters) requestRefresh(areas []string) {
+	select {
+	case r.chRefresh <- areas:
+	default:
+		log.Warningf("Services refresh queue is full - the refresh of areas: %v is skipped until the next scheduled refresh.", areas)
+	}
+}
+
+// sameList returns true if the sorted lists are equal.
+func sameList(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ------------------------------- Adapter -------------------------------
+
+// close closes the RPC connections to the Adapter, once their calls in flight finish.
+func (adp *Adapter) close() {
+	closeCaller(adp.client)
+	adp.connected = false
+}
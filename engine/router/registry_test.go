@@ -0,0 +1,161 @@
+package router
+
+import (
+	"testing"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+func TestRegistry(t *testing.T) {
+	addr, done := serveAdapter(t, structs.NCapabilities{RequestTypes: []structs.NRequestType{structs.NRTCreate}})
+	defer done()
+
+	savedAdp, savedAreas, savedAlias, savedCatalog := adapters.Adapters, adapters.Areas, adapters.areaAlias, GetCatalog()
+	defer func() {
+		adapters.Adapters, adapters.Areas, adapters.areaAlias = savedAdp, savedAreas, savedAlias
+		catalog.Store(savedCatalog)
+		adapters.Registration = RegistrationConfig{}
+	}()
+	static := &Adapter{ID: "CS1", Address: "127.0.0.1:1"}
+	adapters.Adapters = map[string]*Adapter{"CS1": static}
+	adapters.Areas = map[string]*Area{"SJ": {ID: "SJ", Name: "San Jose", Aliases: []string{"san jose"}}}
+	adapters.areaAlias = map[string]*Area{"san jose": adapters.Areas["SJ"]}
+	PublishCatalog(NewCatalog(map[string]structs.NServices{"SJ": {svc("CS1", "SJ", 1, 1)}}, nil))
+	adapters.Registration = RegistrationConfig{Enabled: true, Lease: 30, Token: "secret"}
+
+	refreshed := func() []string {
+		select {
+		case areas := <-GetChRefresh():
+			return areas
+		default:
+			return nil
+		}
+	}
+
+	rqst := &structs.NRegisterRequest{
+		AdpID:   "TST1",
+		Type:    "Test",
+		Address: addr,
+		Areas:   []structs.NRegisterArea{{ID: "SJ", Name: "San Jose"}, {ID: "SC", Name: "Santa Clara", Aliases: []string{"SClara"}}},
+		Lease:   300,
+	}
+	if _, err := RegisterAdapter(rqst, "wrong"); err == nil {
+		t.Errorf("expected an invalid token error")
+	}
+	resp, err := RegisterAdapter(rqst, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Lease != 30 || resp.AdpID != "TST1" {
+		t.Errorf("the lease should be limited to the config lease: %+v", resp)
+	}
+	if areas := refreshed(); len(areas) != 2 {
+		t.Errorf("expected a refresh of SC and SJ, got: %v", areas)
+	}
+	adp, err := GetAdapter("TST1")
+	if err != nil || !adp.Connected() || adp.Capabilities().Supports(structs.NRTSearchLL) {
+		t.Fatalf("expected the connected adapter, got: %v, %v", adp, err)
+	}
+	if id, err := GetAreaID("sclara"); err != nil || id != "SC" {
+		t.Errorf("expected the registered area alias, got: %q, %v", id, err)
+	}
+	if adapters.Areas["SJ"].Name != "San Jose" || len(adapters.Areas["SJ"].Aliases) != 1 {
+		t.Errorf("a configured area should not be changed: %v", adapters.Areas["SJ"])
+	}
+	if r := GetAllRoutes(); len(r) != 2 {
+		t.Errorf("expected routes for both adapters, got: %v", r)
+	}
+
+	// Renewing does not reconnect, or refresh the Services.
+	rqst.Lease = 10
+	if resp, err := RegisterAdapter(rqst, "secret"); err != nil || resp.Lease != 10 {
+		t.Errorf("renew failed: %+v, %v", resp, err)
+	}
+	if a, _ := GetAdapter("TST1"); a != adp {
+		t.Errorf("the adapter should not be replaced on renewal")
+	}
+	if areas := refreshed(); areas != nil {
+		t.Errorf("unexpected refresh: %v", areas)
+	}
+
+	// Another address joins the pool, and is removed alone.
+	addr2, done2 := serveAdapter(t, structs.NCapabilities{RequestTypes: []structs.NRequestType{structs.NRTCreate}})
+	defer done2()
+	rqst2 := *rqst
+	rqst2.Address = addr2
+	if _, err := RegisterAdapter(&rqst2, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if a, _ := GetAdapter("TST1"); a != adp || adp.pool.size() != 2 {
+		t.Errorf("expected the instance to be added to the pool:\n%s", adp.pool)
+	}
+	if areas := refreshed(); areas != nil {
+		t.Errorf("unexpected refresh: %v", areas)
+	}
+	if err := DeregisterAdapter("TST1", "127.0.0.1:1", "secret"); err == nil {
+		t.Errorf("expected an error removing an unknown instance")
+	}
+	if err := DeregisterAdapter("TST1", addr2, "secret"); err != nil || adp.pool.size() != 1 || !adp.pool.has(addr) {
+		t.Errorf("expected only the instance to be removed: %v", err)
+	}
+	if _, err := GetAdapter("TST1"); err != nil {
+		t.Errorf("the adapter should not be removed with an instance left")
+	}
+	if err := DeregisterAdapter("TST1", addr2, "secret"); err == nil {
+		t.Errorf("expected an error removing an instance that is not registered")
+	}
+	if _, err := GetAdapter("TST1"); err != nil || !adp.pool.has(addr) {
+		t.Errorf("the adapter should not be removed by an unknown instance")
+	}
+
+	// Adapters in the config file cannot be registered or removed.
+	if _, err := RegisterAdapter(&structs.NRegisterRequest{AdpID: "CS1", Address: addr}, "secret"); err == nil {
+		t.Errorf("expected an error registering a configured adapter")
+	}
+	if err := DeregisterAdapter("CS1", "", "secret"); err == nil {
+		t.Errorf("expected an error removing a configured adapter")
+	}
+	if _, err := RegisterAdapter(&structs.NRegisterRequest{AdpID: "TST2"}, "secret"); err == nil {
+		t.Errorf("expected an error for a missing address")
+	}
+
+	// An expired lease removes the Adapter, and its Services are removed by the Services
+	// cache.
+	PublishCatalog(NewCatalog(map[string]structs.NServices{"SJ": {svc("CS1", "SJ", 1, 1), svc("TST1", "SJ", 1, 1)}}, nil))
+	defer OnAdapterRemoved(adapterRemoved)
+	var removed []string
+	OnAdapterRemoved(func(adpID string) {
+		removed = append(removed, adpID)
+		PublishCatalog(NewCatalog(map[string]structs.NServices{"SJ": {svc("CS1", "SJ", 1, 1)}}, nil))
+	})
+	if l, _ := GetAreaAdapters("SJ"); len(l) != 2 || l[1] != adp {
+		t.Errorf("expected both adapters for SJ, got: %v", l)
+	}
+	if expired := adapters.expireLeases(time.Now()); len(expired) != 0 {
+		t.Errorf("unexpected expired adapters: %v", expired)
+	}
+	if expired := adapters.expireLeases(time.Now().Add(time.Minute)); len(expired) != 1 || expired[0] != "TST1" {
+		t.Errorf("expected TST1 to expire, got: %v", expired)
+	}
+	if _, err := GetAdapter("TST1"); err == nil || adp.Connected() || len(removed) != 1 || removed[0] != "TST1" {
+		t.Errorf("expected TST1 to be removed: %v", removed)
+	}
+	if l, _ := GetAreaAdapters("SJ"); len(l) != 1 || l[0] != static {
+		t.Errorf("expected only the configured adapter for SJ, got: %v", l)
+	}
+	if routes, _ := GetAreaRoutes("SJ"); len(routes) != 1 || routes[0].AdpID != "CS1" {
+		t.Errorf("expected the TST1 routes to be removed, got: %v", routes)
+	}
+	if areas := refreshed(); len(areas) != 2 {
+		t.Errorf("expected a refresh of SC and SJ, got: %v", areas)
+	}
+	if err := DeregisterAdapter("TST1", "", "secret"); err == nil {
+		t.Errorf("expected an error removing an unknown adapter")
+	}
+
+	adapters.Registration.Enabled = false
+	if _, err := RegisterAdapter(rqst, "secret"); err == nil {
+		t.Errorf("expected an error with registration disabled")
+	}
+}
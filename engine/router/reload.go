@@ -0,0 +1,239 @@
+package router
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+var (
+	configPath string     // The config file loaded by Init(), and re-read by Reload().
+	reloading  sync.Mutex // Serializes the config reloads.
+)
+
+// AdminConfig is the "admin" section of the config file.  The admin API is disabled if the
+// Token is not set.
+type AdminConfig struct {
+	Token string `json:"token"` // Must be sent in the "X-Admin-Token" header.
+}
+
+// CheckAdminToken verifies the admin API is enabled, and the token is valid.
+func CheckAdminToken(token string) error {
+	adapters.RLock()
+	defer adapters.RUnlock()
+	if adapters.Admin.Token == "" {
+		return errors.New("the admin API is not enabled")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(adapters.Admin.Token)) != 1 {
+		return errors.New("invalid admin token")
+	}
+	return nil
+}
+
+// ==============================================================================================================================
+//                                      RELOAD
+// ==============================================================================================================================
+
+// ConfigChanges lists the changes made by Reload().
+type ConfigChanges struct {
+	Added   []string `json:"added"`   // AdpIDs
+	Removed []string `json:"removed"` // AdpIDs
+	Changed []string `json:"changed"` // AdpIDs
+	Restart []string `json:"restart"` // Settings that were changed, but only apply after a restart.
+}
+
+// Reload re-reads the config file, and applies the changes.  The new Adapters are
+// connected, and the removed Adapters are closed once their calls in flight finish.  The
+// Areas, aliases, search radius, regions, Services refresh schedule, taxonomy file,
+// registration and admin settings are replaced, and a Services refresh is requested.  If
+// the config file is invalid, the running config is not changed.
+func Reload() (*ConfigChanges, error) {
+	file, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		msg := fmt.Sprintf("Unable to access the config file - %v.", err)
+		log.Error(msg)
+		return nil, errors.New(msg)
+	}
+	return adapters.reload(file)
+}
+
+// reload validates the config file data, and applies the changes to the running config.
+func (r *Adapters) reload(file []byte) (*ConfigChanges, error) {
+	reloading.Lock()
+	defer reloading.Unlock()
+
+	next := new(Adapters)
+	if err := next.load(file); err != nil {
+		log.Error("Config reload failed - the running config is unchanged.")
+		return nil, err
+	}
+
+	r.RLock()
+	changes := r.diff(next)
+	r.RUnlock()
+	for _, id := range changes.Changed {
+		if next.Adapters[id].Transport == transportInproc {
+			msg := fmt.Sprintf("In-process adapter %q cannot be changed without a restart.", id)
+			log.Error(msg)
+			return nil, errors.New(msg)
+		}
+	}
+
+	// Connect the new Adapters before they are added.
+	connecting := make(map[string]*Adapter)
+	for _, id := range append(changes.Added, changes.Changed...) {
+		adp := next.Adapters[id]
+		adp.auth = &r.RPCAuth
+		connecting[id] = adp
+	}
+	connectAdapters(connecting)
+	for id, adp := range connecting {
+		if adp.Transport == transportInproc && !adp.Connected() {
+			for _, a := range connecting {
+				a.close()
+			}
+			msg := fmt.Sprintf("Unable to load in-process adapter %q.", id)
+			log.Error(msg)
+			return nil, errors.New(msg)
+		}
+	}
+
+	r.Lock()
+	var closing []*Adapter
+	adps := make(map[string]*Adapter)
+	for id := range next.Adapters {
+		if adp, ok := connecting[id]; ok {
+			adps[id] = adp
+			continue
+		}
+		adps[id] = r.Adapters[id]
+	}
+	areas := next.Areas
+	if areas == nil {
+		areas = make(map[string]*Area)
+	}
+	alias := next.areaAlias
+	for id, adp := range r.Adapters {
+		if adps[id] == adp {
+			continue
+		}
+		if _, ok := next.Adapters[id]; !ok && adp.registered {
+			// Keep the registered Adapter, and its Areas.
+			adps[id] = adp
+			for _, areaID := range adp.areas {
+				area, ok := r.Areas[areaID]
+				if _, exists := areas[areaID]; !ok || exists {
+					continue
+				}
+				areas[areaID] = area
+				for _, a := range area.Aliases {
+					if _, ok := alias[a]; !ok {
+						alias[a] = area
+					}
+				}
+			}
+			continue
+		}
+		closing = append(closing, adp)
+	}
+	r.Adapters = adps
+	r.Areas = areas
+	r.areaAlias = alias
+	r.General = next.General
+	r.Regions = next.Regions
+	r.Refresh = next.Refresh
+	r.Taxonomy = next.Taxonomy
+	r.Registration = next.Registration
+	r.Admin = next.Admin
+	r.Network.ShutdownGrace = next.Network.ShutdownGrace
+	r.loadedAt = time.Now()
+	refresh := make([]string, 0, len(areas))
+	for id := range areas {
+		refresh = append(refresh, id)
+	}
+	registration := r.Registration.Enabled
+	r.Unlock()
+
+	for _, adp := range closing {
+		adp.close()
+	}
+	for _, id := range changes.Removed {
+		removeCatalogAdapter(id)
+	}
+	if len(changes.Removed) > 0 {
+		go func(names []string) {
+			ctx, cancel := context.WithTimeout(context.Background(), GetShutdownGrace())
+			defer cancel()
+			children.stop(ctx, names...)
+		}(changes.Removed)
+	}
+	if registration {
+		startLeaseWatch()
+	}
+
+	log.WithFields(log.Fields{
+		"added":   changes.Added,
+		"removed": changes.Removed,
+		"changed": changes.Changed,
+		"restart": changes.Restart,
+	}).Info("Config reloaded")
+	sort.Strings(refresh)
+	r.requestRefresh(refresh)
+	return changes, nil
+}
+
+// diff compares the running config with the next config.  A registered Adapter replaced
+// by an Adapter in the config file is changed.  The Adapters must be locked.
+func (r *Adapters) diff(next *Adapters) *ConfigChanges {
+	c := new(ConfigChanges)
+	for id, n := range next.Adapters {
+		adp, ok := r.Adapters[id]
+		switch {
+		case !ok:
+			c.Added = append(c.Added, id)
+		case adp.registered || !adp.sameConfig(n):
+			c.Changed = append(c.Changed, id)
+		}
+	}
+	for id, adp := range r.Adapters {
+		if _, ok := next.Adapters[id]; !ok && !adp.registered {
+			c.Removed = append(c.Removed, id)
+		}
+	}
+
+	restart := func(setting string, same bool) {
+		if !same {
+			log.Warningf("The %q setting is only changed when the Engine is restarted.", setting)
+			c.Restart = append(c.Restart, setting)
+		}
+	}
+	n := next.Network
+	restart("network", r.Network.Address == n.Address && r.Network.Protocol == n.Protocol &&
+		r.Network.CertFile == n.CertFile && r.Network.KeyFile == n.KeyFile)
+	restart("monitor", r.Monitor == next.Monitor)
+	restart("geocoder", r.Geocoder == next.Geocoder)
+	restart("searchCache", r.SearchCache == next.SearchCache)
+	restart("rpcAuth", r.RPCAuth.CertFile == next.RPCAuth.CertFile && r.RPCAuth.KeyFile == next.RPCAuth.KeyFile &&
+		r.RPCAuth.CAFile == next.RPCAuth.CAFile && r.RPCAuth.Secret == next.RPCAuth.Secret)
+	restart("auxiliary", reflect.DeepEqual(r.AuxProgs, next.AuxProgs))
+
+	sort.Strings(c.Added)
+	sort.Strings(c.Removed)
+	sort.Strings(c.Changed)
+	return c
+}
+
+// sameConfig returns true if the Adapter has the same config file settings as n.
+func (adp *Adapter) sameConfig(n *Adapter) bool {
+	return adp.Type == n.Type && adp.Address == n.Address && sameList(adp.Addresses, n.Addresses) &&
+		adp.Balance == n.Balance && adp.Transport == n.Transport && adp.Config == n.Config &&
+		reflect.DeepEqual(adp.Startup, n.Startup)
+}
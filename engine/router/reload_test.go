@@ -0,0 +1,91 @@
+package router
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+func TestReload(t *testing.T) {
+	caps := structs.NCapabilities{RequestTypes: []structs.NRequestType{structs.NRTCreate}}
+	addr1, done1 := serveAdapter(t, caps)
+	defer done1()
+	addr2, done2 := serveAdapter(t, caps)
+	defer done2()
+
+	config := func(adapters, alias string, radiusMax int, address string) []byte {
+		return []byte(fmt.Sprintf(`{
+			"network": {"address": %q},
+			"general": {"searchRadiusMin": 100, "searchRadiusMax": %d},
+			"adapters": {%s},
+			"areas": {"SJ": {"name": "San Jose", "aliases": [%q]}}
+		}`, address, radiusMax, adapters, alias))
+	}
+	r := &Adapters{chRefresh: make(chan []string, 10)}
+	if err := r.load(config(fmt.Sprintf(`"A1": {"type": "Test", "address": %q}, "A2": {"type": "Test", "address": %q}`, addr1, addr2), "san jose", 500, ":8080")); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.connect(); err != nil {
+		t.Fatal(err)
+	}
+	a1, a2 := r.Adapters["A1"], r.Adapters["A2"]
+	reg := &Adapter{ID: "REG", Address: addr1, registered: true, areas: []string{"SC"}}
+	r.Adapters["REG"] = reg
+	r.addAreas([]structs.NRegisterArea{{ID: "SC", Name: "Santa Clara"}})
+
+	// A2 is removed, A3 is added, and the SJ alias and search radius are changed.
+	changes, err := r.reload(config(fmt.Sprintf(`"A1": {"type": "Test", "address": %q}, "A3": {"type": "Test", "address": %q}`, addr1, addr2), "sj", 800, ":8081"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(changes.Added, changes.Removed, changes.Changed, changes.Restart) != "[A3] [A2] [] [network]" {
+		t.Errorf("unexpected changes: %+v", changes)
+	}
+	if r.Adapters["A1"] != a1 || r.Adapters["REG"] != reg || r.Adapters["A2"] != nil {
+		t.Errorf("expected A1 and REG to be kept, and A2 removed: %v", r.Adapters)
+	}
+	if a2.Connected() || !r.Adapters["A3"].Connected() {
+		t.Errorf("expected A2 to be closed, and A3 connected")
+	}
+	if id, err := r.areaID("sj"); err != nil || id != "SJ" {
+		t.Errorf("expected the new alias, got: %q, %v", id, err)
+	}
+	if _, err := r.areaID("san jose"); err == nil {
+		t.Errorf("expected the old alias to be removed")
+	}
+	if id, err := r.areaID("santa clara"); err != nil || id != "SC" {
+		t.Errorf("expected the registered area to be kept, got: %q, %v", id, err)
+	}
+	if r.General.SearchRadiusMax != 800 || r.Network.Address != ":8080" {
+		t.Errorf("expected the search radius to change, and not the network: %+v, %+v", r.General, r.Network)
+	}
+	select {
+	case areas := <-r.chRefresh:
+		if fmt.Sprint(areas) != "[SC SJ]" {
+			t.Errorf("expected a refresh of all areas, got: %v", areas)
+		}
+	default:
+		t.Errorf("expected a Services refresh")
+	}
+
+	// An invalid config file is not applied.
+	if _, err := r.reload(config(`"A1": {"type": "Test", "address": ":1", "balance": "random"}`, "sj", 100, ":8080")); err == nil {
+		t.Errorf("expected an invalid config error")
+	}
+	if r.Adapters["A3"] == nil || r.General.SearchRadiusMax != 800 {
+		t.Errorf("the running config should not change")
+	}
+
+	// A changed Adapter is replaced.
+	changes, err = r.reload(config(fmt.Sprintf(`"A1": {"type": "Test", "address": %q}, "A3": {"type": "Test", "address": %q}`, addr2, addr2), "sj", 800, ":8080"))
+	if err != nil || fmt.Sprint(changes.Changed, changes.Restart) != "[A1] []" {
+		t.Fatalf("unexpected changes: %+v, %v", changes, err)
+	}
+	if r.Adapters["A1"] == a1 || a1.Connected() || !r.Adapters["A1"].Connected() {
+		t.Errorf("expected A1 to be replaced")
+	}
+	for _, adp := range r.Adapters {
+		adp.close()
+	}
+}
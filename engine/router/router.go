@@ -17,6 +17,7 @@ func Init(configFile string) error {
 	if err := readConfig(configFile); err != nil {
 		return err
 	}
+	configPath = configFile
 	log.Debug("Adapters: " + adapters.String())
 
 	if err := adapters.AuxProgs.start(); err != nil {
@@ -28,6 +29,10 @@ func Init(configFile string) error {
 		return err
 	}
 
+	if adapters.Registration.Enabled {
+		startLeaseWatch()
+	}
+
 	return nil
 }
 
@@ -1,7 +1,6 @@
 package router
 
 import (
-	"CitySourcedAPI/logs"
 	"fmt"
 	"testing"
 
@@ -12,8 +11,7 @@ var Debug = true
 
 func TestReadConfig(t *testing.T) {
 	fmt.Println("\n\n\n\n============================= [TestReadConfig] =============================")
-	logs.Init(true)
-	if err := Init("../data/config.json"); err != nil {
+	if err := Init("../config.json"); err != nil {
 		t.Errorf("Init() failed: %s", err)
 	}
 
@@ -19,11 +19,8 @@ func RoutesArea(areaID string) (routes structs.NRoutes, err error) {
 	return nil, nil
 }
 
-// RoutesAll returns all routes from all CONFIG'ured adapters.  This call does not
-// use the Services cache for this list - it uses the config.json file.
+// RoutesAll returns all routes from all CONFIG'ured and registered adapters.  This call
+// does not use the Services cache for this list - it uses the Adapter list.
 func RoutesAll() (routes structs.NRoutes, err error) {
-	for _, adp := range adapters.Adapters {
-		routes = append(routes, structs.NRoute{AdpID: adp.ID, AreaID: "all", ProviderID: 0})
-	}
-	return routes, nil
+	return adapters.getAllRoutes(), nil
 }
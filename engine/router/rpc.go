@@ -1,6 +1,7 @@
 package router
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sync"
@@ -16,8 +17,7 @@ import (
 )
 
 const (
-	rpcChanSize = 5
-	rpcTimeout  = time.Second * 3 // 3 seconds
+	rpcTimeout = time.Second * 3 // 3 seconds
 )
 
 var (
@@ -60,6 +60,7 @@ type RPCCallMgr struct {
 
 	calls map[structs.NRoute]*rpcCall
 	errs  []error
+	late  bool // An Adapter did not reply by the deadline.
 }
 
 type requester interface {
@@ -74,14 +75,18 @@ func NewRPCCallMgr(reqmgr requester) (*RPCCallMgr, error) {
 	if len(reqmgr.Routes()) == 0 {
 		return nil, fmt.Errorf("no routes")
 	}
+	routes := FilterRoutes(reqmgr.Routes(), reqmgr.RType())
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("no adapter supports the %s request", reqmgr.RType())
+	}
 	r := &RPCCallMgr{
 		reqmgr:        reqmgr,
 		serviceMethod: serviceMethods[reqmgr.RType()],
-		results:       make(chan structs.NRoute, rpcChanSize),
+		results:       make(chan structs.NRoute, len(routes)), // Abandoned calls must not block.
 		calls:         make(map[structs.NRoute]*rpcCall),
 	}
 
-	for _, route := range reqmgr.Routes() {
+	for _, route := range routes {
 		rpccall, err := newrpcCall(r, route)
 		if err != nil {
 			log.Error(err.Error())
@@ -93,9 +98,9 @@ func NewRPCCallMgr(reqmgr requester) (*RPCCallMgr, error) {
 	return r, nil
 }
 
-func (r *RPCCallMgr) send() {
+func (r *RPCCallMgr) send(ctx context.Context) {
 	for _, call := range r.calls {
-		err := call.run()
+		err := call.run(ctx)
 		if err != nil {
 			r.errs = append(r.errs, err)
 			continue
@@ -104,18 +109,22 @@ func (r *RPCCallMgr) send() {
 	}
 }
 
-func (r *RPCCallMgr) receive() {
+func (r *RPCCallMgr) receive(ctx context.Context) {
 	// Responses are serialized via the results channel
 	// Collect responses via the "r.results" channel.
 	if r.pending > 0 {
 		var timedout bool
-		timeout := common.TimeoutChan(rpcTimeout)
 		for !timedout {
 			select {
 			case respKey := <-r.results:
 				answer := r.calls[respKey]
 				r.decPending()
 				telemetry.SendRPC(answer.response.(structs.NResponser).GetIDS(), "done", "", time.Now())
+				if answer.err == context.Canceled || answer.err == context.DeadlineExceeded {
+					// Abandoned at the deadline - handled below.
+					timedout = true
+					break
+				}
 				if answer.err != nil {
 					r.errs = append(r.errs, answer.err)
 					log.WithFields(log.Fields{
@@ -136,16 +145,29 @@ func (r *RPCCallMgr) receive() {
 					break
 				}
 
-			case timedout = <-timeout:
+			case <-ctx.Done():
+				timedout = true
 			}
 
 			if r.pending == 0 {
 				break
 			}
 		}
-		if timedout {
+		switch {
+		case timedout && ctx.Err() == context.Canceled:
+			// The client went away - the calls in flight are abandoned.
+			r.errs = append(r.errs, errors.New("request cancelled"))
+			log.WithFields(log.Fields{
+				"method":  r.serviceMethod,
+				"pending": r.pending,
+			}).Warn("Request cancelled.")
+		case timedout:
 			for route, call := range r.calls {
-				if !call.replied {
+				call.Lock()
+				late := !call.replied || call.err == context.DeadlineExceeded
+				call.Unlock()
+				if late {
+					r.late = true
 					log.WithFields(log.Fields{
 						"method": r.serviceMethod,
 						"route":  route.String(),
@@ -159,15 +181,19 @@ func (r *RPCCallMgr) receive() {
 	}
 }
 
-// Run executes all RPC calls.
-func (r *RPCCallMgr) Run() error {
+// Run executes all RPC calls, and waits for the responses until the context is done, or
+// rpcTimeout.  The deadline is sent to the Adapters in the requests.  If the context is
+// cancelled (e.g. the client disconnected), the calls in flight are abandoned.
+func (r *RPCCallMgr) Run(ctx context.Context) error {
 	startTime := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, rpcTimeout)
+	defer cancel()
 
 	// Initiate all RPC calls
-	r.send()
+	r.send(ctx)
 
 	// Process responses
-	r.receive()
+	r.receive(ctx)
 
 	if showRunTimes {
 		log.WithFields(log.Fields{
@@ -193,6 +219,12 @@ func (r *RPCCallMgr) Run() error {
 	return nil
 }
 
+// TimedOut returns true if any Adapter did not reply by the deadline.  Run returns the
+// replies of the other Adapters without an error.
+func (r *RPCCallMgr) TimedOut() bool {
+	return r.late
+}
+
 // -------------------------------- rpcmanager Interface ---------------------------------
 
 func (r *RPCCallMgr) rType() structs.NRequestType {
@@ -304,10 +336,11 @@ func (r *rpcCall) setSent() {
 	r.sent = true
 }
 
-func (r *rpcCall) prepRPC() (rqstCopy interface{}, err error) {
+func (r *rpcCall) prepRPC(deadline time.Time) (rqstCopy interface{}, err error) {
 	prep := func(d structs.NRequester) {
 		d.SetID(0, r.id)
 		d.SetRoute(r.route)
+		d.SetDeadline(deadline)
 	}
 	switch data := r.rpc.data().(type) {
 	case *structs.NServiceRequest:
@@ -323,6 +356,9 @@ func (r *rpcCall) prepRPC() (rqstCopy interface{}, err error) {
 	case *structs.NSearchRequestLL:
 		rCopy := *data
 		prep(&rCopy)
+		if max := r.adp.Capabilities().MaxRadius; max > 0 && rCopy.Radius > max {
+			rCopy.Radius = max
+		}
 		rqstCopy = &rCopy
 		log.Debugf("Sending: %s", rCopy.String())
 	case *structs.NSearchRequestDID:
@@ -343,9 +379,10 @@ func (r *rpcCall) prepRPC() (rqstCopy interface{}, err error) {
 	return rqstCopy, nil
 }
 
-func (r *rpcCall) run() error {
+func (r *rpcCall) run(ctx context.Context) error {
 	if r.adp.Connected() {
-		payload, err := r.prepRPC()
+		deadline, _ := ctx.Deadline()
+		payload, err := r.prepRPC(deadline)
 		if err != nil {
 			return err
 		}
@@ -353,9 +390,10 @@ func (r *rpcCall) run() error {
 		telemetry.SendRPC(payload.(structs.NRequester).GetIDS(), "open", r.route.String(), time.Now())
 		go func() {
 			response := newResponse[r.rpc.rType()]()
-			r.err = r.adp.Call(r.rpc.service(), payload, response)
+			err := r.adp.CallContext(ctx, r.rpc.service(), payload, response)
 			r.Lock()
 			defer r.Unlock()
+			r.err = err
 			r.response = response
 			r.replied = true
 			r.rpc.resultChan() <- r.route
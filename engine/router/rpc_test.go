@@ -0,0 +1,108 @@
+package router
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+	"github.com/codeforsanjose/open311-gateway/engine/telemetry"
+)
+
+// slowCaller is an rpcCaller that replies for Area "SJ", and waits for the context for
+// the other Areas.  It records the deadline of each request.
+type slowCaller struct {
+	sync.Mutex
+	deadlines []time.Time
+	abandoned int
+}
+
+func (c *slowCaller) Call(serviceMethod string, args interface{}, reply interface{}) error {
+	return c.CallContext(context.Background(), serviceMethod, args, reply)
+}
+
+func (c *slowCaller) CallContext(ctx context.Context, serviceMethod string, args interface{}, reply interface{}) error {
+	rqst := args.(*structs.NServiceRequest)
+	c.Lock()
+	c.deadlines = append(c.deadlines, rqst.Deadline)
+	c.Unlock()
+	if rqst.Route.AreaID == "SJ" {
+		reply.(*structs.NServicesResponse).AdpID = rqst.Route.AdpID
+		return nil
+	}
+	<-ctx.Done()
+	c.Lock()
+	c.abandoned++
+	c.Unlock()
+	return ctx.Err()
+}
+
+// servicesRequester is a requester for a Services request.
+type servicesRequester struct {
+	routes  structs.NRoutes
+	replies int
+}
+
+func (r *servicesRequester) Routes() structs.NRoutes     { return r.routes }
+func (r *servicesRequester) RType() structs.NRequestType { return structs.NRTServicesAll }
+func (r *servicesRequester) Data() interface{}           { return &structs.NServiceRequest{Area: "all"} }
+func (r *servicesRequester) Processer() func(interface{}) error {
+	return func(interface{}) error { r.replies++; return nil }
+}
+
+func TestRunContext(t *testing.T) {
+	telemetry.Init("127.0.0.1:9")
+	caller := &slowCaller{}
+	saved := adapters.Adapters
+	defer func() { adapters.Adapters = saved }()
+	adapters.Adapters = map[string]*Adapter{"TST1": {ID: "TST1", client: caller, connected: true}}
+	routes := structs.NRoutes{{AdpID: "TST1", AreaID: "SJ"}, {AdpID: "TST1", AreaID: "SC"}}
+
+	// The client goes away: the call in flight is abandoned.
+	reqmgr := &servicesRequester{routes: routes}
+	mgr, err := NewRPCCallMgr(reqmgr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(time.Millisecond*50, cancel)
+	start := time.Now()
+	if err := mgr.Run(ctx); err == nil {
+		t.Errorf("expected a cancelled request error")
+	}
+	if d := time.Since(start); d > rpcTimeout/2 {
+		t.Errorf("Run should return when the request is cancelled, took: %v", d)
+	}
+	if reqmgr.replies != 1 {
+		t.Errorf("expected the SJ reply, got: %d", reqmgr.replies)
+	}
+
+	// The Adapters get the deadline of the request.
+	deadline := time.Now().Add(time.Millisecond * 100)
+	ctx, cancel = context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	reqmgr = &servicesRequester{routes: routes}
+	if mgr, err = NewRPCCallMgr(reqmgr); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.Run(ctx); err != nil || !mgr.TimedOut() {
+		t.Errorf("a timed out Adapter should not fail the request, but be reported: %v, %t", err, mgr.TimedOut())
+	}
+	caller.Lock()
+	defer caller.Unlock()
+	if len(caller.deadlines) != 4 || !caller.deadlines[2].Equal(deadline) || !caller.deadlines[3].Equal(deadline) {
+		t.Errorf("expected the request deadline, got: %v", caller.deadlines)
+	}
+	if d := caller.deadlines[0].Sub(start); d < rpcTimeout || d > rpcTimeout+time.Second {
+		t.Errorf("expected the rpcTimeout deadline, got: %v", caller.deadlines[0])
+	}
+	for i := 0; i < 100 && caller.abandoned < 2; i++ {
+		caller.Unlock()
+		time.Sleep(time.Millisecond * 10)
+		caller.Lock()
+	}
+	if caller.abandoned != 2 {
+		t.Errorf("expected the slow calls to be abandoned, got: %d", caller.abandoned)
+	}
+}
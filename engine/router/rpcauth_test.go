@@ -0,0 +1,55 @@
+package router
+
+import (
+	"net"
+	"testing"
+
+	"github.com/codeforsanjose/open311-gateway/common/adaptersdk"
+	"github.com/codeforsanjose/open311-gateway/common/rpcauth"
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+func TestRPCAuth(t *testing.T) {
+	a := adaptersdk.New(func() adaptersdk.Provider { return new(adaptersdk.ProviderBase) })
+	if err := a.Load([]byte(`{"adapter": {"name": "TST1"}, "rpcAuth": {"secret": "s3cret"}, "serviceAreas": {}}`), nil); err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go a.Serve(l)
+	addr := l.Addr().String()
+
+	r := new(Adapters)
+	if err := r.load([]byte(`{"rpcAuth": {"secret": "s3cret"}, "adapters": {
+		"TST1": {"type": "Test", "address": "` + addr + `"},
+		"TST2": {"type": "Test", "address": "` + addr + `", "transport": "jsonrpc"}}}`)); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"TST1", "TST2"} {
+		adp := r.Adapters[id]
+		if err := adp.connect(); err != nil {
+			t.Fatalf("%s: %v", id, err)
+		}
+		var resp structs.NServicesResponse
+		if err := adp.Call("Services.All", &structs.NServiceRequest{Area: "all"}, &resp); err != nil || resp.AdpID != "TST1" {
+			t.Errorf("%s: unexpected response: %+v, %v", id, resp, err)
+		}
+		adp.close()
+	}
+
+	for _, auth := range []*rpcauth.Config{nil, {Secret: "guess"}} {
+		for _, transport := range []string{transportRPC, transportJSONRPC} {
+			adp := &Adapter{ID: "TST1", Address: addr, Transport: transport, auth: auth}
+			if err := adp.connect(); err == nil {
+				t.Errorf("%s: expected the adapter to reject secret %v", transport, auth)
+			}
+		}
+	}
+
+	if err := new(Adapters).load([]byte(`{"rpcAuth": {"certFile": "engine.crt"}}`)); err == nil {
+		t.Errorf("expected an incomplete rpcAuth error")
+	}
+}
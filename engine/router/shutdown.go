@@ -0,0 +1,129 @@
+package router
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+// dfltShutdownGrace is how long the Engine waits for requests in progress on shutdown,
+// if "network.shutdownGrace" is not set.
+const dfltShutdownGrace = 10 // seconds
+
+// ==============================================================================================================================
+//                                      SHUTDOWN
+// ==============================================================================================================================
+
+// Shutdown closes the connections to the Adapters, once their calls in flight finish, and
+// stops the Adapters and auxiliary programs started by the Engine.  Programs still running
+// when the context is done are killed.
+func Shutdown(ctx context.Context) {
+	adapters.RLock()
+	for _, adp := range adapters.Adapters {
+		adp.close()
+	}
+	adapters.RUnlock()
+	children.stop(ctx)
+}
+
+// ==============================================================================================================================
+//                                      CHILD PROCESSES
+// ==============================================================================================================================
+
+// children is the Adapter and auxiliary programs started by the Engine.
+var children = childProcs{procs: make(map[*exec.Cmd]string)}
+
+type childProcs struct {
+	procs map[*exec.Cmd]string // Value: name
+	sync.Mutex
+}
+
+// run starts the program, and waits for it to exit.  It is stopped by stop().
+func (r *childProcs) run(name string, cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	r.Lock()
+	r.procs[cmd] = name
+	r.Unlock()
+	defer func() {
+		r.Lock()
+		delete(r.procs, cmd)
+		r.Unlock()
+	}()
+	return cmd.Wait()
+}
+
+// running returns the number of programs running.
+func (r *childProcs) running() int {
+	return r.count(func(string) bool { return true })
+}
+
+// count returns the number of programs running that match.
+func (r *childProcs) count(match func(name string) bool) int {
+	r.Lock()
+	defer r.Unlock()
+	n := 0
+	for _, name := range r.procs {
+		if match(name) {
+			n++
+		}
+	}
+	return n
+}
+
+// stop interrupts the named programs (all of them, if no names are given), and waits for
+// them to exit.  The programs still running when the context is done are killed.
+func (r *childProcs) stop(ctx context.Context, names ...string) {
+	stopping := func(name string) bool {
+		if len(names) == 0 {
+			return true
+		}
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+	r.Lock()
+	for cmd, name := range r.procs {
+		if !stopping(name) {
+			continue
+		}
+		log.WithFields(log.Fields{
+			"name": name,
+			"pid":  cmd.Process.Pid,
+		}).Info("Stopping program")
+		if err := cmd.Process.Signal(os.Interrupt); err != nil {
+			log.Warningf("Unable to interrupt %s - %s", name, err)
+		}
+	}
+	r.Unlock()
+
+	t := time.NewTicker(time.Millisecond * 50)
+	defer t.Stop()
+	for r.count(stopping) > 0 && ctx.Err() == nil {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+		}
+	}
+
+	r.Lock()
+	defer r.Unlock()
+	for cmd, name := range r.procs {
+		if !stopping(name) {
+			continue
+		}
+		log.WithFields(log.Fields{
+			"name": name,
+			"pid":  cmd.Process.Pid,
+		}).Warn("Killing program")
+		_ = cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,48 @@
+package router
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestChildProcsStop(t *testing.T) {
+	r := childProcs{procs: make(map[*exec.Cmd]string)}
+	// The first program exits when interrupted, the second ignores it and is killed.
+	progs := []*exec.Cmd{
+		exec.Command("sleep", "10"),
+		exec.Command("sh", "-c", `trap "" INT; sleep 10`),
+	}
+	done := make(chan error, len(progs))
+	for i, cmd := range progs {
+		go func(name string, cmd *exec.Cmd) { done <- r.run(name, cmd) }([]string{"sleep", "trap"}[i], cmd)
+	}
+	for i := 0; i < 100 && r.running() < len(progs); i++ {
+		time.Sleep(time.Millisecond * 10)
+	}
+	if r.running() != len(progs) {
+		t.Fatalf("expected %d programs running, got: %d", len(progs), r.running())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*300)
+	defer cancel()
+	start := time.Now()
+	r.stop(ctx)
+	for range progs {
+		select {
+		case err := <-done:
+			if err == nil {
+				t.Errorf("expected the programs to be stopped by a signal")
+			}
+		case <-time.After(time.Second * 2):
+			t.Fatal("expected the programs to exit")
+		}
+	}
+	if d := time.Since(start); d > time.Second*2 {
+		t.Errorf("stop should kill the programs when the context is done, took: %v", d)
+	}
+	if r.running() != 0 {
+		t.Errorf("expected no programs running, got: %d", r.running())
+	}
+}
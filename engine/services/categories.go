@@ -0,0 +1,83 @@
+package services
+
+import (
+	"sort"
+
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+	"github.com/codeforsanjose/open311-gateway/engine/router"
+	"github.com/codeforsanjose/open311-gateway/engine/taxonomy"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+// Reclassify maps the Services to the Categories of the loaded taxonomy, and publishes
+// them.  It is called when the taxonomy file is reloaded.
+func Reclassify() {
+	servicesData.Lock()
+	servicesData.rebuild()
+	servicesData.Unlock()
+	servicesData.publish()
+	reportUnmapped(nil)
+}
+
+// Unmapped returns the MIDs of the Services in the Areas (all Areas, if areas is nil)
+// that are not mapped to a taxonomy Category, indexed by AreaID.  It is empty if the
+// taxonomy has no Categories.
+func Unmapped(areas []string) map[string][]string {
+	unmapped := make(map[string][]string)
+	if !taxonomy.Get().Enabled() {
+		return unmapped
+	}
+	c := router.GetCatalog()
+	for areaID, l := range c.Services {
+		if areas != nil && !overlaps(areas, []string{areaID}) {
+			continue
+		}
+		for _, ns := range l {
+			if _, ok := c.Categories[ns.MID()]; !ok {
+				unmapped[areaID] = append(unmapped[areaID], ns.MID())
+			}
+		}
+		sort.Strings(unmapped[areaID])
+	}
+	return unmapped
+}
+
+// categorize maps the Services of the Catalog to the taxonomy Categories.  The Catalog
+// must not be published yet.
+func categorize(c *router.Catalog) {
+	t := taxonomy.Get()
+	if !t.Enabled() {
+		return
+	}
+	for _, l := range c.Services {
+		for _, ns := range l {
+			if id, ok := t.Classify(ns); ok {
+				c.Categories[ns.MID()] = id
+			}
+		}
+	}
+}
+
+// synonyms returns the taxonomy synonyms of the Category of each Service in the Catalog,
+// for the search index.
+func synonyms(c *router.Catalog) func(ns structs.NService) []string {
+	t := taxonomy.Get()
+	return func(ns structs.NService) []string {
+		if cat, ok := t.Category(c.Categories[ns.MID()]); ok {
+			return cat.Synonyms
+		}
+		return nil
+	}
+}
+
+// reportUnmapped logs the Services in the Areas (all Areas, if areas is nil) that are not
+// mapped to a taxonomy Category.
+func reportUnmapped(areas []string) {
+	for areaID, mids := range Unmapped(areas) {
+		log.WithFields(log.Fields{
+			"area":     areaID,
+			"services": mids,
+		}).Warn("Services without a taxonomy category")
+	}
+}
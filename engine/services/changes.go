@@ -0,0 +1,274 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+	"github.com/codeforsanjose/open311-gateway/engine/router"
+	"github.com/codeforsanjose/open311-gateway/engine/telemetry"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+// maxChanges is the number of recent Service changes kept in memory.
+const maxChanges = 1000
+
+// Service change types.
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeRenamed  = "renamed"
+	ChangeMetadata = "metadata"
+)
+
+var changes changeLog
+
+// Change is a change to a Service of an Area, found by a refresh.
+type Change struct {
+	At         time.Time `json:"at"`
+	Generation uint64    `json:"generation"` // The Catalog with the change.
+	AreaID     string    `json:"area_id"`
+	Change     string    `json:"change"` // ChangeAdded, ChangeRemoved, ChangeRenamed or ChangeMetadata.
+	MID        string    `json:"service_code"`
+	Name       string    `json:"service_name"`
+	OldName    string    `json:"old_service_name,omitempty"` // ChangeRenamed
+	Fields     []string  `json:"fields,omitempty"`           // ChangeMetadata - the fields that changed.
+}
+
+// Changes returns the Service changes after the time (for all Areas, if areaID is empty),
+// oldest first.  complete is false if older changes after the time were dropped.
+func Changes(since time.Time, areaID string) (list []Change, complete bool) {
+	return changes.since(since, areaID)
+}
+
+// ==============================================================================================================================
+//                                      CHANGE LOG
+// ==============================================================================================================================
+
+// changeLog is the recent Service changes, and the "servicesRefresh.changeLog" file they
+// are appended to.
+type changeLog struct {
+	list    []Change
+	dropped time.Time // The time of the last change dropped from the list.
+	sync.RWMutex
+}
+
+// load reads the recent changes from the change log file, if it exists.  If the file has
+// more than maxChanges entries, or invalid entries, it is rewritten with the last
+// maxChanges valid entries, so it does not grow without limit.
+func (r *changeLog) load(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var (
+		list    []Change
+		entries int
+	)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		entries++
+		var c Change
+		if err := json.Unmarshal(scanner.Bytes(), &c); err != nil {
+			log.Warningf("Skipping an invalid entry in the Service change log - %s", err)
+			continue
+		}
+		list = append(list, c)
+	}
+	f.Close()
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	r.Lock()
+	defer r.Unlock()
+	r.list = nil
+	r.add(list)
+	if entries > len(r.list) {
+		if err := rewriteChanges(file, r.list); err != nil {
+			return err
+		}
+		log.Infof("Truncated the Service change log from %d to %d entries", entries, len(r.list))
+	}
+	return nil
+}
+
+// record timestamps the changes, and adds them to the list, the change log file, and the
+// telemetry.
+func (r *changeLog) record(file string, list []Change) {
+	if len(list) == 0 {
+		return
+	}
+	r.Lock()
+	defer r.Unlock()
+	now := time.Now()
+	for i := range list {
+		list[i].At = now
+	}
+	r.add(list)
+	for _, c := range list {
+		log.WithFields(log.Fields{
+			"area":    c.AreaID,
+			"service": c.MID,
+			"change":  c.Change,
+		}).Info("Service changed")
+		telemetry.SendServiceChange(c.MID, c.AreaID, c.Change, c.Name, c.At)
+	}
+	if file != "" {
+		if err := appendChanges(file, list); err != nil {
+			log.Errorf("Unable to write the Service change log - %s", err)
+		}
+	}
+}
+
+// add adds the changes to the list, dropping the oldest.  The change log must be locked.
+func (r *changeLog) add(list []Change) {
+	r.list = append(r.list, list...)
+	if n := len(r.list) - maxChanges; n > 0 {
+		r.dropped = r.list[n-1].At
+		r.list = append([]Change(nil), r.list[n:]...)
+	}
+}
+
+func (r *changeLog) since(since time.Time, areaID string) ([]Change, bool) {
+	r.RLock()
+	defer r.RUnlock()
+	list := make([]Change, 0)
+	for _, c := range r.list {
+		if c.At.After(since) && (areaID == "" || c.AreaID == areaID) {
+			list = append(list, c)
+		}
+	}
+	return list, !r.dropped.After(since)
+}
+
+func appendChanges(file string, list []Change) error {
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	return writeChanges(f, list)
+}
+
+// rewriteChanges replaces the change log file with the changes.  They are written to a
+// temporary file, which is then renamed, so the file is never left part written.
+func rewriteChanges(file string, list []Change) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := writeChanges(tmp, list); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// writeChanges writes the changes to the file, one JSON entry per line, and closes it.
+func writeChanges(f *os.File, list []Change) error {
+	enc := json.NewEncoder(f)
+	for _, c := range list {
+		if err := enc.Encode(c); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// ==============================================================================================================================
+//                                      DIFF
+// ==============================================================================================================================
+
+// diffCatalogs returns the changes to the Services of each Area from prev to next.
+func diffCatalogs(prev, next *router.Catalog) []Change {
+	areas := make(map[string]bool)
+	for areaID := range prev.Services {
+		areas[areaID] = true
+	}
+	for areaID := range next.Services {
+		areas[areaID] = true
+	}
+	ids := make([]string, 0, len(areas))
+	for areaID := range areas {
+		ids = append(ids, areaID)
+	}
+	sort.Strings(ids)
+
+	var list []Change
+	for _, areaID := range ids {
+		list = append(list, diffArea(areaID, prev.Services[areaID], next.Services[areaID])...)
+	}
+	for i := range list {
+		list[i].Generation = next.Generation
+	}
+	return list
+}
+
+// diffArea returns the changes to the Services of an Area, sorted by MID.
+func diffArea(areaID string, prev, next structs.NServices) []Change {
+	index := func(l structs.NServices) map[string]structs.NService {
+		m := make(map[string]structs.NService)
+		for _, ns := range l {
+			m[ns.MID()] = ns
+		}
+		return m
+	}
+	before, after := index(prev), index(next)
+
+	var list []Change
+	for mid, ns := range after {
+		old, ok := before[mid]
+		if !ok {
+			list = append(list, Change{AreaID: areaID, Change: ChangeAdded, MID: mid, Name: ns.Name})
+			continue
+		}
+		if old.Name != ns.Name {
+			list = append(list, Change{AreaID: areaID, Change: ChangeRenamed, MID: mid, Name: ns.Name, OldName: old.Name})
+		}
+		if fields := changedFields(old, ns); len(fields) > 0 {
+			list = append(list, Change{AreaID: areaID, Change: ChangeMetadata, MID: mid, Name: ns.Name, Fields: fields})
+		}
+	}
+	for mid, ns := range before {
+		if _, ok := after[mid]; !ok {
+			list = append(list, Change{AreaID: areaID, Change: ChangeRemoved, MID: mid, Name: ns.Name})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].MID < list[j].MID })
+	return list
+}
+
+// changedFields returns the JSON names of the fields (other than the name) that differ.
+func changedFields(a, b structs.NService) []string {
+	var l []string
+	if a.Description != b.Description {
+		l = append(l, "description")
+	}
+	if a.Metadata != b.Metadata {
+		l = append(l, "metadata")
+	}
+	if a.ResponseType != b.ResponseType {
+		l = append(l, "responseType")
+	}
+	if a.ServiceNotice != b.ServiceNotice {
+		l = append(l, "service_notice")
+	}
+	if !reflect.DeepEqual(a.Keywords, b.Keywords) && (len(a.Keywords) > 0 || len(b.Keywords) > 0) {
+		l = append(l, "keywords")
+	}
+	if a.Group != b.Group {
+		l = append(l, "group")
+	}
+	return l
+}
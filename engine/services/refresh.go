@@ -1,8 +1,8 @@
 package services
 
 import (
+	"context"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/codeforsanjose/open311-gateway/common"
@@ -28,14 +28,19 @@ type refreshMgr struct {
 
 	routes structs.NRoutes
 	rpc    *router.RPCCallMgr
+	reply  structs.NServices
 }
 
-func refresh(area string) (reterr error) {
+// refreshAdapter loads the Services of the Adapter, and merges them into the cache for
+// the Areas (all Areas, if areas is nil).  If the call fails, or returns no Services,
+// the cache is not changed.
+func refreshAdapter(adpID string, areas []string) (reterr error) {
 	tid := "SrvRrsh"
 	rqstID := sid.RequestID()
 	mgr := refreshMgr{
-		id:    rqstID,
-		start: time.Now(),
+		id:      rqstID,
+		start:   time.Now(),
+		reqType: structs.NRTServicesAll,
 		nreq: &structs.NServiceRequest{
 			NRequestCommon: structs.NRequestCommon{
 				ID: structs.NID{
@@ -45,6 +50,7 @@ func refresh(area string) (reterr error) {
 			},
 			Area: "all",
 		},
+		routes: structs.NRoutes{{AdpID: adpID, AreaID: "all"}},
 	}
 
 	telemetry.SendTelemetry(mgr.id, tid, "open")
@@ -56,33 +62,18 @@ func refresh(area string) (reterr error) {
 		}
 	}()
 
-	area = strings.ToUpper(area)
-	switch {
-	case area == "ALL":
-		mgr.reqType = structs.NRTServicesAll
-	case len(area) >= 2:
-		mgr.reqType = structs.NRTServicesArea
-		return fmt.Errorf("Services Refresh currently only supports requests for ALL areas")
-	default:
-		return fmt.Errorf("Invalid Area %q specified for Services Refresh", area)
-	}
-
-	routes, err := router.RoutesAll()
-	log.Debugf("router.RoutesAll(): %T: %#[1]v  len: %d", routes, len(routes))
-	switch {
-	case err != nil:
-		return err
-	case len(routes) == 0:
-		return fmt.Errorf("unable to get route")
-	}
-	mgr.routes = routes
-
 	log.Debug("Before callRPC: " + mgr.String())
 	if err := mgr.callRPC(); err != nil {
 		log.Error("processRefresh.callRPC() failed - " + err.Error())
 		return err
 	}
-
+	if len(mgr.reply) == 0 {
+		return fmt.Errorf("adapter %s returned no Services - keeping the previous Services", adpID)
+	}
+	if _, err := router.GetAdapter(adpID); err != nil {
+		return fmt.Errorf("adapter %s was removed during the refresh", adpID)
+	}
+	servicesData.merge(adpID, areas, mgr.reply)
 	return nil
 }
 
@@ -117,7 +108,7 @@ func (r *refreshMgr) callRPC() (err error) {
 	}
 
 	log.Debug("Before RPC" + r.String())
-	if err = r.rpc.Run(); err != nil {
+	if err = r.rpc.Run(context.Background()); err != nil {
 		log.Error(err.Error())
 		return err
 	}
@@ -125,7 +116,8 @@ func (r *refreshMgr) callRPC() (err error) {
 }
 
 func (r *refreshMgr) processReply(ndata interface{}) error {
-	return servicesData.merge(ndata)
+	r.reply = append(r.reply, (ndata.(*structs.NServicesResponse)).Services...)
+	return nil
 }
 
 // ------------------------------ String -------------------------------------------------
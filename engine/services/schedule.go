@@ -0,0 +1,82 @@
+package services
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/engine/router"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+// sched refreshes the Services of each Area on the "servicesRefresh" schedule.
+var sched = scheduler{done: make(chan struct{})}
+
+// scheduler refreshes each Area every "servicesRefresh.interval" seconds, plus a random
+// delay of up to "servicesRefresh.jitter" seconds.  The schedule and Areas are read on
+// each pass, so config reloads and registered Areas are picked up.
+type scheduler struct {
+	next map[string]time.Time // Index: AreaID - the next refresh.
+	done chan struct{}
+	once sync.Once
+}
+
+func (s *scheduler) run() {
+	s.next = make(map[string]time.Time)
+	for {
+		wait := s.pass(time.Now())
+		select {
+		case <-time.After(wait):
+		case <-s.done:
+			log.Info("Services refresh schedule stopped.")
+			return
+		}
+	}
+}
+
+// pass starts the refresh of the Areas that are due, and returns the time until the next
+// refresh.  Each Area is refreshed separately, so a slow Adapter only delays its Areas.
+func (s *scheduler) pass(now time.Time) time.Duration {
+	cfg := router.GetRefreshConfig()
+	interval := time.Duration(cfg.Interval) * time.Second
+	wait := interval
+	areas := make(map[string]bool)
+	for _, areaID := range router.GetAreaIDs() {
+		areas[areaID] = true
+		t, ok := s.next[areaID]
+		if !ok {
+			t = now.Add(interval + jitter(cfg.Jitter))
+		}
+		if !t.After(now) {
+			log.Debugf("Scheduled Services refresh for area: %s", areaID)
+			go RefreshAreas([]string{areaID})
+			t = now.Add(interval + jitter(cfg.Jitter))
+		}
+		s.next[areaID] = t
+		if d := t.Sub(now); d < wait {
+			wait = d
+		}
+	}
+	for areaID := range s.next {
+		if !areas[areaID] {
+			delete(s.next, areaID)
+		}
+	}
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return wait
+}
+
+func (s *scheduler) stop() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// jitter returns a random delay of up to max seconds.
+func jitter(max int) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)*int64(time.Second) + 1))
+}
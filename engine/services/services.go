@@ -1,53 +1,180 @@
 package services
 
 import (
-	"errors"
 	"fmt"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/codeforsanjose/open311-gateway/common"
-	"github.com/codeforsanjose/open311-gateway/engine/router"
 	"github.com/codeforsanjose/open311-gateway/common/structs"
+	"github.com/codeforsanjose/open311-gateway/engine/index"
+	"github.com/codeforsanjose/open311-gateway/engine/router"
 
 	log "github.com/jeffizhungry/logrus"
 )
 
 var (
 	servicesData cache
+	refreshing   sync.RWMutex // Held for reading by each refresh, and for writing by Shutdown().
 )
 
-// GetArea returns a list of Services for the specified Area.
+// GetArea returns a list of Services for the specified Area, from the published Catalog.
 func GetArea(areaID string) (structs.NServices, error) {
-	return servicesData.getArea(areaID)
+	return router.GetCatalog().AreaServices(areaID)
 }
 
-// Refresh initiates a refresh of the Services Cache.
+// Init loads the Services Cache from the snapshot file (if any), refreshes it, and starts
+// refreshing each Area on the "servicesRefresh" schedule.  The changes to the Services are
+// recorded from the snapshot, or if there isn't one, after the first refresh.
+func Init() {
+	if err := changes.load(router.GetRefreshConfig().ChangeLog); err != nil {
+		log.Errorf("Unable to load the Service change log - %s", err)
+	}
+	loadSnapshot()
+	Refresh()
+	servicesData.track()
+	go sched.run()
+}
+
+// Refresh refreshes the Services of all Areas.
 func Refresh() {
-	if err := refresh("all"); err != nil {
-		log.Errorf("service cache refresh failed - " + err.Error())
+	refreshAreas(nil)
+}
+
+// RefreshAreas refreshes the Services Cache for the Areas.  The Adapters serving the
+// Areas, and any Adapters without Services in the cache (e.g. after they are added, or if
+// they were down), are called.
+func RefreshAreas(areas []string) {
+	log.Infof("Refreshing the Services for areas: %v", areas)
+	refreshAreas(areas)
+}
+
+// RefreshAdapter refreshes all the Services of the Adapter.
+func RefreshAdapter(adpID string) error {
+	if _, err := router.GetAdapter(adpID); err != nil {
+		return err
 	}
+	refreshing.RLock()
+	defer refreshing.RUnlock()
+	if servicesData.isStopped() {
+		return fmt.Errorf("the Services cache is shut down")
+	}
+	log.Infof("Refreshing the Services for adapter: %s", adpID)
+	if err := refreshAdapter(adpID, nil); err != nil {
+		return err
+	}
+	servicesData.publish()
+	saveSnapshot()
+	reportUnmapped(servicesData.adapterAreas(adpID))
+	return nil
+}
 
-	if err := servicesData.indexAreaAdapters(); err != nil {
-		log.Errorf("service cache indexAreaAdapters() failed - " + err.Error())
+// RemoveAdapter removes the Services of the Adapter from the cache, and publishes the
+// Catalog without them.  It is called by the router when an Adapter is removed.
+func RemoveAdapter(adpID string) {
+	refreshing.RLock()
+	defer refreshing.RUnlock()
+	if servicesData.isStopped() {
+		return
 	}
-	if err := servicesData.sendRoutes(); err != nil {
-		log.Errorf("service cache sendRoutes() failed - " + err.Error())
+	if servicesData.remove(adpID) {
+		servicesData.publish()
+		saveSnapshot()
 	}
+}
 
-	servicesData.switchSet()
-	// log.Debugf("After refresh:\n%v", servicesData.String())
+// refreshAreas refreshes the Areas (all Areas, if areas is nil).  Each Adapter is called
+// separately, and its Services are added to the cache when it replies, so a slow Adapter
+// does not hold up the others.  If any Adapter was refreshed, the snapshot is saved, and
+// the Services without a taxonomy Category are reported.
+func refreshAreas(areas []string) {
+	refreshing.RLock()
+	defer refreshing.RUnlock()
+	if servicesData.isStopped() {
+		return
+	}
 
+	routes, err := router.RoutesAll()
+	if err != nil {
+		log.Errorf("service cache refresh failed - " + err.Error())
+		return
+	}
+	active := make(map[string]bool)
+	for _, route := range routes {
+		active[route.AdpID] = true
+	}
+	servicesData.prune(active, router.GetRegistrationLease())
+
+	var (
+		wg        sync.WaitGroup
+		refreshed int32
+	)
+	for adpID := range active {
+		scope := areas
+		switch served := servicesData.adapterAreas(adpID); {
+		case len(served) == 0:
+			// Not loaded yet - load all of its Areas.
+			scope = nil
+		case areas != nil && !overlaps(served, areas):
+			continue
+		}
+		wg.Add(1)
+		go func(adpID string, scope []string) {
+			defer wg.Done()
+			if err := refreshAdapter(adpID, scope); err != nil {
+				log.Errorf("service cache refresh for adapter %s failed - %s", adpID, err)
+				return
+			}
+			atomic.AddInt32(&refreshed, 1)
+			servicesData.publish()
+		}(adpID, scope)
+	}
+	wg.Wait()
+	servicesData.publish()
+	if refreshed > 0 {
+		saveSnapshot()
+		reportUnmapped(areas)
+	}
+}
+
+// publish publishes the Catalog built by the last change to the cache, and records the
+// changes to the Services since the previous Catalog.  The cache is locked while
+// publishing and recording, so the Catalogs are published, and their changes recorded,
+// in order.
+func (r *cache) publish() {
+	r.Lock()
+	defer r.Unlock()
+	if r.view == nil || r.view == r.published {
+		return
+	}
+	prev, next := r.published, r.view
+	router.PublishCatalog(next)
+	r.published = next
+	if r.tracking && prev != nil {
+		changes.record(router.GetRefreshConfig().ChangeLog, diffCatalogs(prev, next))
+	}
+}
+
+// track starts recording the changes to the Services.
+func (r *cache) track() {
+	r.Lock()
+	defer r.Unlock()
+	r.tracking = true
 }
 
 // ValidateServiceID determines if a ServicID is present in the Services cache, and hence "valid".
 func ValidateServiceID(srvID structs.ServiceID) bool {
-	return servicesData.validateService(srvID)
+	return router.GetCatalog().ValidService(srvID)
 }
 
-// Shutdown should be called at system shutdown.  It will terminate the update channel, and
-// permform any other necessary cleanup.
+// Shutdown should be called at system shutdown.  It stops the refresh schedule, waits for
+// the refreshes in progress, and performs any other necessary cleanup.
 func Shutdown() {
+	sched.stop()
+	refreshing.Lock()
+	defer refreshing.Unlock()
 	servicesData.shutdown()
 }
 
@@ -55,176 +182,195 @@ func Shutdown() {
 //                                      SERVICE CACHE
 // ==============================================================================================================================
 
-// cache is the cache for Services data.  The "list" is the active list.  The
-// active list is a simple copy of either list0 or list1 (i.e. it's effectively a
-// pointer to the underlying data in list0 or list1).  If the activeSet is 0, then list1
-// is cleared and is available for loading.  Vice versa for activeSet1.
+// cache is the cache for Services data.  The Services of each Adapter are kept separately
+// for each Area, so an Adapter can be refreshed without reloading the others.  Each change
+// builds a new router.Catalog (the "view") with the Services of all Adapters for each
+// Area, which is then published.  Services loaded from the snapshot are "stale" until
+// their Adapter is refreshed.
 type cache struct {
-	list        [2]map[string]structs.NServices // Index: AreaID
-	services    [2]map[string]bool
-	activeSet   int
-	lastUpdated time.Time
-	update      chan bool // Update request queue
+	areas     map[string]map[string]structs.NServices // Index: AreaID, AdpID
+	refreshed map[string]time.Time                    // Index: AreaID - the last refresh of the Area.
+	stale     map[string]map[string]bool              // Index: AreaID, AdpID
+	view      *router.Catalog                         // Built by rebuild().
+	published *router.Catalog                         // The last view published.
+	loaded    time.Time                               // When the snapshot was loaded.
+	tracking  bool                                    // Record the changes to the Services.
+	stopped   bool                                    // Shutdown() was called.
 	sync.RWMutex
 }
 
 // getArea retrieves the ServiceList for the specified area.
 func (r *cache) getArea(areaID string) (structs.NServices, error) {
-	r.RLock()
-	defer r.RUnlock()
-	l, ok := r.list[r.activeSet][areaID]
-	if !ok {
-		return nil, fmt.Errorf("The requested AreaID: %q is not serviced by this gateway.", areaID)
-	}
-	return l, nil
+	return r.current().AreaServices(areaID)
 }
 
-// getArea retrieves the ServiceList for the specified area.
+// validateService returns true if the Service is in the cache.
 func (r *cache) validateService(srvID structs.ServiceID) bool {
+	return r.current().ValidService(srvID)
+}
+
+// current returns the last Catalog built.
+func (r *cache) current() *router.Catalog {
 	r.RLock()
 	defer r.RUnlock()
-	if _, ok := r.services[r.activeSet][srvID.MID()]; !ok {
-		return false
+	if r.view == nil {
+		return router.NewCatalog(nil, nil)
 	}
-	return true
+	return r.view
 }
 
-// sendRoutes builds a unique list of all NRoutes and posts it to the
-// router.GetChRouteUpd() channel.
-func (r *cache) sendRoutes() error {
-	ls := r.loadSet()
-
-	// Build a unique list of NRoutes.
-	allRoutes := make(map[structs.NRoute]bool)
-	for _, nservices := range r.list[ls] {
-		for _, nservice := range nservices {
-			allRoutes[nservice.GetRoute()] = true
+// adapterAreas returns the Areas the Adapter has Services in.
+func (r *cache) adapterAreas(adpID string) []string {
+	r.RLock()
+	defer r.RUnlock()
+	var l []string
+	for areaID, adps := range r.areas {
+		if _, ok := adps[adpID]; ok {
+			l = append(l, areaID)
 		}
 	}
-	log.Debug("Sending ALL route data")
-	router.GetChRouteUpd() <- allRoutes
-	return nil
+	return l
 }
 
-// indexAreaAdapters indexes all Adapters by the AreaID, and sends to router via
-// the router.GetChAreaAdp() channel
-func (r *cache) indexAreaAdapters() error {
-	ls := r.loadSet()
-	type mk struct {
-		areaID, adp string
+// merge replaces the Services of the Adapter in the Areas (all Areas, if areas is nil)
+// with the Services in the reply.  If the reply has no Services for an Area, the previous
+// Services are kept.
+func (r *cache) merge(adpID string, areas []string, data structs.NServices) {
+	byArea := make(map[string]structs.NServices)
+	for _, ns := range data {
+		byArea[ns.AreaID] = append(byArea[ns.AreaID], ns)
 	}
-
-	// Build a unique list of [AreaID, AdpID] combinations.
-	areaAdp := make(map[mk]bool)
-	for _, nservices := range r.list[ls] {
-		for _, nservice := range nservices {
-			areaAdp[mk{nservice.AreaID, nservice.AdpID}] = true
-		}
+	r.Lock()
+	defer r.Unlock()
+	if r.areas == nil {
+		r.areas = make(map[string]map[string]structs.NServices)
+		r.refreshed = make(map[string]time.Time)
 	}
-
-	// Convert the above list to a map of a list of AdpID for each AreaID.
-	alist := make(map[string][]string)
-	for k := range areaAdp {
-		if _, ok := alist[k.areaID]; !ok {
-			alist[k.areaID] = make([]string, 0)
+	now := time.Now()
+	for areaID, l := range byArea {
+		if areas != nil && !overlaps(areas, []string{areaID}) {
+			continue
 		}
-		alist[k.areaID] = append(alist[k.areaID], k.adp)
+		if _, ok := r.areas[areaID]; !ok {
+			log.Infof("Created Area: %q", areaID)
+			r.areas[areaID] = make(map[string]structs.NServices)
+		}
+		r.areas[areaID][adpID] = l
+		r.refreshed[areaID] = now
+		r.fresh(areaID, adpID)
 	}
-
-	router.GetChAreaAdp() <- alist
-
-	return nil
-}
-
-func (r *cache) loadSet() (ls int) {
-	if r.activeSet == 0 {
-		ls = 1
+	for _, areaID := range areas {
+		if _, ok := byArea[areaID]; !ok {
+			if _, ok := r.areas[areaID][adpID]; ok {
+				log.Warningf("Adapter %s returned no Services for area %s - keeping the previous Services.", adpID, areaID)
+			}
+		}
 	}
-	return
-}
-
-func (r *cache) clearLoadSet(ds int) {
-	r.list[r.loadSet()] = make(map[string]structs.NServices)
-	r.services[r.loadSet()] = make(map[string]bool)
-}
-
-// refresh initiates a service list update - that is, it requests the current service lists
-// from all Adapters, merges the data back into the standby service list cache, and makes
-// the freshly loaded cache the active cache.
-func (r *cache) refresh() {
-	r.update <- true
+	r.rebuild()
 }
 
-func (r *cache) switchSet() {
+// prune removes the Services of the Adapters that are not active.  The stale Services
+// loaded from the snapshot are kept for the grace period after they were loaded, so an
+// Adapter that registers itself can do so after a restart without its Services being
+// removed and added again.
+func (r *cache) prune(active map[string]bool, grace time.Duration) {
 	r.Lock()
 	defer r.Unlock()
-	if r.activeSet == 0 {
-		log.Debug("[ServicesCache] Switched from list 0 to 1")
-		r.activeSet = 1
-		r.clearLoadSet(0)
-	} else {
-		log.Debug("[ServicesCache] Switched from list 1 to 0")
-		r.activeSet = 0
-		r.clearLoadSet(1)
+	pruned := false
+	waiting := time.Since(r.loaded) < grace
+	for areaID, adps := range r.areas {
+		for adpID := range adps {
+			if !active[adpID] {
+				if waiting && r.stale[areaID][adpID] {
+					continue
+				}
+				log.Infof("Removing the Services of adapter %s for area %s", adpID, areaID)
+				delete(adps, adpID)
+				r.fresh(areaID, adpID)
+				pruned = true
+			}
+		}
+		if len(adps) == 0 {
+			delete(r.areas, areaID)
+		}
+	}
+	if pruned {
+		r.rebuild()
 	}
 }
 
-func (r *cache) merge(ndata interface{}) error {
-	data := (ndata.(*structs.NServicesResponse)).Services
+// remove removes the Services of the Adapter from all Areas, and returns true if it had
+// any.
+func (r *cache) remove(adpID string) bool {
 	r.Lock()
 	defer r.Unlock()
-	var loadSet int
-	switch r.activeSet {
-	case 0:
-		loadSet = 1
-	case 1:
-		loadSet = 0
-	default:
-		msg := fmt.Sprintf("Invalid ServiceData activeSet: %v", r.activeSet)
-		log.Fatal(msg)
-		return errors.New(msg)
+	removed := false
+	for areaID, adps := range r.areas {
+		if _, ok := adps[adpID]; !ok {
+			continue
+		}
+		log.Infof("Removing the Services of adapter %s for area %s", adpID, areaID)
+		delete(adps, adpID)
+		r.fresh(areaID, adpID)
+		removed = true
+		if len(adps) == 0 {
+			delete(r.areas, areaID)
+		}
 	}
-	for _, ns := range data {
-		if _, ok := r.list[loadSet][ns.AreaID]; !ok {
-			log.Infof("Created Area: %q", ns.AreaID)
-			r.list[loadSet][ns.AreaID] = make(structs.NServices, 0)
+	if removed {
+		r.rebuild()
+	}
+	return removed
+}
+
+// rebuild builds a new Catalog from the Services of each Adapter.  The cache must be
+// locked.
+func (r *cache) rebuild() {
+	list := make(map[string]structs.NServices)
+	for areaID, adps := range r.areas {
+		ids := make([]string, 0, len(adps))
+		for adpID := range adps {
+			ids = append(ids, adpID)
+		}
+		sort.Strings(ids)
+		l := make(structs.NServices, 0)
+		for _, adpID := range ids {
+			l = append(l, adps[adpID]...)
 		}
-		r.list[loadSet][ns.AreaID] = append(r.list[loadSet][ns.AreaID], ns)
-		r.services[loadSet][ns.MID()] = true
+		list[areaID] = l
 	}
-	return nil
+	stale := make(map[string]bool)
+	for areaID := range r.stale {
+		stale[areaID] = true
+	}
+	r.view = router.NewCatalog(list, stale)
+	categorize(r.view)
+	r.view.Index = index.Build(list, synonyms(r.view))
+}
+
+func (r *cache) isStopped() bool {
+	r.RLock()
+	defer r.RUnlock()
+	return r.stopped
 }
 
-func (r *cache) init() {
+// Shutdown should be called at system shutdown.  No refreshes are run after it.
+func (r *cache) shutdown() {
 	r.Lock()
 	defer r.Unlock()
-	r.list[0] = make(map[string]structs.NServices)
-	r.list[1] = make(map[string]structs.NServices)
-
-	r.services[0] = make(map[string]bool)
-	r.services[1] = make(map[string]bool)
-
-	r.update = make(chan bool, 1)
-	r.activeSet = 0
+	r.stopped = true
+}
 
-	go func() {
-		for {
-			_, ok := <-r.update
-			if !ok {
-				log.Info("Terminating cache refresh queue...")
-				break
-			} else {
-				log.Debug("Running Services refresh...")
-				r.refresh()
+// overlaps returns true if the lists have an item in common.
+func overlaps(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
 			}
 		}
-	}()
-}
-
-// Shutdown should be called at system shutdown.  It will terminate the update channel, and
-// permform any other necessary cleanup.
-func (r *cache) shutdown() {
-	close(r.update)
+	}
+	return false
 }
 
 // ==============================================================================================================================
@@ -232,28 +378,35 @@ func (r *cache) shutdown() {
 // ==============================================================================================================================
 
 // String returns a string representation of the cache type.
-func (r cache) String() string {
+func (r *cache) String() string {
+	r.RLock()
+	defer r.RUnlock()
 	ls := new(common.FmtBoxer)
-	ls.AddF("cache [%d]\n", r.activeSet)
+	if r.view == nil {
+		ls.AddS("cache - empty\n")
+		return ls.Box(90)
+	}
+	ls.AddF("cache - updated: %v  generation: %d\n", r.view.Built.Format(time.RFC3339), r.view.Generation)
 	ls.AddS("------- Area Service List --------\n")
-	for k, v := range r.list[r.activeSet] {
-		ls.AddF("<<<<<Area: %s >>>>>%s", k, v)
+	for k, v := range r.view.Services {
+		ls.AddF("<<<<<Area: %s  refreshed: %v>>>>>%s", k, r.refreshed[k].Format(time.RFC3339), v)
 	}
 	ls.AddS("------- Service List --------\n")
-	for k := range r.services[r.activeSet] {
+	for k := range r.view.MIDs {
 		ls.AddF("%s\n", k)
 	}
 	return ls.Box(90)
 }
 
-// ==============================================================================================================================
-//                                      MISC
-// ==============================================================================================================================
-
 // ==============================================================================================================================
 //                                      INIT
 // ==============================================================================================================================
 
 func init() {
-	servicesData.init()
+	router.OnAdapterRemoved(RemoveAdapter)
+	go func() {
+		for areas := range router.GetChRefresh() {
+			RefreshAreas(areas)
+		}
+	}()
 }
@@ -2,9 +2,16 @@ package services
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/codeforsanjose/open311-gateway/common/structs"
 	"github.com/codeforsanjose/open311-gateway/engine/router"
 
 	"github.com/davecgh/go-spew/spew"
@@ -21,6 +28,18 @@ func init() {
 	}
 }
 
+// svc returns a Service of the Adapter for the Area, named "S{id}".
+func svc(adpID, areaID string, id int) structs.NService {
+	return structs.NService{ServiceID: structs.ServiceID{AdpID: adpID, AreaID: areaID, ProviderID: 1, ID: id}, Name: fmt.Sprintf("S%d", id)}
+}
+
+// named returns an A1 Service for SJ, with the name and group.
+func named(id int, name, group string) structs.NService {
+	ns := svc("A1", "SJ", id)
+	ns.Name, ns.Group = name, group
+	return ns
+}
+
 func isError(e error) bool {
 	if e == nil {
 		return false
@@ -78,3 +97,257 @@ func TestShutdown(t *testing.T) {
 	time.Sleep(time.Second * 1)
 	Shutdown()
 }
+
+func TestCacheMerge(t *testing.T) {
+	var c cache
+	c.merge("A1", nil, structs.NServices{svc("A1", "SJ", 1), svc("A1", "SC", 2)})
+	c.merge("A2", nil, structs.NServices{svc("A2", "SJ", 3)})
+	if l, _ := c.getArea("SJ"); len(l) != 2 || l[0].AdpID != "A1" || l[1].AdpID != "A2" {
+		t.Errorf("expected the Services of both adapters for SJ, got: %v", l)
+	}
+
+	// A refresh of SJ replaces the A1 Services for SJ only.
+	c.merge("A1", []string{"SJ"}, structs.NServices{svc("A1", "SJ", 4), svc("A1", "SC", 5)})
+	if !c.validateService(svc("A1", "SJ", 4).ServiceID) || c.validateService(svc("A1", "SJ", 1).ServiceID) {
+		t.Errorf("expected the SJ Services to be replaced")
+	}
+	if !c.validateService(svc("A1", "SC", 2).ServiceID) || c.validateService(svc("A1", "SC", 5).ServiceID) {
+		t.Errorf("the SC Services should not change")
+	}
+
+	// A reply without Services for an Area keeps the previous Services.
+	c.merge("A2", []string{"SJ"}, structs.NServices{})
+	c.merge("A1", nil, structs.NServices{svc("A1", "SJ", 6)})
+	if !c.validateService(svc("A2", "SJ", 3).ServiceID) || !c.validateService(svc("A1", "SC", 2).ServiceID) {
+		t.Errorf("expected the previous Services to be kept")
+	}
+	if l := c.adapterAreas("A1"); len(l) != 2 {
+		t.Errorf("expected A1 to serve 2 areas, got: %v", l)
+	}
+
+	// Removed Adapters are pruned.
+	c.prune(map[string]bool{"A2": true}, 0)
+	if _, err := c.getArea("SC"); err == nil || c.validateService(svc("A1", "SJ", 6).ServiceID) {
+		t.Errorf("expected the A1 Services to be removed")
+	}
+	if l, _ := c.getArea("SJ"); len(l) != 1 {
+		t.Errorf("expected the A2 Services for SJ, got: %v", l)
+	}
+}
+
+func TestRemoveAdapter(t *testing.T) {
+	saved := router.GetCatalog()
+	defer router.PublishCatalog(saved)
+
+	var c cache
+	c.merge("A1", nil, structs.NServices{svc("A1", "SJ", 1), svc("A1", "SC", 2)})
+	c.merge("A2", nil, structs.NServices{svc("A2", "SJ", 3)})
+	c.publish()
+	c.track()
+	start := time.Now()
+
+	// The removal is published once, and recorded in the change log.
+	gen := router.GetCatalog().Generation
+	if !c.remove("A1") || c.remove("A1") {
+		t.Errorf("expected A1 to be removed once")
+	}
+	c.publish()
+	cat := router.GetCatalog()
+	if cat.Generation != gen+1 || cat.ValidService(svc("A1", "SJ", 1).ServiceID) || len(cat.Services["SJ"]) != 1 {
+		t.Errorf("expected the catalog without A1, got:\n%s", cat)
+	}
+	if _, err := cat.AreaServices("SC"); err == nil {
+		t.Errorf("expected SC to be removed")
+	}
+	var removed []string
+	l, _ := changes.since(start, "")
+	for _, ch := range l {
+		if ch.Change == ChangeRemoved {
+			removed = append(removed, ch.MID)
+		}
+	}
+	sort.Strings(removed)
+	if fmt.Sprint(removed) != "[A1-SC-1-2 A1-SJ-1-1]" {
+		t.Errorf("expected the A1 Services to be recorded as removed, got: %v", removed)
+	}
+
+	// A later refresh of another Adapter does not publish the removed Adapter.
+	c.merge("A2", nil, structs.NServices{svc("A2", "SJ", 3), svc("A2", "SJ", 4)})
+	c.publish()
+	if cat := router.GetCatalog(); cat.ValidService(svc("A1", "SJ", 1).ServiceID) || len(cat.Services["SJ"]) != 2 {
+		t.Errorf("expected only the A2 Services, got:\n%s", cat)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	if d := jitter(0); d != 0 {
+		t.Errorf("expected no jitter, got: %v", d)
+	}
+	for i := 0; i < 100; i++ {
+		if d := jitter(2); d < 0 || d > 2*time.Second {
+			t.Fatalf("jitter out of range: %v", d)
+		}
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	var c cache
+	c.merge("A1", nil, structs.NServices{svc("A1", "SJ", 1), svc("A1", "SC", 2)})
+	c.merge("A2", nil, structs.NServices{svc("A2", "SJ", 3)})
+
+	dir, err := ioutil.TempDir("", "services")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "snapshot.json")
+	if err := c.snapshot().save(file); err != nil {
+		t.Fatal(err)
+	}
+	snap, err := readSnapshot(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The loaded Services are served, and stale until refreshed.
+	var loaded cache
+	loaded.load(snap)
+	if l, _ := loaded.getArea("SJ"); len(l) != 2 || l[0].Name != "S1" || !loaded.validateService(svc("A1", "SC", 2).ServiceID) {
+		t.Errorf("expected the snapshot Services, got: %v", l)
+	}
+	if !loaded.isStale("SJ") || !loaded.isStale("SC") {
+		t.Errorf("expected the snapshot Services to be stale")
+	}
+	if l := loaded.current().AreaRoutes["SJ"]; fmt.Sprint(l) != fmt.Sprint(structs.NRoutes{{AdpID: "A1", AreaID: "SJ", ProviderID: 1}, {AdpID: "A2", AreaID: "SJ", ProviderID: 1}}) {
+		t.Errorf("expected the SJ routes to be rebuilt, got: %v", l)
+	}
+	loaded.merge("A1", nil, structs.NServices{svc("A1", "SJ", 1), svc("A1", "SC", 2)})
+	if !loaded.isStale("SJ") || loaded.isStale("SC") {
+		t.Errorf("expected only the A2 Services for SJ to be stale")
+	}
+	// The stale Services of an inactive Adapter are kept for the grace period, e.g. until
+	// a registered Adapter registers again after a restart.
+	loaded.prune(map[string]bool{"A1": true}, time.Minute)
+	if !loaded.isStale("SJ") || !loaded.validateService(svc("A2", "SJ", 3).ServiceID) {
+		t.Errorf("expected the stale A2 Services to be kept")
+	}
+	loaded.prune(map[string]bool{"A1": true}, 0)
+	if loaded.isStale("SJ") {
+		t.Errorf("expected SJ to be refreshed once A2 is removed")
+	}
+
+	if _, err := readSnapshot(filepath.Join(dir, "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("expected a missing file error, got: %v", err)
+	}
+}
+
+func TestChanges(t *testing.T) {
+	prev := router.NewCatalog(map[string]structs.NServices{
+		"SJ": {named(1, "Pothole", "Streets"), named(2, "Graffiti", "Graffiti"), named(3, "Trash", "Trash")},
+	}, nil)
+	next := router.NewCatalog(map[string]structs.NServices{
+		"SJ": {named(1, "Pothole Repair", "Streets"), named(3, "Trash", "Sanitation"), named(4, "Streetlight", "Lighting")},
+	}, nil)
+	next.Generation = 7
+	list := diffCatalogs(prev, next)
+	var got []string
+	for _, c := range list {
+		got = append(got, fmt.Sprintf("%s:%s:%s%v", c.MID, c.Change, c.OldName, c.Fields))
+		if c.Generation != 7 || c.AreaID != "SJ" {
+			t.Errorf("unexpected change: %+v", c)
+		}
+	}
+	want := "[A1-SJ-1-1:renamed:Pothole[] A1-SJ-1-2:removed:[] A1-SJ-1-3:metadata:[group] A1-SJ-1-4:added:[]]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("unexpected changes:\n got: %v\nwant: %s", got, want)
+	}
+	if l := diffCatalogs(next, next); len(l) != 0 {
+		t.Errorf("expected no changes, got: %v", l)
+	}
+
+	// The changes are appended to the change log, and loaded at startup.
+	dir, err := ioutil.TempDir("", "services")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "changes.log")
+	var cl changeLog
+	start := time.Now().Add(-time.Second)
+	cl.record(file, list[:2])
+	mark := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	cl.record(file, list[2:])
+	if l, complete := cl.since(mark, ""); len(l) != 2 || !complete || l[0].Change != ChangeMetadata {
+		t.Errorf("expected the last 2 changes, got: %v, %t", l, complete)
+	}
+	if l, _ := cl.since(start, "SC"); len(l) != 0 {
+		t.Errorf("expected no SC changes, got: %v", l)
+	}
+
+	var loaded changeLog
+	if err := loaded.load(file); err != nil {
+		t.Fatal(err)
+	}
+	if l, complete := loaded.since(start, "SJ"); len(l) != 4 || !complete || l[3].Change != ChangeAdded {
+		t.Errorf("expected 4 changes from the change log, got: %v, %t", l, complete)
+	}
+
+	// Old changes are dropped.
+	many := make([]Change, maxChanges)
+	loaded.record("", many)
+	if l, complete := loaded.since(start, ""); len(l) != maxChanges || complete {
+		t.Errorf("expected %d changes, and some dropped, got: %d, %t", maxChanges, len(l), complete)
+	}
+
+	// The change log file is truncated to the last maxChanges entries when it is loaded.
+	for i := range many {
+		many[i] = Change{Generation: uint64(i + 10), Change: ChangeAdded}
+	}
+	if err := appendChanges(file, many); err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.load(file); err != nil {
+		t.Fatal(err)
+	}
+	var truncated changeLog
+	if err := truncated.load(file); err != nil {
+		t.Fatal(err)
+	}
+	if len(truncated.list) != maxChanges || truncated.list[0].Generation != 10 || !reflect.DeepEqual(truncated.list, loaded.list) {
+		t.Errorf("expected the last %d changes in the file, got: %d", maxChanges, len(truncated.list))
+	}
+}
+
+func TestPublishOrder(t *testing.T) {
+	saved := router.GetCatalog()
+	defer router.PublishCatalog(saved)
+
+	var c cache
+	c.merge("A0", nil, structs.NServices{svc("A0", "SJ", 1)})
+	c.publish()
+	c.track()
+	start := time.Now()
+
+	// The changes of concurrent refreshes are recorded in the order they are published.
+	var wg sync.WaitGroup
+	for i := 1; i <= 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			adpID := fmt.Sprintf("A%d", i)
+			c.merge(adpID, nil, structs.NServices{svc(adpID, "SJ", i)})
+			c.publish()
+		}(i)
+	}
+	wg.Wait()
+	l, _ := changes.since(start, "SJ")
+	if len(l) != 20 {
+		t.Errorf("expected 20 changes, got: %d", len(l))
+	}
+	for i := 1; i < len(l); i++ {
+		if l[i].Generation < l[i-1].Generation {
+			t.Fatalf("generation %d was recorded after %d", l[i].Generation, l[i-1].Generation)
+		}
+	}
+}
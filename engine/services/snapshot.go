@@ -0,0 +1,171 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+	"github.com/codeforsanjose/open311-gateway/engine/router"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+// saving serializes the snapshot writes.
+var saving sync.Mutex
+
+// snapshot is the Services cache saved to the "servicesRefresh.snapshot" file, so the
+// Engine can serve the Services of an Adapter that is down at startup.  The routes are
+// rebuilt from the Services when it is loaded.
+type snapshot struct {
+	Saved time.Time                               `json:"saved"`
+	Areas map[string]map[string]structs.NServices `json:"areas"` // Index: AreaID, AdpID
+}
+
+// Stale returns true if any of the Services of the Area were loaded from the snapshot, and
+// have not been refreshed since.
+func Stale(areaID string) bool {
+	return router.GetCatalog().Stale[areaID]
+}
+
+// saveSnapshot writes the Services cache to the snapshot file.  The file is replaced
+// in one step, so a crash while saving leaves the previous snapshot.
+func saveSnapshot() {
+	file := router.GetRefreshConfig().Snapshot
+	if file == "" {
+		return
+	}
+	saving.Lock()
+	defer saving.Unlock()
+	if err := servicesData.snapshot().save(file); err != nil {
+		log.Errorf("Unable to save the Services snapshot - %s", err)
+		return
+	}
+	log.Debugf("Saved the Services snapshot: %s", file)
+}
+
+// loadSnapshot loads the Services cache from the snapshot file, and publishes it.  The
+// routes are rebuilt from the Services.  All of the Services are stale until they are
+// refreshed.
+func loadSnapshot() {
+	file := router.GetRefreshConfig().Snapshot
+	if file == "" {
+		return
+	}
+	snap, err := readSnapshot(file)
+	switch {
+	case os.IsNotExist(err):
+		log.Infof("No Services snapshot found: %s", file)
+		return
+	case err != nil:
+		log.Errorf("Unable to load the Services snapshot - %s", err)
+		return
+	}
+	servicesData.load(snap)
+	servicesData.publish()
+	log.WithFields(log.Fields{
+		"file":  file,
+		"saved": snap.Saved.Format(time.RFC3339),
+		"areas": len(snap.Areas),
+	}).Info("Loaded the Services snapshot - stale until refreshed")
+}
+
+func readSnapshot(file string) (*snapshot, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	snap := new(snapshot)
+	if err := json.Unmarshal(data, snap); err != nil {
+		return nil, fmt.Errorf("invalid snapshot file %s - %s", file, err)
+	}
+	return snap, nil
+}
+
+func (r *snapshot) save(file string) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// ==============================================================================================================================
+//                                      SERVICE CACHE
+// ==============================================================================================================================
+
+// snapshot returns a copy of the Services cache.
+func (r *cache) snapshot() *snapshot {
+	r.RLock()
+	defer r.RUnlock()
+	snap := &snapshot{
+		Saved: time.Now(),
+		Areas: make(map[string]map[string]structs.NServices),
+	}
+	for areaID, adps := range r.areas {
+		snap.Areas[areaID] = make(map[string]structs.NServices)
+		for adpID, l := range adps {
+			snap.Areas[areaID][adpID] = l
+		}
+	}
+	return snap
+}
+
+// load replaces the Services cache with the snapshot, and marks all of it stale.  The
+// changes to the Services are recorded from the snapshot.  The stale Services of Adapters
+// that are not active yet are kept by prune() for the registration lease.
+func (r *cache) load(snap *snapshot) {
+	r.Lock()
+	defer r.Unlock()
+	r.areas = make(map[string]map[string]structs.NServices)
+	r.refreshed = make(map[string]time.Time)
+	r.stale = make(map[string]map[string]bool)
+	for areaID, adps := range snap.Areas {
+		r.areas[areaID] = make(map[string]structs.NServices)
+		r.stale[areaID] = make(map[string]bool)
+		for adpID, l := range adps {
+			r.areas[areaID][adpID] = l
+			r.stale[areaID][adpID] = true
+		}
+		r.refreshed[areaID] = snap.Saved
+	}
+	r.loaded = time.Now()
+	r.tracking = true
+	r.rebuild()
+}
+
+// isStale returns true if any of the Services of the Area are from the snapshot.
+func (r *cache) isStale(areaID string) bool {
+	r.RLock()
+	defer r.RUnlock()
+	return len(r.stale[areaID]) > 0
+}
+
+// fresh marks the Services of the Adapter in the Area as refreshed.  The cache must be
+// locked.
+func (r *cache) fresh(areaID, adpID string) {
+	if !r.stale[areaID][adpID] {
+		return
+	}
+	delete(r.stale[areaID], adpID)
+	if len(r.stale[areaID]) == 0 {
+		delete(r.stale, areaID)
+		log.Infof("The Services for area %s are no longer stale", areaID)
+	}
+}
@@ -0,0 +1,176 @@
+// Package taxonomy maps the Services of all Adapters to the Gateway's Service categories
+// (streets, lighting, graffiti, trash, ...), from the "taxonomy" file in the config.
+package taxonomy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync/atomic"
+	"unicode"
+
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+
+	log "github.com/jeffizhungry/logrus"
+)
+
+// dfltLanguage is the language of the labels, if the "defaultLanguage" is not set.
+const dfltLanguage = "en"
+
+var current atomic.Value // *Taxonomy
+
+// Get returns the loaded Taxonomy.  It must not be changed.
+func Get() *Taxonomy {
+	if t, ok := current.Load().(*Taxonomy); ok {
+		return t
+	}
+	return empty
+}
+
+// empty is the Taxonomy used until a taxonomy file is loaded.
+var empty = &Taxonomy{DefaultLanguage: dfltLanguage, index: make(map[string]*Category)}
+
+// Load loads the taxonomy file.  If the file is empty, the Services are not categorized.
+// If the file is invalid, the loaded Taxonomy is not changed.
+func Load(file string) error {
+	if file == "" {
+		current.Store(empty)
+		return nil
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		msg := fmt.Sprintf("Unable to access the taxonomy file - %v.", err)
+		log.Error(msg)
+		return errors.New(msg)
+	}
+	t, err := parse(data)
+	if err != nil {
+		log.Error(err.Error())
+		return err
+	}
+	current.Store(t)
+	log.WithFields(log.Fields{
+		"file":       file,
+		"categories": len(t.Categories),
+		"mappings":   len(t.Services),
+	}).Info("Loaded the taxonomy")
+	return nil
+}
+
+// ==============================================================================================================================
+//                                      TAXONOMY
+// ==============================================================================================================================
+
+// Taxonomy is the list of Categories, and the explicit Service mappings.  A Service in
+// the Services list is mapped to its Category.  Otherwise, it is mapped to the first
+// Category with a keyword in the Service name, group or keywords.
+type Taxonomy struct {
+	DefaultLanguage string               `json:"defaultLanguage"`
+	Categories      []*Category          `json:"categories"`
+	Services        map[string]string    `json:"services"` // Index: MID - the Category ID.
+	index           map[string]*Category // Index: lower case Category ID
+}
+
+// Category is a Service category.  The Synonyms are searched with the Services in the
+// Category, e.g. "dark" for the lighting Services.
+type Category struct {
+	ID       string            `json:"id"`
+	Icon     string            `json:"icon"`
+	Labels   map[string]string `json:"labels"` // Index: language, e.g. "en" or "es".
+	Keywords []string          `json:"keywords"`
+	Synonyms []string          `json:"synonyms"`
+	keywords []string          // Normalized.
+}
+
+func parse(data []byte) (*Taxonomy, error) {
+	t := new(Taxonomy)
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("Invalid taxonomy file - %v.", err)
+	}
+	t.DefaultLanguage = strings.ToLower(t.DefaultLanguage)
+	if t.DefaultLanguage == "" {
+		t.DefaultLanguage = dfltLanguage
+	}
+	t.index = make(map[string]*Category)
+	for _, c := range t.Categories {
+		switch _, dup := t.index[strings.ToLower(c.ID)]; {
+		case c.ID == "":
+			return nil, errors.New("Invalid taxonomy file - a category has no id.")
+		case dup:
+			return nil, fmt.Errorf("Invalid taxonomy file - duplicate category: %q.", c.ID)
+		}
+		t.index[strings.ToLower(c.ID)] = c
+		labels := make(map[string]string)
+		for lang, label := range c.Labels {
+			labels[strings.ToLower(lang)] = label
+		}
+		c.Labels = labels
+		for _, kw := range c.Keywords {
+			if n := normalize(kw); n != "" {
+				c.keywords = append(c.keywords, n)
+			}
+		}
+	}
+	for mid, id := range t.Services {
+		if _, ok := t.index[strings.ToLower(id)]; !ok {
+			return nil, fmt.Errorf("Invalid taxonomy file - service %s is mapped to an unknown category: %q.", mid, id)
+		}
+	}
+	return t, nil
+}
+
+// Enabled returns true if the Taxonomy has any Categories.
+func (t *Taxonomy) Enabled() bool {
+	return len(t.Categories) > 0
+}
+
+// Category returns the Category with the ID (ignoring case).
+func (t *Taxonomy) Category(id string) (*Category, bool) {
+	c, ok := t.index[strings.ToLower(id)]
+	return c, ok
+}
+
+// Classify returns the ID of the Category of the Service.  ok is false if the Service
+// is not mapped to a Category.
+func (t *Taxonomy) Classify(ns structs.NService) (id string, ok bool) {
+	if id, ok := t.Services[ns.MID()]; ok {
+		return t.index[strings.ToLower(id)].ID, true
+	}
+	text := normalize(strings.Join(append([]string{ns.Name, ns.Group}, ns.Keywords...), " "))
+	for _, c := range t.Categories {
+		for _, kw := range c.keywords {
+			if strings.Contains(text, kw) {
+				return c.ID, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Label returns the label of the Category in the language (e.g. "es" or "es-MX").  If
+// there is no label for the language, the label in the default language, or the ID,
+// is returned.
+func (t *Taxonomy) Label(c *Category, lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	base := strings.SplitN(lang, "-", 2)[0]
+	for _, l := range []string{lang, base, t.DefaultLanguage} {
+		if label, ok := c.Labels[l]; ok && l != "" {
+			return label
+		}
+	}
+	return c.ID
+}
+
+// normalize returns the lower case words of s, separated and surrounded by spaces, so
+// that keywords only match whole words.  If s has no words, "" is returned.
+func normalize(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+	return " " + strings.Join(words, " ") + " "
+}
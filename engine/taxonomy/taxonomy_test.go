@@ -0,0 +1,95 @@
+package taxonomy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/codeforsanjose/open311-gateway/common/structs"
+)
+
+// svc returns a CS1 Service for SJ.
+func svc(id int, name, group string, keywords ...string) structs.NService {
+	return structs.NService{ServiceID: structs.ServiceID{AdpID: "CS1", AreaID: "SJ", ProviderID: 1, ID: id}, Name: name, Group: group, Keywords: keywords}
+}
+
+func TestClassify(t *testing.T) {
+	tx, err := parse([]byte(`{
+		"categories": [
+			{"id": "lighting", "icon": "lightbulb", "labels": {"en": "Streetlights", "ES": "Alumbrado"}, "keywords": ["street light", "streetlight"]},
+			{"id": "Streets", "labels": {"en": "Streets"}, "keywords": ["pothole", "street"]},
+			{"id": "trash", "keywords": ["dumping"]}
+		],
+		"services": {"CS1-SJ-1-9": "streets"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		ns   structs.NService
+		want string
+	}{
+		{svc(1, "Street Light Out", ""), "lighting"},
+		{svc(2, "Pothole", ""), "Streets"},
+		{svc(3, "Illegal Dumping", "Sanitation"), "trash"},
+		{svc(4, "Abandoned Vehicle", "", "car", "dumping"), "trash"},
+		{svc(5, "Streetlights", "Lights"), ""}, // Only whole words match.
+		{svc(9, "Abandoned Vehicle", ""), "Streets"},
+	}
+	for _, c := range cases {
+		if id, _ := tx.Classify(c.ns); id != c.want {
+			t.Errorf("Classify(%q) = %q, want %q", c.ns.Name, id, c.want)
+		}
+	}
+
+	c, ok := tx.Category("streets")
+	if !ok || c.ID != "Streets" {
+		t.Fatalf("expected the category lookup to ignore case")
+	}
+	light, _ := tx.Category("lighting")
+	for lang, want := range map[string]string{"es": "Alumbrado", "es-MX": "Alumbrado", "fr": "Streetlights", "": "Streetlights"} {
+		if got := tx.Label(light, lang); got != want {
+			t.Errorf("Label(%q) = %q, want %q", lang, got, want)
+		}
+	}
+	trash, _ := tx.Category("trash")
+	if got := tx.Label(trash, "en"); got != "trash" {
+		t.Errorf("expected the ID without a label, got: %q", got)
+	}
+
+	for _, bad := range []string{
+		`{"categories": [{"id": "a"}, {"id": "A"}]}`,
+		`{"categories": [{"icon": "x"}]}`,
+		`{"categories": [{"id": "a"}], "services": {"CS1-SJ-1-1": "b"}}`,
+	} {
+		if _, err := parse([]byte(bad)); err == nil {
+			t.Errorf("expected an error for: %s", bad)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	defer current.Store(empty)
+	dir, err := ioutil.TempDir("", "taxonomy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "taxonomy.json")
+	if err := ioutil.WriteFile(file, []byte(`{"categories": [{"id": "trash"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Load(file); err != nil || !Get().Enabled() {
+		t.Fatalf("expected the taxonomy to load: %v", err)
+	}
+	if err := ioutil.WriteFile(file, []byte(`{"categories": [`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Load(file); err == nil || !Get().Enabled() {
+		t.Errorf("expected an error, and the previous taxonomy to be kept")
+	}
+	if err := Load(""); err != nil || Get().Enabled() {
+		t.Errorf("expected no taxonomy")
+	}
+}
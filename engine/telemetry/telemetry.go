@@ -1,17 +1,39 @@
 package telemetry
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"sync"
 	"time"
 
+	tm "github.com/codeforsanjose/open311-gateway/common/telemetry"
+
 	log "github.com/jeffizhungry/logrus"
 )
 
 var (
 	chTQue chan msgSender
+	done   chan struct{} // Closed when the sender has sent the queued messages, after Shutdown.
+	closed bool
+	mu     sync.RWMutex // Held for reading while queueing a message, so Shutdown can close chTQue.
 )
 
+// msgSender is a telemetry message.
+type msgSender interface {
+	Marshal() ([]byte, error)
+}
+
+// send queues the message.  Messages are discarded before Init, and after Shutdown.
+func send(m msgSender) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if chTQue == nil || closed {
+		return
+	}
+	chTQue <- m
+}
+
 // SendTelemetry sends a telemetry message.
 func SendTelemetry(rqstID int64, op, status string) {
 	SendRequest(rqstID, op, status, "", time.Now())
@@ -19,36 +41,81 @@ func SendTelemetry(rqstID int64, op, status string) {
 
 // SendRequest queues an Engine REST Request message onto the send channel.
 func SendRequest(msgID int64, rType, status, areaID string, at time.Time) {
-	statusMsg := EngRequestMsgType{
+	statusMsg := tm.EngRequestMsgType{
 		ID:     fmt.Sprintf("%d", msgID),
 		Rtype:  rType,
 		Status: status,
 		At:     at,
 		AreaID: areaID,
 	}
-	chTQue <- msgSender(statusMsg)
+	send(statusMsg)
 
 }
 
 // SendRPC sends an Adapter RPC status message to the monitor.
 func SendRPC(id, status, route string, at time.Time) {
-	statusMsg := EngRPCMsgType{
+	statusMsg := tm.EngRPCMsgType{
 		ID:     id,
 		Status: status,
 		Route:  route,
 		At:     at,
 	}
-	chTQue <- msgSender(statusMsg)
+	send(statusMsg)
+}
+
+// SendCache sends the statistics for an Engine cache to the monitor.
+func SendCache(name string, hits, misses, size, evictions int64) {
+	statusMsg := tm.EngCacheMsgType{
+		Name:      name,
+		Hits:      hits,
+		Misses:    misses,
+		Size:      size,
+		Evictions: evictions,
+		At:        time.Now(),
+	}
+	send(statusMsg)
+}
+
+// SendServiceChange sends a change to the Services of an Area to the monitor.
+func SendServiceChange(mid, areaID, change, name string, at time.Time) {
+	statusMsg := tm.EngServiceChangeMsgType{
+		MID:    mid,
+		AreaID: areaID,
+		Change: change,
+		Name:   name,
+		At:     at,
+	}
+	send(statusMsg)
 }
 
-// Shutdown should be called to gracefully stop the telemetry processes.
-func Shutdown() {
+// Shutdown stops the telemetry, after the queued messages are sent or the context is
+// done.
+func Shutdown(ctx context.Context) {
+	mu.Lock()
+	if chTQue == nil || closed {
+		mu.Unlock()
+		return
+	}
+	closed = true
 	close(chTQue)
+	mu.Unlock()
+	if done == nil {
+		return
+	}
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Warning("Telemetry queue not flushed - " + ctx.Err().Error())
+	}
 }
 
 // Init initializes the Monitor system
 func Init(addr string) {
+	mu.Lock()
+	defer mu.Unlock()
 	chTQue = make(chan msgSender, 100)
+	closed = false
+	done = nil
 
 	tlmtryServer, err := net.ResolveUDPAddr("udp", addr)
 	if err != nil {
@@ -62,11 +129,13 @@ func Init(addr string) {
 		return
 	}
 
-	go func() {
+	done = make(chan struct{})
+	go func(chTQue chan msgSender, done chan struct{}) {
 		log.Debugf("Telemetry sender starting on: %v", addr)
 		finish := func() {
 			log.Debugf("Closing telemetry connection...")
 			_ = conn.Close()
+			close(done)
 		}
 		defer finish()
 		for m := range chTQue {
@@ -80,5 +149,5 @@ func Init(addr string) {
 				log.Warning(err.Error())
 			}
 		}
-	}()
+	}(chTQue, done)
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	tm "github.com/codeforsanjose/open311-gateway/common/telemetry"
 	"github.com/codeforsanjose/open311-gateway/monitor/logs"
 	"github.com/codeforsanjose/open311-gateway/monitor/telemetry"
 
@@ -17,6 +18,8 @@ var (
 	engStatuses *sortedData
 	engRequests *sortedData
 	engAdpCalls *sortedData
+	engCaches   *sortedData
+	engChanges  *sortedData
 
 	adpStatuses *sortedData
 	adpCalls01  *sortedData
@@ -40,9 +43,11 @@ func (r *displays) init() error {
 
 	r.newList("Engine Status", 0, 0, 10, 80, engStatuses.display)
 	r.newList("Adapter Status", 80, 0, 10, 80, adpStatuses.display)
+	r.newList("Eng Caches", 160, 0, 10, 80, engCaches.display)
 
 	r.newList("Eng Requests", 0, 10, 15, 80, engRequests.display)
 	r.newList("Eng Adapter Calls", 80, 10, 15, 80, engAdpCalls.display)
+	r.newList("Service Changes", 160, 10, 15, 80, engChanges.display)
 
 	r.newList("Adapter Calls", 0, 25, 15, 160, adpCalls01.display)
 
@@ -106,6 +111,8 @@ func clearAll() {
 	engStatuses.clear()
 	engRequests.clear()
 	engAdpCalls.clear()
+	engCaches.clear()
+	engChanges.clear()
 
 	adpStatuses.clear()
 	adpCalls01.clear()
@@ -124,24 +131,32 @@ func Start() {
 		for msg := range msgChan {
 			telemetry.DebugMsg("Message type [%s] - %#v\n", msg.Mtype(), msg.Data())
 			switch msg.Mtype() {
-			case telemetry.MsgTypeES:
+			case tm.MsgTypeES:
 				if err := engStatuses.update(msg); err != nil {
 					log.Error(err.Error())
 				}
-			case telemetry.MsgTypeER:
+			case tm.MsgTypeER:
 				if err := engRequests.update(msg); err != nil {
 					log.Error(err.Error())
 				}
-			case telemetry.MsgTypeERPC:
+			case tm.MsgTypeERPC:
 				if err := engAdpCalls.update(msg); err != nil {
 					log.Error(err.Error())
 				}
+			case tm.MsgTypeEC:
+				if err := engCaches.update(msg); err != nil {
+					log.Error(err.Error())
+				}
+			case tm.MsgTypeESC:
+				if err := engChanges.update(msg); err != nil {
+					log.Error(err.Error())
+				}
 
-			case telemetry.MsgTypeAS:
+			case tm.MsgTypeAS:
 				if err := adpStatuses.update(msg); err != nil {
 					log.Error(err.Error())
 				}
-			case telemetry.MsgTypeARPC:
+			case tm.MsgTypeARPC:
 				if err := adpCalls01.update(msg); err != nil {
 					log.Error(err.Error())
 				}
@@ -156,21 +171,21 @@ func Start() {
 }
 
 func runTests() {
-	if err := engStatuses.update(telemetry.NewMessageTest([]string{"ES", "Sys1", "active!", "CS1, CS2", "127.0.0.1/5081"})); err != nil {
+	if err := engStatuses.update(tm.NewMessageTest([]string{"ES", "Sys1", "active!", "CS1, CS2", "127.0.0.1/5081"})); err != nil {
 		log.Error(err.Error())
 	}
-	if err := engStatuses.update(telemetry.NewMessageTest([]string{"ES", "Sys2", "active", "", "127.0.0.1/5082"})); err != nil {
+	if err := engStatuses.update(tm.NewMessageTest([]string{"ES", "Sys2", "active", "", "127.0.0.1/5082"})); err != nil {
 		log.Error(err.Error())
 	}
 
-	if err := engRequests.update(telemetry.NewMessageTest([]string{"ER", "10001", "Create", "Active", time.Now().Format(time.RFC3339), "SJ"})); err != nil {
+	if err := engRequests.update(tm.NewMessageTest([]string{"ER", "10001", "Create", "Active", time.Now().Format(time.RFC3339), "SJ"})); err != nil {
 		log.Error(err.Error())
 	}
 
-	if err := engAdpCalls.update(telemetry.NewMessageTest([]string{"ERPC", "10001-1", "active", "CS1-SJ-1", time.Now().Format(time.RFC3339)})); err != nil {
+	if err := engAdpCalls.update(tm.NewMessageTest([]string{"ERPC", "10001-1", "active", "CS1-SJ-1", time.Now().Format(time.RFC3339)})); err != nil {
 		log.Error(err.Error())
 	}
-	if err := engAdpCalls.update(telemetry.NewMessageTest([]string{"ERPC", "10001-2", "active", "CS1-SC-1", time.Now().Format(time.RFC3339)})); err != nil {
+	if err := engAdpCalls.update(tm.NewMessageTest([]string{"ERPC", "10001-2", "active", "CS1-SC-1", time.Now().Format(time.RFC3339)})); err != nil {
 		log.Error(err.Error())
 	}
 
@@ -179,7 +194,7 @@ func runTests() {
 		for {
 			cnt++
 			id := fmt.Sprintf("Sys%02d", cnt)
-			if err := engStatuses.update(telemetry.NewMessageTest([]string{"ES", id, "CS1, CS2", "active", ""})); err != nil {
+			if err := engStatuses.update(tm.NewMessageTest([]string{"ES", id, "CS1, CS2", "active", ""})); err != nil {
 				log.Fatalf(err.Error())
 			}
 			for name, data := range engStatuses.data {
@@ -188,7 +203,7 @@ func runTests() {
 				}
 			}
 			if cnt == 10 {
-				if err := engStatuses.update(telemetry.NewMessageTest([]string{"ES", "Sys3", "active", "XXX", "127.0.0.1/5083"})); err != nil {
+				if err := engStatuses.update(tm.NewMessageTest([]string{"ES", "Sys3", "active", "XXX", "127.0.0.1/5083"})); err != nil {
 					log.Fatalf(err.Error())
 				}
 			}
@@ -202,12 +217,14 @@ func runTests() {
 // ==============================================================================================================================
 
 func init() {
-	engStatuses = newSortedData(telemetry.MsgTypeES, true)
-	engRequests = newSortedData(telemetry.MsgTypeER, false)
-	engAdpCalls = newSortedData(telemetry.MsgTypeERPC, false)
-
-	adpStatuses = newSortedData(telemetry.MsgTypeAS, true)
-	adpCalls01 = newSortedData(telemetry.MsgTypeARPC, false)
+	engStatuses = newSortedData(tm.MsgTypeES, true)
+	engRequests = newSortedData(tm.MsgTypeER, false)
+	engAdpCalls = newSortedData(tm.MsgTypeERPC, false)
+	engCaches = newSortedData(tm.MsgTypeEC, true)
+	engChanges = newSortedData(tm.MsgTypeESC, true)
+
+	adpStatuses = newSortedData(tm.MsgTypeAS, true)
+	adpCalls01 = newSortedData(tm.MsgTypeARPC, false)
 
 	displayList.init()
 }
@@ -0,0 +1,60 @@
+package display
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common/telemetry"
+)
+
+type engCacheType struct {
+	name       string
+	hits       int64
+	misses     int64
+	size       int64
+	evictions  int64
+	hitRate    float64
+	status     string
+	lastUpdate time.Time
+}
+
+func newEngCache(m telemetry.Message) (dataInterface, error) {
+	engCache := new(engCacheType)
+	err := engCache.update(m)
+	if err != nil {
+		return nil, err
+	}
+	return dataInterface(engCache), nil
+}
+
+func (r engCacheType) display() string {
+	return fmt.Sprintf("%-10s  hit: %5.1f%%  (%d/%d)  size: %d  evicted: %d", r.name, r.hitRate, r.hits, r.hits+r.misses, r.size, r.evictions)
+}
+
+func (r *engCacheType) update(m telemetry.Message) error {
+	s, err := telemetry.UnmarshalEngCacheMsg(m)
+	if err != nil {
+		return err
+	}
+
+	r.name = s.Name
+	r.hits = s.Hits
+	r.misses = s.Misses
+	r.size = s.Size
+	r.evictions = s.Evictions
+	r.hitRate = s.HitRate()
+	r.lastUpdate = time.Now()
+	return nil
+}
+
+func (r *engCacheType) key() string {
+	return r.name
+}
+
+func (r *engCacheType) getLastUpdate() time.Time {
+	return r.lastUpdate
+}
+
+func (r *engCacheType) setStatus(status string) {
+	r.status = status
+}
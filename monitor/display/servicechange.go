@@ -0,0 +1,58 @@
+package display
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/codeforsanjose/open311-gateway/common/telemetry"
+)
+
+type engServiceChangeType struct {
+	mid        string
+	areaID     string
+	change     string
+	name       string
+	at         time.Time
+	status     string
+	lastUpdate time.Time
+}
+
+func newEngServiceChange(m telemetry.Message) (dataInterface, error) {
+	engChange := new(engServiceChangeType)
+	err := engChange.update(m)
+	if err != nil {
+		return nil, err
+	}
+	return dataInterface(engChange), nil
+}
+
+func (r engServiceChangeType) display() string {
+	return fmt.Sprintf("%-18s  %-8s  %-25s  %s", r.mid, r.change, r.name, r.at.Format("01/02 15:04:05"))
+}
+
+func (r *engServiceChangeType) update(m telemetry.Message) error {
+	s, err := telemetry.UnmarshalEngServiceChangeMsg(m)
+	if err != nil {
+		return err
+	}
+
+	r.mid = s.MID
+	r.areaID = s.AreaID
+	r.change = s.Change
+	r.name = s.Name
+	r.at = s.At
+	r.lastUpdate = time.Now()
+	return nil
+}
+
+func (r *engServiceChangeType) key() string {
+	return r.mid
+}
+
+func (r *engServiceChangeType) getLastUpdate() time.Time {
+	return r.lastUpdate
+}
+
+func (r *engServiceChangeType) setStatus(status string) {
+	r.status = status
+}
@@ -6,7 +6,7 @@ import (
 	"sync"
 	"time"
 
-	"github.com/codeforsanjose/open311-gateway/monitor/telemetry"
+	"github.com/codeforsanjose/open311-gateway/common/telemetry"
 )
 
 // ==============================================================================================================================
@@ -83,6 +83,16 @@ func (r *sortedData) add(m telemetry.Message) (err error) {
 		if err != nil {
 			return err
 		}
+	case telemetry.MsgTypeEC:
+		d, err = newEngCache(m)
+		if err != nil {
+			return err
+		}
+	case telemetry.MsgTypeESC:
+		d, err = newEngServiceChange(m)
+		if err != nil {
+			return err
+		}
 	case telemetry.MsgTypeAS:
 		d, err = newAdpRPC(m)
 		if err != nil {
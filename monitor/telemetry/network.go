@@ -4,19 +4,20 @@ import (
 	"fmt"
 	"net"
 
+	tm "github.com/codeforsanjose/open311-gateway/common/telemetry"
 	"github.com/codeforsanjose/open311-gateway/monitor/logs"
 )
 
 var (
 	log         = logs.Log
-	msgChan     chan Message
+	msgChan     chan tm.Message
 	done        chan bool
 	monitorConn *net.UDPConn
 	monitorAddr string
 )
 
 func init() {
-	msgChan = make(chan Message, 1000)
+	msgChan = make(chan tm.Message, 1000)
 	done = make(chan bool)
 }
 
@@ -41,7 +42,7 @@ func SetAddr(addr string) {
 }
 
 // GetMsgChan returns the message queue channel.
-func GetMsgChan() chan Message {
+func GetMsgChan() chan tm.Message {
 	return msgChan
 }
 
@@ -51,7 +52,7 @@ func GetMsgChan() chan Message {
 
 // StartReceiver starts the UDP receive process.  The bytes received are parsed
 // by the separator character "|" into a slice of strings, and put on the msgChan.
-func StartReceiver(addr string, msgChan chan Message, done <-chan bool) error {
+func StartReceiver(addr string, msgChan chan tm.Message, done <-chan bool) error {
 	// log.Debug("Address: %v", addr)
 	a, err := net.ResolveUDPAddr("udp", addr)
 	if err != nil {
@@ -76,7 +77,7 @@ func StartReceiver(addr string, msgChan chan Message, done <-chan bool) error {
 			}
 
 			if n > 0 {
-				msg, err := NewMessage(buf, n)
+				msg, err := tm.NewMessage(buf, n)
 				if err == nil {
 					msgChan <- msg
 				} else {